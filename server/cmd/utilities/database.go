@@ -3,14 +3,31 @@ package main
 import (
 	"encoding/json"
 
-	"verni/internal/db"
+	defaultBalancesController "verni/internal/controllers/balances/default"
+	defaultCompactionController "verni/internal/controllers/compaction/default"
+	defaultDevicesController "verni/internal/controllers/devices/default"
+	"verni/internal/db/migrations"
+	postgresMigrator "verni/internal/db/migrations/postgres"
 	postgresDb "verni/internal/db/postgres"
+	defaultAuthRepository "verni/internal/repositories/auth/default"
+	defaultBalancesRepository "verni/internal/repositories/balances/default"
+	defaultOperationsRepository "verni/internal/repositories/operations/default"
+	defaultPushNotificationsRepository "verni/internal/repositories/pushNotifications/default"
 	"verni/internal/services/logging"
 )
 
 type databaseActions struct {
-	setup func()
-	drop  func()
+	migrate  func()
+	status   func()
+	rollback func(to migrations.Version)
+	// rebuildBalances recomputes the balances projection from the operations log
+	rebuildBalances func()
+	// compactOperations removes superseded operations from the operations log
+	compactOperations func()
+	// collectDevices forgets devices without session with their confirmations and push tokens
+	collectDevices func()
+	// deadNotifications lists up to `limit` notifications that ran out of delivery attempts, oldest first
+	deadNotifications func(limit int)
 }
 
 func createDatabaseActions(configData []byte, logger logging.Service) (databaseActions, error) {
@@ -22,187 +39,102 @@ func createDatabaseActions(configData []byte, logger logging.Service) (databaseA
 		logger.LogFatal("failed to initialize postgres err: %v", err)
 	}
 	logger.LogInfo("initialized postgres")
+	migrator := postgresMigrator.New(database, logger)
 	return databaseActions{
-		setup: func() {
-			for _, table := range tables() {
-				if err := table.create(database); err != nil {
-					logger.LogInfo("failed to create table %s err: %v", table.name, err)
-				} else {
-					logger.LogInfo("created table %s", table.name)
-				}
+		migrate: func() {
+			if err := migrator.Migrate(); err != nil {
+				logger.LogFatal("failed to migrate err: %v", err)
 			}
+			logger.LogInfo("database schema is at version %d", migrations.Latest())
 		},
-		drop: func() {
-			for _, table := range tables() {
-				if err := table.delete(database); err != nil {
-					logger.LogInfo("failed to drop table %s err: %v", table.name, err)
-				}
-				logger.LogInfo("droped table %s", table.name)
+		status: func() {
+			status, err := migrator.Status()
+			if err != nil {
+				logger.LogFatal("failed to get migrations status err: %v", err)
 			}
+			for _, migration := range status.Applied {
+				logger.LogInfo("applied  %d_%s at %d", migration.Version, migration.Name, migration.AppliedAt)
+			}
+			for _, migration := range status.Pending {
+				logger.LogInfo("pending  %d_%s", migration.Version, migration.Name)
+			}
+			logger.LogInfo("current version %d, latest version %d", status.Current, status.Latest)
 		},
-	}, nil
-}
-
-type table struct {
-	name   string
-	create func(db db.DB) error
-	delete func(db db.DB) error
-}
-
-func tables() []table {
-	return []table{
-		{
-			name: "credentials",
-			create: func(db db.DB) error {
-				_, err := db.Exec(`
-				CREATE TABLE credentials(
-					userId text NOT NULL PRIMARY KEY,
-					email text NOT NULL,
-					password text NOT NULL,
-					emailVerified bool NOT NULL
-				);`)
-				return err
-			},
-			delete: func(db db.DB) error {
-				_, err := db.Exec(`DROP TABLE credentials;`)
-				return err
-			},
-		},
-		{
-			name: "refreshTokens",
-			create: func(db db.DB) error {
-				_, err := db.Exec(`
-				CREATE TABLE refreshTokens(
-					userId text NOT NULL,
-					deviceId text NOT NULL,
-					refreshToken text NOT NULL,
-					PRIMARY KEY(userId, deviceId)
-				);`)
-				return err
-			},
-			delete: func(db db.DB) error {
-				_, err := db.Exec(`DROP TABLE refreshTokens;`)
-				return err
-			},
-		},
-		{
-			name: "operations",
-			create: func(db db.DB) error {
-				_, err := db.Exec(`
-				CREATE TABLE operations(
-					operationId text NOT NULL PRIMARY KEY,
-					createdAt bigint NOT NULL,
-					authorId text NOT NULL,
-					operationType text NOT NULL,
-					isLarge text NOT NULL,
-					data BYTEA NOT NULL,
-					searchHint text
-				);`)
-				return err
-			},
-			delete: func(db db.DB) error {
-				_, err := db.Exec(`DROP TABLE operations;`)
-				return err
-			},
-		},
-		{
-			name: "confirmedOperations",
-			create: func(db db.DB) error {
-				_, err := db.Exec(`
-				CREATE TABLE confirmedOperations(
-					userId text NOT NULL,
-					deviceId text NOT NULL,
-					operationId text NOT NULL,
-					PRIMARY KEY(userId, deviceId, operationId)
-				);`)
-				return err
-			},
-			delete: func(db db.DB) error {
-				_, err := db.Exec(`DROP TABLE confirmedOperations;`)
-				return err
-			},
-		},
-		{
-			name: "trackedEntities",
-			create: func(db db.DB) error {
-				_, err := db.Exec(`
-				CREATE TABLE trackedEntities(
-					userId text NOT NULL,
-					entityId text NOT NULL,
-					entityType text NOT NULL,
-					operationId text NOT NULL,
-					PRIMARY KEY(userId, entityId, entityType, operationId)
-				);`)
-				return err
-			},
-			delete: func(db db.DB) error {
-				_, err := db.Exec(`DROP TABLE trackedEntities;`)
-				return err
-			},
+		rollback: func(to migrations.Version) {
+			if err := migrator.Rollback(to); err != nil {
+				logger.LogFatal("failed to rollback to version %d err: %v", to, err)
+			}
+			logger.LogInfo("database schema is at version %d", to)
 		},
-		{
-			name: "operationsAffectingEntity",
-			create: func(db db.DB) error {
-				_, err := db.Exec(`
-				CREATE TABLE operationsAffectingEntity(
-					operationId text NOT NULL,
-					entityId text NOT NULL,
-					entityType text NOT NULL,
-					PRIMARY KEY(operationId, entityId, entityType)
-				);`)
-				return err
-			},
-			delete: func(db db.DB) error {
-				_, err := db.Exec(`DROP TABLE operationsAffectingEntity;`)
-				return err
-			},
+		rebuildBalances: func() {
+			if err := migrator.Check(); err != nil {
+				logger.LogFatal("refusing to rebuild balances against database schema, run `utilities --command migrate` err: %v", err)
+			}
+			controller := defaultBalancesController.New(
+				defaultOperationsRepository.New(database, logger),
+				defaultBalancesRepository.New(database, logger),
+				logger,
+			)
+			if err := controller.Rebuild(); err != nil {
+				logger.LogFatal("failed to rebuild balances err: %v", err)
+			}
+			logger.LogInfo("balances are rebuilt")
 		},
-		{
-			name: "images",
-			create: func(db db.DB) error {
-				_, err := db.Exec(`
-				CREATE TABLE images(
-					id text NOT NULL PRIMARY KEY,
-					base64 text NOT NULL
-				);`)
-				return err
-			},
-			delete: func(db db.DB) error {
-				_, err := db.Exec(`DROP TABLE images;`)
-				return err
-			},
+		compactOperations: func() {
+			if err := migrator.Check(); err != nil {
+				logger.LogFatal("refusing to compact operations against database schema, run `utilities --command migrate` err: %v", err)
+			}
+			controller := defaultCompactionController.New(
+				defaultOperationsRepository.New(database, logger),
+				defaultAuthRepository.New(database, logger),
+				logger,
+			)
+			report, err := controller.Compact()
+			if err != nil {
+				logger.LogFatal("failed to compact operations err: %v", err)
+			}
+			logger.LogInfo("operations are compacted, scanned %d snapshots %d removed %d", report.Scanned, report.Snapshots, report.Removed)
 		},
-		{
-			name: "pushTokens",
-			create: func(db db.DB) error {
-				_, err := db.Exec(`
-				CREATE TABLE pushTokens(
-					userId text NOT NULL,
-					deviceId text NOT NULL,
-					token text NOT NULL,
-					PRIMARY KEY(userId, deviceId)
-				);`)
-				return err
-			},
-			delete: func(db db.DB) error {
-				_, err := db.Exec(`DROP TABLE pushTokens;`)
-				return err
-			},
+		collectDevices: func() {
+			if err := migrator.Check(); err != nil {
+				logger.LogFatal("refusing to collect devices against database schema, run `utilities --command migrate` err: %v", err)
+			}
+			controller := defaultDevicesController.New(
+				defaultAuthRepository.New(database, logger),
+				defaultOperationsRepository.New(database, logger),
+				defaultPushNotificationsRepository.New(database, logger),
+				logger,
+			)
+			report, err := controller.CollectGarbage()
+			if err != nil {
+				logger.LogFatal("failed to collect devices err: %v", err)
+			}
+			logger.LogInfo(
+				"devices without session are forgotten, devices %d confirmations %d push tokens %d",
+				report.Devices,
+				report.Confirmations,
+				report.PushTokens,
+			)
 		},
-		{
-			name: "emailVerification",
-			create: func(db db.DB) error {
-				_, err := db.Exec(`
-				CREATE TABLE emailVerification(
-					email text NOT NULL PRIMARY KEY,
-					code text
-				);`)
-				return err
-			},
-			delete: func(db db.DB) error {
-				_, err := db.Exec(`DROP TABLE emailVerification;`)
-				return err
-			},
+		deadNotifications: func(limit int) {
+			if err := migrator.Check(); err != nil {
+				logger.LogFatal("refusing to read notifications against database schema, run `utilities --command migrate` err: %v", err)
+			}
+			dead, err := defaultOperationsRepository.New(database, logger).DeadNotifications(limit)
+			if err != nil {
+				logger.LogFatal("failed to get dead notifications err: %v", err)
+			}
+			for _, notification := range dead {
+				logger.LogInfo(
+					"dead  %s created at %d after %d attempts, last error: %s, payload: %s",
+					notification.Id,
+					notification.CreatedAt,
+					notification.Attempts,
+					notification.LastError,
+					notification.Payload,
+				)
+			}
+			logger.LogInfo("%d dead notifications", len(dead))
 		},
-	}
+	}, nil
 }
@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 
+	"verni/internal/db/migrations"
+	"verni/internal/services/logging"
 	standartOutputLoggingService "verni/internal/services/logging/standartOutput"
 	"verni/internal/services/pathProvider"
 	defaultPathProvider "verni/internal/services/pathProvider/default"
@@ -22,42 +25,92 @@ func main() {
 		logger.LogFatal("failed to get command type: %v", err)
 	}
 	switch command {
-	case commandNameCreateTables:
-		configData, err := getConfigData(args, pathProvider)
+	case commandNameMigrate, commandNameCreateTables:
+		actions := databaseActionsFromArgs(args, pathProvider, logger)
+		actions.migrate()
+	case commandNameMigrateStatus:
+		actions := databaseActionsFromArgs(args, pathProvider, logger)
+		actions.status()
+	case commandNameRollback:
+		toValue, err := valueForArg(argNameRollbackTarget, args)
 		if err != nil {
-			logger.LogFatal("failed to get config data: %v", err)
+			logger.LogFatal("failed to get rollback target version: %v", err)
 		}
-		actions, err := createDatabaseActions(configData, logger)
-		if err != nil {
-			logger.LogFatal("failed to create database actions err: %v", err)
+		to, err := strconv.Atoi(toValue)
+		if err != nil || to < 0 {
+			logger.LogFatal("bad rollback target version %s", toValue)
 		}
-		actions.setup()
+		actions := databaseActionsFromArgs(args, pathProvider, logger)
+		actions.rollback(migrations.Version(to))
 	case commandNameDropTables:
-		configData, err := getConfigData(args, pathProvider)
-		if err != nil {
-			logger.LogFatal("failed to get config data: %v", err)
-		}
-		actions, err := createDatabaseActions(configData, logger)
-		if err != nil {
-			logger.LogFatal("failed to create database actions err: %v", err)
+		actions := databaseActionsFromArgs(args, pathProvider, logger)
+		actions.rollback(0)
+	case commandNameRebuildBalances:
+		actions := databaseActionsFromArgs(args, pathProvider, logger)
+		actions.rebuildBalances()
+	case commandNameCompactOperations:
+		actions := databaseActionsFromArgs(args, pathProvider, logger)
+		actions.compactOperations()
+	case commandNameCollectDevices:
+		actions := databaseActionsFromArgs(args, pathProvider, logger)
+		actions.collectDevices()
+	case commandNameDeadNotifications:
+		limit := defaultDeadNotificationsLimit
+		if limitValue, err := valueForArg(argNameLimit, args); err == nil {
+			limit, err = strconv.Atoi(limitValue)
+			if err != nil || limit <= 0 {
+				logger.LogFatal("bad limit %s", limitValue)
+			}
 		}
-		actions.drop()
+		actions := databaseActionsFromArgs(args, pathProvider, logger)
+		actions.deadNotifications(limit)
+	default:
+		logger.LogFatal("unknown command %s", command)
 	}
 }
 
+func databaseActionsFromArgs(args []string, pathProvider pathProvider.Service, logger logging.Service) databaseActions {
+	configData, err := getConfigData(args, pathProvider)
+	if err != nil {
+		logger.LogFatal("failed to get config data: %v", err)
+	}
+	actions, err := createDatabaseActions(configData, logger)
+	if err != nil {
+		logger.LogFatal("failed to create database actions err: %v", err)
+	}
+	return actions
+}
+
 const (
-	argNameCommandType   = "--command"
-	argNameConfigKeyPath = "--config-key-path"
-	argNameConfigPath    = "--config-path"
+	argNameCommandType    = "--command"
+	argNameConfigKeyPath  = "--config-key-path"
+	argNameConfigPath     = "--config-path"
+	argNameRollbackTarget = "--to"
+	argNameLimit          = "--limit"
 )
 
 const (
+	commandNameMigrate       = "migrate"
+	commandNameMigrateStatus = "migrate-status"
+	commandNameRollback      = "rollback"
+	// rebuild-balances should be run while the server is stopped
+	commandNameRebuildBalances = "rebuild-balances"
+	// compact-operations can be run while the server is running
+	commandNameCompactOperations = "compact-operations"
+	// collect-devices can be run while the server is running
+	commandNameCollectDevices = "collect-devices"
+	// dead-notifications lists notifications that ran out of delivery attempts
+	commandNameDeadNotifications = "dead-notifications"
+
+	// kept for existing scripts, equivalent to `migrate` and `rollback --to 0`
 	commandNameCreateTables = "create-tables"
 	commandNameDropTables   = "drop-tables"
 )
 
 const (
 	argNotFoundError = "arg not found"
+
+	defaultDeadNotificationsLimit = 100
 )
 
 func valueForArg(argName string, args []string) (string, error) {
@@ -8,25 +8,40 @@ import (
 	"time"
 
 	"verni/internal/db"
+	postgresMigrator "verni/internal/db/migrations/postgres"
 	postgresDb "verni/internal/db/postgres"
 	openapi "verni/internal/openapi/go"
 	"verni/internal/openapi/openapiImplementation"
 	authRepository "verni/internal/repositories/auth"
 	defaultAuthRepository "verni/internal/repositories/auth/default"
+	memoryAuthRepository "verni/internal/repositories/auth/memory"
+	balancesRepository "verni/internal/repositories/balances"
+	defaultBalancesRepository "verni/internal/repositories/balances/default"
+	memoryBalancesRepository "verni/internal/repositories/balances/memory"
+	invitationsRepository "verni/internal/repositories/invitations"
+	defaultInvitationsRepository "verni/internal/repositories/invitations/default"
+	memoryInvitationsRepository "verni/internal/repositories/invitations/memory"
 	operationsRepository "verni/internal/repositories/operations"
 	defaultOperationsRepository "verni/internal/repositories/operations/default"
+	memoryOperationsRepository "verni/internal/repositories/operations/memory"
 	pushRegistryRepository "verni/internal/repositories/pushNotifications"
 	defaultPushRegistryRepository "verni/internal/repositories/pushNotifications/default"
+	memoryPushRegistryRepository "verni/internal/repositories/pushNotifications/memory"
 	verificationRepository "verni/internal/repositories/verification"
 	defaultVerificationRepository "verni/internal/repositories/verification/default"
+	memoryVerificationRepository "verni/internal/repositories/verification/memory"
 	defaultServer "verni/internal/server/default"
 
 	"verni/internal/server"
 
+	"verni/internal/services/dispatcher"
+	poolDispatcher "verni/internal/services/dispatcher/pool"
 	"verni/internal/services/emailSender"
 	yandexEmailSender "verni/internal/services/emailSender/yandex"
 	"verni/internal/services/formatValidation"
 	defaultFormatValidation "verni/internal/services/formatValidation/default"
+	"verni/internal/services/imageStorage"
+	localImageStorage "verni/internal/services/imageStorage/local"
 	"verni/internal/services/jwt"
 	defaultJwtService "verni/internal/services/jwt/default"
 	"verni/internal/services/logging"
@@ -38,14 +53,23 @@ import (
 	applePushNotifications "verni/internal/services/pushNotifications/apns"
 	"verni/internal/services/realtimeEvents"
 	defaultRealtimeEvents "verni/internal/services/realtimeEvents/default"
+	postgresRealtimeEvents "verni/internal/services/realtimeEvents/postgres"
 	"verni/internal/services/watchdog"
 	telegramWatchdog "verni/internal/services/watchdog/telegram"
 
 	"errors"
 	authController "verni/internal/controllers/auth"
 	defaultAuthController "verni/internal/controllers/auth/default"
+	balancesController "verni/internal/controllers/balances"
+	defaultBalancesController "verni/internal/controllers/balances/default"
+	compactionController "verni/internal/controllers/compaction"
+	defaultCompactionController "verni/internal/controllers/compaction/default"
+	devicesController "verni/internal/controllers/devices"
+	defaultDevicesController "verni/internal/controllers/devices/default"
 	imagesController "verni/internal/controllers/images"
 	defaultImagesController "verni/internal/controllers/images/default"
+	invitationsController "verni/internal/controllers/invitations"
+	defaultInvitationsController "verni/internal/controllers/invitations/default"
 	operationsController "verni/internal/controllers/operations"
 	defaultOperationsController "verni/internal/controllers/operations/default"
 	usersController "verni/internal/controllers/users"
@@ -56,6 +80,10 @@ import (
 
 const (
 	argNameConfigPath = "--config-path"
+	// dispatcherStatsInterval is how often queue depth and lag of background tasks are logged
+	dispatcherStatsInterval = time.Minute
+	// notificationsRelayInterval is how often the outbox is checked for notifications to deliver or retry
+	notificationsRelayInterval = 10 * time.Second
 )
 
 var (
@@ -64,6 +92,8 @@ var (
 
 type Repositories struct {
 	auth         authRepository.Repository
+	balances     balancesRepository.Repository
+	invitations  invitationsRepository.Repository
 	operations   operationsRepository.Repository
 	pushRegistry pushRegistryRepository.Repository
 	verification verificationRepository.Repository
@@ -75,11 +105,17 @@ type Services struct {
 	emailSender             emailSender.Service
 	formatValidationService formatValidation.Service
 	realtimeEventsService   realtimeEvents.Service
+	imageStorage            imageStorage.Service
+	dispatcher              dispatcher.Service
 }
 
 type Controllers struct {
 	auth         authController.Controller
+	balances     balancesController.Controller
+	compaction   compactionController.Controller
+	devices      devicesController.Controller
 	images       imagesController.Controller
+	invitations  invitationsController.Controller
 	operations   operationsController.Controller
 	users        usersController.Controller
 	verification verificationController.Controller
@@ -105,8 +141,13 @@ func main() {
 		PushNotifications Module `json:"pushNotifications"`
 		EmailSender       Module `json:"emailSender"`
 		Jwt               Module `json:"jwt"`
+		ImageStorage      Module `json:"imageStorage"`
+		RealtimeEvents    Module `json:"realtimeEvents"`
+		Dispatcher        Module `json:"dispatcher"`
 		Server            Module `json:"server"`
 		Watchdog          Module `json:"watchdog"`
+		Compaction        Module `json:"compaction"`
+		DevicesCollection Module `json:"devicesCollection"`
 	}
 	logger, pathProvider, config := func() (logging.Service, pathProvider.Service, Config) {
 		startupTime := time.Now()
@@ -171,7 +212,7 @@ func main() {
 	}()
 	logger.LogInfo("initializing with config %v", config)
 
-	database := func() db.DB {
+	database, repositories := func() (db.DB, Repositories) {
 		switch config.Storage.Type {
 		case "postgres":
 			data, err := json.Marshal(config.Storage.Config)
@@ -186,18 +227,34 @@ func main() {
 				logger.LogFatal("failed to initialize postgres err: %v", err)
 			}
 			logger.LogInfo("initialized postgres")
-			return db
+			if err := postgresMigrator.New(db, logger).Check(); err != nil {
+				logger.LogFatal("refusing to start against database schema, run `utilities --command migrate` err: %v", err)
+			}
+			return db, Repositories{
+				auth:         defaultAuthRepository.New(db, logger),
+				balances:     defaultBalancesRepository.New(db, logger),
+				invitations:  defaultInvitationsRepository.New(db, logger),
+				operations:   defaultOperationsRepository.New(db, logger),
+				pushRegistry: defaultPushRegistryRepository.New(db, logger),
+				verification: defaultVerificationRepository.New(db, logger),
+			}
+		case "memory":
+			logger.LogInfo("initialized in-memory storage, data will not survive restart")
+			return nil, Repositories{
+				auth:         memoryAuthRepository.New(logger),
+				balances:     memoryBalancesRepository.New(logger),
+				invitations:  memoryInvitationsRepository.New(logger),
+				operations:   memoryOperationsRepository.New(logger),
+				pushRegistry: memoryPushRegistryRepository.New(logger),
+				verification: memoryVerificationRepository.New(logger),
+			}
 		default:
 			logger.LogFatal("unknown storage type %s", config.Storage.Type)
-			return nil
+			return nil, Repositories{}
 		}
 	}()
-	defer database.Close()
-	repositories := Repositories{
-		auth:         defaultAuthRepository.New(database, logger),
-		operations:   defaultOperationsRepository.New(database, logger),
-		pushRegistry: defaultPushRegistryRepository.New(database, logger),
-		verification: defaultVerificationRepository.New(database, logger),
+	if database != nil {
+		defer database.Close()
 	}
 	services := Services{
 		push: func() pushNotifications.Service {
@@ -263,7 +320,74 @@ func main() {
 			return defaultFormatValidation.New(logger)
 		}(),
 		realtimeEventsService: func() realtimeEvents.Service {
-			return defaultRealtimeEvents.NewUserUpdateService()
+			switch config.RealtimeEvents.Type {
+			case "", "local":
+				// updates reach only clients connected to this instance
+				return defaultRealtimeEvents.NewUserUpdateService()
+			case "postgres":
+				if config.Storage.Type != "postgres" || database == nil {
+					logger.LogFatal("postgres realtime events require postgres storage, got %s", config.Storage.Type)
+				}
+				data, err := json.Marshal(config.RealtimeEvents.Config)
+				if err != nil {
+					logger.LogFatal("failed to serialize postgres realtime events config err: %v", err)
+				}
+				var realtimeConfig postgresRealtimeEvents.PostgresConfig
+				json.Unmarshal(data, &realtimeConfig)
+				data, err = json.Marshal(config.Storage.Config)
+				if err != nil {
+					logger.LogFatal("failed to serialize postgres config err: %v", err)
+				}
+				var connection postgresDb.PostgresConfig
+				json.Unmarshal(data, &connection)
+				logger.LogInfo("creating postgres realtime events with config %v", realtimeConfig)
+				service, err := postgresRealtimeEvents.New(realtimeConfig, database, connection, logger)
+				if err != nil {
+					logger.LogFatal("failed to initialize postgres realtime events err: %v", err)
+				}
+				logger.LogInfo("initialized postgres realtime events")
+				return service
+			default:
+				logger.LogFatal("unknown realtime events type %s", config.RealtimeEvents.Type)
+				return nil
+			}
+		}(),
+		dispatcher: func() dispatcher.Service {
+			switch config.Dispatcher.Type {
+			case "", "pool":
+				data, err := json.Marshal(config.Dispatcher.Config)
+				if err != nil {
+					logger.LogFatal("failed to serialize pool dispatcher config err: %v", err)
+				}
+				var poolConfig poolDispatcher.PoolConfig
+				json.Unmarshal(data, &poolConfig)
+				logger.LogInfo("creating pool dispatcher with config %v", poolConfig)
+				return poolDispatcher.New(poolConfig, logger)
+			default:
+				logger.LogFatal("unknown dispatcher type %s", config.Dispatcher.Type)
+				return nil
+			}
+		}(),
+		imageStorage: func() imageStorage.Service {
+			switch config.ImageStorage.Type {
+			case "", "local":
+				data, err := json.Marshal(config.ImageStorage.Config)
+				if err != nil {
+					logger.LogFatal("failed to serialize local image storage config err: %v", err)
+				}
+				var localConfig localImageStorage.LocalConfig
+				json.Unmarshal(data, &localConfig)
+				logger.LogInfo("creating local image storage with config %v", localConfig)
+				service, err := localImageStorage.New(localConfig, pathProvider, logger)
+				if err != nil {
+					logger.LogFatal("failed to initialize local image storage err: %v", err)
+				}
+				logger.LogInfo("initialized local image storage")
+				return service
+			default:
+				logger.LogFatal("unknown image storage type %s", config.ImageStorage.Type)
+				return nil
+			}
 		}(),
 	}
 	controllers := Controllers{
@@ -275,15 +399,38 @@ func main() {
 			services.formatValidationService,
 			logger,
 		),
+		balances: defaultBalancesController.New(
+			repositories.operations,
+			repositories.balances,
+			logger,
+		),
+		compaction: defaultCompactionController.New(
+			repositories.operations,
+			repositories.auth,
+			logger,
+		),
+		devices: defaultDevicesController.New(
+			repositories.auth,
+			repositories.operations,
+			repositories.pushRegistry,
+			logger,
+		),
 		images: defaultImagesController.New(
 			repositories.operations,
+			services.imageStorage,
 			logger,
 		),
 		operations: defaultOperationsController.New(
 			repositories.operations,
+			repositories.balances,
 			services.realtimeEventsService,
 			services.push,
 			repositories.pushRegistry,
+			services.formatValidationService,
+			services.dispatcher,
+			func() time.Time {
+				return time.Now()
+			},
 			logger,
 		),
 		users: defaultUsersController.New(
@@ -297,6 +444,96 @@ func main() {
 			logger,
 		),
 	}
+	controllers.invitations = defaultInvitationsController.New(
+		repositories.invitations,
+		repositories.operations,
+		controllers.operations,
+		func() time.Time {
+			return time.Now()
+		},
+		logger,
+	)
+	schedule := func(name string, module Module, job func()) {
+		switch module.Type {
+		case "periodic":
+			data, err := json.Marshal(module.Config)
+			if err != nil {
+				logger.LogFatal("failed to serialize periodic %s config err: %v", name, err)
+			}
+			var periodicConfig struct {
+				IntervalHours int `json:"intervalHours"`
+			}
+			json.Unmarshal(data, &periodicConfig)
+			if periodicConfig.IntervalHours <= 0 {
+				logger.LogFatal("bad periodic %s interval %d", name, periodicConfig.IntervalHours)
+			}
+			logger.LogInfo("scheduling %s with config %v", name, periodicConfig)
+			go func() {
+				ticker := time.NewTicker(time.Duration(periodicConfig.IntervalHours) * time.Hour)
+				defer ticker.Stop()
+				for range ticker.C {
+					job()
+				}
+			}()
+		case "":
+			logger.LogInfo("%s is not scheduled", name)
+		default:
+			logger.LogFatal("unknown %s type %s", name, module.Type)
+		}
+	}
+	schedule("operations compaction", config.Compaction, func() {
+		report, err := controllers.compaction.Compact()
+		if err != nil {
+			logger.LogError("scheduled operations compaction failed err: %v", err)
+			return
+		}
+		logger.LogInfo("operations are compacted, scanned %d snapshots %d removed %d", report.Scanned, report.Snapshots, report.Removed)
+	})
+	schedule("devices garbage collection", config.DevicesCollection, func() {
+		report, err := controllers.devices.CollectGarbage()
+		if err != nil {
+			logger.LogError("scheduled devices garbage collection failed err: %v", err)
+			return
+		}
+		logger.LogInfo(
+			"devices without session are forgotten, devices %d confirmations %d push tokens %d",
+			report.Devices,
+			report.Confirmations,
+			report.PushTokens,
+		)
+	})
+	go func() {
+		ticker := time.NewTicker(dispatcherStatsInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			stats := services.dispatcher.Stats()
+			logger.LogInfo(
+				"dispatcher queue %d/%d, dispatched %d completed %d rejected %d, lag last %v max %v",
+				stats.Depth,
+				stats.Capacity,
+				stats.Dispatched,
+				stats.Completed,
+				stats.Rejected,
+				stats.LastLag,
+				stats.MaxLag,
+			)
+		}
+	}()
+	go func() {
+		ticker := time.NewTicker(notificationsRelayInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			report, err := controllers.operations.DeliverNotifications()
+			if err != nil {
+				logger.LogError("delivering notifications failed err: %v", err)
+				continue
+			}
+			if report == (operationsController.NotificationsReport{}) {
+				continue
+			}
+			logger.LogInfo("notifications are delivered %d retried %d dead %d dropped %d", report.Delivered, report.Retried, report.Dead, report.Dropped)
+		}
+	}()
 	api := func() openapi.DefaultAPIServicer {
 		return openapiImplementation.New(
 			controllers.auth,
@@ -304,6 +541,8 @@ func main() {
 			controllers.users,
 			controllers.images,
 			controllers.operations,
+			controllers.balances,
+			controllers.invitations,
 			logger,
 		)
 	}()
@@ -317,15 +556,35 @@ func main() {
 			var defaultConfig defaultServer.ServerConfig
 			json.Unmarshal(data, &defaultConfig)
 			logger.LogInfo("creating gin server with config %v", defaultConfig)
+			operationsQueue := openapiImplementation.NewOperationsQueue(
+				services.realtimeEventsService,
+				controllers.operations,
+				controllers.images,
+				func() time.Time {
+					return time.Now()
+				},
+				logger,
+			)
 
 			return defaultServer.New(
 				defaultConfig,
 				openapiImplementation.NewSSEHandler(
-					services.realtimeEventsService,
+					operationsQueue,
 					controllers.auth,
+					logger,
+				),
+				openapiImplementation.NewWebSocketHandler(
+					operationsQueue,
+					controllers.auth,
+					controllers.images,
 					controllers.operations,
 					logger,
 				),
+				openapiImplementation.NewImagesHandler(
+					controllers.auth,
+					controllers.images,
+					logger,
+				),
 				api,
 				pathProvider,
 				logger,
@@ -18,6 +18,8 @@ type Session struct {
 type StartupData struct {
 	Session    Session
 	Operations []openapi.SomeOperation
+	// HasMore is set when there are more operations to be pulled than returned in startup data
+	HasMore bool
 }
 
 type UserDevice struct {
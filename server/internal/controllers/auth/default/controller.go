@@ -26,6 +26,9 @@ type AuthRepository authRepository.Repository
 type OperationsRepository operationsRepository.Repository
 type PushTokensRepository pushNotificationsRepository.Repository
 
+// startupOperationsLimit bounds operations returned on login, the rest is expected to be pulled page by page
+const startupOperationsLimit = 500
+
 func New(
 	authRepository AuthRepository,
 	operationsRepository OperationsRepository,
@@ -112,6 +115,7 @@ func (c *defaultController) Signup(device auth.DeviceId, email string, password
 		operationsRepository.UserId(subject.User),
 		operationsRepository.DeviceId(subject.Device),
 		false,
+		nil,
 	)
 	if err := createOperationTransaction.Perform(); err != nil {
 		return auth.StartupData{}, fmt.Errorf("%s: creating operation: %w", op, err)
@@ -190,11 +194,17 @@ func (c *defaultController) Login(device auth.DeviceId, email string, password a
 	rawPulledOperations, err := c.operationsRepository.Pull(
 		operationsRepository.UserId(subject.User),
 		operationsRepository.DeviceId(subject.Device),
+		0,
+		startupOperationsLimit+1,
 		operationsRepository.OperationTypeRegular,
 	)
 	if err != nil {
 		return auth.StartupData{}, fmt.Errorf("%s: pulling operations for startup: %w", op, err)
 	}
+	hasMore := len(rawPulledOperations) > startupOperationsLimit
+	if hasMore {
+		rawPulledOperations = rawPulledOperations[:startupOperationsLimit]
+	}
 
 	startupOperations := make([]openapi.SomeOperation, len(rawPulledOperations))
 	for index, operation := range rawPulledOperations {
@@ -226,6 +236,7 @@ func (c *defaultController) Login(device auth.DeviceId, email string, password a
 			RefreshToken: string(refreshToken),
 		},
 		Operations: startupOperations,
+		HasMore:    hasMore,
 	}, nil
 }
 
@@ -45,7 +45,7 @@ func TestController_Signup(t *testing.T) {
 		}
 
 		opsRepo := &operationsRepository_mock.RepositoryMock{
-			PushImpl: func(operations []operationsRepository.PushOperation, userId operationsRepository.UserId, deviceId operationsRepository.DeviceId, confirm bool) repositories.UnitOfWork {
+			PushImpl: func(operations []operationsRepository.PushOperation, userId operationsRepository.UserId, deviceId operationsRepository.DeviceId, confirm bool, notifications []operationsRepository.Notification) repositories.UnitOfWork {
 				return repositories.UnitOfWork{
 					Perform:  func() error { return nil },
 					Rollback: func() error { return nil },
@@ -173,7 +173,7 @@ func TestController_Login(t *testing.T) {
 		}
 
 		opsRepo := &operationsRepository_mock.RepositoryMock{
-			PullImpl: func(userId operationsRepository.UserId, deviceId operationsRepository.DeviceId, operationType operationsRepository.OperationType) ([]operationsRepository.Operation, error) {
+			PullImpl: func(userId operationsRepository.UserId, deviceId operationsRepository.DeviceId, after operationsRepository.SequenceNumber, limit int, operationType operationsRepository.OperationType) ([]operationsRepository.Operation, error) {
 				return []operationsRepository.Operation{}, nil
 			},
 		}
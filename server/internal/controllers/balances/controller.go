@@ -0,0 +1,26 @@
+package balances
+
+import (
+	"errors"
+	openapi "verni/internal/openapi/go"
+)
+
+type UserId string
+type GroupId string
+
+var (
+	NoSuchGroup     = errors.New("no such group")
+	NotAParticipant = errors.New("not a participant")
+)
+
+type Controller interface {
+	// Get returns non-zero balances of the user in every group and with every counterparty
+	Get(userId UserId) (openapi.Balances, error)
+	// Settle suggests transfers settling debts between participants of the group, at most one less than
+	// the number of users with a balance per currency. Users who left the group are settled too while
+	// their balance is not zero. Fails with NoSuchGroup or NotAParticipant if the user can not see the group.
+	Settle(userId UserId, groupId GroupId) ([]openapi.SettlementTransfer, error)
+	// Rebuild recomputes balances from the whole operations log, previous balances are kept if it fails.
+	// Pushes are not synchronized with it, so it should be run while the server is stopped.
+	Rebuild() error
+}
@@ -0,0 +1,125 @@
+package defaultController
+
+import (
+	"encoding/json"
+	"fmt"
+	"verni/internal/common"
+	"verni/internal/controllers/balances"
+	openapi "verni/internal/openapi/go"
+	"verni/internal/repositories"
+	balancesRepository "verni/internal/repositories/balances"
+	operationsRepository "verni/internal/repositories/operations"
+	"verni/internal/services/logging"
+)
+
+type OperationsRepository operationsRepository.Repository
+type BalancesRepository balancesRepository.Repository
+
+const rebuildPageSize = 1000
+
+func New(
+	operationsRepository OperationsRepository,
+	balancesRepository BalancesRepository,
+	logger logging.Service,
+) balances.Controller {
+	return &defaultController{
+		operationsRepository: operationsRepository,
+		balancesRepository:   balancesRepository,
+		logger:               logger,
+	}
+}
+
+type defaultController struct {
+	operationsRepository OperationsRepository
+	balancesRepository   BalancesRepository
+	logger               logging.Service
+}
+
+func (c *defaultController) Get(userId balances.UserId) (openapi.Balances, error) {
+	const op = "controllers.balances.defaultController.Get"
+	c.logger.LogInfo("%s: start[user=%s]", op, userId)
+
+	groups, err := c.balancesRepository.GetGroupBalances(balancesRepository.UserId(userId))
+	if err != nil {
+		return openapi.Balances{}, fmt.Errorf("getting group balances from repository: %w", err)
+	}
+	counterparties, err := c.balancesRepository.GetCounterpartyBalances(balancesRepository.UserId(userId))
+	if err != nil {
+		return openapi.Balances{}, fmt.Errorf("getting counterparty balances from repository: %w", err)
+	}
+
+	c.logger.LogInfo("%s: success[user=%s]", op, userId)
+	return openapi.Balances{
+		Groups: common.Map(groups, func(balance balancesRepository.GroupBalance) openapi.GroupBalance {
+			return openapi.GroupBalance{
+				GroupId:  string(balance.GroupId),
+				Currency: string(balance.Currency),
+				Amount:   balance.Amount,
+			}
+		}),
+		Counterparties: common.Map(counterparties, func(balance balancesRepository.CounterpartyBalance) openapi.CounterpartyBalance {
+			return openapi.CounterpartyBalance{
+				CounterpartyId: string(balance.CounterpartyId),
+				Currency:       string(balance.Currency),
+				Amount:         balance.Amount,
+			}
+		}),
+	}, nil
+}
+
+func (c *defaultController) Rebuild() error {
+	const op = "controllers.balances.defaultController.Rebuild"
+	c.logger.LogInfo("%s: start", op)
+
+	performed := []repositories.UnitOfWork{}
+	rollback := func() {
+		for index := len(performed) - 1; index >= 0; index-- {
+			if err := performed[index].Rollback(); err != nil {
+				c.logger.LogError("%s: rolling back balances: %v", op, err)
+			}
+		}
+	}
+
+	reset := c.balancesRepository.Reset()
+	if err := reset.Perform(); err != nil {
+		return fmt.Errorf("resetting balances: %w", err)
+	}
+	performed = append(performed, reset)
+
+	var after operationsRepository.SequenceNumber
+	for {
+		page, err := c.operationsRepository.Log(after, rebuildPageSize, operationsRepository.OperationTypeRegular)
+		if err != nil {
+			rollback()
+			return fmt.Errorf("getting operations after %d: %w", after, err)
+		}
+		for _, operation := range page {
+			data, err := operation.Payload.Data()
+			if err != nil {
+				rollback()
+				return fmt.Errorf("getting data from operation %s: %w", operation.OperationId, err)
+			}
+			var converted openapi.SomeOperation
+			if err := json.Unmarshal(data, &converted); err != nil {
+				rollback()
+				return fmt.Errorf("parsing operation %s: %w", operation.OperationId, err)
+			}
+			work, affects := balancesRepository.Project(c.balancesRepository, converted)
+			if !affects {
+				continue
+			}
+			if err := work.Perform(); err != nil {
+				rollback()
+				return fmt.Errorf("projecting operation %s: %w", operation.OperationId, err)
+			}
+			performed = append(performed, work)
+		}
+		if len(page) < rebuildPageSize {
+			break
+		}
+		after = page[len(page)-1].SequenceNumber
+	}
+
+	c.logger.LogInfo("%s: success[projected=%d]", op, len(performed)-1)
+	return nil
+}
@@ -0,0 +1,428 @@
+package defaultController_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"verni/internal/controllers/balances"
+	defaultController "verni/internal/controllers/balances/default"
+	openapi "verni/internal/openapi/go"
+	"verni/internal/repositories"
+	balancesRepository "verni/internal/repositories/balances"
+	balancesRepository_mock "verni/internal/repositories/balances/mock"
+	"verni/internal/repositories/operations"
+	operationsRepository_mock "verni/internal/repositories/operations/mock"
+	standartOutputLoggingService "verni/internal/services/logging/standartOutput"
+)
+
+// Mock implementation of OperationPayload interface
+type mockOperationPayload struct {
+	payloadType operations.OperationPayloadType
+	data        []byte
+}
+
+func (m mockOperationPayload) Type() operations.OperationPayloadType {
+	return m.payloadType
+}
+
+func (m mockOperationPayload) Data() ([]byte, error) {
+	return m.data, nil
+}
+
+func (m mockOperationPayload) TrackedEntities() []operations.TrackedEntity {
+	return []operations.TrackedEntity{}
+}
+
+func (m mockOperationPayload) IsLarge() bool {
+	return false
+}
+
+func (m mockOperationPayload) SearchHint() *string {
+	return nil
+}
+
+func spendingOperation(t *testing.T, sequenceNumber operations.SequenceNumber, spendingId string) operations.Operation {
+	data, err := json.Marshal(openapi.SomeOperation{
+		OperationId: spendingId,
+		AuthorId:    "alice",
+		CreateSpending: openapi.CreateSpendingOperationCreateSpending{
+			SpendingId: spendingId,
+			GroupId:    "trip",
+			Currency:   "EUR",
+			Amount:     100,
+			Shares: []openapi.SpendingShare{
+				{UserId: "alice", Amount: 50},
+				{UserId: "bob", Amount: -50},
+			},
+		},
+	})
+	require.NoError(t, err)
+	return operations.Operation{
+		SequenceNumber: sequenceNumber,
+		OperationId:    operations.OperationId(spendingId),
+		AuthorId:       "alice",
+		Payload:        mockOperationPayload{payloadType: operations.CreateSpendingOperationPayloadType, data: data},
+	}
+}
+
+// groupRepository knows a single group "trip" created by alice with bob and carol
+func groupRepository(t *testing.T) *operationsRepository_mock.RepositoryMock {
+	data, err := json.Marshal(openapi.SomeOperation{
+		OperationId: "create-trip",
+		AuthorId:    "alice",
+		CreateSpendingGroup: openapi.CreateSpendingGroupOperationCreateSpendingGroup{
+			GroupId:      "trip",
+			Participants: []string{"bob", "carol"},
+		},
+	})
+	require.NoError(t, err)
+	return &operationsRepository_mock.RepositoryMock{
+		GetImpl: func(affectingEntities []operations.TrackedEntity) ([]operations.Operation, error) {
+			if affectingEntities[0].Id != "trip" {
+				return []operations.Operation{}, nil
+			}
+			return []operations.Operation{
+				{
+					OperationId: "create-trip",
+					AuthorId:    "alice",
+					Payload:     mockOperationPayload{payloadType: operations.CreateSpendingGroupOperationPayloadType, data: data},
+				},
+			}, nil
+		},
+	}
+}
+
+func TestController_Get(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+
+	t.Run("balances are converted", func(t *testing.T) {
+		// Arrange
+		repository := &balancesRepository_mock.RepositoryMock{
+			GetGroupBalancesImpl: func(userId balancesRepository.UserId) ([]balancesRepository.GroupBalance, error) {
+				return []balancesRepository.GroupBalance{{GroupId: "trip", Currency: "EUR", Amount: 50}}, nil
+			},
+			GetCounterpartyBalancesImpl: func(userId balancesRepository.UserId) ([]balancesRepository.CounterpartyBalance, error) {
+				return []balancesRepository.CounterpartyBalance{{CounterpartyId: "bob", Currency: "EUR", Amount: 50}}, nil
+			},
+		}
+		controller := defaultController.New(&operationsRepository_mock.RepositoryMock{}, repository, logger)
+
+		// Act
+		result, err := controller.Get(balances.UserId("alice"))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, openapi.Balances{
+			Groups:         []openapi.GroupBalance{{GroupId: "trip", Currency: "EUR", Amount: 50}},
+			Counterparties: []openapi.CounterpartyBalance{{CounterpartyId: "bob", Currency: "EUR", Amount: 50}},
+		}, result)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		// Arrange
+		expectedErr := errors.New("repository error")
+		repository := &balancesRepository_mock.RepositoryMock{
+			GetGroupBalancesImpl: func(userId balancesRepository.UserId) ([]balancesRepository.GroupBalance, error) {
+				return nil, expectedErr
+			},
+		}
+		controller := defaultController.New(&operationsRepository_mock.RepositoryMock{}, repository, logger)
+
+		// Act
+		_, err := controller.Get(balances.UserId("alice"))
+
+		// Assert
+		assert.ErrorIs(t, err, expectedErr)
+	})
+}
+
+func TestController_Rebuild(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	log := []operations.Operation{
+		spendingOperation(t, 1, "dinner"),
+		spendingOperation(t, 2, "taxi"),
+	}
+
+	t.Run("spendings of the log are accounted after reset", func(t *testing.T) {
+		// Arrange
+		calls := []string{}
+		operationsRepository := &operationsRepository_mock.RepositoryMock{
+			LogImpl: func(after operations.SequenceNumber, limit int, operationType operations.OperationType) ([]operations.Operation, error) {
+				assert.Equal(t, operations.SequenceNumber(0), after)
+				return log, nil
+			},
+		}
+		repository := &balancesRepository_mock.RepositoryMock{
+			ResetImpl: func() repositories.UnitOfWork {
+				return repositories.UnitOfWork{
+					Perform: func() error {
+						calls = append(calls, "reset")
+						return nil
+					},
+				}
+			},
+			AddSpendingImpl: func(spending balancesRepository.Spending) repositories.UnitOfWork {
+				return repositories.UnitOfWork{
+					Perform: func() error {
+						calls = append(calls, string(spending.Id))
+						return nil
+					},
+				}
+			},
+		}
+		controller := defaultController.New(operationsRepository, repository, logger)
+
+		// Act
+		err := controller.Rebuild()
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"reset", "dinner", "taxi"}, calls)
+	})
+
+	t.Run("everything is rolled back on failure", func(t *testing.T) {
+		// Arrange
+		expectedErr := errors.New("repository error")
+		rolledBack := []string{}
+		operationsRepository := &operationsRepository_mock.RepositoryMock{
+			LogImpl: func(after operations.SequenceNumber, limit int, operationType operations.OperationType) ([]operations.Operation, error) {
+				return log, nil
+			},
+		}
+		repository := &balancesRepository_mock.RepositoryMock{
+			ResetImpl: func() repositories.UnitOfWork {
+				return repositories.UnitOfWork{
+					Perform: func() error { return nil },
+					Rollback: func() error {
+						rolledBack = append(rolledBack, "reset")
+						return nil
+					},
+				}
+			},
+			AddSpendingImpl: func(spending balancesRepository.Spending) repositories.UnitOfWork {
+				return repositories.UnitOfWork{
+					Perform: func() error {
+						if spending.Id == "taxi" {
+							return expectedErr
+						}
+						return nil
+					},
+					Rollback: func() error {
+						rolledBack = append(rolledBack, string(spending.Id))
+						return nil
+					},
+				}
+			},
+		}
+		controller := defaultController.New(operationsRepository, repository, logger)
+
+		// Act
+		err := controller.Rebuild()
+
+		// Assert
+		assert.ErrorIs(t, err, expectedErr)
+		assert.Equal(t, []string{"dinner", "reset"}, rolledBack)
+	})
+}
+
+func TestController_Settle(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	repository := &balancesRepository_mock.RepositoryMock{
+		GetParticipantBalancesImpl: func(groupId balancesRepository.GroupId) ([]balancesRepository.ParticipantBalance, error) {
+			return []balancesRepository.ParticipantBalance{
+				{UserId: "alice", Currency: "USD", Amount: 70},
+				{UserId: "bob", Currency: "USD", Amount: -40},
+				{UserId: "carol", Currency: "USD", Amount: -30},
+				{UserId: "alice", Currency: "EUR", Amount: -100},
+				{UserId: "bob", Currency: "EUR", Amount: 60},
+				{UserId: "carol", Currency: "EUR", Amount: 40},
+				{UserId: "mallory", Currency: "EUR", Amount: 10},
+			}, nil
+		},
+	}
+
+	t.Run("transfers settle every currency", func(t *testing.T) {
+		// Arrange
+		controller := defaultController.New(groupRepository(t), repository, logger)
+
+		// Act
+		result, err := controller.Settle("bob", "trip")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, []openapi.SettlementTransfer{
+			{From: "alice", To: "bob", Currency: "EUR", Amount: 60},
+			{From: "alice", To: "carol", Currency: "EUR", Amount: 40},
+			{From: "bob", To: "alice", Currency: "USD", Amount: 40},
+			{From: "carol", To: "alice", Currency: "USD", Amount: 30},
+		}, result)
+	})
+
+	t.Run("participants added and removed after creation", func(t *testing.T) {
+		// Arrange
+		groups := groupRepository(t)
+		getGroup := groups.GetImpl
+		membershipChange := func(createdAt int64, change openapi.SomeOperation) operations.Operation {
+			change.OperationId = fmt.Sprintf("change-%d", createdAt)
+			change.CreatedAt = createdAt
+			change.AuthorId = "alice"
+			data, err := json.Marshal(change)
+			require.NoError(t, err)
+			payload := operations.OpenApiOperation{SomeOperation: change}
+			return operations.Operation{
+				OperationId: operations.OperationId(change.OperationId),
+				CreatedAt:   createdAt,
+				AuthorId:    "alice",
+				Payload:     mockOperationPayload{payloadType: payload.Type(), data: data},
+			}
+		}
+		groups.GetImpl = func(affectingEntities []operations.TrackedEntity) ([]operations.Operation, error) {
+			created, err := getGroup(affectingEntities)
+			// changes are returned newest first to check that they are applied in creation order
+			return append([]operations.Operation{
+				membershipChange(4, openapi.SomeOperation{
+					AddGroupParticipant: openapi.AddGroupParticipantOperationAddGroupParticipant{GroupId: "trip", UserId: "bob"},
+				}),
+				membershipChange(3, openapi.SomeOperation{
+					RemoveGroupParticipant: openapi.RemoveGroupParticipantOperationRemoveGroupParticipant{GroupId: "trip", UserId: "bob"},
+				}),
+				membershipChange(2, openapi.SomeOperation{
+					RemoveGroupParticipant: openapi.RemoveGroupParticipantOperationRemoveGroupParticipant{GroupId: "trip", UserId: "carol"},
+				}),
+				membershipChange(1, openapi.SomeOperation{
+					AddGroupParticipant: openapi.AddGroupParticipantOperationAddGroupParticipant{GroupId: "trip", UserId: "mallory"},
+				}),
+			}, created...), err
+		}
+		controller := defaultController.New(groups, repository, logger)
+
+		// Act
+		result, err := controller.Settle("mallory", "trip")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, []openapi.SettlementTransfer{
+			{From: "alice", To: "bob", Currency: "EUR", Amount: 60},
+			{From: "alice", To: "carol", Currency: "EUR", Amount: 40},
+			{From: "bob", To: "alice", Currency: "USD", Amount: 40},
+			{From: "carol", To: "alice", Currency: "USD", Amount: 30},
+		}, result)
+	})
+
+	t.Run("participant who left while owing money is settled", func(t *testing.T) {
+		// Arrange
+		groups := groupRepository(t)
+		getGroup := groups.GetImpl
+		data, err := json.Marshal(openapi.SomeOperation{
+			OperationId: "carol-left",
+			CreatedAt:   1,
+			AuthorId:    "carol",
+			RemoveGroupParticipant: openapi.RemoveGroupParticipantOperationRemoveGroupParticipant{
+				GroupId: "trip",
+				UserId:  "carol",
+			},
+		})
+		require.NoError(t, err)
+		groups.GetImpl = func(affectingEntities []operations.TrackedEntity) ([]operations.Operation, error) {
+			created, err := getGroup(affectingEntities)
+			if affectingEntities[0].Id != "trip" {
+				return created, err
+			}
+			return append(created, operations.Operation{
+				OperationId: "carol-left",
+				CreatedAt:   1,
+				AuthorId:    "carol",
+				Payload:     mockOperationPayload{payloadType: operations.RemoveGroupParticipantOperationPayloadType, data: data},
+			}), err
+		}
+		owing := &balancesRepository_mock.RepositoryMock{
+			GetParticipantBalancesImpl: func(groupId balancesRepository.GroupId) ([]balancesRepository.ParticipantBalance, error) {
+				return []balancesRepository.ParticipantBalance{
+					{UserId: "alice", Currency: "USD", Amount: 50},
+					{UserId: "bob", Currency: "USD", Amount: -20},
+					{UserId: "carol", Currency: "USD", Amount: -30},
+				}, nil
+			},
+		}
+		controller := defaultController.New(groups, owing, logger)
+
+		// Act
+		result, err := controller.Settle("bob", "trip")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, []openapi.SettlementTransfer{
+			{From: "carol", To: "alice", Currency: "USD", Amount: 30},
+			{From: "bob", To: "alice", Currency: "USD", Amount: 20},
+		}, result)
+	})
+
+	t.Run("bound sandbox participant is settled as registered user", func(t *testing.T) {
+		// Arrange
+		groups := groupRepository(t)
+		getGroup := groups.GetImpl
+		data, err := json.Marshal(openapi.SomeOperation{
+			OperationId: "bind-carol",
+			AuthorId:    "dave",
+			BindUser:    openapi.BindUserOperationBindUser{OldId: "carol", NewId: "dave"},
+		})
+		require.NoError(t, err)
+		groups.GetImpl = func(affectingEntities []operations.TrackedEntity) ([]operations.Operation, error) {
+			if affectingEntities[0].Type != operations.EntityTypeUser {
+				return getGroup(affectingEntities)
+			}
+			for _, entity := range affectingEntities {
+				if entity.Id == "carol" {
+					return []operations.Operation{
+						{
+							OperationId: "bind-carol",
+							AuthorId:    "dave",
+							Payload:     mockOperationPayload{payloadType: operations.BindUserOperationPayloadType, data: data},
+						},
+					}, nil
+				}
+			}
+			return []operations.Operation{}, nil
+		}
+		controller := defaultController.New(groups, repository, logger)
+
+		// Act
+		result, err := controller.Settle("dave", "trip")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, []openapi.SettlementTransfer{
+			{From: "alice", To: "bob", Currency: "EUR", Amount: 60},
+			{From: "alice", To: "dave", Currency: "EUR", Amount: 40},
+			{From: "bob", To: "alice", Currency: "USD", Amount: 40},
+			{From: "dave", To: "alice", Currency: "USD", Amount: 30},
+		}, result)
+	})
+
+	t.Run("not a participant", func(t *testing.T) {
+		// Arrange
+		controller := defaultController.New(groupRepository(t), repository, logger)
+
+		// Act
+		_, err := controller.Settle("mallory", "trip")
+
+		// Assert
+		assert.ErrorIs(t, err, balances.NotAParticipant)
+	})
+
+	t.Run("no such group", func(t *testing.T) {
+		// Arrange
+		controller := defaultController.New(groupRepository(t), repository, logger)
+
+		// Act
+		_, err := controller.Settle("alice", "party")
+
+		// Assert
+		assert.ErrorIs(t, err, balances.NoSuchGroup)
+	})
+}
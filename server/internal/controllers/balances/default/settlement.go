@@ -0,0 +1,183 @@
+package defaultController
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"verni/internal/common"
+	"verni/internal/controllers/balances"
+	openapi "verni/internal/openapi/go"
+	balancesRepository "verni/internal/repositories/balances"
+	operationsRepository "verni/internal/repositories/operations"
+)
+
+func (c *defaultController) Settle(userId balances.UserId, groupId balances.GroupId) ([]openapi.SettlementTransfer, error) {
+	const op = "controllers.balances.defaultController.Settle"
+	c.logger.LogInfo("%s: start[user=%s group=%s]", op, userId, groupId)
+
+	participants, err := c.groupParticipants(groupId)
+	if err != nil {
+		return nil, err
+	}
+	boundUsers, err := operationsRepository.BoundUsers(
+		c.operationsRepository,
+		common.Map(participants, func(participant string) operationsRepository.UserId {
+			return operationsRepository.UserId(participant)
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("getting bound participants of group %s: %w", groupId, err)
+	}
+	participants = common.Map(participants, func(participant string) string {
+		if newId, bound := boundUsers[operationsRepository.UserId(participant)]; bound {
+			return string(newId)
+		}
+		return participant
+	})
+	if !slices.Contains(participants, string(userId)) {
+		return nil, fmt.Errorf("%s is not a participant of group %s: %w", userId, groupId, balances.NotAParticipant)
+	}
+	participantBalances, err := c.balancesRepository.GetParticipantBalances(balancesRepository.GroupId(groupId))
+	if err != nil {
+		return nil, fmt.Errorf("getting balances of group %s from repository: %w", groupId, err)
+	}
+
+	// participants who left the group keep their balances until they are settled, so every non-zero
+	// balance takes part in the matching, not only balances of current participants
+	byCurrency := map[balancesRepository.Currency][]balancesRepository.ParticipantBalance{}
+	for _, balance := range mergeBoundUsers(participantBalances, boundUsers) {
+		byCurrency[balance.Currency] = append(byCurrency[balance.Currency], balance)
+	}
+	currencies := []balancesRepository.Currency{}
+	for currency := range byCurrency {
+		currencies = append(currencies, currency)
+	}
+	slices.Sort(currencies)
+
+	transfers := []openapi.SettlementTransfer{}
+	for _, currency := range currencies {
+		transfers = append(transfers, settle(byCurrency[currency])...)
+	}
+
+	c.logger.LogInfo("%s: success[user=%s group=%s transfers=%d]", op, userId, groupId, len(transfers))
+	return transfers, nil
+}
+
+// groupParticipants returns participants of the group as it was created with participants added and removed
+// later, membership changes are applied in creation order
+func (c *defaultController) groupParticipants(groupId balances.GroupId) ([]string, error) {
+	affecting, err := c.operationsRepository.Get([]operationsRepository.TrackedEntity{
+		{
+			Id:   string(groupId),
+			Type: operationsRepository.EntityTypeSpendingGroup,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting operations of group %s: %w", groupId, err)
+	}
+	var created *openapi.SomeOperation
+	changes := []openapi.SomeOperation{}
+	for _, operation := range affecting {
+		switch operation.Payload.Type() {
+		case operationsRepository.CreateSpendingGroupOperationPayloadType,
+			operationsRepository.AddGroupParticipantOperationPayloadType,
+			operationsRepository.RemoveGroupParticipantOperationPayloadType:
+		default:
+			continue
+		}
+		data, err := operation.Payload.Data()
+		if err != nil {
+			return nil, fmt.Errorf("getting data from operation %s: %w", operation.OperationId, err)
+		}
+		var converted openapi.SomeOperation
+		if err := json.Unmarshal(data, &converted); err != nil {
+			return nil, fmt.Errorf("parsing operation %s: %w", operation.OperationId, err)
+		}
+		if operation.Payload.Type() == operationsRepository.CreateSpendingGroupOperationPayloadType {
+			created = &converted
+		} else {
+			changes = append(changes, converted)
+		}
+	}
+	if created == nil {
+		return nil, fmt.Errorf("group %s is not created: %w", groupId, balances.NoSuchGroup)
+	}
+	slices.SortFunc(changes, func(a, b openapi.SomeOperation) int {
+		return cmp.Or(cmp.Compare(a.CreatedAt, b.CreatedAt), cmp.Compare(a.OperationId, b.OperationId))
+	})
+	participants := operationsRepository.CreatedGroupParticipants(*created)
+	for _, change := range changes {
+		if !openapi.IsZeroValue(change.AddGroupParticipant) {
+			participants = append(participants, change.AddGroupParticipant.UserId)
+			continue
+		}
+		participants = slices.DeleteFunc(participants, func(participant string) bool {
+			return participant == change.RemoveGroupParticipant.UserId
+		})
+	}
+	return participants, nil
+}
+
+// mergeBoundUsers accounts balances of sandbox users to registered users they were bound to
+func mergeBoundUsers(
+	participantBalances []balancesRepository.ParticipantBalance,
+	boundUsers map[operationsRepository.UserId]operationsRepository.UserId,
+) []balancesRepository.ParticipantBalance {
+	merged := []balancesRepository.ParticipantBalance{}
+	for _, balance := range participantBalances {
+		if newId, bound := boundUsers[operationsRepository.UserId(balance.UserId)]; bound {
+			balance.UserId = balancesRepository.UserId(newId)
+		}
+		index := slices.IndexFunc(merged, func(existing balancesRepository.ParticipantBalance) bool {
+			return existing.UserId == balance.UserId && existing.Currency == balance.Currency
+		})
+		if index < 0 {
+			merged = append(merged, balance)
+		} else {
+			merged[index].Amount += balance.Amount
+		}
+	}
+	return merged
+}
+
+// settle pays off the largest debt to the largest credit until every balance is zero, each transfer
+// settles at least one participant. Ties are broken by user identifier to keep suggestions stable.
+func settle(participantBalances []balancesRepository.ParticipantBalance) []openapi.SettlementTransfer {
+	creditors := []balancesRepository.ParticipantBalance{}
+	debtors := []balancesRepository.ParticipantBalance{}
+	for _, balance := range participantBalances {
+		if balance.Amount > 0 {
+			creditors = append(creditors, balance)
+		} else if balance.Amount < 0 {
+			balance.Amount = -balance.Amount
+			debtors = append(debtors, balance)
+		}
+	}
+	largestFirst := func(a, b balancesRepository.ParticipantBalance) int {
+		return cmp.Or(cmp.Compare(b.Amount, a.Amount), cmp.Compare(a.UserId, b.UserId))
+	}
+
+	transfers := []openapi.SettlementTransfer{}
+	for len(creditors) > 0 && len(debtors) > 0 {
+		slices.SortFunc(creditors, largestFirst)
+		slices.SortFunc(debtors, largestFirst)
+
+		amount := min(creditors[0].Amount, debtors[0].Amount)
+		transfers = append(transfers, openapi.SettlementTransfer{
+			From:     string(debtors[0].UserId),
+			To:       string(creditors[0].UserId),
+			Currency: string(creditors[0].Currency),
+			Amount:   amount,
+		})
+		creditors[0].Amount -= amount
+		debtors[0].Amount -= amount
+		if creditors[0].Amount == 0 {
+			creditors = creditors[1:]
+		}
+		if debtors[0].Amount == 0 {
+			debtors = debtors[1:]
+		}
+	}
+	return transfers
+}
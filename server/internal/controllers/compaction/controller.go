@@ -0,0 +1,20 @@
+package compaction
+
+type Report struct {
+	// Scanned is the number of operations read from the log
+	Scanned int
+	// Snapshots is the number of snapshot operations written to the log
+	Snapshots int
+	// Removed is the number of superseded operations deleted from the log
+	Removed int
+}
+
+type Controller interface {
+	// Compact removes operations superseded by later operations of the same entity: display name and avatar
+	// changes of a user but the latest ones and spending updates whose every field is overwritten later or whose
+	// spending is deleted. Spendings with several updates setting their latest values get a snapshot, an update
+	// setting all of them, that supersedes the folded updates. Latest changes of users are their snapshots
+	// already. Operations that a device with a session has not confirmed are kept, and so are the updates a
+	// snapshot would fold, until the device confirms them. It is safe to run while the server is running.
+	Compact() (Report, error)
+}
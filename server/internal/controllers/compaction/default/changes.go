@@ -0,0 +1,212 @@
+package defaultController
+
+import (
+	"cmp"
+	"slices"
+	openapi "verni/internal/openapi/go"
+	operationsRepository "verni/internal/repositories/operations"
+)
+
+// version orders changes of the same field, the latest one wins like it does for clients and balances
+type version struct {
+	createdAt   int64
+	operationId string
+}
+
+func (v version) compare(other version) int {
+	return cmp.Or(cmp.Compare(v.createdAt, other.createdAt), cmp.Compare(v.operationId, other.operationId))
+}
+
+// field is a value of an entity that every change overwrites as a whole
+type field struct {
+	entity string
+	name   string
+}
+
+// change is an operation that may be removed once every field it sets is overwritten later
+type change struct {
+	version    version
+	fields     []field
+	spendingId string
+	operation  openapi.SomeOperation
+}
+
+// snapshot is an update of a spending setting the latest value of every field set by its live updates, it is
+// later than all of them so they are superseded once it is written
+type snapshot struct {
+	operation openapi.SomeOperation
+	folded    []operationsRepository.OperationId
+}
+
+// changeLog collects changes of the log to find superseded ones
+type changeLog struct {
+	latest           map[field]version
+	changes          []change
+	deletedSpendings map[string]struct{}
+}
+
+func newChangeLog() *changeLog {
+	return &changeLog{
+		latest:           map[field]version{},
+		deletedSpendings: map[string]struct{}{},
+	}
+}
+
+func (l *changeLog) add(operation openapi.SomeOperation) {
+	current := version{createdAt: operation.CreatedAt, operationId: operation.OperationId}
+	payload := operationsRepository.OpenApiOperation{SomeOperation: operation}
+	switch payload.Type() {
+	case operationsRepository.UpdateDisplayNameOperationPayloadType:
+		l.addChange(change{
+			version:   current,
+			fields:    []field{{entity: operation.UpdateDisplayName.UserId, name: "displayName"}},
+			operation: operation,
+		})
+	case operationsRepository.UpdateAvatarOperationPayloadType:
+		l.addChange(change{
+			version:   current,
+			fields:    []field{{entity: operation.UpdateAvatar.UserId, name: "avatar"}},
+			operation: operation,
+		})
+	case operationsRepository.CreateSpendingOperationPayloadType:
+		// creation is never removed, but updates made before it are ignored by the spending
+		for _, created := range spendingFields(operation.CreateSpending.SpendingId, true, true, true, true) {
+			l.overwrite(created, current)
+		}
+	case operationsRepository.UpdateSpendingOperationPayloadType:
+		update := operation.UpdateSpending
+		l.addChange(change{
+			version: current,
+			fields: spendingFields(
+				update.SpendingId,
+				update.Name != "",
+				update.Currency != "",
+				update.Amount != 0,
+				len(update.Shares) > 0,
+			),
+			spendingId: update.SpendingId,
+			operation:  operation,
+		})
+	case operationsRepository.DeleteSpendingOperationPayloadType:
+		l.deletedSpendings[operation.DeleteSpending.SpendingId] = struct{}{}
+	}
+}
+
+func (l *changeLog) addChange(change change) {
+	l.changes = append(l.changes, change)
+	for _, changed := range change.fields {
+		l.overwrite(changed, change.version)
+	}
+}
+
+func (l *changeLog) overwrite(changed field, current version) {
+	if latest, exists := l.latest[changed]; !exists || current.compare(latest) > 0 {
+		l.latest[changed] = current
+	}
+}
+
+// operations returns every change of the log
+func (l *changeLog) operations() []openapi.SomeOperation {
+	result := make([]openapi.SomeOperation, len(l.changes))
+	for index, change := range l.changes {
+		result[index] = change.operation
+	}
+	return result
+}
+
+// superseded returns changes that set no latest value of any field, updates of deleted spendings are superseded by deletion
+func (l *changeLog) superseded() []operationsRepository.OperationId {
+	result := []operationsRepository.OperationId{}
+	for _, change := range l.changes {
+		if !l.isLive(change) {
+			result = append(result, operationsRepository.OperationId(change.version.operationId))
+		}
+	}
+	return result
+}
+
+// snapshots folds updates of every spending with more than one live update, latest changes of users are their
+// snapshots already. Snapshots have no operation id yet.
+func (l *changeLog) snapshots() []snapshot {
+	live := map[string][]change{}
+	spendingIds := []string{}
+	for _, change := range l.changes {
+		if change.spendingId == "" || !l.isLive(change) {
+			continue
+		}
+		if _, seen := live[change.spendingId]; !seen {
+			spendingIds = append(spendingIds, change.spendingId)
+		}
+		live[change.spendingId] = append(live[change.spendingId], change)
+	}
+	result := []snapshot{}
+	for _, spendingId := range spendingIds {
+		updates := live[spendingId]
+		if len(updates) < 2 {
+			continue
+		}
+		folded := openapi.UpdateSpendingOperationUpdateSpending{SpendingId: spendingId}
+		latest := updates[0]
+		ids := []operationsRepository.OperationId{}
+		for _, update := range updates {
+			source := update.operation.UpdateSpending
+			folded.GroupId = source.GroupId
+			for _, changed := range update.fields {
+				if l.latest[changed] != update.version {
+					continue
+				}
+				switch changed.name {
+				case "name":
+					folded.Name = source.Name
+				case "currency":
+					folded.Currency = source.Currency
+				case "amount":
+					folded.Amount = source.Amount
+				case "shares":
+					folded.Shares = source.Shares
+				}
+			}
+			if update.version.compare(latest.version) > 0 {
+				latest = update
+			}
+			ids = append(ids, operationsRepository.OperationId(update.version.operationId))
+		}
+		result = append(result, snapshot{
+			operation: openapi.SomeOperation{
+				CreatedAt:      latest.version.createdAt + 1,
+				AuthorId:       latest.operation.AuthorId,
+				UpdateSpending: folded,
+			},
+			folded: ids,
+		})
+	}
+	return result
+}
+
+// isLive tells changes setting the latest value of some field of an entity that still exists
+func (l *changeLog) isLive(change change) bool {
+	if _, deleted := l.deletedSpendings[change.spendingId]; change.spendingId != "" && deleted {
+		return false
+	}
+	return slices.ContainsFunc(change.fields, func(changed field) bool {
+		return l.latest[changed] == change.version
+	})
+}
+
+func spendingFields(spendingId string, name, currency, amount, shares bool) []field {
+	fields := []field{}
+	for _, candidate := range []struct {
+		name string
+		set  bool
+	}{
+		{name: "name", set: name},
+		{name: "currency", set: currency},
+		{name: "amount", set: amount},
+		{name: "shares", set: shares},
+	} {
+		if candidate.set {
+			fields = append(fields, field{entity: spendingId, name: candidate.name})
+		}
+	}
+	return fields
+}
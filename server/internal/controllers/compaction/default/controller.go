@@ -0,0 +1,193 @@
+package defaultController
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"verni/internal/common"
+	"verni/internal/controllers/compaction"
+	openapi "verni/internal/openapi/go"
+	authRepository "verni/internal/repositories/auth"
+	operationsRepository "verni/internal/repositories/operations"
+	"verni/internal/services/logging"
+
+	"github.com/google/uuid"
+)
+
+type OperationsRepository operationsRepository.Repository
+type AuthRepository authRepository.Repository
+
+const (
+	logPageSize     = 1000
+	removeBatchSize = 500
+)
+
+func New(
+	operationsRepository OperationsRepository,
+	authRepository AuthRepository,
+	logger logging.Service,
+) compaction.Controller {
+	return &defaultController{
+		operationsRepository: operationsRepository,
+		authRepository:       authRepository,
+		logger:               logger,
+	}
+}
+
+type defaultController struct {
+	operationsRepository OperationsRepository
+	authRepository       AuthRepository
+	logger               logging.Service
+}
+
+func (c *defaultController) Compact() (compaction.Report, error) {
+	const op = "controllers.compaction.defaultController.Compact"
+	c.logger.LogInfo("%s: start", op)
+
+	report := compaction.Report{}
+	changes := newChangeLog()
+	var after operationsRepository.SequenceNumber
+	for {
+		page, err := c.operationsRepository.Log(after, logPageSize, operationsRepository.OperationTypeRegular)
+		if err != nil {
+			return report, fmt.Errorf("getting operations after %d: %w", after, err)
+		}
+		for _, operation := range page {
+			switch operation.Payload.Type() {
+			case operationsRepository.UpdateDisplayNameOperationPayloadType,
+				operationsRepository.UpdateAvatarOperationPayloadType,
+				operationsRepository.CreateSpendingOperationPayloadType,
+				operationsRepository.UpdateSpendingOperationPayloadType,
+				operationsRepository.DeleteSpendingOperationPayloadType:
+			default:
+				continue
+			}
+			data, err := operation.Payload.Data()
+			if err != nil {
+				return report, fmt.Errorf("getting data from operation %s: %w", operation.OperationId, err)
+			}
+			var converted openapi.SomeOperation
+			if err := json.Unmarshal(data, &converted); err != nil {
+				return report, fmt.Errorf("parsing operation %s: %w", operation.OperationId, err)
+			}
+			changes.add(converted)
+		}
+		report.Scanned += len(page)
+		if len(page) < logPageSize {
+			break
+		}
+		after = page[len(page)-1].SequenceNumber
+	}
+
+	pending, err := c.unconfirmed(changes.operations())
+	if err != nil {
+		return report, err
+	}
+	isPending := func(operationId operationsRepository.OperationId) bool {
+		_, found := pending[operationId]
+		return found
+	}
+	// snapshots are written only when every update they fold can be removed, otherwise they would just be pulled
+	// along with the updates
+	for _, snapshot := range changes.snapshots() {
+		if slices.ContainsFunc(snapshot.folded, isPending) {
+			continue
+		}
+		snapshot.operation.OperationId = uuid.New().String()
+		// no device has seen the snapshot, so it is written from no device
+		write := c.operationsRepository.Push(
+			[]operationsRepository.PushOperation{operationsRepository.CreateOperation(snapshot.operation)},
+			operationsRepository.UserId(snapshot.operation.AuthorId),
+			"",
+			false,
+			nil,
+		)
+		if err := write.Perform(); err != nil {
+			return report, fmt.Errorf("writing snapshot of spending %s: %w", snapshot.operation.UpdateSpending.SpendingId, err)
+		}
+		changes.add(snapshot.operation)
+		report.Snapshots++
+	}
+
+	// every batch leaves the log consistent, so batches removed before a failure are not restored
+	superseded := slices.DeleteFunc(changes.superseded(), isPending)
+	for start := 0; start < len(superseded); start += removeBatchSize {
+		batch := superseded[start:min(start+removeBatchSize, len(superseded))]
+		if err := c.operationsRepository.Remove(batch).Perform(); err != nil {
+			return report, fmt.Errorf("removing superseded operations: %w", err)
+		}
+		report.Removed += len(batch)
+	}
+
+	c.logger.LogInfo("%s: success[scanned=%d snapshots=%d removed=%d]", op, report.Scanned, report.Snapshots, report.Removed)
+	return report, nil
+}
+
+// unconfirmed returns operations some device with a session has not confirmed yet while its user sees them,
+// they are kept so the device does not miss them
+func (c *defaultController) unconfirmed(changes []openapi.SomeOperation) (map[operationsRepository.OperationId]struct{}, error) {
+	devices, err := c.authRepository.GetDevicesWithSession()
+	if err != nil {
+		return nil, fmt.Errorf("getting devices with session: %w", err)
+	}
+	sessions := map[operationsRepository.UserId][]operationsRepository.DeviceId{}
+	for _, device := range devices {
+		user := operationsRepository.UserId(device.User)
+		sessions[user] = append(sessions[user], operationsRepository.DeviceId(device.Device))
+	}
+
+	confirmed := map[operationsRepository.Confirmation]struct{}{}
+	for start := 0; start < len(changes); start += removeBatchSize {
+		batch := changes[start:min(start+removeBatchSize, len(changes))]
+		confirmations, err := c.operationsRepository.GetConfirmations(
+			common.Map(batch, func(operation openapi.SomeOperation) operationsRepository.OperationId {
+				return operationsRepository.OperationId(operation.OperationId)
+			}),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("getting confirmations: %w", err)
+		}
+		for _, confirmation := range confirmations {
+			confirmed[confirmation] = struct{}{}
+		}
+	}
+
+	// operations of the same entities are seen by the same users
+	viewers := map[string][]operationsRepository.UserId{}
+	result := map[operationsRepository.OperationId]struct{}{}
+	for _, operation := range changes {
+		payload := operationsRepository.OpenApiOperation{SomeOperation: operation}
+		entities := payload.TrackedEntities()
+		key := entitiesKey(entities)
+		users, cached := viewers[key]
+		if !cached {
+			users, err = c.operationsRepository.GetUsers(entities)
+			if err != nil {
+				return nil, fmt.Errorf("getting users of operation %s: %w", operation.OperationId, err)
+			}
+			viewers[key] = users
+		}
+		operationId := operationsRepository.OperationId(operation.OperationId)
+		for _, user := range users {
+			for _, device := range sessions[user] {
+				if _, found := confirmed[operationsRepository.Confirmation{
+					OperationId: operationId,
+					UserId:      user,
+					DeviceId:    device,
+				}]; !found {
+					result[operationId] = struct{}{}
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+func entitiesKey(entities []operationsRepository.TrackedEntity) string {
+	parts := make([]string, len(entities))
+	for index, entity := range entities {
+		parts[index] = string(entity.Type) + "/" + entity.Id
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,280 @@
+package defaultController_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"verni/internal/common"
+	"verni/internal/controllers/compaction"
+	defaultController "verni/internal/controllers/compaction/default"
+	openapi "verni/internal/openapi/go"
+	"verni/internal/repositories/auth"
+	authMemory "verni/internal/repositories/auth/memory"
+	"verni/internal/repositories/operations"
+	operationsMemory "verni/internal/repositories/operations/memory"
+	operationsRepository_mock "verni/internal/repositories/operations/mock"
+	standartOutputLoggingService "verni/internal/services/logging/standartOutput"
+)
+
+func push(t *testing.T, repository operations.Repository, operation openapi.SomeOperation) {
+	operation.AuthorId = "alice"
+	pushed := operations.CreateOperation(operation)
+	require.NoError(t, repository.Push([]operations.PushOperation{pushed}, "alice", "phone", true, nil).Perform())
+}
+
+func pulled(t *testing.T, repository operations.Repository, deviceId operations.DeviceId) []openapi.SomeOperation {
+	page, err := repository.Pull("alice", deviceId, 0, 100, operations.OperationTypeRegular)
+	require.NoError(t, err)
+	return common.Map(page, func(operation operations.Operation) openapi.SomeOperation {
+		data, err := operation.Payload.Data()
+		require.NoError(t, err)
+		var converted openapi.SomeOperation
+		require.NoError(t, json.Unmarshal(data, &converted))
+		return converted
+	})
+}
+
+func pulledIds(t *testing.T, repository operations.Repository, deviceId operations.DeviceId) []string {
+	pulled, err := repository.Pull("alice", deviceId, 0, 100, operations.OperationTypeRegular)
+	require.NoError(t, err)
+	return common.Map(pulled, func(operation operations.Operation) string {
+		return string(operation.OperationId)
+	})
+}
+
+func TestController_Compact(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+
+	t.Run("superseded user changes are removed", func(t *testing.T) {
+		// Arrange
+		repository := operationsMemory.New(logger)
+		push(t, repository, openapi.SomeOperation{
+			OperationId: "create-alice",
+			CreatedAt:   1,
+			CreateUser:  openapi.CreateUserOperationCreateUser{UserId: "alice", DisplayName: "alice"},
+		})
+		// changes are pushed out of order to check that the latest one by creation is kept
+		for _, change := range []struct {
+			operationId string
+			createdAt   int64
+		}{
+			{operationId: "rename-2", createdAt: 2},
+			{operationId: "rename-4", createdAt: 4},
+			{operationId: "rename-3", createdAt: 3},
+		} {
+			push(t, repository, openapi.SomeOperation{
+				OperationId:       change.operationId,
+				CreatedAt:         change.createdAt,
+				UpdateDisplayName: openapi.UpdateDisplayNameOperationUpdateDisplayName{UserId: "alice", DisplayName: change.operationId},
+			})
+		}
+		imageId := "image"
+		push(t, repository, openapi.SomeOperation{
+			OperationId:  "avatar",
+			CreatedAt:    5,
+			UpdateAvatar: openapi.UpdateAvatarOperationUpdateAvatar{UserId: "alice", ImageId: &imageId},
+		})
+		controller := defaultController.New(repository, authMemory.New(logger), logger)
+
+		// Act
+		report, err := controller.Compact()
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 5, report.Scanned)
+		assert.Equal(t, 2, report.Removed)
+		assert.Equal(t, []string{"create-alice", "rename-4", "avatar"}, pulledIds(t, repository, "laptop"))
+		assert.Empty(t, pulledIds(t, repository, "phone"))
+	})
+
+	t.Run("spending updates are removed once every field is overwritten or folded into a snapshot", func(t *testing.T) {
+		// Arrange
+		repository := operationsMemory.New(logger)
+		push(t, repository, openapi.SomeOperation{
+			OperationId: "create-group",
+			CreatedAt:   1,
+			CreateSpendingGroup: openapi.CreateSpendingGroupOperationCreateSpendingGroup{
+				GroupId: "trip",
+			},
+		})
+		for _, spendingId := range []string{"lunch", "dinner"} {
+			push(t, repository, openapi.SomeOperation{
+				OperationId: "create-" + spendingId,
+				CreatedAt:   2,
+				CreateSpending: openapi.CreateSpendingOperationCreateSpending{
+					SpendingId: spendingId,
+					GroupId:    "trip",
+					Name:       spendingId,
+					Currency:   "USD",
+					Amount:     100,
+					Shares:     []openapi.SpendingShare{{UserId: "alice", Amount: 100}},
+				},
+			})
+		}
+		for _, update := range []openapi.SomeOperation{
+			{
+				OperationId:    "rename-and-reprice-lunch",
+				CreatedAt:      3,
+				UpdateSpending: openapi.UpdateSpendingOperationUpdateSpending{SpendingId: "lunch", GroupId: "trip", Name: "brunch", Amount: 200},
+			},
+			{
+				OperationId:    "rename-lunch",
+				CreatedAt:      4,
+				UpdateSpending: openapi.UpdateSpendingOperationUpdateSpending{SpendingId: "lunch", GroupId: "trip", Name: "late lunch"},
+			},
+			{
+				OperationId:    "reprice-lunch-before-creation",
+				CreatedAt:      1,
+				UpdateSpending: openapi.UpdateSpendingOperationUpdateSpending{SpendingId: "lunch", GroupId: "trip", Amount: 300},
+			},
+			{
+				OperationId:    "rename-dinner",
+				CreatedAt:      3,
+				UpdateSpending: openapi.UpdateSpendingOperationUpdateSpending{SpendingId: "dinner", GroupId: "trip", Name: "supper"},
+			},
+			{
+				OperationId:    "delete-dinner",
+				CreatedAt:      4,
+				DeleteSpending: openapi.DeleteSpendingOperationDeleteSpending{SpendingId: "dinner", GroupId: "trip"},
+			},
+		} {
+			push(t, repository, update)
+		}
+		controller := defaultController.New(repository, authMemory.New(logger), logger)
+
+		// Act
+		report, err := controller.Compact()
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Snapshots)
+		assert.Equal(t, 4, report.Removed)
+		ids := pulledIds(t, repository, "laptop")
+		require.Len(t, ids, 5)
+		assert.Equal(t, []string{
+			"create-group",
+			"create-lunch",
+			"create-dinner",
+			"delete-dinner",
+		}, ids[:4])
+		snapshot := pulled(t, repository, "laptop")[4]
+		assert.Equal(t, int64(5), snapshot.CreatedAt)
+		assert.Equal(t, openapi.UpdateSpendingOperationUpdateSpending{
+			SpendingId: "lunch",
+			GroupId:    "trip",
+			Name:       "late lunch",
+			Amount:     200,
+		}, snapshot.UpdateSpending)
+
+		// Act - the snapshot is not folded again
+		report, err = controller.Compact()
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, compaction.Report{Scanned: 5}, report)
+	})
+
+	t.Run("operations a device with a session has not confirmed are kept", func(t *testing.T) {
+		// Arrange
+		repository := operationsMemory.New(logger)
+		sessions := authMemory.New(logger)
+		for _, device := range []auth.DeviceId{"phone", "laptop"} {
+			require.NoError(t, sessions.UpdateRefreshToken("alice", device, "token-"+string(device)).Perform())
+		}
+		for _, operation := range []openapi.SomeOperation{
+			{
+				OperationId: "create-alice",
+				CreatedAt:   1,
+				CreateUser:  openapi.CreateUserOperationCreateUser{UserId: "alice", DisplayName: "alice"},
+			},
+			{
+				OperationId:       "rename-2",
+				CreatedAt:         2,
+				UpdateDisplayName: openapi.UpdateDisplayNameOperationUpdateDisplayName{UserId: "alice", DisplayName: "alice 2"},
+			},
+			{
+				OperationId:       "rename-3",
+				CreatedAt:         3,
+				UpdateDisplayName: openapi.UpdateDisplayNameOperationUpdateDisplayName{UserId: "alice", DisplayName: "alice 3"},
+			},
+			{
+				OperationId:         "create-group",
+				CreatedAt:           1,
+				CreateSpendingGroup: openapi.CreateSpendingGroupOperationCreateSpendingGroup{GroupId: "trip"},
+			},
+			{
+				OperationId: "create-lunch",
+				CreatedAt:   2,
+				CreateSpending: openapi.CreateSpendingOperationCreateSpending{
+					SpendingId: "lunch",
+					GroupId:    "trip",
+					Name:       "lunch",
+					Currency:   "USD",
+					Amount:     100,
+					Shares:     []openapi.SpendingShare{{UserId: "alice", Amount: 100}},
+				},
+			},
+			{
+				OperationId:    "rename-and-reprice-lunch",
+				CreatedAt:      3,
+				UpdateSpending: openapi.UpdateSpendingOperationUpdateSpending{SpendingId: "lunch", GroupId: "trip", Name: "brunch", Amount: 200},
+			},
+			{
+				OperationId:    "rename-lunch",
+				CreatedAt:      4,
+				UpdateSpending: openapi.UpdateSpendingOperationUpdateSpending{SpendingId: "lunch", GroupId: "trip", Name: "late lunch"},
+			},
+		} {
+			push(t, repository, operation)
+		}
+		controller := defaultController.New(repository, sessions, logger)
+
+		// Act - the laptop has confirmed nothing
+		report, err := controller.Compact()
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, compaction.Report{Scanned: 7}, report)
+		assert.Len(t, pulledIds(t, repository, "laptop"), 7)
+
+		// Act - the laptop catches up
+		require.NoError(t, repository.Confirm(
+			common.Map(pulledIds(t, repository, "laptop"), func(id string) operations.OperationId {
+				return operations.OperationId(id)
+			}),
+			"alice",
+			"laptop",
+		).Perform())
+		report, err = controller.Compact()
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Snapshots)
+		assert.Equal(t, 3, report.Removed)
+		ids := pulledIds(t, repository, "tablet")
+		require.Len(t, ids, 5)
+		assert.Equal(t, []string{"create-alice", "rename-3", "create-group", "create-lunch"}, ids[:4])
+		assert.Len(t, pulledIds(t, repository, "laptop"), 1)
+	})
+
+	t.Run("log error", func(t *testing.T) {
+		// Arrange
+		expectedErr := errors.New("log error")
+		repository := &operationsRepository_mock.RepositoryMock{
+			LogImpl: func(after operations.SequenceNumber, limit int, operationType operations.OperationType) ([]operations.Operation, error) {
+				return nil, expectedErr
+			},
+		}
+		controller := defaultController.New(repository, authMemory.New(logger), logger)
+
+		// Act
+		_, err := controller.Compact()
+
+		// Assert
+		assert.ErrorIs(t, err, expectedErr)
+	})
+}
@@ -0,0 +1,17 @@
+package devices
+
+type Report struct {
+	// Devices is the number of devices without a session that are forgotten
+	Devices int
+	// Confirmations is the number of operation confirmations removed with the devices
+	Confirmations int
+	// PushTokens is the number of push tokens removed with the devices
+	PushTokens int
+}
+
+type Controller interface {
+	// CollectGarbage forgets devices that have no session anymore, either revoked by an exclusive session of
+	// another device or never finished signing in, together with their operation confirmations and push tokens.
+	// A forgotten device that signs in again pulls the log from scratch. It is safe to run while the server is running.
+	CollectGarbage() (Report, error)
+}
@@ -0,0 +1,127 @@
+package defaultController
+
+import (
+	"errors"
+	"fmt"
+	"verni/internal/controllers/devices"
+	authRepository "verni/internal/repositories/auth"
+	operationsRepository "verni/internal/repositories/operations"
+	pushNotificationsRepository "verni/internal/repositories/pushNotifications"
+	"verni/internal/services/logging"
+)
+
+type AuthRepository authRepository.Repository
+type OperationsRepository operationsRepository.Repository
+type PushTokensRepository pushNotificationsRepository.Repository
+
+func New(
+	authRepository AuthRepository,
+	operationsRepository OperationsRepository,
+	pushTokensRepository PushTokensRepository,
+	logger logging.Service,
+) devices.Controller {
+	return &defaultController{
+		authRepository:       authRepository,
+		operationsRepository: operationsRepository,
+		pushTokensRepository: pushTokensRepository,
+		logger:               logger,
+	}
+}
+
+type defaultController struct {
+	authRepository       AuthRepository
+	operationsRepository OperationsRepository
+	pushTokensRepository PushTokensRepository
+	logger               logging.Service
+}
+
+func (c *defaultController) CollectGarbage() (devices.Report, error) {
+	const op = "controllers.devices.defaultController.CollectGarbage"
+	c.logger.LogInfo("%s: start", op)
+
+	dead, err := c.authRepository.GetDevicesWithoutSession()
+	if err != nil {
+		return devices.Report{}, fmt.Errorf("%s: getting devices without session: %w", op, err)
+	}
+
+	// every device is forgotten on its own, so devices forgotten before a failure are not restored
+	report := devices.Report{}
+	for _, device := range dead {
+		forgotten, err := c.forget(device)
+		if err != nil {
+			return report, fmt.Errorf("%s: forgetting device %s of user %s: %w", op, device.Device, device.User, err)
+		}
+		report.Devices += forgotten.Devices
+		report.Confirmations += forgotten.Confirmations
+		report.PushTokens += forgotten.PushTokens
+	}
+
+	c.logger.LogInfo(
+		"%s: success[devices=%d confirmations=%d pushTokens=%d]",
+		op,
+		report.Devices,
+		report.Confirmations,
+		report.PushTokens,
+	)
+	return report, nil
+}
+
+func (c *defaultController) forget(device authRepository.Device) (devices.Report, error) {
+	const op = "controllers.devices.defaultController.forget"
+	c.logger.LogInfo("%s: start[user=%s device=%s lastSeen=%d]", op, device.User, device.Device, device.LastSeen)
+
+	// the device row goes first and only while the device has no session, so a device that signed in again since
+	// it was listed keeps its confirmations and push token. A device signing in after it is forgotten pulls the log
+	// from scratch.
+	forgetDeviceTransaction := c.authRepository.ForgetDevice(device.User, device.Device)
+	if err := forgetDeviceTransaction.Perform(); err != nil {
+		if errors.Is(err, authRepository.ErrNotForgotten) {
+			c.logger.LogInfo("%s: device has a session again, skipping", op)
+			return devices.Report{}, nil
+		}
+		return devices.Report{}, fmt.Errorf("forgetting device: %w", err)
+	}
+
+	user := operationsRepository.UserId(device.User)
+	deviceId := operationsRepository.DeviceId(device.Device)
+	confirmations, err := c.operationsRepository.CountConfirmations(user, deviceId)
+	if err != nil {
+		forgetDeviceTransaction.Rollback()
+		return devices.Report{}, fmt.Errorf("counting confirmations: %w", err)
+	}
+	pushToken, err := c.pushTokensRepository.GetPushToken(
+		pushNotificationsRepository.UserId(device.User),
+		pushNotificationsRepository.DeviceId(device.Device),
+	)
+	if err != nil {
+		forgetDeviceTransaction.Rollback()
+		return devices.Report{}, fmt.Errorf("getting push token: %w", err)
+	}
+	pushTokens := 0
+	if pushToken != nil {
+		pushTokens = 1
+	}
+
+	removeConfirmationsTransaction := c.operationsRepository.RemoveConfirmations(user, deviceId)
+	if err := removeConfirmationsTransaction.Perform(); err != nil {
+		forgetDeviceTransaction.Rollback()
+		return devices.Report{}, fmt.Errorf("removing confirmations: %w", err)
+	}
+
+	removePushTokenTransaction := c.pushTokensRepository.RemovePushToken(
+		pushNotificationsRepository.UserId(device.User),
+		pushNotificationsRepository.DeviceId(device.Device),
+	)
+	if err := removePushTokenTransaction.Perform(); err != nil {
+		removeConfirmationsTransaction.Rollback()
+		forgetDeviceTransaction.Rollback()
+		return devices.Report{}, fmt.Errorf("removing push token: %w", err)
+	}
+
+	c.logger.LogInfo("%s: success[confirmations=%d pushTokens=%d]", op, confirmations, pushTokens)
+	return devices.Report{
+		Devices:       1,
+		Confirmations: confirmations,
+		PushTokens:    pushTokens,
+	}, nil
+}
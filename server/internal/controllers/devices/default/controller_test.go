@@ -0,0 +1,147 @@
+package defaultController_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	defaultController "verni/internal/controllers/devices/default"
+	openapi "verni/internal/openapi/go"
+	"verni/internal/repositories/auth"
+	authMemory "verni/internal/repositories/auth/memory"
+	authRepository_mock "verni/internal/repositories/auth/mock"
+	"verni/internal/repositories/operations"
+	operationsMemory "verni/internal/repositories/operations/memory"
+	pushNotificationsMemory "verni/internal/repositories/pushNotifications/memory"
+	standartOutputLoggingService "verni/internal/services/logging/standartOutput"
+)
+
+func TestController_CollectGarbage(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+
+	t.Run("revoked device is forgotten with its confirmations and push token", func(t *testing.T) {
+		// Arrange
+		authRepository := authMemory.New(logger)
+		operationsRepository := operationsMemory.New(logger)
+		pushTokensRepository := pushNotificationsMemory.New(logger)
+		for _, device := range []auth.DeviceId{"phone", "laptop"} {
+			require.NoError(t, authRepository.UpdateRefreshToken("alice", device, string(device)).Perform())
+		}
+		require.NoError(t, pushTokensRepository.StorePushToken("alice", "laptop", "laptop-token").Perform())
+		for _, operationId := range []string{"create-alice", "rename-alice"} {
+			operation := operations.CreateOperation(openapi.SomeOperation{
+				OperationId:       operationId,
+				AuthorId:          "alice",
+				UpdateDisplayName: openapi.UpdateDisplayNameOperationUpdateDisplayName{UserId: "alice", DisplayName: operationId},
+			})
+			require.NoError(t, operationsRepository.Push([]operations.PushOperation{operation}, "alice", "phone", true, nil).Perform())
+			require.NoError(t, operationsRepository.Confirm([]operations.OperationId{operations.OperationId(operationId)}, "alice", "laptop").Perform())
+		}
+		require.NoError(t, authRepository.ExclusiveSession("alice", "phone").Perform())
+		controller := defaultController.New(authRepository, operationsRepository, pushTokensRepository, logger)
+
+		// Act
+		report, err := controller.CollectGarbage()
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Devices)
+		assert.Equal(t, 2, report.Confirmations)
+		assert.Equal(t, 1, report.PushTokens)
+
+		remaining, err := authRepository.GetDevicesWithoutSession()
+		require.NoError(t, err)
+		assert.Empty(t, remaining)
+
+		laptopConfirmations, err := operationsRepository.CountConfirmations("alice", "laptop")
+		require.NoError(t, err)
+		assert.Equal(t, 0, laptopConfirmations)
+		phoneConfirmations, err := operationsRepository.CountConfirmations("alice", "phone")
+		require.NoError(t, err)
+		assert.Equal(t, 2, phoneConfirmations)
+
+		laptopToken, err := pushTokensRepository.GetPushToken("alice", "laptop")
+		require.NoError(t, err)
+		assert.Nil(t, laptopToken)
+	})
+
+	t.Run("devices with session are kept", func(t *testing.T) {
+		// Arrange
+		authRepository := authMemory.New(logger)
+		operationsRepository := operationsMemory.New(logger)
+		pushTokensRepository := pushNotificationsMemory.New(logger)
+		require.NoError(t, authRepository.UpdateRefreshToken("alice", "phone", "token").Perform())
+		controller := defaultController.New(authRepository, operationsRepository, pushTokensRepository, logger)
+
+		// Act
+		report, err := controller.CollectGarbage()
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 0, report.Devices)
+		exists, err := authRepository.IsSessionExists("alice", "phone")
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("device signing in again after it is listed keeps its confirmations and push token", func(t *testing.T) {
+		// Arrange
+		memory := authMemory.New(logger)
+		operationsRepository := operationsMemory.New(logger)
+		pushTokensRepository := pushNotificationsMemory.New(logger)
+		for _, device := range []auth.DeviceId{"phone", "laptop"} {
+			require.NoError(t, memory.UpdateRefreshToken("alice", device, string(device)).Perform())
+		}
+		require.NoError(t, pushTokensRepository.StorePushToken("alice", "laptop", "laptop-token").Perform())
+		operation := operations.CreateOperation(openapi.SomeOperation{
+			OperationId:       "create-alice",
+			AuthorId:          "alice",
+			UpdateDisplayName: openapi.UpdateDisplayNameOperationUpdateDisplayName{UserId: "alice", DisplayName: "alice"},
+		})
+		require.NoError(t, operationsRepository.Push([]operations.PushOperation{operation}, "alice", "laptop", true, nil).Perform())
+		require.NoError(t, memory.ExclusiveSession("alice", "phone").Perform())
+		authRepository := &authRepository_mock.RepositoryMock{
+			GetDevicesWithoutSessionImpl: func() ([]auth.Device, error) {
+				listed, err := memory.GetDevicesWithoutSession()
+				require.Len(t, listed, 1)
+				// the laptop signs in right after it is listed
+				require.NoError(t, memory.UpdateRefreshToken("alice", "laptop", "laptop-again").Perform())
+				return listed, err
+			},
+			ForgetDeviceImpl: memory.ForgetDevice,
+		}
+		controller := defaultController.New(authRepository, operationsRepository, pushTokensRepository, logger)
+
+		// Act
+		report, err := controller.CollectGarbage()
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 0, report.Devices)
+		laptopConfirmations, err := operationsRepository.CountConfirmations("alice", "laptop")
+		require.NoError(t, err)
+		assert.Equal(t, 1, laptopConfirmations)
+		laptopToken, err := pushTokensRepository.GetPushToken("alice", "laptop")
+		require.NoError(t, err)
+		assert.NotNil(t, laptopToken)
+	})
+
+	t.Run("devices error", func(t *testing.T) {
+		// Arrange
+		expectedErr := errors.New("devices error")
+		authRepository := &authRepository_mock.RepositoryMock{
+			GetDevicesWithoutSessionImpl: func() ([]auth.Device, error) {
+				return nil, expectedErr
+			},
+		}
+		controller := defaultController.New(authRepository, operationsMemory.New(logger), pushNotificationsMemory.New(logger), logger)
+
+		// Act
+		_, err := controller.CollectGarbage()
+
+		// Assert
+		assert.ErrorIs(t, err, expectedErr)
+	})
+}
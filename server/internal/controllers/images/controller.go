@@ -2,18 +2,46 @@ package images
 
 import (
 	"errors"
+	openapi "verni/internal/openapi/go"
 )
 
 type ImageId string
+
+// Size is the longest side of an image in pixels, OriginalSize asks for the image as uploaded
+type Size int
+
+const OriginalSize Size = 0
+
 type Image struct {
 	Id     ImageId
 	Base64 string
 }
 
+// Blob is the content of an image
+type Blob struct {
+	ContentType string
+	// ETag is a strong entity tag derived from the content, quoted as http expects it
+	ETag string
+	Data []byte
+}
+
 var (
 	NoSuchImage = errors.New("no such image")
+	BadFormat   = errors.New("bad format")
 )
 
 type Controller interface {
-	GetImages(ids []ImageId) ([]Image, error)
+	// GetImages returns the smallest version of every image that is at least of the size, or the largest one
+	GetImages(ids []ImageId, size Size) ([]Image, error)
+	// GetImage returns the content of an uploaded image picked like GetImages does
+	GetImage(id ImageId, size Size) (Blob, error)
+	// StoreUploads checks uploaded images, stores them with their thumbnails stripped of metadata in the image
+	// storage and returns operations keeping only references to the stored bytes. Uploads that are not png,
+	// jpeg or webp images or are too large fail with BadFormat. Operations other than image uploads are
+	// returned as is.
+	StoreUploads(operations []openapi.SomeOperation) ([]openapi.SomeOperation, error)
+	// InlineUploads returns operations with base64 of image uploads filled with the stored original when they are
+	// served, stored operations keep only references for clients still reading images from operations. Other
+	// operations are returned as is.
+	InlineUploads(operations []openapi.SomeOperation) ([]openapi.SomeOperation, error)
 }
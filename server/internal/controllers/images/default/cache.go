@@ -0,0 +1,60 @@
+package defaultController
+
+import (
+	"container/list"
+	"sync"
+	"verni/internal/controllers/images"
+	openapi "verni/internal/openapi/go"
+)
+
+// uploadsCacheCapacity is the number of uploads kept in memory, each one is a few keys without image bytes
+const uploadsCacheCapacity = 4096
+
+// uploadsCache keeps recently requested uploads, an upload never changes once pushed so entries are
+// only evicted when the cache is full
+type uploadsCache struct {
+	mutex    sync.Mutex
+	capacity int
+	order    *list.List
+	entries  map[images.ImageId]*list.Element
+}
+
+type cachedUpload struct {
+	id     images.ImageId
+	upload openapi.UploadImageOperationUploadImage
+}
+
+func newUploadsCache(capacity int) *uploadsCache {
+	return &uploadsCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  map[images.ImageId]*list.Element{},
+	}
+}
+
+func (c *uploadsCache) get(id images.ImageId) (openapi.UploadImageOperationUploadImage, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	element, ok := c.entries[id]
+	if !ok {
+		return openapi.UploadImageOperationUploadImage{}, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(cachedUpload).upload, true
+}
+
+func (c *uploadsCache) put(id images.ImageId, upload openapi.UploadImageOperationUploadImage) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if element, ok := c.entries[id]; ok {
+		element.Value = cachedUpload{id: id, upload: upload}
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[id] = c.order.PushFront(cachedUpload{id: id, upload: upload})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(cachedUpload).id)
+	}
+}
@@ -1,12 +1,21 @@
 package defaultController
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"image"
+	"net/http"
+	"slices"
+	"strconv"
 	"verni/internal/common"
 	"verni/internal/controllers/images"
 	openapi "verni/internal/openapi/go"
 	operationsRepository "verni/internal/repositories/operations"
+	"verni/internal/services/imageStorage"
 	"verni/internal/services/logging"
 )
 
@@ -14,22 +23,253 @@ type OperationsRepository operationsRepository.Repository
 
 func New(
 	operationsRepository OperationsRepository,
+	imageStorage imageStorage.Service,
 	logger logging.Service,
 ) images.Controller {
 	return &defaultController{
 		operationsRepository: operationsRepository,
+		imageStorage:         imageStorage,
+		cache:                newUploadsCache(uploadsCacheCapacity),
 		logger:               logger,
 	}
 }
 
 type defaultController struct {
 	operationsRepository OperationsRepository
+	imageStorage         imageStorage.Service
+	cache                *uploadsCache
 	logger               logging.Service
 }
 
-func (c *defaultController) GetImages(ids []images.ImageId) ([]images.Image, error) {
+func (c *defaultController) GetImages(ids []images.ImageId, size images.Size) ([]images.Image, error) {
 	const op = "avatars.defaultController.GetAvatars"
-	c.logger.LogInfo("%s: start[ids=%s]", op, ids)
+	c.logger.LogInfo("%s: start[ids=%s size=%d]", op, ids, size)
+	uploads, err := c.uploads(ids)
+	if err != nil {
+		c.logger.LogInfo("%s: %v", op, err)
+		return []images.Image{}, err
+	}
+	result := []images.Image{}
+	for _, upload := range uploads {
+		if upload.StorageKey == "" {
+			// images uploaded before the image storage have no thumbnails and are returned as uploaded
+			result = append(result, images.Image{Id: images.ImageId(upload.ImageId), Base64: upload.Base64})
+			continue
+		}
+		data, err := c.load(upload, size)
+		if err != nil {
+			err := fmt.Errorf("loading image %s: %w", upload.ImageId, err)
+			c.logger.LogInfo("%s: %v", op, err)
+			return []images.Image{}, err
+		}
+		result = append(
+			result,
+			images.Image{
+				Id:     images.ImageId(upload.ImageId),
+				Base64: base64.StdEncoding.EncodeToString(data),
+			},
+		)
+	}
+	c.logger.LogInfo("%s: success[ids=%s]", op, ids)
+	return result, nil
+}
+
+func (c *defaultController) GetImage(id images.ImageId, size images.Size) (images.Blob, error) {
+	const op = "images.defaultController.GetImage"
+	c.logger.LogInfo("%s: start[id=%s size=%d]", op, id, size)
+	uploads, err := c.uploads([]images.ImageId{id})
+	if err != nil {
+		return images.Blob{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(uploads) == 0 {
+		return images.Blob{}, fmt.Errorf("%s: image %s is not uploaded: %w", op, id, images.NoSuchImage)
+	}
+	data, err := c.load(uploads[0], size)
+	if errors.Is(err, imageStorage.NoSuchImage) {
+		return images.Blob{}, fmt.Errorf("%s: loading image %s: %w", op, id, images.NoSuchImage)
+	} else if err != nil {
+		return images.Blob{}, fmt.Errorf("%s: loading image %s: %w", op, id, err)
+	}
+	c.logger.LogInfo("%s: success[id=%s size=%d]", op, id, len(data))
+	digest := sha256.Sum256(data)
+	return images.Blob{
+		ContentType: http.DetectContentType(data),
+		ETag:        fmt.Sprintf("%q", hex.EncodeToString(digest[:])),
+		Data:        data,
+	}, nil
+}
+
+// load returns bytes of the smallest thumbnail that is at least of the size, or the uploaded image
+func (c *defaultController) load(upload openapi.UploadImageOperationUploadImage, size images.Size) ([]byte, error) {
+	if upload.StorageKey == "" {
+		// images uploaded before the image storage keep their bytes inside the operation
+		data, err := base64.StdEncoding.DecodeString(upload.Base64)
+		if err != nil {
+			return nil, fmt.Errorf("decoding legacy image: %w", err)
+		}
+		return data, nil
+	}
+	key := imageStorage.Key(upload.StorageKey)
+	if size != images.OriginalSize {
+		best := images.OriginalSize
+		for side, thumbnailKey := range upload.Thumbnails {
+			thumbnailSize, err := strconv.Atoi(side)
+			if err != nil || images.Size(thumbnailSize) < size {
+				continue
+			}
+			if best == images.OriginalSize || images.Size(thumbnailSize) < best {
+				best = images.Size(thumbnailSize)
+				key = imageStorage.Key(thumbnailKey)
+			}
+		}
+	}
+	return c.imageStorage.Load(key)
+}
+
+func (c *defaultController) StoreUploads(operations []openapi.SomeOperation) ([]openapi.SomeOperation, error) {
+	const op = "images.defaultController.StoreUploads"
+	result := make([]openapi.SomeOperation, len(operations))
+	for index, operation := range operations {
+		result[index] = operation
+		payload := operationsRepository.OpenApiOperation{SomeOperation: operation}
+		if payload.Type() != operationsRepository.UploadImageOperationPayloadType {
+			continue
+		}
+		upload := operation.UploadImage
+		c.logger.LogInfo("%s: start[id=%s]", op, upload.ImageId)
+		if upload.Base64 == "" {
+			// operations pulled from the server already reference stored bytes
+			if err := c.checkStored(upload); err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			continue
+		}
+		stored, err := c.store(upload)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		result[index].UploadImage = stored
+		c.logger.LogInfo("%s: stored[id=%s key=%s thumbnails=%d]", op, upload.ImageId, stored.StorageKey, len(stored.Thumbnails))
+	}
+	return result, nil
+}
+
+func (c *defaultController) InlineUploads(operations []openapi.SomeOperation) ([]openapi.SomeOperation, error) {
+	const op = "images.defaultController.InlineUploads"
+	result := make([]openapi.SomeOperation, len(operations))
+	for index, operation := range operations {
+		result[index] = operation
+		payload := operationsRepository.OpenApiOperation{SomeOperation: operation}
+		if payload.Type() != operationsRepository.UploadImageOperationPayloadType || operation.UploadImage.Base64 != "" {
+			continue
+		}
+		data, err := c.load(operation.UploadImage, images.OriginalSize)
+		if err != nil {
+			return nil, fmt.Errorf("%s: loading image %s: %w", op, operation.UploadImage.ImageId, err)
+		}
+		result[index].UploadImage.Base64 = base64.StdEncoding.EncodeToString(data)
+	}
+	return result, nil
+}
+
+func (c *defaultController) store(upload openapi.UploadImageOperationUploadImage) (openapi.UploadImageOperationUploadImage, error) {
+	data, err := base64.StdEncoding.DecodeString(upload.Base64)
+	if err != nil {
+		return openapi.UploadImageOperationUploadImage{}, fmt.Errorf("decoding image %s: %w", upload.ImageId, images.BadFormat)
+	}
+	decoded, format, err := decodeUpload(data)
+	if err != nil {
+		return openapi.UploadImageOperationUploadImage{}, fmt.Errorf("checking image %s: %w", upload.ImageId, err)
+	}
+	stored := openapi.UploadImageOperationUploadImage{
+		ImageId:    upload.ImageId,
+		Thumbnails: map[string]string{},
+	}
+	// the original is encoded again as well to drop its metadata
+	if stored.StorageKey, err = c.encodeAndStore(decoded, format); err != nil {
+		return openapi.UploadImageOperationUploadImage{}, fmt.Errorf("storing image %s: %w", upload.ImageId, err)
+	}
+	for _, size := range thumbnailSizes {
+		scaled, ok := thumbnail(decoded, size)
+		if !ok {
+			continue
+		}
+		key, err := c.encodeAndStore(scaled, format)
+		if err != nil {
+			return openapi.UploadImageOperationUploadImage{}, fmt.Errorf("storing %d thumbnail of image %s: %w", size, upload.ImageId, err)
+		}
+		stored.Thumbnails[strconv.Itoa(int(size))] = key
+	}
+	return stored, nil
+}
+
+func (c *defaultController) encodeAndStore(img image.Image, format string) (string, error) {
+	data, err := encode(img, format)
+	if err != nil {
+		return "", err
+	}
+	key, err := c.imageStorage.Store(data)
+	if err != nil {
+		return "", err
+	}
+	return string(key), nil
+}
+
+func (c *defaultController) checkStored(upload openapi.UploadImageOperationUploadImage) error {
+	if upload.StorageKey == "" {
+		return fmt.Errorf("image %s has no content: %w", upload.ImageId, images.BadFormat)
+	}
+	keys := []string{upload.StorageKey}
+	for _, key := range upload.Thumbnails {
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		if _, err := c.imageStorage.Load(imageStorage.Key(key)); errors.Is(err, imageStorage.NoSuchImage) {
+			return fmt.Errorf("image %s references unknown key %s: %w", upload.ImageId, key, images.BadFormat)
+		} else if err != nil {
+			return fmt.Errorf("checking image %s: %w", upload.ImageId, err)
+		}
+	}
+	return nil
+}
+
+// uploads returns uploads of the images in the order of ids skipping images that are not uploaded, uploads
+// never change so they are read from the operations only once while they stay in the cache
+func (c *defaultController) uploads(ids []images.ImageId) ([]openapi.UploadImageOperationUploadImage, error) {
+	found := map[images.ImageId]openapi.UploadImageOperationUploadImage{}
+	missing := []images.ImageId{}
+	for _, id := range ids {
+		if _, seen := found[id]; seen || slices.Contains(missing, id) {
+			continue
+		}
+		if upload, ok := c.cache.get(id); ok {
+			found[id] = upload
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		loaded, err := c.loadUploads(missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, upload := range loaded {
+			id := images.ImageId(upload.ImageId)
+			c.cache.put(id, upload)
+			found[id] = upload
+		}
+	}
+	result := []openapi.UploadImageOperationUploadImage{}
+	for _, id := range ids {
+		if upload, ok := found[id]; ok {
+			result = append(result, upload)
+			delete(found, id)
+		}
+	}
+	return result, nil
+}
+
+func (c *defaultController) loadUploads(ids []images.ImageId) ([]openapi.UploadImageOperationUploadImage, error) {
 	operations, err := c.operationsRepository.Get(
 		common.Map(
 			ids,
@@ -42,36 +282,23 @@ func (c *defaultController) GetImages(ids []images.ImageId) ([]images.Image, err
 		),
 	)
 	if err != nil {
-		err := fmt.Errorf("getting corresponding operations: %w", err)
-		c.logger.LogInfo("%s: %v", op, err)
-		return []images.Image{}, err
+		return nil, fmt.Errorf("getting corresponding operations: %w", err)
 	}
-	result := []images.Image{}
+	result := []openapi.UploadImageOperationUploadImage{}
 	for _, operation := range operations {
 		if operation.Payload.Type() != operationsRepository.UploadImageOperationPayloadType {
-			c.logger.LogInfo("%s: unexpected operation type %s, skipping", op, operation.Payload.Type())
+			c.logger.LogInfo("unexpected operation type %s, skipping", operation.Payload.Type())
 			continue
 		}
 		data, err := operation.Payload.Data()
 		if err != nil {
-			err := fmt.Errorf("getting operation payload: %w", err)
-			c.logger.LogInfo("%s: %v", op, err)
-			return []images.Image{}, err
+			return nil, fmt.Errorf("getting operation payload: %w", err)
 		}
 		var uploadOperation openapi.UploadImageOperation
 		if err := json.Unmarshal(data, &uploadOperation); err != nil {
-			err := fmt.Errorf("decoding operation payload: %w", err)
-			c.logger.LogInfo("%s: %v", op, err)
-			return []images.Image{}, err
+			return nil, fmt.Errorf("decoding operation payload: %w", err)
 		}
-		result = append(
-			result,
-			images.Image{
-				Id:     images.ImageId(uploadOperation.UploadImage.ImageId),
-				Base64: uploadOperation.UploadImage.Base64,
-			},
-		)
+		result = append(result, uploadOperation.UploadImage)
 	}
-	c.logger.LogInfo("%s: success[ids=%s]", op, ids)
 	return result, nil
 }
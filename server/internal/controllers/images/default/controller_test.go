@@ -1,8 +1,17 @@
 package defaultController_test
 
 import (
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -12,7 +21,10 @@ import (
 	defaultController "verni/internal/controllers/images/default"
 	openapi "verni/internal/openapi/go"
 	"verni/internal/repositories/operations"
+	operationsMemory "verni/internal/repositories/operations/memory"
 	operationsRepository_mock "verni/internal/repositories/operations/mock"
+	"verni/internal/services/imageStorage"
+	imageStorage_mock "verni/internal/services/imageStorage/mock"
 	standartOutputLoggingService "verni/internal/services/logging/standartOutput"
 )
 
@@ -96,10 +108,10 @@ func TestController_GetImages(t *testing.T) {
 			},
 		}
 
-		controller := defaultController.New(opsRepo, logger)
+		controller := defaultController.New(opsRepo, &imageStorage_mock.ServiceMock{}, logger)
 
 		// Act
-		result, err := controller.GetImages([]images.ImageId{imageId1, imageId2})
+		result, err := controller.GetImages([]images.ImageId{imageId1, imageId2}, images.OriginalSize)
 
 		// Assert
 		assert.NoError(t, err)
@@ -118,10 +130,10 @@ func TestController_GetImages(t *testing.T) {
 			},
 		}
 
-		controller := defaultController.New(opsRepo, logger)
+		controller := defaultController.New(opsRepo, &imageStorage_mock.ServiceMock{}, logger)
 
 		// Act
-		result, err := controller.GetImages([]images.ImageId{images.ImageId("image-1")})
+		result, err := controller.GetImages([]images.ImageId{images.ImageId("image-1")}, images.OriginalSize)
 
 		// Assert
 		assert.Error(t, err)
@@ -165,10 +177,10 @@ func TestController_GetImages(t *testing.T) {
 			},
 		}
 
-		controller := defaultController.New(opsRepo, logger)
+		controller := defaultController.New(opsRepo, &imageStorage_mock.ServiceMock{}, logger)
 
 		// Act
-		result, err := controller.GetImages([]images.ImageId{imageId})
+		result, err := controller.GetImages([]images.ImageId{imageId}, images.OriginalSize)
 
 		// Assert
 		assert.NoError(t, err)
@@ -194,10 +206,10 @@ func TestController_GetImages(t *testing.T) {
 			},
 		}
 
-		controller := defaultController.New(opsRepo, logger)
+		controller := defaultController.New(opsRepo, &imageStorage_mock.ServiceMock{}, logger)
 
 		// Act
-		result, err := controller.GetImages([]images.ImageId{images.ImageId("image-1")})
+		result, err := controller.GetImages([]images.ImageId{images.ImageId("image-1")}, images.OriginalSize)
 
 		// Assert
 		assert.Error(t, err)
@@ -221,10 +233,10 @@ func TestController_GetImages(t *testing.T) {
 			},
 		}
 
-		controller := defaultController.New(opsRepo, logger)
+		controller := defaultController.New(opsRepo, &imageStorage_mock.ServiceMock{}, logger)
 
 		// Act
-		result, err := controller.GetImages([]images.ImageId{images.ImageId("image-1")})
+		result, err := controller.GetImages([]images.ImageId{images.ImageId("image-1")}, images.OriginalSize)
 
 		// Assert
 		assert.Error(t, err)
@@ -239,13 +251,297 @@ func TestController_GetImages(t *testing.T) {
 			},
 		}
 
-		controller := defaultController.New(opsRepo, logger)
+		controller := defaultController.New(opsRepo, &imageStorage_mock.ServiceMock{}, logger)
 
 		// Act
-		result, err := controller.GetImages([]images.ImageId{})
+		result, err := controller.GetImages([]images.ImageId{}, images.OriginalSize)
 
 		// Assert
 		assert.NoError(t, err)
 		assert.Empty(t, result)
 	})
 }
+
+func memoryImageStorage() *imageStorage_mock.ServiceMock {
+	stored := map[imageStorage.Key][]byte{}
+	return &imageStorage_mock.ServiceMock{
+		StoreImpl: func(data []byte) (imageStorage.Key, error) {
+			key := imageStorage.Key(fmt.Sprintf("key-%d", len(stored)))
+			stored[key] = data
+			return key, nil
+		},
+		LoadImpl: func(key imageStorage.Key) ([]byte, error) {
+			data, ok := stored[key]
+			if !ok {
+				return nil, imageStorage.NoSuchImage
+			}
+			return data, nil
+		},
+	}
+}
+
+func encodedImage(t *testing.T, width, height int, encode func(io.Writer, image.Image) error) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buffer bytes.Buffer
+	require.NoError(t, encode(&buffer, img))
+	return buffer.Bytes()
+}
+
+func encodeJpeg(w io.Writer, img image.Image) error {
+	return jpeg.Encode(w, img, nil)
+}
+
+// withExifOrientation inserts an exif segment with the orientation right after the jpeg start of image
+func withExifOrientation(data []byte, orientation uint16) []byte {
+	tiff := []byte{'M', 'M', 0, 42, 0, 0, 0, 8, 0, 1, 0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, byte(orientation), 0, 0, 0, 0, 0, 0}
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	result := []byte{0xff, 0xd8, 0xff, 0xe1, byte((len(segment) + 2) >> 8), byte(len(segment) + 2)}
+	result = append(result, segment...)
+	return append(result, data[2:]...)
+}
+
+func decodedSize(t *testing.T, data []byte) (int, int) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	require.NoError(t, err)
+	return config.Width, config.Height
+}
+
+func push(t *testing.T, repository operations.Repository, operation openapi.SomeOperation) {
+	require.NoError(t, repository.Push(
+		[]operations.PushOperation{operations.CreateOperation(operation)},
+		"alice",
+		"phone",
+		true,
+		nil,
+	).Perform())
+}
+
+func TestController_StoreUploads(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+
+	t.Run("uploaded image is stored with thumbnails and served by reference", func(t *testing.T) {
+		// Arrange
+		operationsRepository := operationsMemory.New(logger)
+		controller := defaultController.New(operationsRepository, memoryImageStorage(), logger)
+		upload := openapi.SomeOperation{
+			OperationId: "upload",
+			AuthorId:    "alice",
+			UploadImage: openapi.UploadImageOperationUploadImage{
+				ImageId: "avatar",
+				Base64:  base64.StdEncoding.EncodeToString(encodedImage(t, 300, 150, png.Encode)),
+			},
+		}
+		rename := openapi.SomeOperation{
+			OperationId:       "rename",
+			AuthorId:          "alice",
+			UpdateDisplayName: openapi.UpdateDisplayNameOperationUpdateDisplayName{UserId: "alice", DisplayName: "alice"},
+		}
+
+		// Act
+		stored, err := controller.StoreUploads([]openapi.SomeOperation{upload, rename})
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, stored, 2)
+		assert.Empty(t, stored[0].UploadImage.Base64)
+		assert.NotEmpty(t, stored[0].UploadImage.StorageKey)
+		// the image is smaller than 512 so only two thumbnails are made
+		assert.Len(t, stored[0].UploadImage.Thumbnails, 2)
+		assert.Contains(t, stored[0].UploadImage.Thumbnails, "64")
+		assert.Contains(t, stored[0].UploadImage.Thumbnails, "256")
+		assert.Equal(t, rename, stored[1])
+
+		push(t, operationsRepository, stored[0])
+		for _, testCase := range []struct {
+			size          images.Size
+			width, height int
+		}{
+			{size: images.OriginalSize, width: 300, height: 150},
+			{size: 32, width: 64, height: 32},
+			{size: 64, width: 64, height: 32},
+			{size: 100, width: 256, height: 128},
+			{size: 1000, width: 300, height: 150},
+		} {
+			blob, err := controller.GetImage("avatar", testCase.size)
+			require.NoError(t, err)
+			assert.Equal(t, "image/png", blob.ContentType)
+			width, height := decodedSize(t, blob.Data)
+			assert.Equal(t, testCase.width, width, "size %d", testCase.size)
+			assert.Equal(t, testCase.height, height, "size %d", testCase.size)
+		}
+		result, err := controller.GetImages([]images.ImageId{"avatar"}, 64)
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		data, err := base64.StdEncoding.DecodeString(result[0].Base64)
+		require.NoError(t, err)
+		width, _ := decodedSize(t, data)
+		assert.Equal(t, 64, width)
+
+		// pushing the pulled operation again keeps the reference
+		restored, err := controller.StoreUploads(stored[:1])
+		require.NoError(t, err)
+		assert.Equal(t, stored[:1], restored)
+	})
+
+	t.Run("jpeg is rotated by its exif orientation and stripped of it", func(t *testing.T) {
+		// Arrange
+		operationsRepository := operationsMemory.New(logger)
+		controller := defaultController.New(operationsRepository, memoryImageStorage(), logger)
+		upload := openapi.SomeOperation{
+			OperationId: "upload",
+			AuthorId:    "alice",
+			UploadImage: openapi.UploadImageOperationUploadImage{
+				ImageId: "photo",
+				// orientation 6 asks viewers to rotate the image clockwise
+				Base64: base64.StdEncoding.EncodeToString(withExifOrientation(encodedImage(t, 40, 20, encodeJpeg), 6)),
+			},
+		}
+
+		// Act
+		stored, err := controller.StoreUploads([]openapi.SomeOperation{upload})
+
+		// Assert
+		require.NoError(t, err)
+		push(t, operationsRepository, stored[0])
+		blob, err := controller.GetImage("photo", images.OriginalSize)
+		require.NoError(t, err)
+		assert.Equal(t, "image/jpeg", blob.ContentType)
+		assert.NotContains(t, string(blob.Data), "Exif")
+		width, height := decodedSize(t, blob.Data)
+		assert.Equal(t, 20, width)
+		assert.Equal(t, 40, height)
+	})
+
+	t.Run("bad uploads", func(t *testing.T) {
+		controller := defaultController.New(operationsMemory.New(logger), memoryImageStorage(), logger)
+		for _, upload := range []openapi.UploadImageOperationUploadImage{
+			{ImageId: "not-base64", Base64: "%%%"},
+			{ImageId: "no-content"},
+			{ImageId: "unknown-key", StorageKey: "unknown"},
+			{ImageId: "not-an-image", Base64: base64.StdEncoding.EncodeToString([]byte("hello"))},
+			{ImageId: "gif", Base64: base64.StdEncoding.EncodeToString(encodedImage(t, 8, 8, func(w io.Writer, img image.Image) error {
+				return gif.Encode(w, img, nil)
+			}))},
+			{ImageId: "truncated", Base64: base64.StdEncoding.EncodeToString(encodedImage(t, 64, 64, png.Encode)[:64])},
+			{ImageId: "too-large", Base64: base64.StdEncoding.EncodeToString(encodedImage(t, 4097, 1, png.Encode))},
+		} {
+			// Act
+			_, err := controller.StoreUploads([]openapi.SomeOperation{{OperationId: upload.ImageId, UploadImage: upload}})
+
+			// Assert
+			assert.ErrorIs(t, err, images.BadFormat, upload.ImageId)
+		}
+	})
+
+	t.Run("uploads are read from the operations once", func(t *testing.T) {
+		// Arrange
+		operationsRepository := operationsMemory.New(logger)
+		storage := memoryImageStorage()
+		controller := defaultController.New(operationsRepository, storage, logger)
+		stored, err := controller.StoreUploads([]openapi.SomeOperation{{
+			OperationId: "upload",
+			AuthorId:    "alice",
+			UploadImage: openapi.UploadImageOperationUploadImage{
+				ImageId: "avatar",
+				Base64:  base64.StdEncoding.EncodeToString(encodedImage(t, 100, 100, png.Encode)),
+			},
+		}})
+		require.NoError(t, err)
+		push(t, operationsRepository, stored[0])
+		requested := [][]operations.TrackedEntity{}
+		countingRepository := &operationsRepository_mock.RepositoryMock{
+			GetImpl: func(entities []operations.TrackedEntity) ([]operations.Operation, error) {
+				requested = append(requested, entities)
+				return operationsRepository.Get(entities)
+			},
+		}
+		controller = defaultController.New(countingRepository, storage, logger)
+
+		// Act
+		first, err := controller.GetImage("avatar", images.OriginalSize)
+		require.NoError(t, err)
+		second, err := controller.GetImage("avatar", images.OriginalSize)
+		require.NoError(t, err)
+		thumbnail, err := controller.GetImage("avatar", 64)
+		require.NoError(t, err)
+		_, err = controller.GetImages([]images.ImageId{"avatar", "missing"}, images.OriginalSize)
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, [][]operations.TrackedEntity{
+			{{Id: "avatar", Type: operations.EntityTypeImage}},
+			{{Id: "missing", Type: operations.EntityTypeImage}},
+		}, requested)
+		assert.NotEmpty(t, first.ETag)
+		assert.Equal(t, first.ETag, second.ETag)
+		assert.NotEqual(t, first.ETag, thumbnail.ETag)
+	})
+
+	t.Run("image is not uploaded", func(t *testing.T) {
+		// Arrange
+		controller := defaultController.New(operationsMemory.New(logger), memoryImageStorage(), logger)
+
+		// Act
+		_, err := controller.GetImage("missing", images.OriginalSize)
+
+		// Assert
+		assert.ErrorIs(t, err, images.NoSuchImage)
+	})
+}
+
+func TestController_InlineUploads(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+
+	t.Run("stored uploads are served with the original", func(t *testing.T) {
+		// Arrange
+		controller := defaultController.New(operationsMemory.New(logger), memoryImageStorage(), logger)
+		stored, err := controller.StoreUploads([]openapi.SomeOperation{{
+			OperationId: "upload",
+			AuthorId:    "alice",
+			UploadImage: openapi.UploadImageOperationUploadImage{
+				ImageId: "avatar",
+				Base64:  base64.StdEncoding.EncodeToString(encodedImage(t, 300, 150, png.Encode)),
+			},
+		}})
+		require.NoError(t, err)
+		rename := openapi.SomeOperation{
+			OperationId:       "rename",
+			AuthorId:          "alice",
+			UpdateDisplayName: openapi.UpdateDisplayNameOperationUpdateDisplayName{UserId: "alice", DisplayName: "alice"},
+		}
+
+		// Act
+		served, err := controller.InlineUploads([]openapi.SomeOperation{stored[0], rename})
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, served, 2)
+		assert.Empty(t, stored[0].UploadImage.Base64)
+		data, err := base64.StdEncoding.DecodeString(served[0].UploadImage.Base64)
+		require.NoError(t, err)
+		width, height := decodedSize(t, data)
+		assert.Equal(t, 300, width)
+		assert.Equal(t, 150, height)
+		assert.Equal(t, stored[0].UploadImage.StorageKey, served[0].UploadImage.StorageKey)
+		assert.Equal(t, rename, served[1])
+	})
+
+	t.Run("unknown storage key", func(t *testing.T) {
+		// Arrange
+		controller := defaultController.New(operationsMemory.New(logger), memoryImageStorage(), logger)
+
+		// Act
+		_, err := controller.InlineUploads([]openapi.SomeOperation{{
+			OperationId: "upload",
+			UploadImage: openapi.UploadImageOperationUploadImage{ImageId: "avatar", StorageKey: "unknown"},
+		}})
+
+		// Assert
+		assert.ErrorIs(t, err, imageStorage.NoSuchImage)
+	})
+}
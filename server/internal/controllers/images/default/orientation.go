@@ -0,0 +1,111 @@
+package defaultController
+
+import (
+	"encoding/binary"
+	"image"
+)
+
+const (
+	exifOrientationTag = 0x0112
+	jpegStartOfScan    = 0xda
+	jpegApp1           = 0xe1
+)
+
+// jpegOrientation returns the exif orientation of a jpeg image, 1 when there is none. Re-encoding drops
+// exif, so the orientation is applied to pixels instead of being lost.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return 1
+	}
+	offset := 2
+	for offset+4 <= len(data) {
+		if data[offset] != 0xff {
+			return 1
+		}
+		marker := data[offset+1]
+		length := int(binary.BigEndian.Uint16(data[offset+2:]))
+		if marker == jpegStartOfScan || length < 2 || offset+2+length > len(data) {
+			return 1
+		}
+		segment := data[offset+4 : offset+2+length]
+		if marker == jpegApp1 && len(segment) > 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return exifOrientation(segment[6:])
+		}
+		offset += 2 + length
+	}
+	return 1
+}
+
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 0 || ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for index := 0; index < entries; index++ {
+		entry := ifd + 2 + index*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) != exifOrientationTag {
+			continue
+		}
+		orientation := int(order.Uint16(tiff[entry+8:]))
+		if orientation < 1 || orientation > 8 {
+			return 1
+		}
+		return orientation
+	}
+	return 1
+}
+
+// orient transforms the image the way its exif orientation asks viewers to
+func orient(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	// orientations from 5 to 8 swap the axes
+	transposed := orientation >= 5
+	resultWidth, resultHeight := width, height
+	if transposed {
+		resultWidth, resultHeight = height, width
+	}
+	result := image.NewRGBA(image.Rect(0, 0, resultWidth, resultHeight))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var targetX, targetY int
+			switch orientation {
+			case 2:
+				targetX, targetY = width-1-x, y
+			case 3:
+				targetX, targetY = width-1-x, height-1-y
+			case 4:
+				targetX, targetY = x, height-1-y
+			case 5:
+				targetX, targetY = y, x
+			case 6:
+				targetX, targetY = height-1-y, x
+			case 7:
+				targetX, targetY = height-1-y, width-1-x
+			case 8:
+				targetX, targetY = y, width-1-x
+			}
+			result.Set(targetX, targetY, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return result
+}
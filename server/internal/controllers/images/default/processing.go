@@ -0,0 +1,82 @@
+package defaultController
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"verni/internal/controllers/images"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	maxUploadBytes = 8 << 20
+	maxDimension   = 4096
+	jpegQuality    = 90
+)
+
+// thumbnailSizes are the longest sides in pixels of thumbnails made for every upload
+var thumbnailSizes = []images.Size{64, 256, 512}
+
+// decodeUpload checks that data is a png, jpeg or webp image of acceptable size and decodes it, the
+// dimensions are checked before decoding so oversized images are never allocated
+func decodeUpload(data []byte) (image.Image, string, error) {
+	if len(data) > maxUploadBytes {
+		return nil, "", fmt.Errorf("image of %d bytes exceeds %d bytes: %w", len(data), maxUploadBytes, images.BadFormat)
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("reading image header: %v: %w", err, images.BadFormat)
+	}
+	switch format {
+	case "png", "jpeg", "webp":
+	default:
+		return nil, "", fmt.Errorf("image format %s is not supported: %w", format, images.BadFormat)
+	}
+	if config.Width > maxDimension || config.Height > maxDimension {
+		return nil, "", fmt.Errorf("image of %dx%d exceeds %dx%d: %w", config.Width, config.Height, maxDimension, maxDimension, images.BadFormat)
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("decoding %s image: %v: %w", format, err, images.BadFormat)
+	}
+	if format == "jpeg" {
+		decoded = orient(decoded, jpegOrientation(data))
+	}
+	return decoded, format, nil
+}
+
+// encode writes the image without any metadata, jpeg stays jpeg and everything else becomes png
+// since there is no webp encoder
+func encode(img image.Image, format string) ([]byte, error) {
+	var buffer bytes.Buffer
+	if format == "jpeg" {
+		if err := jpeg.Encode(&buffer, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, fmt.Errorf("encoding jpeg: %w", err)
+		}
+		return buffer.Bytes(), nil
+	}
+	if err := png.Encode(&buffer, img); err != nil {
+		return nil, fmt.Errorf("encoding png: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// thumbnail scales the image down keeping its aspect ratio so the longest side equals size,
+// false is returned when the image is not larger than size
+func thumbnail(img image.Image, size images.Size) (image.Image, bool) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	longest := max(width, height)
+	if longest <= int(size) {
+		return nil, false
+	}
+	scaledWidth := max(1, width*int(size)/longest)
+	scaledHeight := max(1, height*int(size)/longest)
+	scaled := image.NewRGBA(image.Rect(0, 0, scaledWidth, scaledHeight))
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, bounds, draw.Src, nil)
+	return scaled, true
+}
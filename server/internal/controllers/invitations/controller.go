@@ -0,0 +1,30 @@
+package invitations
+
+import (
+	"errors"
+	"time"
+	openapi "verni/internal/openapi/go"
+)
+
+type UserId string
+type GroupId string
+type Token string
+
+var (
+	NoSuchGroup       = errors.New("no such group")
+	NotAParticipant   = errors.New("not a participant")
+	NoSuchInvitation  = errors.New("no such invitation")
+	InvitationExpired = errors.New("invitation expired")
+	BadFormat         = errors.New("bad format")
+)
+
+type Controller interface {
+	// Create issues a token inviting to the group, it can be redeemed up to `maxUses` times during `lifetime`.
+	// Zero limits stand for defaults. Fails with NoSuchGroup or NotAParticipant if the user can not see the group
+	// and with BadFormat if limits are out of range.
+	Create(userId UserId, groupId GroupId, lifetime time.Duration, maxUses int) (openapi.GroupInvitation, error)
+	// Redeem adds the user to the group on behalf of the inviter and returns the group, participants redeeming
+	// the token again are not counted as a use. Fails with NoSuchInvitation for unknown tokens and with
+	// InvitationExpired if the token is expired, used up or the inviter can not add participants anymore.
+	Redeem(userId UserId, token Token) (GroupId, error)
+}
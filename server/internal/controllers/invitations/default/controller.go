@@ -0,0 +1,197 @@
+package defaultController
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+	"verni/internal/controllers/invitations"
+	"verni/internal/controllers/operations"
+	openapi "verni/internal/openapi/go"
+	invitationsRepository "verni/internal/repositories/invitations"
+	operationsRepository "verni/internal/repositories/operations"
+	"verni/internal/services/logging"
+
+	"github.com/google/uuid"
+)
+
+type InvitationsRepository invitationsRepository.Repository
+type OperationsRepository operationsRepository.Repository
+type OperationsController operations.Controller
+
+const (
+	defaultLifetime = 7 * 24 * time.Hour
+	lifetimeLimit   = 30 * 24 * time.Hour
+	defaultMaxUses  = 10
+	usesLimit       = 100
+	tokenLength     = 16
+)
+
+func New(
+	invitationsRepository InvitationsRepository,
+	operationsRepository OperationsRepository,
+	operationsController OperationsController,
+	currentTime func() time.Time,
+	logger logging.Service,
+) invitations.Controller {
+	return &defaultController{
+		invitationsRepository: invitationsRepository,
+		operationsRepository:  operationsRepository,
+		operationsController:  operationsController,
+		currentTime:           currentTime,
+		logger:                logger,
+	}
+}
+
+type defaultController struct {
+	invitationsRepository InvitationsRepository
+	operationsRepository  OperationsRepository
+	operationsController  OperationsController
+	currentTime           func() time.Time
+	logger                logging.Service
+}
+
+func (c *defaultController) Create(
+	userId invitations.UserId,
+	groupId invitations.GroupId,
+	lifetime time.Duration,
+	maxUses int,
+) (openapi.GroupInvitation, error) {
+	const op = "controllers.invitations.defaultController.Create"
+	c.logger.LogInfo("%s: start[user=%s group=%s]", op, userId, groupId)
+
+	if lifetime == 0 {
+		lifetime = defaultLifetime
+	}
+	if maxUses == 0 {
+		maxUses = defaultMaxUses
+	}
+	if lifetime < 0 || lifetime > lifetimeLimit {
+		return openapi.GroupInvitation{}, fmt.Errorf("lifetime %v is out of range: %w", lifetime, invitations.BadFormat)
+	}
+	if maxUses < 0 || maxUses > usesLimit {
+		return openapi.GroupInvitation{}, fmt.Errorf("max uses %d is out of range: %w", maxUses, invitations.BadFormat)
+	}
+	participants, err := c.groupParticipants(groupId)
+	if err != nil {
+		return openapi.GroupInvitation{}, err
+	}
+	if !slices.Contains(participants, operationsRepository.UserId(userId)) {
+		return openapi.GroupInvitation{}, fmt.Errorf("%s is not a participant of group %s: %w", userId, groupId, invitations.NotAParticipant)
+	}
+	token, err := generateToken()
+	if err != nil {
+		return openapi.GroupInvitation{}, fmt.Errorf("generating token: %w", err)
+	}
+	invitation := invitationsRepository.Invitation{
+		Token:     invitationsRepository.Token(token),
+		GroupId:   invitationsRepository.GroupId(groupId),
+		CreatedBy: invitationsRepository.UserId(userId),
+		ExpiresAt: c.currentTime().Add(lifetime).UnixMilli(),
+		MaxUses:   maxUses,
+	}
+	if err := c.invitationsRepository.CreateInvitation(invitation).Perform(); err != nil {
+		return openapi.GroupInvitation{}, fmt.Errorf("storing invitation: %w", err)
+	}
+
+	c.logger.LogInfo("%s: success[user=%s group=%s]", op, userId, groupId)
+	return openapi.GroupInvitation{
+		Token:     token,
+		GroupId:   string(groupId),
+		ExpiresAt: invitation.ExpiresAt,
+		MaxUses:   int32(invitation.MaxUses),
+	}, nil
+}
+
+func (c *defaultController) Redeem(userId invitations.UserId, token invitations.Token) (invitations.GroupId, error) {
+	const op = "controllers.invitations.defaultController.Redeem"
+	c.logger.LogInfo("%s: start[user=%s]", op, userId)
+
+	invitation, err := c.invitationsRepository.GetInvitation(invitationsRepository.Token(token))
+	if err != nil {
+		return "", fmt.Errorf("getting invitation: %w", err)
+	}
+	if invitation == nil {
+		return "", fmt.Errorf("getting invitation: %w", invitations.NoSuchInvitation)
+	}
+	groupId := invitations.GroupId(invitation.GroupId)
+	participants, err := c.groupParticipants(groupId)
+	if err != nil && !errors.Is(err, invitations.NoSuchGroup) {
+		return "", err
+	}
+	if slices.Contains(participants, operationsRepository.UserId(userId)) {
+		c.logger.LogInfo("%s: already a participant[user=%s group=%s]", op, userId, groupId)
+		return groupId, nil
+	}
+	if c.currentTime().UnixMilli() >= invitation.ExpiresAt {
+		return "", fmt.Errorf("invitation to group %s has expired: %w", groupId, invitations.InvitationExpired)
+	}
+	use := c.invitationsRepository.UseInvitation(invitation.Token)
+	if err := use.Perform(); err != nil {
+		if errors.Is(err, invitationsRepository.ErrNoUsesLeft) {
+			return "", fmt.Errorf("invitation to group %s is used up: %w", groupId, invitations.InvitationExpired)
+		}
+		return "", fmt.Errorf("using invitation: %w", err)
+	}
+	// the inviter vouches for the new participant, so the operation passes the same checks as if they pushed it
+	addition := openapi.SomeOperation{
+		OperationId: uuid.New().String(),
+		CreatedAt:   c.currentTime().UnixMilli(),
+		AuthorId:    string(invitation.CreatedBy),
+		AddGroupParticipant: openapi.AddGroupParticipantOperationAddGroupParticipant{
+			GroupId: string(groupId),
+			UserId:  string(userId),
+		},
+	}
+	// no device of the inviter has seen the addition, so it is pushed from no device
+	if err := c.operationsController.Push(
+		[]openapi.SomeOperation{addition},
+		operations.UserId(invitation.CreatedBy),
+		"",
+	); err != nil {
+		if rollbackErr := use.Rollback(); rollbackErr != nil {
+			c.logger.LogError("%s: rolling back invitation use: %v", op, rollbackErr)
+		}
+		if errors.Is(err, operations.PrivacyViolation) || errors.Is(err, operations.BadFormat) {
+			return "", fmt.Errorf("invitation to group %s is no longer valid: %v: %w", groupId, err, invitations.InvitationExpired)
+		}
+		return "", fmt.Errorf("pushing participant addition: %w", err)
+	}
+
+	c.logger.LogInfo("%s: success[user=%s group=%s]", op, userId, groupId)
+	return groupId, nil
+}
+
+// groupParticipants fails with NoSuchGroup for groups that were never created or are already deleted
+func (c *defaultController) groupParticipants(groupId invitations.GroupId) ([]operationsRepository.UserId, error) {
+	group := []operationsRepository.TrackedEntity{
+		{Id: string(groupId), Type: operationsRepository.EntityTypeSpendingGroup},
+	}
+	participants, err := c.operationsRepository.GetUsers(group)
+	if err != nil {
+		return nil, fmt.Errorf("getting participants of group %s: %w", groupId, err)
+	}
+	if len(participants) == 0 {
+		return nil, fmt.Errorf("group %s is not created: %w", groupId, invitations.NoSuchGroup)
+	}
+	affecting, err := c.operationsRepository.Get(group)
+	if err != nil {
+		return nil, fmt.Errorf("getting operations of group %s: %w", groupId, err)
+	}
+	for _, operation := range affecting {
+		if operation.Payload.Type() == operationsRepository.DeleteSpendingGroupOperationPayloadType {
+			return nil, fmt.Errorf("group %s is deleted: %w", groupId, invitations.NoSuchGroup)
+		}
+	}
+	return participants, nil
+}
+
+func generateToken() (string, error) {
+	bytes := make([]byte, tokenLength)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
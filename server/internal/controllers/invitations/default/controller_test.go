@@ -0,0 +1,250 @@
+package defaultController_test
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"verni/internal/common"
+	"verni/internal/controllers/invitations"
+	defaultController "verni/internal/controllers/invitations/default"
+	"verni/internal/controllers/operations"
+	defaultOperationsController "verni/internal/controllers/operations/default"
+	openapi "verni/internal/openapi/go"
+	balancesMemory "verni/internal/repositories/balances/memory"
+	invitationsMemory "verni/internal/repositories/invitations/memory"
+	operationsMemory "verni/internal/repositories/operations/memory"
+	pushNotifications "verni/internal/repositories/pushNotifications"
+	pushNotifications_mock "verni/internal/repositories/pushNotifications/mock"
+	dispatcher_mock "verni/internal/services/dispatcher/mock"
+	defaultFormatValidation "verni/internal/services/formatValidation/default"
+	standartOutputLoggingService "verni/internal/services/logging/standartOutput"
+	realtimeEvents "verni/internal/services/realtimeEvents"
+	realtimeEvents_mock "verni/internal/services/realtimeEvents/mock"
+)
+
+type environment struct {
+	invitations invitations.Controller
+	operations  operations.Controller
+	now         time.Time
+}
+
+// newEnvironment creates users alice, bob, carol and dave and a group "trip" of alice and bob
+func newEnvironment(t *testing.T) *environment {
+	logger := standartOutputLoggingService.New()
+	repository := operationsMemory.New(logger)
+	realtimeService := &realtimeEvents_mock.ServiceMock{
+		NotifyUpdateImpl: func(realtimeEvents.UserId, []realtimeEvents.DeviceId, []json.RawMessage) {},
+	}
+	pushNotificationsRepository := &pushNotifications_mock.RepositoryMock{
+		GetPushTokensImpl: func(userIds []pushNotifications.UserId) (map[pushNotifications.UserId][]string, error) {
+			return map[pushNotifications.UserId][]string{}, nil
+		},
+	}
+	env := &environment{
+		operations: defaultOperationsController.New(
+			repository,
+			balancesMemory.New(logger),
+			realtimeService,
+			nil,
+			pushNotificationsRepository,
+			defaultFormatValidation.New(logger),
+			&dispatcher_mock.ServiceMock{
+				DispatchImpl: func(name string, task func()) error {
+					task()
+					return nil
+				},
+			},
+			time.Now,
+			logger,
+		),
+		now: time.UnixMilli(1000),
+	}
+	env.invitations = defaultController.New(
+		invitationsMemory.New(logger),
+		repository,
+		env.operations,
+		func() time.Time {
+			return env.now
+		},
+		logger,
+	)
+	push := func(author string, operation openapi.SomeOperation) {
+		operation.AuthorId = author
+		operation.CreatedAt = env.now.UnixMilli()
+		require.NoError(t, env.operations.Push([]openapi.SomeOperation{operation}, operations.UserId(author), "device"))
+	}
+	for _, user := range []string{"alice", "bob", "carol", "dave"} {
+		push(user, openapi.SomeOperation{
+			OperationId: "create-" + user,
+			CreateUser:  openapi.CreateUserOperationCreateUser{UserId: user, DisplayName: user},
+		})
+	}
+	push("alice", openapi.SomeOperation{
+		OperationId:         "create-trip",
+		CreateSpendingGroup: openapi.CreateSpendingGroupOperationCreateSpendingGroup{GroupId: "trip", Participants: []string{"bob"}},
+	})
+	return env
+}
+
+func (e *environment) pulledIds(t *testing.T, userId string) []string {
+	page, err := e.operations.Pull(operations.UserId(userId), "device", openapi.REGULAR, 0, 0)
+	require.NoError(t, err)
+	return common.Map(page.Operations, func(operation openapi.SomeOperation) string {
+		return operation.OperationId
+	})
+}
+
+func TestController_Create(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		// Arrange
+		env := newEnvironment(t)
+
+		// Act
+		invitation, err := env.invitations.Create("bob", "trip", 0, 0)
+
+		// Assert
+		require.NoError(t, err)
+		assert.NotEmpty(t, invitation.Token)
+		assert.Equal(t, "trip", invitation.GroupId)
+		assert.Equal(t, env.now.Add(7*24*time.Hour).UnixMilli(), invitation.ExpiresAt)
+		assert.Equal(t, int32(10), invitation.MaxUses)
+	})
+
+	for _, testCase := range []struct {
+		name     string
+		userId   invitations.UserId
+		groupId  invitations.GroupId
+		lifetime time.Duration
+		maxUses  int
+		err      error
+	}{
+		{name: "not a participant", userId: "carol", groupId: "trip", err: invitations.NotAParticipant},
+		{name: "unknown group", userId: "alice", groupId: "unknown", err: invitations.NoSuchGroup},
+		{name: "too long lifetime", userId: "alice", groupId: "trip", lifetime: 31 * 24 * time.Hour, err: invitations.BadFormat},
+		{name: "negative max uses", userId: "alice", groupId: "trip", maxUses: -1, err: invitations.BadFormat},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Arrange
+			env := newEnvironment(t)
+
+			// Act
+			_, err := env.invitations.Create(testCase.userId, testCase.groupId, testCase.lifetime, testCase.maxUses)
+
+			// Assert
+			assert.ErrorIs(t, err, testCase.err)
+		})
+	}
+}
+
+func TestController_Redeem(t *testing.T) {
+	t.Run("redeemed invitation adds the user to the group", func(t *testing.T) {
+		// Arrange
+		env := newEnvironment(t)
+		invitation, err := env.invitations.Create("bob", "trip", time.Hour, 1)
+		require.NoError(t, err)
+
+		// Act
+		groupId, err := env.invitations.Redeem("carol", invitations.Token(invitation.Token))
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, invitations.GroupId("trip"), groupId)
+		assert.Contains(t, env.pulledIds(t, "carol"), "create-trip")
+		assert.Contains(t, env.pulledIds(t, "carol"), "create-alice")
+		assert.Contains(t, env.pulledIds(t, "alice"), "create-carol")
+
+		t.Run("addition is not confirmed for any device of the inviter", func(t *testing.T) {
+			// Act
+			page, err := env.operations.Pull("bob", "", openapi.REGULAR, 0, 0)
+
+			// Assert
+			require.NoError(t, err)
+			assert.True(t, slices.ContainsFunc(page.Operations, func(operation openapi.SomeOperation) bool {
+				return operation.AddGroupParticipant.UserId == "carol"
+			}))
+		})
+
+		t.Run("redeeming again is not counted as a use", func(t *testing.T) {
+			// Act
+			groupId, err := env.invitations.Redeem("carol", invitations.Token(invitation.Token))
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, invitations.GroupId("trip"), groupId)
+		})
+
+		t.Run("used up invitation", func(t *testing.T) {
+			// Act
+			_, err := env.invitations.Redeem("dave", invitations.Token(invitation.Token))
+
+			// Assert
+			assert.ErrorIs(t, err, invitations.InvitationExpired)
+			assert.NotContains(t, env.pulledIds(t, "dave"), "create-trip")
+		})
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		// Arrange
+		env := newEnvironment(t)
+
+		// Act
+		_, err := env.invitations.Redeem("carol", "unknown")
+
+		// Assert
+		assert.ErrorIs(t, err, invitations.NoSuchInvitation)
+	})
+
+	t.Run("expired invitation", func(t *testing.T) {
+		// Arrange
+		env := newEnvironment(t)
+		invitation, err := env.invitations.Create("bob", "trip", time.Hour, 1)
+		require.NoError(t, err)
+		env.now = env.now.Add(time.Hour)
+
+		// Act
+		_, err = env.invitations.Redeem("carol", invitations.Token(invitation.Token))
+
+		// Assert
+		assert.ErrorIs(t, err, invitations.InvitationExpired)
+	})
+
+	t.Run("inviter left the group", func(t *testing.T) {
+		// Arrange
+		env := newEnvironment(t)
+		invitation, err := env.invitations.Create("bob", "trip", time.Hour, 1)
+		require.NoError(t, err)
+		require.NoError(t, env.operations.Push([]openapi.SomeOperation{{
+			OperationId:            "remove-bob",
+			CreatedAt:              env.now.UnixMilli(),
+			AuthorId:               "alice",
+			RemoveGroupParticipant: openapi.RemoveGroupParticipantOperationRemoveGroupParticipant{GroupId: "trip", UserId: "bob"},
+		}}, "alice", "device"))
+
+		// Act
+		_, err = env.invitations.Redeem("carol", invitations.Token(invitation.Token))
+
+		// Assert
+		assert.ErrorIs(t, err, invitations.InvitationExpired)
+
+		t.Run("failed redemption is not counted as a use", func(t *testing.T) {
+			// Arrange
+			require.NoError(t, env.operations.Push([]openapi.SomeOperation{{
+				OperationId:         "add-bob",
+				CreatedAt:           env.now.UnixMilli(),
+				AuthorId:            "alice",
+				AddGroupParticipant: openapi.AddGroupParticipantOperationAddGroupParticipant{GroupId: "trip", UserId: "bob"},
+			}}, "alice", "device"))
+
+			// Act
+			_, err := env.invitations.Redeem("carol", invitations.Token(invitation.Token))
+
+			// Assert
+			assert.NoError(t, err)
+		})
+	})
+}
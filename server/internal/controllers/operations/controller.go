@@ -1,15 +1,53 @@
 package operations
 
 import (
+	"errors"
 	openapi "verni/internal/openapi/go"
 )
 
 type OperationId string
 type UserId string
 type DeviceId string
+type SequenceNumber int64
+
+var (
+	PrivacyViolation = errors.New("privacy violation")
+	BadFormat        = errors.New("bad format")
+)
+
+type OperationsPage struct {
+	Operations []openapi.SomeOperation
+	// Cursor should be passed as `since` or as a page token to get the next page, equals to the passed one if the page is empty
+	Cursor  SequenceNumber
+	HasMore bool
+}
+
+// NotificationsReport counts notifications handled by a delivery round
+type NotificationsReport struct {
+	Delivered int
+	Retried   int
+	Dead      int
+	// Dropped counts alerts to push tokens that will never receive them, the tokens are removed
+	Dropped int
+}
 
 type Controller interface {
+	// Push fails with PrivacyViolation if any of the operations is not allowed for the user
+	// and with BadFormat if any of them is malformed or inconsistent with the log, nothing is pushed in both cases.
+	// Operations are checked once pushes are serialized, so concurrent pushes can not invalidate the checks.
+	// Operations are confirmed for the device, an empty device stands for a push on behalf of the user that no
+	// device has seen yet.
 	Push(operations []openapi.SomeOperation, userId UserId, deviceId DeviceId) error
-	Pull(userId UserId, deviceId DeviceId, operationsType openapi.OperationType) ([]openapi.SomeOperation, error)
+	// Check fails like Push would without pushing anything. Push checks operations itself, Check is only for side
+	// effects that have to happen before the push, like storing uploaded images, so they can wait for the checks.
+	Check(operations []openapi.SomeOperation, userId UserId) error
+	// Pull returns operations not confirmed by the device, pageToken is a cursor of a previous page or 0 for the first one.
+	// Non-positive limit stands for the default page size.
+	Pull(userId UserId, deviceId DeviceId, operationsType openapi.OperationType, pageToken SequenceNumber, limit int) (OperationsPage, error)
+	PullSince(userId UserId, since SequenceNumber, operationsType openapi.OperationType, limit int) (OperationsPage, error)
 	Confirm(operations []OperationId, userId UserId, deviceId DeviceId) error
+	// DeliverNotifications delivers due notifications about pushed operations, failed deliveries are retried with
+	// exponential backoff until they run out of attempts and are kept as dead. Alerts to invalid push tokens are
+	// dropped and the tokens are removed.
+	DeliverNotifications() (NotificationsReport, error)
 }
@@ -0,0 +1,110 @@
+package defaultController
+
+import (
+	"fmt"
+	"verni/internal/controllers/operations"
+	openapi "verni/internal/openapi/go"
+	operationsRepository "verni/internal/repositories/operations"
+)
+
+func (c *defaultController) authorizeOperation(operation openapi.SomeOperation, author string, state *pushState) error {
+	if operation.AuthorId != author {
+		return fmt.Errorf("authored by %s instead of %s: %w", operation.AuthorId, author, operations.PrivacyViolation)
+	}
+	payload := operationsRepository.OpenApiOperation{SomeOperation: operation}
+	switch payload.Type() {
+	case operationsRepository.CreateUserOperationPayloadType:
+		if _, exists, err := c.userOwner(operation.CreateUser.UserId, state); err != nil {
+			return err
+		} else if exists {
+			return fmt.Errorf("user %s already exists: %w", operation.CreateUser.UserId, operations.PrivacyViolation)
+		}
+		return nil
+	case operationsRepository.UpdateDisplayNameOperationPayloadType:
+		return c.checkProfileEditable(operation.UpdateDisplayName.UserId, author, state)
+	case operationsRepository.UpdateAvatarOperationPayloadType:
+		return c.checkProfileEditable(operation.UpdateAvatar.UserId, author, state)
+	case operationsRepository.UploadImageOperationPayloadType:
+		imageId := operation.UploadImage.ImageId
+		if owner, exists, err := c.imageOwner(imageId, state); err != nil {
+			return err
+		} else if exists && owner != author {
+			return fmt.Errorf("image %s is uploaded by %s: %w", imageId, owner, operations.PrivacyViolation)
+		}
+		return nil
+	case operationsRepository.BindUserOperationPayloadType:
+		return c.checkBindable(operation.BindUser, author, state)
+	case operationsRepository.CreateSpendingGroupOperationPayloadType:
+		groupId := operation.CreateSpendingGroup.GroupId
+		if _, exists, err := c.groupParticipants(groupId, state); err != nil {
+			return err
+		} else if exists {
+			return fmt.Errorf("group %s already exists: %w", groupId, operations.PrivacyViolation)
+		}
+		return nil
+	case operationsRepository.DeleteSpendingGroupOperationPayloadType:
+		return c.checkGroupParticipant(operation.DeleteSpendingGroup.GroupId, author, state)
+	case operationsRepository.AddGroupParticipantOperationPayloadType:
+		return c.checkGroupParticipant(operation.AddGroupParticipant.GroupId, author, state)
+	case operationsRepository.RemoveGroupParticipantOperationPayloadType:
+		return c.checkGroupParticipant(operation.RemoveGroupParticipant.GroupId, author, state)
+	case operationsRepository.CreateSpendingOperationPayloadType:
+		return c.checkGroupParticipant(operation.CreateSpending.GroupId, author, state)
+	case operationsRepository.UpdateSpendingOperationPayloadType:
+		return c.checkGroupParticipant(operation.UpdateSpending.GroupId, author, state)
+	case operationsRepository.DeleteSpendingOperationPayloadType:
+		return c.checkGroupParticipant(operation.DeleteSpending.GroupId, author, state)
+	case operationsRepository.SettlementOperationPayloadType:
+		return c.checkGroupParticipant(operation.Settlement.GroupId, author, state)
+	default:
+		// email operations reflect the state of credentials and are issued by the server only
+		return fmt.Errorf("%s can not be pushed by clients: %w", payload.Type(), operations.PrivacyViolation)
+	}
+}
+
+// checkProfileEditable allows editing own profile and sandbox users created by the author
+func (c *defaultController) checkProfileEditable(userId string, author string, state *pushState) error {
+	if userId == author {
+		return nil
+	}
+	owner, exists, err := c.userOwner(userId, state)
+	if err != nil {
+		return err
+	}
+	if !exists || owner != author {
+		return fmt.Errorf("profile of %s is not editable by %s: %w", userId, author, operations.PrivacyViolation)
+	}
+	return nil
+}
+
+// checkBindable allows binding sandbox users created by the author to registered users
+func (c *defaultController) checkBindable(operation openapi.BindUserOperationBindUser, author string, state *pushState) error {
+	oldOwner, exists, err := c.userOwner(operation.OldId, state)
+	if err != nil {
+		return err
+	}
+	if !exists || oldOwner != author || operation.OldId == author {
+		return fmt.Errorf("user %s is not a sandbox user of %s: %w", operation.OldId, author, operations.PrivacyViolation)
+	}
+	newOwner, exists, err := c.userOwner(operation.NewId, state)
+	if err != nil {
+		return err
+	}
+	if !exists || newOwner != operation.NewId {
+		return fmt.Errorf("user %s is not a registered user: %w", operation.NewId, operations.PrivacyViolation)
+	}
+	return nil
+}
+
+func (c *defaultController) checkGroupParticipant(groupId string, author string, state *pushState) error {
+	participants, _, err := c.groupParticipants(groupId, state)
+	if err != nil {
+		return err
+	}
+	for _, participant := range participants {
+		if participant == author {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not a participant of group %s: %w", author, groupId, operations.PrivacyViolation)
+}
@@ -0,0 +1,275 @@
+package defaultController
+
+import (
+	"fmt"
+	"slices"
+	"verni/internal/common"
+	"verni/internal/controllers/operations"
+	openapi "verni/internal/openapi/go"
+	operationsRepository "verni/internal/repositories/operations"
+)
+
+// pushState keeps entities created by earlier operations of the same push,
+// so a single push can create a group and add spendings to it
+type pushState struct {
+	userOwners        map[string]string
+	imageOwners       map[string]string
+	groupParticipants map[string][]string
+	deletedGroups     map[string]struct{}
+	spendings         map[string]*spendingState
+	// spendingChanges are keyed by identifiers of update operations
+	spendingChanges map[string]spendingChange
+	// entityBindActions depend on the state of the group and are keyed by identifiers of operations
+	entityBindActions   map[string][]operationsRepository.EntityBindAction
+	entityUnbindActions map[string][]operationsRepository.EntityBindAction
+	// boundUsers resolve sandbox users to registered users they are bound to, other users resolve to themselves
+	boundUsers map[string]string
+	// sandboxUsers are loaded by validation of BindUser operations and keyed by identifiers of sandbox users
+	sandboxUsers map[string]sandboxUser
+}
+
+// sandboxUser is what the sandbox user tracks and who watches it, it is all handed over to the user it is bound to
+type sandboxUser struct {
+	tracked  []operationsRepository.TrackedEntity
+	watchers []string
+}
+
+// checkOperations authorizes and validates operations in order, nothing should be pushed if any of them fails
+func (c *defaultController) checkOperations(pushed []openapi.SomeOperation, userId operations.UserId) (pushState, error) {
+	state := pushState{
+		userOwners:          map[string]string{},
+		imageOwners:         map[string]string{},
+		groupParticipants:   map[string][]string{},
+		deletedGroups:       map[string]struct{}{},
+		spendings:           map[string]*spendingState{},
+		spendingChanges:     map[string]spendingChange{},
+		entityBindActions:   map[string][]operationsRepository.EntityBindAction{},
+		entityUnbindActions: map[string][]operationsRepository.EntityBindAction{},
+		boundUsers:          map[string]string{},
+		sandboxUsers:        map[string]sandboxUser{},
+	}
+	for _, operation := range pushed {
+		if err := c.authorizeOperation(operation, string(userId), &state); err != nil {
+			return pushState{}, fmt.Errorf("authorizing operation %s: %w", operation.OperationId, err)
+		}
+		if err := c.validateOperation(operation, &state); err != nil {
+			return pushState{}, fmt.Errorf("validating operation %s: %w", operation.OperationId, err)
+		}
+		state.apply(operation)
+	}
+	return state, nil
+}
+
+func (s *pushState) apply(operation openapi.SomeOperation) {
+	payload := operationsRepository.OpenApiOperation{SomeOperation: operation}
+	switch payload.Type() {
+	case operationsRepository.CreateUserOperationPayloadType:
+		s.userOwners[operation.CreateUser.UserId] = operation.AuthorId
+	case operationsRepository.UploadImageOperationPayloadType:
+		s.imageOwners[operation.UploadImage.ImageId] = operation.AuthorId
+	case operationsRepository.BindUserOperationPayloadType:
+		s.bindUser(operation)
+	case operationsRepository.CreateSpendingGroupOperationPayloadType:
+		s.groupParticipants[operation.CreateSpendingGroup.GroupId] = operationsRepository.CreatedGroupParticipants(operation)
+	case operationsRepository.DeleteSpendingGroupOperationPayloadType:
+		s.deletedGroups[operation.DeleteSpendingGroup.GroupId] = struct{}{}
+	case operationsRepository.AddGroupParticipantOperationPayloadType:
+		// authorization has loaded participants already
+		groupId, userId := operation.AddGroupParticipant.GroupId, operation.AddGroupParticipant.UserId
+		participants := s.groupParticipants[groupId]
+		actions := []operationsRepository.EntityBindAction{{
+			Watchers: common.Map(participants, func(participant string) operationsRepository.UserId {
+				return operationsRepository.UserId(participant)
+			}),
+			Entity: operationsRepository.TrackedEntity{Id: userId, Type: operationsRepository.EntityTypeUser},
+		}}
+		for _, participant := range participants {
+			actions = append(actions, operationsRepository.EntityBindAction{
+				Watchers: []operationsRepository.UserId{operationsRepository.UserId(userId)},
+				Entity:   operationsRepository.TrackedEntity{Id: participant, Type: operationsRepository.EntityTypeUser},
+			})
+		}
+		s.entityBindActions[operation.OperationId] = actions
+		s.groupParticipants[groupId] = append(slices.Clone(participants), userId)
+	case operationsRepository.RemoveGroupParticipantOperationPayloadType:
+		groupId, userId := operation.RemoveGroupParticipant.GroupId, operation.RemoveGroupParticipant.UserId
+		s.groupParticipants[groupId] = slices.DeleteFunc(slices.Clone(s.groupParticipants[groupId]), func(participant string) bool {
+			return participant == userId
+		})
+	case operationsRepository.CreateSpendingOperationPayloadType:
+		s.spendings[operation.CreateSpending.SpendingId] = newSpendingState(operation)
+	case operationsRepository.UpdateSpendingOperationPayloadType:
+		// validation has loaded the spending already
+		if spending, exists := s.spendings[operation.UpdateSpending.SpendingId]; exists {
+			before := spending.spending
+			spending.merge(operation)
+			s.spendingChanges[operation.OperationId] = spendingChange{
+				before: before,
+				after:  spending.spending,
+			}
+		}
+	case operationsRepository.DeleteSpendingOperationPayloadType:
+		if spending, exists := s.spendings[operation.DeleteSpending.SpendingId]; exists {
+			spending.deleted = true
+		} else {
+			s.spendings[operation.DeleteSpending.SpendingId] = &spendingState{deleted: true}
+		}
+	}
+}
+
+// bindUser hands everything the sandbox user tracks over to the registered user, validation has loaded the sandbox user.
+// The bindings are made by the BindUser operation, so cursor pulls of the registered user return the inherited history
+// at its position.
+func (s *pushState) bindUser(operation openapi.SomeOperation) {
+	oldId, newId := operation.BindUser.OldId, operation.BindUser.NewId
+	sandbox := s.sandboxUsers[oldId]
+	newUser := operationsRepository.TrackedEntity{Id: newId, Type: operationsRepository.EntityTypeUser}
+	actions := []operationsRepository.EntityBindAction{{
+		Watchers: []operationsRepository.UserId{operationsRepository.UserId(newId)},
+		Entity:   operationsRepository.TrackedEntity{Id: oldId, Type: operationsRepository.EntityTypeUser},
+	}}
+	unbindActions := []operationsRepository.EntityBindAction{}
+	for _, entity := range sandbox.tracked {
+		unbindActions = append(unbindActions, operationsRepository.EntityBindAction{
+			Watchers: []operationsRepository.UserId{operationsRepository.UserId(oldId)},
+			Entity:   entity,
+		})
+		if entity == newUser {
+			continue
+		}
+		actions = append(actions, operationsRepository.EntityBindAction{
+			Watchers: []operationsRepository.UserId{operationsRepository.UserId(newId)},
+			Entity:   entity,
+		})
+	}
+	watchers := common.Filter(sandbox.watchers, func(watcher string) bool {
+		return watcher != oldId && watcher != newId
+	})
+	if len(watchers) > 0 {
+		actions = append(actions, operationsRepository.EntityBindAction{
+			Watchers: common.Map(watchers, func(watcher string) operationsRepository.UserId {
+				return operationsRepository.UserId(watcher)
+			}),
+			Entity: newUser,
+		})
+	}
+	s.entityBindActions[operation.OperationId] = actions
+	s.entityUnbindActions[operation.OperationId] = unbindActions
+	s.boundUsers[oldId] = newId
+	for groupId, participants := range s.groupParticipants {
+		if slices.Contains(participants, oldId) {
+			s.groupParticipants[groupId] = bindParticipants(participants, s.boundUsers)
+		}
+	}
+}
+
+// bindParticipants replaces sandbox participants with users they are bound to
+func bindParticipants(participants []string, boundUsers map[string]string) []string {
+	bound := make([]string, 0, len(participants))
+	for _, participant := range participants {
+		if boundId, exists := boundUsers[participant]; exists {
+			participant = boundId
+		}
+		if !slices.Contains(bound, participant) {
+			bound = append(bound, participant)
+		}
+	}
+	return bound
+}
+
+// boundUser returns the registered user the sandbox user is bound to, other users are returned as is
+func (c *defaultController) boundUser(userId string, state *pushState) (string, error) {
+	if boundId, exists := state.boundUsers[userId]; exists {
+		return boundId, nil
+	}
+	bound, err := operationsRepository.BoundUsers(c.operationsRepository, []operationsRepository.UserId{operationsRepository.UserId(userId)})
+	if err != nil {
+		return "", fmt.Errorf("getting binding of user %s: %w", userId, err)
+	}
+	boundId := userId
+	if registered, isBound := bound[operationsRepository.UserId(userId)]; isBound {
+		boundId = string(registered)
+	}
+	state.boundUsers[userId] = boundId
+	return boundId, nil
+}
+
+// userOwner returns the author of the operation that created the user,
+// registered users are created by themselves
+func (c *defaultController) userOwner(userId string, state *pushState) (string, bool, error) {
+	if owner, exists := state.userOwners[userId]; exists {
+		return owner, true, nil
+	}
+	affecting, err := c.operationsRepository.Get([]operationsRepository.TrackedEntity{
+		{Id: userId, Type: operationsRepository.EntityTypeUser},
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("getting operations of user %s: %w", userId, err)
+	}
+	for _, operation := range affecting {
+		if operation.Payload.Type() == operationsRepository.CreateUserOperationPayloadType {
+			return string(operation.AuthorId), true, nil
+		}
+	}
+	return "", false, nil
+}
+
+func (c *defaultController) imageOwner(imageId string, state *pushState) (string, bool, error) {
+	if owner, exists := state.imageOwners[imageId]; exists {
+		return owner, true, nil
+	}
+	affecting, err := c.operationsRepository.Get([]operationsRepository.TrackedEntity{
+		{Id: imageId, Type: operationsRepository.EntityTypeImage},
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("getting operations of image %s: %w", imageId, err)
+	}
+	for _, operation := range affecting {
+		if operation.Payload.Type() == operationsRepository.UploadImageOperationPayloadType {
+			return string(operation.AuthorId), true, nil
+		}
+	}
+	return "", false, nil
+}
+
+func (c *defaultController) groupParticipants(groupId string, state *pushState) ([]string, bool, error) {
+	if participants, exists := state.groupParticipants[groupId]; exists {
+		return participants, true, nil
+	}
+	watchers, err := c.operationsRepository.GetUsers([]operationsRepository.TrackedEntity{
+		{Id: groupId, Type: operationsRepository.EntityTypeSpendingGroup},
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("getting participants of group %s: %w", groupId, err)
+	}
+	participants := make([]string, len(watchers))
+	for index, watcher := range watchers {
+		participants[index] = string(watcher)
+	}
+	if len(participants) == 0 {
+		return participants, false, nil
+	}
+	// sandbox users bound earlier in the push still track the group in the repository
+	participants = bindParticipants(participants, state.boundUsers)
+	// later operations of the push may change participants, so they are kept in the state
+	state.groupParticipants[groupId] = participants
+	return participants, true, nil
+}
+
+func (c *defaultController) isGroupDeleted(groupId string, state *pushState) (bool, error) {
+	if _, deleted := state.deletedGroups[groupId]; deleted {
+		return true, nil
+	}
+	affecting, err := c.operationsRepository.Get([]operationsRepository.TrackedEntity{
+		{Id: groupId, Type: operationsRepository.EntityTypeSpendingGroup},
+	})
+	if err != nil {
+		return false, fmt.Errorf("getting operations of group %s: %w", groupId, err)
+	}
+	for _, operation := range affecting {
+		if operation.Payload.Type() == operationsRepository.DeleteSpendingGroupOperationPayloadType {
+			return true, nil
+		}
+	}
+	return false, nil
+}
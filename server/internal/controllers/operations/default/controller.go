@@ -1,41 +1,57 @@
 package defaultController
 
 import (
-	"encoding/json"
 	"fmt"
+	"time"
 	"verni/internal/common"
 	"verni/internal/controllers/operations"
 	openapi "verni/internal/openapi/go"
+	balancesRepository "verni/internal/repositories/balances"
 	operationsRepository "verni/internal/repositories/operations"
 	pushTokens "verni/internal/repositories/pushNotifications"
+	"verni/internal/services/dispatcher"
+	"verni/internal/services/formatValidation"
 	"verni/internal/services/logging"
 	"verni/internal/services/pushNotifications"
 	"verni/internal/services/realtimeEvents"
 )
 
 type OperationsRepository operationsRepository.Repository
+type BalancesRepository balancesRepository.Repository
 
 func New(
 	operationsRepository OperationsRepository,
+	balancesRepository BalancesRepository,
 	realtimeEvents realtimeEvents.Service,
 	pushNotifications pushNotifications.Service,
 	pushTokensRepository pushTokens.Repository,
+	formatValidation formatValidation.Service,
+	dispatcher dispatcher.Service,
+	now func() time.Time,
 	logger logging.Service,
 ) operations.Controller {
 	return &defaultController{
 		operationsRepository: operationsRepository,
+		balancesRepository:   balancesRepository,
 		realtimeEvents:       realtimeEvents,
 		pushNotifications:    pushNotifications,
 		pushTokensRepository: pushTokensRepository,
+		formatValidation:     formatValidation,
+		dispatcher:           dispatcher,
+		now:                  now,
 		logger:               logger,
 	}
 }
 
 type defaultController struct {
 	operationsRepository OperationsRepository
+	balancesRepository   BalancesRepository
 	realtimeEvents       realtimeEvents.Service
 	pushNotifications    pushNotifications.Service
 	pushTokensRepository pushTokens.Repository
+	formatValidation     formatValidation.Service
+	dispatcher           dispatcher.Service
+	now                  func() time.Time
 	logger               logging.Service
 }
 
@@ -46,96 +62,146 @@ func (c *defaultController) Push(
 ) error {
 	const op = "controllers.operations.defaultController.Push"
 	c.logger.LogInfo("%s: start[user=%s device=%s]", op, userId, deviceId)
-	operationsToPush := common.Map(operations, func(operation openapi.SomeOperation) operationsRepository.PushOperation {
-		return operationsRepository.CreateOperation(operation)
-	})
-	if err := c.operationsRepository.Push(
-		operationsToPush,
-		operationsRepository.UserId(userId),
-		operationsRepository.DeviceId(deviceId),
-		true,
-	).Perform(); err != nil {
-		return fmt.Errorf("pushing operations to repository: %w", err)
-	}
-	for index, operation := range operationsToPush {
-		trackedEntities := operation.Payload.TrackedEntities()
-		userIdsToNotify, err := c.operationsRepository.GetUsers(trackedEntities)
+	// operations are checked once pushes are serialized, so another push can not change what the checks have read
+	// before the operations are stored
+	var rejected error
+	var pushed operationsRepository.Notification
+	prepare := func() ([]operationsRepository.PushOperation, []operationsRepository.Notification, error) {
+		state, err := c.checkOperations(operations, userId)
 		if err != nil {
-			c.logger.LogError("getting users to notify: %v", err)
-			continue
+			rejected = err
+			return nil, nil, err
 		}
-		for _, userToNotify := range userIdsToNotify {
-			devicesToIgnore := []realtimeEvents.DeviceId{}
-			if userToNotify == operationsRepository.UserId(userId) {
-				devicesToIgnore = append(devicesToIgnore, realtimeEvents.DeviceId(deviceId))
+		operationsToPush := common.Map(operations, func(operation openapi.SomeOperation) operationsRepository.PushOperation {
+			pushOperation := operationsRepository.CreateOperation(operation)
+			pushOperation.EntityBindActions = append(pushOperation.EntityBindActions, state.entityBindActions[operation.OperationId]...)
+			pushOperation.EntityUnbindActions = append(pushOperation.EntityUnbindActions, state.entityUnbindActions[operation.OperationId]...)
+			// balances are accounted in the transaction of the push, so they never miss a stored operation
+			if projection, affects := balancesRepository.Project(c.balancesRepository, operation); affects {
+				pushOperation.Projections = append(pushOperation.Projections, projection)
 			}
-			c.logger.LogInfo("notifying %s about update, devices to ignore: %v", userToNotify, devicesToIgnore)
-			c.realtimeEvents.NotifyUpdate(realtimeEvents.UserId(userToNotify), devicesToIgnore)
-		}
-		userToNotifyWithoutCurrentUser := common.Filter(userIdsToNotify, func(id operationsRepository.UserId) bool {
-			return id != operationsRepository.UserId(userId)
+			return pushOperation
 		})
-		switch operation.Payload.Type() {
-		case operationsRepository.CreateSpendingGroupOperationPayloadType:
-			if err := c.sendCreateSpendingGroupPush(
-				operations[index].CreateSpendingGroup,
-				userToNotifyWithoutCurrentUser,
-			); err != nil {
-				c.logger.LogError("sending create spending group push: %v", err)
-			}
-		case operationsRepository.CreateSpendingOperationPayloadType:
-			if err := c.sendCreateSpendingPush(
-				operations[index].CreateSpending,
-				userToNotifyWithoutCurrentUser,
-			); err != nil {
-				c.logger.LogError("sending create spending push: %v", err)
-			}
+		// the notification is written with the operations and delivered by the outbox, so it is not lost if the
+		// instance stops before delivering it
+		pushed, err = c.newNotification(notificationPayload{
+			Pushed: &pushedPayload{
+				UserId:          string(userId),
+				DeviceId:        string(deviceId),
+				Operations:      operations,
+				SpendingChanges: storedSpendingChanges(state.spendingChanges),
+			},
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("encoding notification about pushed operations: %w", err)
+		}
+		return operationsToPush, []operationsRepository.Notification{pushed}, nil
+	}
+	// operations pushed by the server on behalf of the user come from no device and are left for every device to pull
+	push := c.operationsRepository.PushPrepared(
+		prepare,
+		operationsRepository.UserId(userId),
+		operationsRepository.DeviceId(deviceId),
+		deviceId != "",
+	)
+	if err := push.Perform(); err != nil {
+		if rejected != nil {
+			c.logger.LogInfo("%s: rejected[user=%s device=%s]: %v", op, userId, deviceId, rejected)
+			return rejected
 		}
+		return fmt.Errorf("pushing operations to repository: %w", err)
+	}
+	// notifying is not a part of the push, it runs in the background and the client gets the response as soon
+	// as operations are stored
+	notify := func() {
+		c.deliverOwned(pushed)
+	}
+	if err := c.dispatcher.Dispatch("notifying about pushed operations", notify); err != nil {
+		// the notification stays in the outbox and is delivered by the relay once its lease expires
+		c.logger.LogError("%s: dispatching notifications, leaving them to the outbox: %v", op, err)
 	}
 	c.logger.LogInfo("%s: success[user=%s device=%s]", op, userId, deviceId)
 	return nil
 }
 
+func (c *defaultController) Check(operations []openapi.SomeOperation, userId operations.UserId) error {
+	const op = "controllers.operations.defaultController.Check"
+	c.logger.LogInfo("%s: start[user=%s]", op, userId)
+	if _, err := c.checkOperations(operations, userId); err != nil {
+		c.logger.LogInfo("%s: rejected[user=%s]: %v", op, userId, err)
+		return err
+	}
+	c.logger.LogInfo("%s: success[user=%s]", op, userId)
+	return nil
+}
+
 func (c *defaultController) Pull(
 	userId operations.UserId,
 	deviceId operations.DeviceId,
 	operationsType openapi.OperationType,
-) ([]openapi.SomeOperation, error) {
+	pageToken operations.SequenceNumber,
+	limit int,
+) (operations.OperationsPage, error) {
 	const op = "controllers.operations.defaultController.Pull"
-	c.logger.LogInfo("%s: start[user=%s device=%s]", op, userId, deviceId)
+	c.logger.LogInfo("%s: start[user=%s device=%s pageToken=%d limit=%d]", op, userId, deviceId, pageToken, limit)
 
+	limit = pageLimit(limit)
 	pulled, err := c.operationsRepository.Pull(
 		operationsRepository.UserId(userId),
 		operationsRepository.DeviceId(deviceId),
-		func() operationsRepository.OperationType {
-			switch operationsType {
-			case openapi.REGULAR:
-				return operationsRepository.OperationTypeRegular
-			case openapi.LARGE:
-				return operationsRepository.OperationTypeLarge
-			default:
-				return operationsRepository.OperationTypeRegular
-			}
-		}(),
+		operationsRepository.SequenceNumber(pageToken),
+		limit+1,
+		repositoryOperationType(operationsType),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("pulling operations from repository: %w", err)
+		return operations.OperationsPage{}, fmt.Errorf("pulling operations from repository: %w", err)
 	}
 
-	result := make([]openapi.SomeOperation, len(pulled))
-	for index, operation := range pulled {
-		data, err := operation.Payload.Data()
-		if err != nil {
-			return nil, fmt.Errorf("getting data from operation %v: %w", operation, err)
-		}
-		var converted openapi.SomeOperation
-		if err := json.Unmarshal(data, &converted); err != nil {
-			return nil, fmt.Errorf("parsing operation from %v payload data: %w", operation, err)
-		}
-		result[index] = converted
+	page, err := makePage(pulled, limit, pageToken)
+	if err != nil {
+		return operations.OperationsPage{}, err
+	}
+	c.logger.LogInfo("%s: success[user=%s device=%s count=%d hasMore=%t]", op, userId, deviceId, len(page.Operations), page.HasMore)
+	return page, nil
+}
+
+func (c *defaultController) PullSince(
+	userId operations.UserId,
+	since operations.SequenceNumber,
+	operationsType openapi.OperationType,
+	limit int,
+) (operations.OperationsPage, error) {
+	const op = "controllers.operations.defaultController.PullSince"
+	c.logger.LogInfo("%s: start[user=%s since=%d limit=%d]", op, userId, since, limit)
+
+	limit = pageLimit(limit)
+	pulled, err := c.operationsRepository.PullSince(
+		operationsRepository.UserId(userId),
+		operationsRepository.SequenceNumber(since),
+		limit+1,
+		repositoryOperationType(operationsType),
+	)
+	if err != nil {
+		return operations.OperationsPage{}, fmt.Errorf("pulling operations since %d from repository: %w", since, err)
+	}
+
+	page, err := makePage(pulled, limit, since)
+	if err != nil {
+		return operations.OperationsPage{}, err
+	}
+	c.logger.LogInfo("%s: success[user=%s since=%d cursor=%d hasMore=%t]", op, userId, since, page.Cursor, page.HasMore)
+	return page, nil
+}
+
+func repositoryOperationType(operationsType openapi.OperationType) operationsRepository.OperationType {
+	switch operationsType {
+	case openapi.REGULAR:
+		return operationsRepository.OperationTypeRegular
+	case openapi.LARGE:
+		return operationsRepository.OperationTypeLarge
+	default:
+		return operationsRepository.OperationTypeRegular
 	}
-	c.logger.LogInfo("%s: success[user=%s device=%s]", op, userId, deviceId)
-	return result, nil
 }
 
 func (c *defaultController) Confirm(
@@ -3,20 +3,29 @@ package defaultController_test
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"verni/internal/common"
 	"verni/internal/controllers/operations"
 	defaultController "verni/internal/controllers/operations/default"
 	openapi "verni/internal/openapi/go"
 	"verni/internal/repositories"
+	"verni/internal/repositories/balances"
+	balancesMemory "verni/internal/repositories/balances/memory"
+	balancesRepository_mock "verni/internal/repositories/balances/mock"
 	operationsRepository "verni/internal/repositories/operations"
+	operationsMemory "verni/internal/repositories/operations/memory"
 	operationsRepository_mock "verni/internal/repositories/operations/mock"
 	pushNotifications "verni/internal/repositories/pushNotifications"
 	pushNotifications_mock "verni/internal/repositories/pushNotifications/mock"
+	"verni/internal/services/dispatcher"
+	dispatcher_mock "verni/internal/services/dispatcher/mock"
+	defaultFormatValidation "verni/internal/services/formatValidation/default"
 	standartOutputLoggingService "verni/internal/services/logging/standartOutput"
 	pushTokens "verni/internal/services/pushNotifications"
 	pushTokens_mock "verni/internal/services/pushNotifications/mock"
@@ -53,6 +62,16 @@ func (m mockOperationPayload) SearchHint() *string {
 	return m.searchHintImpl
 }
 
+// inPlaceDispatcher runs tasks before returning so notifications can be checked right after Push
+func inPlaceDispatcher() dispatcher.Service {
+	return &dispatcher_mock.ServiceMock{
+		DispatchImpl: func(name string, task func()) error {
+			task()
+			return nil
+		},
+	}
+}
+
 func TestController_Push(t *testing.T) {
 	logger := standartOutputLoggingService.New()
 
@@ -72,7 +91,7 @@ func TestController_Push(t *testing.T) {
 		}
 
 		opsRepo := &operationsRepository_mock.RepositoryMock{
-			PushImpl: func(ops []operationsRepository.PushOperation, uid operationsRepository.UserId, did operationsRepository.DeviceId, confirm bool) repositories.UnitOfWork {
+			PushImpl: func(ops []operationsRepository.PushOperation, uid operationsRepository.UserId, did operationsRepository.DeviceId, confirm bool, notifications []operationsRepository.Notification) repositories.UnitOfWork {
 				return repositories.UnitOfWork{
 					Perform:  func() error { return nil },
 					Rollback: func() error { return nil },
@@ -81,10 +100,19 @@ func TestController_Push(t *testing.T) {
 			GetUsersImpl: func(entities []operationsRepository.TrackedEntity) ([]operationsRepository.UserId, error) {
 				return []operationsRepository.UserId{operationsRepository.UserId(userId)}, nil
 			},
+			ReplaceNotificationImpl: func(notification operationsRepository.Notification, with []operationsRepository.Notification) repositories.UnitOfWork {
+				return repositories.UnitOfWork{
+					Perform:  func() error { return nil },
+					Rollback: func() error { return nil },
+				}
+			},
+			GetImpl: func(entities []operationsRepository.TrackedEntity) ([]operationsRepository.Operation, error) {
+				return []operationsRepository.Operation{}, nil
+			},
 		}
 
 		realtimeService := &realtimeEvents_mock.ServiceMock{
-			NotifyUpdateImpl: func(uid realtimeEvents.UserId, ignoringDevices []realtimeEvents.DeviceId) {},
+			NotifyUpdateImpl: func(realtimeEvents.UserId, []realtimeEvents.DeviceId, []json.RawMessage) {},
 		}
 
 		pushNotificationsRepository := &pushNotifications_mock.RepositoryMock{
@@ -98,7 +126,7 @@ func TestController_Push(t *testing.T) {
 			},
 		}
 
-		controller := defaultController.New(opsRepo, realtimeService, pushNotificationsService, pushNotificationsRepository, logger)
+		controller := defaultController.New(opsRepo, nil, realtimeService, pushNotificationsService, pushNotificationsRepository, nil, inPlaceDispatcher(), time.Now, logger)
 
 		// Act
 		err := controller.Push([]openapi.SomeOperation{testOperation}, userId, deviceId)
@@ -110,7 +138,7 @@ func TestController_Push(t *testing.T) {
 	t.Run("repository push error", func(t *testing.T) {
 		// Arrange
 		opsRepo := &operationsRepository_mock.RepositoryMock{
-			PushImpl: func(ops []operationsRepository.PushOperation, uid operationsRepository.UserId, did operationsRepository.DeviceId, confirm bool) repositories.UnitOfWork {
+			PushImpl: func(ops []operationsRepository.PushOperation, uid operationsRepository.UserId, did operationsRepository.DeviceId, confirm bool, notifications []operationsRepository.Notification) repositories.UnitOfWork {
 				return repositories.UnitOfWork{
 					Perform:  func() error { return errors.New("push error") },
 					Rollback: func() error { return nil },
@@ -128,7 +156,7 @@ func TestController_Push(t *testing.T) {
 			},
 		}
 
-		controller := defaultController.New(opsRepo, nil, pushNotificationsService, pushNotificationsRepository, logger)
+		controller := defaultController.New(opsRepo, nil, nil, pushNotificationsService, pushNotificationsRepository, nil, inPlaceDispatcher(), time.Now, logger)
 
 		// Act
 		err := controller.Push([]openapi.SomeOperation{}, "user-1", "device-1")
@@ -136,6 +164,1325 @@ func TestController_Push(t *testing.T) {
 		// Assert
 		assert.Error(t, err)
 	})
+
+	t.Run("balances projection error fails push", func(t *testing.T) {
+		// Arrange
+		expectedErr := errors.New("projection error")
+		pushedProjections := 0
+		opsRepo := &operationsRepository_mock.RepositoryMock{
+			PushImpl: func(ops []operationsRepository.PushOperation, uid operationsRepository.UserId, did operationsRepository.DeviceId, confirm bool, notifications []operationsRepository.Notification) repositories.UnitOfWork {
+				// projections are performed by the repository in the transaction of the push
+				return repositories.UnitOfWork{
+					Perform: func() error {
+						for _, operation := range ops {
+							for _, projection := range operation.Projections {
+								pushedProjections++
+								if err := projection.Perform(); err != nil {
+									return err
+								}
+							}
+						}
+						return nil
+					},
+					Rollback: func() error { return nil },
+				}
+			},
+			GetImpl: func(entities []operationsRepository.TrackedEntity) ([]operationsRepository.Operation, error) {
+				return []operationsRepository.Operation{}, nil
+			},
+			GetUsersImpl: func(entities []operationsRepository.TrackedEntity) ([]operationsRepository.UserId, error) {
+				return []operationsRepository.UserId{"user-1"}, nil
+			},
+		}
+		balancesRepo := &balancesRepository_mock.RepositoryMock{
+			RemoveGroupImpl: func(groupId balances.GroupId) repositories.UnitOfWork {
+				return repositories.UnitOfWork{
+					Perform:  func() error { return expectedErr },
+					Rollback: func() error { return nil },
+				}
+			},
+		}
+		controller := defaultController.New(opsRepo, balancesRepo, nil, nil, nil, nil, inPlaceDispatcher(), time.Now, logger)
+
+		// Act
+		err := controller.Push([]openapi.SomeOperation{
+			{
+				OperationId:         "op-1",
+				AuthorId:            "user-1",
+				DeleteSpendingGroup: openapi.DeleteSpendingGroupOperationDeleteSpendingGroup{GroupId: "group-1"},
+			},
+		}, "user-1", "device-1")
+
+		// Assert
+		assert.ErrorIs(t, err, expectedErr)
+		assert.Equal(t, 1, pushedProjections)
+	})
+
+	t.Run("operations are checked once the push is serialized", func(t *testing.T) {
+		// Arrange
+		serialized := false
+		reads, readsOutside := 0, 0
+		read := func() {
+			reads++
+			if !serialized {
+				readsOutside++
+			}
+		}
+		opsRepo := &operationsRepository_mock.RepositoryMock{
+			PushPreparedImpl: func(prepare operationsRepository.PreparePush, uid operationsRepository.UserId, did operationsRepository.DeviceId, confirm bool) repositories.UnitOfWork {
+				return repositories.UnitOfWork{
+					Perform: func() error {
+						serialized = true
+						defer func() { serialized = false }()
+						_, _, err := prepare()
+						return err
+					},
+					Rollback: func() error { return nil },
+				}
+			},
+			GetImpl: func(entities []operationsRepository.TrackedEntity) ([]operationsRepository.Operation, error) {
+				read()
+				return []operationsRepository.Operation{}, nil
+			},
+			GetUsersImpl: func(entities []operationsRepository.TrackedEntity) ([]operationsRepository.UserId, error) {
+				read()
+				return []operationsRepository.UserId{"user-1"}, nil
+			},
+		}
+		balancesRepo := &balancesRepository_mock.RepositoryMock{
+			RemoveGroupImpl: func(groupId balances.GroupId) repositories.UnitOfWork {
+				return repositories.UnitOfWork{
+					Perform:  func() error { return nil },
+					Rollback: func() error { return nil },
+				}
+			},
+		}
+		// notifications are left to the outbox, only reads of the checks are counted
+		idle := &dispatcher_mock.ServiceMock{
+			DispatchImpl: func(name string, task func()) error {
+				return nil
+			},
+		}
+		controller := defaultController.New(opsRepo, balancesRepo, nil, nil, nil, nil, idle, time.Now, logger)
+
+		// Act
+		err := controller.Push([]openapi.SomeOperation{
+			{
+				OperationId:         "op-1",
+				AuthorId:            "user-1",
+				DeleteSpendingGroup: openapi.DeleteSpendingGroupOperationDeleteSpendingGroup{GroupId: "group-1"},
+			},
+		}, "user-1", "device-1")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Positive(t, reads)
+		assert.Zero(t, readsOutside)
+	})
+}
+
+// newCheckingController pushes into a log where "owner" and "stranger" are registered users,
+// "sandbox" is created by "owner", "group" is a spending group of "owner" and "sandbox"
+// and "deleted-group" is a deleted spending group of "owner"
+func newCheckingController(pushed *bool) operations.Controller {
+	logger := standartOutputLoggingService.New()
+	createdBy := map[string]operationsRepository.UserId{
+		"owner":    "owner",
+		"stranger": "stranger",
+		"sandbox":  "owner",
+	}
+	opsRepo := &operationsRepository_mock.RepositoryMock{
+		PushImpl: func(ops []operationsRepository.PushOperation, uid operationsRepository.UserId, did operationsRepository.DeviceId, confirm bool, notifications []operationsRepository.Notification) repositories.UnitOfWork {
+			return repositories.UnitOfWork{
+				Perform: func() error {
+					*pushed = true
+					return nil
+				},
+				Rollback: func() error { return nil },
+			}
+		},
+		GetImpl: func(entities []operationsRepository.TrackedEntity) ([]operationsRepository.Operation, error) {
+			if entities[0].Type == operationsRepository.EntityTypeImage && entities[0].Id == "stranger-image" {
+				return []operationsRepository.Operation{
+					{
+						OperationId: "upload-stranger-image",
+						AuthorId:    "stranger",
+						Payload:     mockOperationPayload{typeImpl: operationsRepository.UploadImageOperationPayloadType},
+					},
+				}, nil
+			}
+			if entities[0].Type == operationsRepository.EntityTypeSpendingGroup && entities[0].Id == "deleted-group" {
+				return []operationsRepository.Operation{
+					{
+						OperationId: "delete-group",
+						AuthorId:    "owner",
+						Payload:     mockOperationPayload{typeImpl: operationsRepository.DeleteSpendingGroupOperationPayloadType},
+					},
+				}, nil
+			}
+			if entities[0].Type == operationsRepository.EntityTypeSpendingGroup && entities[0].Id == "group" {
+				data, err := json.Marshal(openapi.SomeOperation{
+					OperationId: "create-stored-spending",
+					CreatedAt:   1,
+					AuthorId:    "owner",
+					CreateSpending: openapi.CreateSpendingOperationCreateSpending{
+						SpendingId: "stored-spending",
+						GroupId:    "group",
+						Name:       "lunch",
+						Currency:   "USD",
+						Amount:     100,
+						Shares: []openapi.SpendingShare{
+							{UserId: "owner", Amount: 50},
+							{UserId: "sandbox", Amount: -50},
+						},
+					},
+				})
+				return []operationsRepository.Operation{
+					{
+						OperationId: "create-stored-spending",
+						CreatedAt:   1,
+						AuthorId:    "owner",
+						Payload: mockOperationPayload{
+							typeImpl: operationsRepository.CreateSpendingOperationPayloadType,
+							dataImpl: func() ([]byte, error) {
+								return data, err
+							},
+						},
+					},
+				}, nil
+			}
+			author, exists := createdBy[entities[0].Id]
+			if !exists || entities[0].Type != operationsRepository.EntityTypeUser {
+				return []operationsRepository.Operation{}, nil
+			}
+			data, err := json.Marshal(openapi.SomeOperation{
+				OperationId: "create-" + entities[0].Id,
+				AuthorId:    string(author),
+				CreateUser:  openapi.CreateUserOperationCreateUser{UserId: entities[0].Id, DisplayName: entities[0].Id},
+			})
+			return []operationsRepository.Operation{
+				{
+					OperationId: operationsRepository.OperationId("create-" + entities[0].Id),
+					AuthorId:    author,
+					Payload: mockOperationPayload{
+						typeImpl: operationsRepository.CreateUserOperationPayloadType,
+						dataImpl: func() ([]byte, error) {
+							return data, err
+						},
+					},
+				},
+			}, nil
+		},
+		GetEntitiesImpl: func(userId operationsRepository.UserId) ([]operationsRepository.TrackedEntity, error) {
+			return []operationsRepository.TrackedEntity{}, nil
+		},
+		GetUsersImpl: func(entities []operationsRepository.TrackedEntity) ([]operationsRepository.UserId, error) {
+			if entities[0].Type != operationsRepository.EntityTypeSpendingGroup {
+				return []operationsRepository.UserId{}, nil
+			}
+			switch entities[0].Id {
+			case "group":
+				return []operationsRepository.UserId{"owner", "sandbox"}, nil
+			case "deleted-group":
+				return []operationsRepository.UserId{"owner"}, nil
+			default:
+				return []operationsRepository.UserId{}, nil
+			}
+		},
+		ReplaceNotificationImpl: func(notification operationsRepository.Notification, with []operationsRepository.Notification) repositories.UnitOfWork {
+			return repositories.UnitOfWork{
+				Perform:  func() error { return nil },
+				Rollback: func() error { return nil },
+			}
+		},
+	}
+	realtimeService := &realtimeEvents_mock.ServiceMock{
+		NotifyUpdateImpl: func(realtimeEvents.UserId, []realtimeEvents.DeviceId, []json.RawMessage) {},
+	}
+	pushNotificationsRepository := &pushNotifications_mock.RepositoryMock{
+		GetPushTokensImpl: func(userIds []pushNotifications.UserId) (map[pushNotifications.UserId][]string, error) {
+			return map[pushNotifications.UserId][]string{}, nil
+		},
+	}
+	return defaultController.New(opsRepo, balancesMemory.New(logger), realtimeService, nil, pushNotificationsRepository, defaultFormatValidation.New(logger), inPlaceDispatcher(), time.Now, logger)
+}
+
+func testOperation(author string, modify func(*openapi.SomeOperation)) openapi.SomeOperation {
+	result := openapi.SomeOperation{
+		OperationId: "op-" + author,
+		CreatedAt:   time.Now().UnixMilli(),
+		AuthorId:    author,
+	}
+	modify(&result)
+	return result
+}
+
+func TestController_PushAuthorization(t *testing.T) {
+	for _, testCase := range []struct {
+		name       string
+		operations []openapi.SomeOperation
+		allowed    bool
+		// user pushing the operations, author of the first operation if empty
+		user operations.UserId
+	}{
+		{
+			name: "author differs from the pushing user",
+			user: "owner",
+			operations: []openapi.SomeOperation{testOperation("stranger", func(o *openapi.SomeOperation) {
+				o.UpdateDisplayName = openapi.UpdateDisplayNameOperationUpdateDisplayName{UserId: "stranger", DisplayName: "name"}
+			})},
+		},
+		{
+			name: "own display name",
+			operations: []openapi.SomeOperation{testOperation("owner", func(o *openapi.SomeOperation) {
+				o.UpdateDisplayName = openapi.UpdateDisplayNameOperationUpdateDisplayName{UserId: "owner", DisplayName: "name"}
+			})},
+			allowed: true,
+		},
+		{
+			name: "display name of own sandbox user",
+			operations: []openapi.SomeOperation{testOperation("owner", func(o *openapi.SomeOperation) {
+				o.UpdateDisplayName = openapi.UpdateDisplayNameOperationUpdateDisplayName{UserId: "sandbox", DisplayName: "name"}
+			})},
+			allowed: true,
+		},
+		{
+			name: "avatar of someone else",
+			operations: []openapi.SomeOperation{testOperation("owner", func(o *openapi.SomeOperation) {
+				o.UpdateAvatar = openapi.UpdateAvatarOperationUpdateAvatar{UserId: "stranger"}
+			})},
+		},
+		{
+			name: "new image",
+			operations: []openapi.SomeOperation{testOperation("owner", func(o *openapi.SomeOperation) {
+				o.UploadImage = openapi.UploadImageOperationUploadImage{ImageId: "new-image", Base64: "image"}
+			})},
+			allowed: true,
+		},
+		{
+			name: "image with id taken by someone else",
+			operations: []openapi.SomeOperation{testOperation("owner", func(o *openapi.SomeOperation) {
+				o.UploadImage = openapi.UploadImageOperationUploadImage{ImageId: "stranger-image", Base64: "image"}
+			})},
+		},
+		{
+			name: "user with taken id",
+			operations: []openapi.SomeOperation{testOperation("owner", func(o *openapi.SomeOperation) {
+				o.CreateUser = openapi.CreateUserOperationCreateUser{UserId: "stranger", DisplayName: "name"}
+			})},
+		},
+		{
+			name: "sandbox user created and renamed in one push",
+			operations: []openapi.SomeOperation{
+				testOperation("owner", func(o *openapi.SomeOperation) {
+					o.CreateUser = openapi.CreateUserOperationCreateUser{UserId: "new-sandbox", DisplayName: "name"}
+				}),
+				testOperation("owner", func(o *openapi.SomeOperation) {
+					o.UpdateDisplayName = openapi.UpdateDisplayNameOperationUpdateDisplayName{UserId: "new-sandbox", DisplayName: "renamed"}
+				}),
+			},
+			allowed: true,
+		},
+		{
+			name: "spending in own group",
+			operations: []openapi.SomeOperation{testOperation("owner", func(o *openapi.SomeOperation) {
+				o.DeleteSpending = openapi.DeleteSpendingOperationDeleteSpending{SpendingId: "stored-spending", GroupId: "group"}
+			})},
+			allowed: true,
+		},
+		{
+			name: "spending in someone else's group",
+			operations: []openapi.SomeOperation{testOperation("stranger", func(o *openapi.SomeOperation) {
+				o.DeleteSpending = openapi.DeleteSpendingOperationDeleteSpending{SpendingId: "stored-spending", GroupId: "group"}
+			})},
+		},
+		{
+			name: "settlement in someone else's group",
+			operations: []openapi.SomeOperation{testOperation("stranger", func(o *openapi.SomeOperation) {
+				o.Settlement = openapi.SettlementOperationSettlement{GroupId: "group", From: "stranger", To: "owner", Currency: "USD", Amount: 50}
+			})},
+		},
+		{
+			name: "spending update in someone else's group",
+			operations: []openapi.SomeOperation{testOperation("stranger", func(o *openapi.SomeOperation) {
+				o.UpdateSpending = openapi.UpdateSpendingOperationUpdateSpending{SpendingId: "stored-spending", GroupId: "group", Name: "dinner"}
+			})},
+		},
+		{
+			name: "participant added to someone else's group",
+			operations: []openapi.SomeOperation{testOperation("stranger", func(o *openapi.SomeOperation) {
+				o.AddGroupParticipant = openapi.AddGroupParticipantOperationAddGroupParticipant{GroupId: "group", UserId: "stranger"}
+			})},
+		},
+		{
+			name: "participant removed from own group",
+			operations: []openapi.SomeOperation{testOperation("owner", func(o *openapi.SomeOperation) {
+				o.RemoveGroupParticipant = openapi.RemoveGroupParticipantOperationRemoveGroupParticipant{GroupId: "group", UserId: "sandbox"}
+			})},
+			allowed: true,
+		},
+		{
+			name: "group with taken id",
+			operations: []openapi.SomeOperation{testOperation("stranger", func(o *openapi.SomeOperation) {
+				o.CreateSpendingGroup = openapi.CreateSpendingGroupOperationCreateSpendingGroup{GroupId: "group", Participants: []string{"stranger"}}
+			})},
+		},
+		{
+			name: "group deleted in the push it is created in",
+			operations: []openapi.SomeOperation{
+				testOperation("stranger", func(o *openapi.SomeOperation) {
+					o.CreateSpendingGroup = openapi.CreateSpendingGroupOperationCreateSpendingGroup{GroupId: "new-group", Participants: []string{"owner"}}
+				}),
+				testOperation("stranger", func(o *openapi.SomeOperation) {
+					o.DeleteSpendingGroup = openapi.DeleteSpendingGroupOperationDeleteSpendingGroup{GroupId: "new-group"}
+				}),
+			},
+			allowed: true,
+		},
+		{
+			name: "bind own sandbox user",
+			operations: []openapi.SomeOperation{testOperation("owner", func(o *openapi.SomeOperation) {
+				o.BindUser = openapi.BindUserOperationBindUser{OldId: "sandbox", NewId: "stranger"}
+			})},
+			allowed: true,
+		},
+		{
+			name: "bind registered user",
+			operations: []openapi.SomeOperation{testOperation("owner", func(o *openapi.SomeOperation) {
+				o.BindUser = openapi.BindUserOperationBindUser{OldId: "stranger", NewId: "owner"}
+			})},
+		},
+		{
+			name: "bind to sandbox user",
+			operations: []openapi.SomeOperation{testOperation("stranger", func(o *openapi.SomeOperation) {
+				o.BindUser = openapi.BindUserOperationBindUser{OldId: "stranger", NewId: "sandbox"}
+			})},
+		},
+		{
+			name: "email verification",
+			operations: []openapi.SomeOperation{testOperation("owner", func(o *openapi.SomeOperation) {
+				o.VerifyEmail = openapi.VerifyEmailOperationVerifyEmail{Verified: true}
+			})},
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Arrange
+			pushed := false
+			controller := newCheckingController(&pushed)
+			user := testCase.user
+			if user == "" {
+				user = operations.UserId(testCase.operations[0].AuthorId)
+			}
+
+			// Act
+			checkErr := controller.Check(testCase.operations, user)
+			pushedByCheck := pushed
+			err := controller.Push(testCase.operations, user, "device")
+
+			// Assert
+			assert.False(t, pushedByCheck)
+			if testCase.allowed {
+				assert.NoError(t, checkErr)
+				assert.NoError(t, err)
+				assert.True(t, pushed)
+			} else {
+				assert.ErrorIs(t, checkErr, operations.PrivacyViolation)
+				assert.ErrorIs(t, err, operations.PrivacyViolation)
+				assert.False(t, pushed)
+			}
+		})
+	}
+}
+
+func TestController_PushValidation(t *testing.T) {
+	createSpending := func(groupId string, currency string, shares ...openapi.SpendingShare) func(*openapi.SomeOperation) {
+		return func(o *openapi.SomeOperation) {
+			o.CreateSpending = openapi.CreateSpendingOperationCreateSpending{
+				SpendingId: "spending",
+				GroupId:    groupId,
+				Name:       "dinner",
+				Currency:   currency,
+				Amount:     100,
+				Shares:     shares,
+			}
+		}
+	}
+
+	settlement := func(groupId string, from string, to string) func(*openapi.SomeOperation) {
+		return func(o *openapi.SomeOperation) {
+			o.Settlement = openapi.SettlementOperationSettlement{
+				GroupId:  groupId,
+				From:     from,
+				To:       to,
+				Currency: "USD",
+				Amount:   50,
+			}
+		}
+	}
+
+	updateSpending := func(spendingId string, modify func(*openapi.UpdateSpendingOperationUpdateSpending)) func(*openapi.SomeOperation) {
+		return func(o *openapi.SomeOperation) {
+			o.UpdateSpending = openapi.UpdateSpendingOperationUpdateSpending{
+				SpendingId: spendingId,
+				GroupId:    "group",
+			}
+			modify(&o.UpdateSpending)
+		}
+	}
+
+	for _, testCase := range []struct {
+		name       string
+		operations []openapi.SomeOperation
+		err        error
+	}{
+		{
+			name: "spending settled between participants",
+			operations: []openapi.SomeOperation{testOperation("owner", createSpending(
+				"group", "USD",
+				openapi.SpendingShare{UserId: "owner", Amount: 50},
+				openapi.SpendingShare{UserId: "sandbox", Amount: -50},
+			))},
+		},
+		{
+			name: "unknown currency",
+			operations: []openapi.SomeOperation{testOperation("owner", createSpending(
+				"group", "ABC",
+				openapi.SpendingShare{UserId: "owner", Amount: 50},
+				openapi.SpendingShare{UserId: "sandbox", Amount: -50},
+			))},
+			err: operations.BadFormat,
+		},
+		{
+			name: "shares do not sum up to zero",
+			operations: []openapi.SomeOperation{testOperation("owner", createSpending(
+				"group", "USD",
+				openapi.SpendingShare{UserId: "owner", Amount: 50},
+				openapi.SpendingShare{UserId: "sandbox", Amount: 50},
+			))},
+			err: operations.BadFormat,
+		},
+		{
+			name: "owed more than spent",
+			operations: []openapi.SomeOperation{testOperation("owner", createSpending(
+				"group", "USD",
+				openapi.SpendingShare{UserId: "owner", Amount: 150},
+				openapi.SpendingShare{UserId: "sandbox", Amount: -150},
+			))},
+			err: operations.BadFormat,
+		},
+		{
+			name: "share of a non participant",
+			operations: []openapi.SomeOperation{testOperation("owner", createSpending(
+				"group", "USD",
+				openapi.SpendingShare{UserId: "owner", Amount: 50},
+				openapi.SpendingShare{UserId: "stranger", Amount: -50},
+			))},
+			err: operations.BadFormat,
+		},
+		{
+			name: "spending in a deleted group",
+			operations: []openapi.SomeOperation{testOperation("owner", createSpending(
+				"deleted-group", "USD",
+				openapi.SpendingShare{UserId: "owner", Amount: 0},
+			))},
+			err: operations.BadFormat,
+		},
+		{
+			name: "spending in a group deleted earlier in the push",
+			operations: []openapi.SomeOperation{
+				testOperation("owner", func(o *openapi.SomeOperation) {
+					o.DeleteSpendingGroup = openapi.DeleteSpendingGroupOperationDeleteSpendingGroup{GroupId: "group"}
+				}),
+				testOperation("owner", createSpending(
+					"group", "USD",
+					openapi.SpendingShare{UserId: "owner", Amount: 50},
+					openapi.SpendingShare{UserId: "sandbox", Amount: -50},
+				)),
+			},
+			err: operations.BadFormat,
+		},
+		{
+			name:       "settlement between participants",
+			operations: []openapi.SomeOperation{testOperation("owner", settlement("group", "sandbox", "owner"))},
+		},
+		{
+			name:       "settlement to oneself",
+			operations: []openapi.SomeOperation{testOperation("owner", settlement("group", "owner", "owner"))},
+			err:        operations.BadFormat,
+		},
+		{
+			name:       "settlement with a non participant",
+			operations: []openapi.SomeOperation{testOperation("owner", settlement("group", "stranger", "owner"))},
+			err:        operations.BadFormat,
+		},
+		{
+			name: "stored spending renamed",
+			operations: []openapi.SomeOperation{testOperation("owner", updateSpending("stored-spending", func(u *openapi.UpdateSpendingOperationUpdateSpending) {
+				u.Name = "dinner"
+			}))},
+		},
+		{
+			name: "stored spending split differently",
+			operations: []openapi.SomeOperation{testOperation("owner", updateSpending("stored-spending", func(u *openapi.UpdateSpendingOperationUpdateSpending) {
+				u.Shares = []openapi.SpendingShare{
+					{UserId: "owner", Amount: 30},
+					{UserId: "sandbox", Amount: -30},
+				}
+			}))},
+		},
+		{
+			name: "spending updated in the push it is created in",
+			operations: []openapi.SomeOperation{
+				testOperation("owner", createSpending(
+					"group", "USD",
+					openapi.SpendingShare{UserId: "owner", Amount: 50},
+					openapi.SpendingShare{UserId: "sandbox", Amount: -50},
+				)),
+				testOperation("owner", updateSpending("spending", func(u *openapi.UpdateSpendingOperationUpdateSpending) {
+					u.Amount = 200
+				})),
+			},
+		},
+		{
+			name:       "update that changes nothing",
+			operations: []openapi.SomeOperation{testOperation("owner", updateSpending("stored-spending", func(u *openapi.UpdateSpendingOperationUpdateSpending) {}))},
+			err:        operations.BadFormat,
+		},
+		{
+			name: "update of unknown spending",
+			operations: []openapi.SomeOperation{testOperation("owner", updateSpending("unknown-spending", func(u *openapi.UpdateSpendingOperationUpdateSpending) {
+				u.Name = "dinner"
+			}))},
+			err: operations.BadFormat,
+		},
+		{
+			name: "update with unknown currency",
+			operations: []openapi.SomeOperation{testOperation("owner", updateSpending("stored-spending", func(u *openapi.UpdateSpendingOperationUpdateSpending) {
+				u.Currency = "ABC"
+			}))},
+			err: operations.BadFormat,
+		},
+		{
+			name: "update owing more than the stored amount",
+			operations: []openapi.SomeOperation{testOperation("owner", updateSpending("stored-spending", func(u *openapi.UpdateSpendingOperationUpdateSpending) {
+				u.Shares = []openapi.SpendingShare{
+					{UserId: "owner", Amount: 150},
+					{UserId: "sandbox", Amount: -150},
+				}
+			}))},
+			err: operations.BadFormat,
+		},
+		{
+			name: "deletion of unknown spending",
+			operations: []openapi.SomeOperation{testOperation("owner", func(o *openapi.SomeOperation) {
+				o.DeleteSpending = openapi.DeleteSpendingOperationDeleteSpending{SpendingId: "unknown-spending", GroupId: "group"}
+			})},
+			err: operations.BadFormat,
+		},
+		{
+			name: "deletion of a settlement",
+			operations: []openapi.SomeOperation{
+				testOperation("owner", func(o *openapi.SomeOperation) {
+					settlement("group", "sandbox", "owner")(o)
+					o.OperationId = "settlement"
+				}),
+				testOperation("owner", func(o *openapi.SomeOperation) {
+					o.DeleteSpending = openapi.DeleteSpendingOperationDeleteSpending{SpendingId: "settlement", GroupId: "group"}
+				}),
+			},
+			err: operations.BadFormat,
+		},
+		{
+			name: "deletion of a spending created in the push",
+			operations: []openapi.SomeOperation{
+				testOperation("owner", func(o *openapi.SomeOperation) {
+					createSpending(
+						"group", "USD",
+						openapi.SpendingShare{UserId: "owner", Amount: 50},
+						openapi.SpendingShare{UserId: "sandbox", Amount: -50},
+					)(o)
+					o.OperationId = "create-spending"
+				}),
+				testOperation("owner", func(o *openapi.SomeOperation) {
+					o.DeleteSpending = openapi.DeleteSpendingOperationDeleteSpending{SpendingId: "spending", GroupId: "group"}
+				}),
+			},
+		},
+		{
+			name: "update of a spending deleted earlier in the push",
+			operations: []openapi.SomeOperation{
+				testOperation("owner", func(o *openapi.SomeOperation) {
+					o.DeleteSpending = openapi.DeleteSpendingOperationDeleteSpending{SpendingId: "stored-spending", GroupId: "group"}
+				}),
+				testOperation("owner", updateSpending("stored-spending", func(u *openapi.UpdateSpendingOperationUpdateSpending) {
+					u.Name = "dinner"
+				})),
+			},
+			err: operations.BadFormat,
+		},
+		{
+			name: "participant added",
+			operations: []openapi.SomeOperation{testOperation("owner", func(o *openapi.SomeOperation) {
+				o.AddGroupParticipant = openapi.AddGroupParticipantOperationAddGroupParticipant{GroupId: "group", UserId: "stranger"}
+			})},
+		},
+		{
+			name: "existing participant added",
+			operations: []openapi.SomeOperation{testOperation("owner", func(o *openapi.SomeOperation) {
+				o.AddGroupParticipant = openapi.AddGroupParticipantOperationAddGroupParticipant{GroupId: "group", UserId: "sandbox"}
+			})},
+			err: operations.BadFormat,
+		},
+		{
+			name: "unknown participant added",
+			operations: []openapi.SomeOperation{testOperation("owner", func(o *openapi.SomeOperation) {
+				o.AddGroupParticipant = openapi.AddGroupParticipantOperationAddGroupParticipant{GroupId: "group", UserId: "nobody"}
+			})},
+			err: operations.BadFormat,
+		},
+		{
+			name: "participant added to a deleted group",
+			operations: []openapi.SomeOperation{testOperation("owner", func(o *openapi.SomeOperation) {
+				o.AddGroupParticipant = openapi.AddGroupParticipantOperationAddGroupParticipant{GroupId: "deleted-group", UserId: "stranger"}
+			})},
+			err: operations.BadFormat,
+		},
+		{
+			name: "non participant removed",
+			operations: []openapi.SomeOperation{testOperation("owner", func(o *openapi.SomeOperation) {
+				o.RemoveGroupParticipant = openapi.RemoveGroupParticipantOperationRemoveGroupParticipant{GroupId: "group", UserId: "stranger"}
+			})},
+			err: operations.BadFormat,
+		},
+		{
+			name: "share of a participant removed earlier in the push",
+			operations: []openapi.SomeOperation{
+				testOperation("owner", func(o *openapi.SomeOperation) {
+					o.RemoveGroupParticipant = openapi.RemoveGroupParticipantOperationRemoveGroupParticipant{GroupId: "group", UserId: "sandbox"}
+				}),
+				testOperation("owner", createSpending(
+					"group", "USD",
+					openapi.SpendingShare{UserId: "owner", Amount: 50},
+					openapi.SpendingShare{UserId: "sandbox", Amount: -50},
+				)),
+			},
+			err: operations.BadFormat,
+		},
+		{
+			name: "last participant removed",
+			operations: []openapi.SomeOperation{
+				testOperation("owner", func(o *openapi.SomeOperation) {
+					o.RemoveGroupParticipant = openapi.RemoveGroupParticipantOperationRemoveGroupParticipant{GroupId: "group", UserId: "sandbox"}
+				}),
+				testOperation("owner", func(o *openapi.SomeOperation) {
+					o.RemoveGroupParticipant = openapi.RemoveGroupParticipantOperationRemoveGroupParticipant{GroupId: "group", UserId: "owner"}
+				}),
+			},
+			err: operations.BadFormat,
+		},
+		{
+			name: "group with unknown participant",
+			operations: []openapi.SomeOperation{testOperation("owner", func(o *openapi.SomeOperation) {
+				o.CreateSpendingGroup = openapi.CreateSpendingGroupOperationCreateSpendingGroup{GroupId: "new-group", Participants: []string{"owner", "nobody"}}
+			})},
+			err: operations.BadFormat,
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Arrange
+			pushed := false
+			controller := newCheckingController(&pushed)
+
+			// Act
+			err := controller.Push(testCase.operations, operations.UserId(testCase.operations[0].AuthorId), "device")
+
+			// Assert
+			if testCase.err == nil {
+				assert.NoError(t, err)
+				assert.True(t, pushed)
+			} else {
+				assert.ErrorIs(t, err, testCase.err)
+				assert.False(t, pushed)
+			}
+		})
+	}
+}
+
+func TestController_PushGroupParticipants(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	repository := operationsMemory.New(logger)
+	realtimeService := &realtimeEvents_mock.ServiceMock{
+		NotifyUpdateImpl: func(realtimeEvents.UserId, []realtimeEvents.DeviceId, []json.RawMessage) {},
+	}
+	pushNotificationsRepository := &pushNotifications_mock.RepositoryMock{
+		GetPushTokensImpl: func(userIds []pushNotifications.UserId) (map[pushNotifications.UserId][]string, error) {
+			return map[pushNotifications.UserId][]string{}, nil
+		},
+	}
+	controller := defaultController.New(repository, balancesMemory.New(logger), realtimeService, nil, pushNotificationsRepository, defaultFormatValidation.New(logger), inPlaceDispatcher(), time.Now, logger)
+	push := func(author string, operationId string, modify func(*openapi.SomeOperation)) {
+		operation := testOperation(author, modify)
+		operation.OperationId = operationId
+		require.NoError(t, controller.Push([]openapi.SomeOperation{operation}, operations.UserId(author), "device"))
+	}
+	pulledIds := func(userId operations.UserId) []string {
+		page, err := controller.Pull(userId, "device", openapi.REGULAR, 0, 0)
+		require.NoError(t, err)
+		return common.Map(page.Operations, func(operation openapi.SomeOperation) string {
+			return operation.OperationId
+		})
+	}
+	for _, user := range []string{"alice", "bob", "carol"} {
+		push(user, "create-"+user, func(o *openapi.SomeOperation) {
+			o.CreateUser = openapi.CreateUserOperationCreateUser{UserId: user, DisplayName: user}
+		})
+	}
+	push("alice", "create-trip", func(o *openapi.SomeOperation) {
+		o.CreateSpendingGroup = openapi.CreateSpendingGroupOperationCreateSpendingGroup{GroupId: "trip", Participants: []string{"alice", "bob"}}
+	})
+
+	t.Run("added participant pulls group history", func(t *testing.T) {
+		// Act
+		push("alice", "add-carol", func(o *openapi.SomeOperation) {
+			o.AddGroupParticipant = openapi.AddGroupParticipantOperationAddGroupParticipant{GroupId: "trip", UserId: "carol"}
+		})
+
+		// Assert
+		pulled := pulledIds("carol")
+		assert.Contains(t, pulled, "create-trip")
+		assert.Contains(t, pulled, "create-alice")
+		assert.Contains(t, pulled, "add-carol")
+		assert.Contains(t, pulledIds("bob"), "create-carol")
+	})
+
+	t.Run("removed participant stops pulling the group", func(t *testing.T) {
+		// Act
+		push("alice", "remove-carol", func(o *openapi.SomeOperation) {
+			o.RemoveGroupParticipant = openapi.RemoveGroupParticipantOperationRemoveGroupParticipant{GroupId: "trip", UserId: "carol"}
+		})
+		push("bob", "delete-trip", func(o *openapi.SomeOperation) {
+			o.DeleteSpendingGroup = openapi.DeleteSpendingGroupOperationDeleteSpendingGroup{GroupId: "trip"}
+		})
+
+		// Assert
+		pulled := pulledIds("carol")
+		assert.Contains(t, pulled, "remove-carol")
+		assert.NotContains(t, pulled, "create-trip")
+		assert.NotContains(t, pulled, "delete-trip")
+	})
+}
+
+func TestController_PullSinceAddedParticipant(t *testing.T) {
+	// Arrange
+	logger := standartOutputLoggingService.New()
+	repository := operationsMemory.New(logger)
+	realtimeService := &realtimeEvents_mock.ServiceMock{
+		NotifyUpdateImpl: func(realtimeEvents.UserId, []realtimeEvents.DeviceId, []json.RawMessage) {},
+	}
+	pushNotificationsRepository := &pushNotifications_mock.RepositoryMock{
+		GetPushTokensImpl: func(userIds []pushNotifications.UserId) (map[pushNotifications.UserId][]string, error) {
+			return map[pushNotifications.UserId][]string{}, nil
+		},
+	}
+	controller := defaultController.New(repository, balancesMemory.New(logger), realtimeService, nil, pushNotificationsRepository, defaultFormatValidation.New(logger), inPlaceDispatcher(), time.Now, logger)
+	push := func(author string, operationId string, modify func(*openapi.SomeOperation)) {
+		operation := testOperation(author, modify)
+		operation.OperationId = operationId
+		require.NoError(t, controller.Push([]openapi.SomeOperation{operation}, operations.UserId(author), "device"))
+	}
+	for _, user := range []string{"alice", "bob", "carol"} {
+		push(user, "create-"+user, func(o *openapi.SomeOperation) {
+			o.CreateUser = openapi.CreateUserOperationCreateUser{UserId: user, DisplayName: user}
+		})
+	}
+	push("alice", "create-trip", func(o *openapi.SomeOperation) {
+		o.CreateSpendingGroup = openapi.CreateSpendingGroupOperationCreateSpendingGroup{GroupId: "trip", Participants: []string{"alice", "bob"}}
+	})
+	spendings := []string{"breakfast", "lunch", "dinner"}
+	for _, spending := range spendings {
+		push("alice", spending, func(o *openapi.SomeOperation) {
+			o.CreateSpending = openapi.CreateSpendingOperationCreateSpending{
+				SpendingId: spending,
+				GroupId:    "trip",
+				Name:       spending,
+				Currency:   "USD",
+				Amount:     100,
+				Shares: []openapi.SpendingShare{
+					{UserId: "alice", Amount: 50},
+					{UserId: "bob", Amount: -50},
+				},
+			}
+		})
+	}
+	// carol is up to date before she is added, the group history is older than her cursor
+	push("carol", "rename-carol", func(o *openapi.SomeOperation) {
+		o.UpdateDisplayName = openapi.UpdateDisplayNameOperationUpdateDisplayName{UserId: "carol", DisplayName: "Carol"}
+	})
+	initial, err := controller.PullSince("carol", 0, openapi.REGULAR, 0)
+	require.NoError(t, err)
+	push("alice", "add-carol", func(o *openapi.SomeOperation) {
+		o.AddGroupParticipant = openapi.AddGroupParticipantOperationAddGroupParticipant{GroupId: "trip", UserId: "carol"}
+	})
+	push("bob", "coffee", func(o *openapi.SomeOperation) {
+		o.CreateSpending = openapi.CreateSpendingOperationCreateSpending{
+			SpendingId: "coffee",
+			GroupId:    "trip",
+			Name:       "coffee",
+			Currency:   "USD",
+			Amount:     10,
+			Shares: []openapi.SpendingShare{
+				{UserId: "bob", Amount: 5},
+				{UserId: "carol", Amount: -5},
+			},
+		}
+	})
+
+	// Act
+	pulled := []string{}
+	cursor := initial.Cursor
+	for {
+		page, err := controller.PullSince("carol", cursor, openapi.REGULAR, 2)
+		require.NoError(t, err)
+		for _, operation := range page.Operations {
+			pulled = append(pulled, operation.OperationId)
+		}
+		require.Greater(t, page.Cursor, cursor)
+		cursor = page.Cursor
+		if !page.HasMore {
+			break
+		}
+	}
+	again, err := controller.PullSince("carol", cursor, openapi.REGULAR, 2)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Subset(t, pulled, append([]string{"create-alice", "create-bob", "create-trip", "add-carol", "coffee"}, spendings...))
+	assert.NotContains(t, pulled, "rename-carol")
+	assert.Equal(t, "coffee", pulled[len(pulled)-1])
+	assert.Empty(t, again.Operations)
+}
+
+func TestController_PushNotifications(t *testing.T) {
+	// Arrange
+	logger := standartOutputLoggingService.New()
+	repository := operationsMemory.New(logger)
+	type notification struct {
+		ignoringDevices []realtimeEvents.DeviceId
+		operationIds    []string
+	}
+	notified := map[realtimeEvents.UserId][]notification{}
+	realtimeService := &realtimeEvents_mock.ServiceMock{
+		NotifyUpdateImpl: func(userId realtimeEvents.UserId, ignoringDevices []realtimeEvents.DeviceId, pushed []json.RawMessage) {
+			operationIds := []string{}
+			for _, data := range pushed {
+				var operation openapi.SomeOperation
+				require.NoError(t, json.Unmarshal(data, &operation))
+				operationIds = append(operationIds, operation.OperationId)
+			}
+			notified[userId] = append(notified[userId], notification{ignoringDevices: ignoringDevices, operationIds: operationIds})
+		},
+	}
+	pushNotificationsRepository := &pushNotifications_mock.RepositoryMock{
+		GetPushTokensImpl: func(userIds []pushNotifications.UserId) (map[pushNotifications.UserId][]string, error) {
+			return map[pushNotifications.UserId][]string{}, nil
+		},
+	}
+	controller := defaultController.New(repository, balancesMemory.New(logger), realtimeService, nil, pushNotificationsRepository, defaultFormatValidation.New(logger), inPlaceDispatcher(), time.Now, logger)
+	for _, user := range []string{"alice", "bob"} {
+		operation := testOperation(user, func(o *openapi.SomeOperation) {
+			o.CreateUser = openapi.CreateUserOperationCreateUser{UserId: user, DisplayName: user}
+		})
+		require.NoError(t, controller.Push([]openapi.SomeOperation{operation}, operations.UserId(user), "phone"))
+	}
+	notified = map[realtimeEvents.UserId][]notification{}
+	createTrip := testOperation("alice", func(o *openapi.SomeOperation) {
+		o.CreateSpendingGroup = openapi.CreateSpendingGroupOperationCreateSpendingGroup{GroupId: "trip", Participants: []string{"alice", "bob"}}
+	})
+	createTrip.OperationId = "create-trip"
+	renameAlice := testOperation("alice", func(o *openapi.SomeOperation) {
+		o.UpdateDisplayName = openapi.UpdateDisplayNameOperationUpdateDisplayName{UserId: "alice", DisplayName: "alice"}
+	})
+	renameAlice.OperationId = "rename-alice"
+
+	// Act
+	err := controller.Push([]openapi.SomeOperation{createTrip, renameAlice}, "alice", "phone")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []notification{{
+		ignoringDevices: []realtimeEvents.DeviceId{"phone"},
+		operationIds:    []string{"create-trip", "rename-alice"},
+	}}, notified["alice"])
+	require.Len(t, notified["bob"], 1)
+	assert.Empty(t, notified["bob"][0].ignoringDevices)
+	assert.Contains(t, notified["bob"][0].operationIds, "create-trip")
+}
+
+func TestController_PushDispatchesNotifications(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	pushNotificationsRepository := &pushNotifications_mock.RepositoryMock{
+		GetPushTokensImpl: func(userIds []pushNotifications.UserId) (map[pushNotifications.UserId][]string, error) {
+			return map[pushNotifications.UserId][]string{}, nil
+		},
+	}
+	createAlice := testOperation("alice", func(o *openapi.SomeOperation) {
+		o.CreateUser = openapi.CreateUserOperationCreateUser{UserId: "alice", DisplayName: "alice"}
+	})
+
+	t.Run("notifies after push returns", func(t *testing.T) {
+		// Arrange
+		notified := []realtimeEvents.UserId{}
+		realtimeService := &realtimeEvents_mock.ServiceMock{
+			NotifyUpdateImpl: func(userId realtimeEvents.UserId, _ []realtimeEvents.DeviceId, _ []json.RawMessage) {
+				notified = append(notified, userId)
+			},
+		}
+		dispatched := []func(){}
+		deferring := &dispatcher_mock.ServiceMock{
+			DispatchImpl: func(name string, task func()) error {
+				dispatched = append(dispatched, task)
+				return nil
+			},
+		}
+		controller := defaultController.New(operationsMemory.New(logger), balancesMemory.New(logger), realtimeService, nil, pushNotificationsRepository, defaultFormatValidation.New(logger), deferring, time.Now, logger)
+
+		// Act
+		err := controller.Push([]openapi.SomeOperation{createAlice}, "alice", "phone")
+
+		// Assert
+		require.NoError(t, err)
+		assert.Empty(t, notified)
+		require.Len(t, dispatched, 1)
+		dispatched[0]()
+		assert.Equal(t, []realtimeEvents.UserId{"alice"}, notified)
+	})
+
+	t.Run("leaves notifications to the outbox when queue is full", func(t *testing.T) {
+		// Arrange
+		now := time.UnixMilli(0)
+		notified := []realtimeEvents.UserId{}
+		realtimeService := &realtimeEvents_mock.ServiceMock{
+			NotifyUpdateImpl: func(userId realtimeEvents.UserId, _ []realtimeEvents.DeviceId, _ []json.RawMessage) {
+				notified = append(notified, userId)
+			},
+		}
+		full := &dispatcher_mock.ServiceMock{
+			DispatchImpl: func(name string, task func()) error {
+				return dispatcher.QueueFull
+			},
+		}
+		controller := defaultController.New(operationsMemory.New(logger), balancesMemory.New(logger), realtimeService, nil, pushNotificationsRepository, defaultFormatValidation.New(logger), full, func() time.Time { return now }, logger)
+
+		// Act
+		err := controller.Push([]openapi.SomeOperation{createAlice}, "alice", "phone")
+		notifiedByPush := notified
+		now = now.Add(time.Minute)
+		_, deliverErr := controller.DeliverNotifications()
+
+		// Assert
+		require.NoError(t, err)
+		require.NoError(t, deliverErr)
+		assert.Empty(t, notifiedByPush)
+		assert.Equal(t, []realtimeEvents.UserId{"alice"}, notified)
+	})
+}
+
+func TestController_DeliverNotifications(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	pushNotificationsRepository := &pushNotifications_mock.RepositoryMock{
+		GetPushTokensImpl: func(userIds []pushNotifications.UserId) (map[pushNotifications.UserId][]string, error) {
+			tokens := map[pushNotifications.UserId][]string{}
+			for _, userId := range userIds {
+				tokens[userId] = []string{string(userId) + "-token"}
+			}
+			return tokens, nil
+		},
+	}
+	createTrip := testOperation("alice", func(o *openapi.SomeOperation) {
+		o.CreateSpendingGroup = openapi.CreateSpendingGroupOperationCreateSpendingGroup{GroupId: "trip", Participants: []string{"alice", "bob"}}
+	})
+	createTrip.OperationId = "create-trip"
+	// pushUsers registers alice and bob, their notifications are delivered in place
+	pushUsers := func(t *testing.T, controller operations.Controller) {
+		for _, user := range []string{"alice", "bob"} {
+			operation := testOperation(user, func(o *openapi.SomeOperation) {
+				o.CreateUser = openapi.CreateUserOperationCreateUser{UserId: user, DisplayName: user}
+			})
+			require.NoError(t, controller.Push([]openapi.SomeOperation{operation}, operations.UserId(user), "phone"))
+		}
+	}
+
+	t.Run("notifications left by a stopped instance are delivered after the lease", func(t *testing.T) {
+		// Arrange
+		repository := operationsMemory.New(logger)
+		now := time.UnixMilli(0)
+		notified := []realtimeEvents.UserId{}
+		realtimeService := &realtimeEvents_mock.ServiceMock{
+			NotifyUpdateImpl: func(userId realtimeEvents.UserId, _ []realtimeEvents.DeviceId, _ []json.RawMessage) {
+				notified = append(notified, userId)
+			},
+		}
+		alerted := []pushTokens.Token{}
+		pushNotificationsService := &pushTokens_mock.ServiceMock{
+			AlertImpl: func(token pushTokens.Token, title string, subtitle *string, body *string, data interface{}) error {
+				alerted = append(alerted, token)
+				return nil
+			},
+		}
+		dropping := &dispatcher_mock.ServiceMock{
+			DispatchImpl: func(name string, task func()) error {
+				return nil
+			},
+		}
+		clock := func() time.Time { return now }
+		pushUsers(t, defaultController.New(repository, balancesMemory.New(logger), realtimeService, pushNotificationsService, pushNotificationsRepository, defaultFormatValidation.New(logger), inPlaceDispatcher(), clock, logger))
+		notified = []realtimeEvents.UserId{}
+		controller := defaultController.New(repository, balancesMemory.New(logger), realtimeService, pushNotificationsService, pushNotificationsRepository, defaultFormatValidation.New(logger), dropping, clock, logger)
+		require.NoError(t, controller.Push([]openapi.SomeOperation{createTrip}, "alice", "phone"))
+
+		// Act
+		beforeLease, err := controller.DeliverNotifications()
+		require.NoError(t, err)
+		now = now.Add(time.Minute)
+		afterLease, err := controller.DeliverNotifications()
+		require.NoError(t, err)
+		again, err := controller.DeliverNotifications()
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, operations.NotificationsReport{}, beforeLease)
+		assert.Equal(t, operations.NotificationsReport{Delivered: 3}, afterLease)
+		assert.Equal(t, operations.NotificationsReport{}, again)
+		assert.ElementsMatch(t, []realtimeEvents.UserId{"alice", "bob"}, notified)
+		assert.Equal(t, []pushTokens.Token{"bob-token"}, alerted)
+	})
+
+	t.Run("failed alerts are retried with backoff until they are dead", func(t *testing.T) {
+		// Arrange
+		repository := operationsMemory.New(logger)
+		now := time.UnixMilli(0)
+		realtimeService := &realtimeEvents_mock.ServiceMock{
+			NotifyUpdateImpl: func(realtimeEvents.UserId, []realtimeEvents.DeviceId, []json.RawMessage) {},
+		}
+		attempts := 0
+		pushNotificationsService := &pushTokens_mock.ServiceMock{
+			AlertImpl: func(token pushTokens.Token, title string, subtitle *string, body *string, data interface{}) error {
+				attempts++
+				return errors.New("apns is unavailable")
+			},
+		}
+		controller := defaultController.New(repository, balancesMemory.New(logger), realtimeService, pushNotificationsService, pushNotificationsRepository, defaultFormatValidation.New(logger), inPlaceDispatcher(), func() time.Time { return now }, logger)
+		pushUsers(t, controller)
+
+		// Act
+		require.NoError(t, controller.Push([]openapi.SomeOperation{createTrip}, "alice", "phone"))
+		reports := []operations.NotificationsReport{}
+		for _, delay := range []time.Duration{
+			5 * time.Second, 5 * time.Second, 20 * time.Second, 40 * time.Second, 80 * time.Second,
+			160 * time.Second, 320 * time.Second, 640 * time.Second, 1280 * time.Second, 2560 * time.Second,
+		} {
+			now = now.Add(delay)
+			report, err := controller.DeliverNotifications()
+			require.NoError(t, err)
+			reports = append(reports, report)
+		}
+		dead, err := repository.DeadNotifications(10)
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, []operations.NotificationsReport{
+			{}, {Retried: 1}, {Retried: 1}, {Retried: 1}, {Retried: 1},
+			{Retried: 1}, {Retried: 1}, {Retried: 1}, {Retried: 1}, {Dead: 1},
+		}, reports)
+		assert.Equal(t, 10, attempts)
+		require.Len(t, dead, 1)
+		assert.Equal(t, 10, dead[0].Attempts)
+		assert.Equal(t, "apns is unavailable", dead[0].LastError)
+	})
+
+	t.Run("alerts to invalid tokens are dropped with the tokens", func(t *testing.T) {
+		// Arrange
+		repository := operationsMemory.New(logger)
+		now := time.UnixMilli(0)
+		realtimeService := &realtimeEvents_mock.ServiceMock{
+			NotifyUpdateImpl: func(realtimeEvents.UserId, []realtimeEvents.DeviceId, []json.RawMessage) {},
+		}
+		pushNotificationsService := &pushTokens_mock.ServiceMock{
+			AlertImpl: func(token pushTokens.Token, title string, subtitle *string, body *string, data interface{}) error {
+				return fmt.Errorf("apns responded 410 Unregistered: %w", pushTokens.InvalidToken)
+			},
+		}
+		removed := []string{}
+		tokensRepository := &pushNotifications_mock.RepositoryMock{
+			GetPushTokensImpl: pushNotificationsRepository.GetPushTokensImpl,
+			RemoveInvalidPushTokenImpl: func(token string) repositories.UnitOfWork {
+				return repositories.UnitOfWork{
+					Perform: func() error {
+						removed = append(removed, token)
+						return nil
+					},
+				}
+			},
+		}
+		controller := defaultController.New(repository, balancesMemory.New(logger), realtimeService, pushNotificationsService, tokensRepository, defaultFormatValidation.New(logger), inPlaceDispatcher(), func() time.Time { return now }, logger)
+		pushUsers(t, controller)
+
+		// Act
+		require.NoError(t, controller.Push([]openapi.SomeOperation{createTrip}, "alice", "phone"))
+		now = now.Add(time.Hour)
+		report, err := controller.DeliverNotifications()
+		require.NoError(t, err)
+		dead, err := repository.DeadNotifications(10)
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, []string{"bob-token"}, removed)
+		assert.Equal(t, operations.NotificationsReport{}, report)
+		assert.Empty(t, dead)
+	})
+
+	t.Run("rejected alerts are dead without retries", func(t *testing.T) {
+		// Arrange
+		repository := operationsMemory.New(logger)
+		realtimeService := &realtimeEvents_mock.ServiceMock{
+			NotifyUpdateImpl: func(realtimeEvents.UserId, []realtimeEvents.DeviceId, []json.RawMessage) {},
+		}
+		attempts := 0
+		pushNotificationsService := &pushTokens_mock.ServiceMock{
+			AlertImpl: func(token pushTokens.Token, title string, subtitle *string, body *string, data interface{}) error {
+				attempts++
+				return fmt.Errorf("apns responded 413 PayloadTooLarge: %w", pushTokens.Rejected)
+			},
+		}
+		now := time.UnixMilli(0)
+		controller := defaultController.New(repository, balancesMemory.New(logger), realtimeService, pushNotificationsService, pushNotificationsRepository, defaultFormatValidation.New(logger), inPlaceDispatcher(), func() time.Time { return now }, logger)
+		pushUsers(t, controller)
+
+		// Act
+		require.NoError(t, controller.Push([]openapi.SomeOperation{createTrip}, "alice", "phone"))
+		now = now.Add(time.Hour)
+		report, err := controller.DeliverNotifications()
+		require.NoError(t, err)
+		dead, err := repository.DeadNotifications(10)
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, operations.NotificationsReport{}, report)
+		assert.Equal(t, 1, attempts)
+		require.Len(t, dead, 1)
+		assert.Equal(t, 1, dead[0].Attempts)
+	})
+}
+
+func TestController_PushBindUser(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	repository := operationsMemory.New(logger)
+	realtimeService := &realtimeEvents_mock.ServiceMock{
+		NotifyUpdateImpl: func(realtimeEvents.UserId, []realtimeEvents.DeviceId, []json.RawMessage) {},
+	}
+	pushNotificationsRepository := &pushNotifications_mock.RepositoryMock{
+		GetPushTokensImpl: func(userIds []pushNotifications.UserId) (map[pushNotifications.UserId][]string, error) {
+			return map[pushNotifications.UserId][]string{}, nil
+		},
+	}
+	controller := defaultController.New(repository, balancesMemory.New(logger), realtimeService, nil, pushNotificationsRepository, defaultFormatValidation.New(logger), inPlaceDispatcher(), time.Now, logger)
+	push := func(author string, operationId string, modify func(*openapi.SomeOperation)) error {
+		operation := testOperation(author, modify)
+		operation.OperationId = operationId
+		return controller.Push([]openapi.SomeOperation{operation}, operations.UserId(author), "device")
+	}
+	pulledIds := func(userId operations.UserId) []string {
+		page, err := controller.Pull(userId, "device", openapi.REGULAR, 0, 0)
+		require.NoError(t, err)
+		return common.Map(page.Operations, func(operation openapi.SomeOperation) string {
+			return operation.OperationId
+		})
+	}
+	for _, user := range []string{"alice", "bob"} {
+		require.NoError(t, push(user, "create-"+user, func(o *openapi.SomeOperation) {
+			o.CreateUser = openapi.CreateUserOperationCreateUser{UserId: user, DisplayName: user}
+		}))
+	}
+	require.NoError(t, push("alice", "create-sandbox", func(o *openapi.SomeOperation) {
+		o.CreateUser = openapi.CreateUserOperationCreateUser{UserId: "sandbox", DisplayName: "Bobby"}
+	}))
+	require.NoError(t, push("alice", "create-trip", func(o *openapi.SomeOperation) {
+		o.CreateSpendingGroup = openapi.CreateSpendingGroupOperationCreateSpendingGroup{GroupId: "trip", Participants: []string{"alice", "sandbox"}}
+	}))
+	require.NoError(t, push("alice", "create-lunch", func(o *openapi.SomeOperation) {
+		o.CreateSpending = openapi.CreateSpendingOperationCreateSpending{
+			SpendingId: "lunch",
+			GroupId:    "trip",
+			Name:       "lunch",
+			Currency:   "USD",
+			Amount:     100,
+			Shares: []openapi.SpendingShare{
+				{UserId: "alice", Amount: 50},
+				{UserId: "sandbox", Amount: -50},
+			},
+		}
+	}))
+	require.NotContains(t, pulledIds("bob"), "create-trip")
+	// bob is up to date before the binding, the sandbox user history is older than his cursor
+	require.NoError(t, push("bob", "rename-bob", func(o *openapi.SomeOperation) {
+		o.UpdateDisplayName = openapi.UpdateDisplayNameOperationUpdateDisplayName{UserId: "bob", DisplayName: "Bob"}
+	}))
+	bobCursor, err := controller.PullSince("bob", 0, openapi.REGULAR, 0)
+	require.NoError(t, err)
+
+	t.Run("registered user inherits sandbox user history", func(t *testing.T) {
+		// Act
+		err := push("alice", "bind-sandbox", func(o *openapi.SomeOperation) {
+			o.BindUser = openapi.BindUserOperationBindUser{OldId: "sandbox", NewId: "bob"}
+		})
+
+		// Assert
+		require.NoError(t, err)
+		pulled := pulledIds("bob")
+		assert.Contains(t, pulled, "create-sandbox")
+		assert.Contains(t, pulled, "create-trip")
+		assert.Contains(t, pulled, "create-lunch")
+		assert.Contains(t, pulled, "bind-sandbox")
+		assert.Contains(t, pulledIds("alice"), "create-bob")
+		page, err := controller.PullSince("bob", bobCursor.Cursor, openapi.REGULAR, 0)
+		require.NoError(t, err)
+		pulledSince := common.Map(page.Operations, func(operation openapi.SomeOperation) string {
+			return operation.OperationId
+		})
+		assert.Subset(t, pulledSince, []string{"create-sandbox", "create-trip", "create-lunch", "bind-sandbox"})
+		assert.NotContains(t, pulledSince, "rename-bob")
+	})
+
+	t.Run("registered user takes part in the group of sandbox user", func(t *testing.T) {
+		// Act
+		err := push("bob", "create-dinner", func(o *openapi.SomeOperation) {
+			o.CreateSpending = openapi.CreateSpendingOperationCreateSpending{
+				SpendingId: "dinner",
+				GroupId:    "trip",
+				Name:       "dinner",
+				Currency:   "USD",
+				Amount:     100,
+				Shares: []openapi.SpendingShare{
+					{UserId: "bob", Amount: 50},
+					{UserId: "alice", Amount: -50},
+				},
+			}
+		})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Contains(t, pulledIds("alice"), "create-dinner")
+	})
+
+	t.Run("bound sandbox user can not be bound again", func(t *testing.T) {
+		// Act
+		err := push("alice", "bind-sandbox-again", func(o *openapi.SomeOperation) {
+			o.BindUser = openapi.BindUserOperationBindUser{OldId: "sandbox", NewId: "alice"}
+		})
+
+		// Assert
+		assert.Error(t, err)
+	})
 }
 
 func TestController_Pull(t *testing.T) {
@@ -157,7 +1504,7 @@ func TestController_Pull(t *testing.T) {
 		require.NoError(t, err)
 
 		opsRepo := &operationsRepository_mock.RepositoryMock{
-			PullImpl: func(uid operationsRepository.UserId, did operationsRepository.DeviceId, opType operationsRepository.OperationType) ([]operationsRepository.Operation, error) {
+			PullImpl: func(uid operationsRepository.UserId, did operationsRepository.DeviceId, after operationsRepository.SequenceNumber, limit int, opType operationsRepository.OperationType) ([]operationsRepository.Operation, error) {
 				return []operationsRepository.Operation{
 					{
 						OperationId: "op-1",
@@ -183,21 +1530,70 @@ func TestController_Pull(t *testing.T) {
 			},
 		}
 
-		controller := defaultController.New(opsRepo, nil, pushNotificationsService, pushNotificationsRepository, logger)
+		controller := defaultController.New(opsRepo, nil, nil, pushNotificationsService, pushNotificationsRepository, nil, inPlaceDispatcher(), time.Now, logger)
 
 		// Act
-		result, err := controller.Pull("user-1", "device-1", openapi.REGULAR)
+		result, err := controller.Pull("user-1", "device-1", openapi.REGULAR, 0, 0)
 
 		// Assert
 		assert.NoError(t, err)
-		assert.Len(t, result, 1)
-		assert.Equal(t, testOperation.OperationId, result[0].OperationId)
+		assert.Len(t, result.Operations, 1)
+		assert.Equal(t, testOperation.OperationId, result.Operations[0].OperationId)
+		assert.False(t, result.HasMore)
+	})
+
+	t.Run("page is cut at the limit", func(t *testing.T) {
+		// Arrange
+		operationData, err := json.Marshal(openapi.SomeOperation{
+			OperationId: "op-1",
+			CreatedAt:   time.Now().UnixMilli(),
+			AuthorId:    "test-user",
+		})
+		require.NoError(t, err)
+
+		opsRepo := &operationsRepository_mock.RepositoryMock{
+			PullImpl: func(uid operationsRepository.UserId, did operationsRepository.DeviceId, after operationsRepository.SequenceNumber, limit int, opType operationsRepository.OperationType) ([]operationsRepository.Operation, error) {
+				assert.Equal(t, operationsRepository.SequenceNumber(5), after)
+				assert.Equal(t, 2, limit)
+				return []operationsRepository.Operation{
+					{
+						OperationId:    "op-1",
+						SequenceNumber: 6,
+						Payload: mockOperationPayload{
+							dataImpl: func() ([]byte, error) {
+								return operationData, nil
+							},
+						},
+					},
+					{
+						OperationId:    "op-2",
+						SequenceNumber: 7,
+						Payload: mockOperationPayload{
+							dataImpl: func() ([]byte, error) {
+								return operationData, nil
+							},
+						},
+					},
+				}, nil
+			},
+		}
+
+		controller := defaultController.New(opsRepo, nil, nil, nil, nil, nil, inPlaceDispatcher(), time.Now, logger)
+
+		// Act
+		result, err := controller.Pull("user-1", "device-1", openapi.REGULAR, 5, 1)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, result.Operations, 1)
+		assert.True(t, result.HasMore)
+		assert.Equal(t, operations.SequenceNumber(6), result.Cursor)
 	})
 
 	t.Run("repository pull error", func(t *testing.T) {
 		// Arrange
 		opsRepo := &operationsRepository_mock.RepositoryMock{
-			PullImpl: func(uid operationsRepository.UserId, did operationsRepository.DeviceId, opType operationsRepository.OperationType) ([]operationsRepository.Operation, error) {
+			PullImpl: func(uid operationsRepository.UserId, did operationsRepository.DeviceId, after operationsRepository.SequenceNumber, limit int, opType operationsRepository.OperationType) ([]operationsRepository.Operation, error) {
 				return nil, errors.New("pull error")
 			},
 		}
@@ -212,20 +1608,20 @@ func TestController_Pull(t *testing.T) {
 			},
 		}
 
-		controller := defaultController.New(opsRepo, nil, pushNotificationsService, pushNotificationsRepository, logger)
+		controller := defaultController.New(opsRepo, nil, nil, pushNotificationsService, pushNotificationsRepository, nil, inPlaceDispatcher(), time.Now, logger)
 
 		// Act
-		result, err := controller.Pull("user-1", "device-1", openapi.REGULAR)
+		result, err := controller.Pull("user-1", "device-1", openapi.REGULAR, 0, 0)
 
 		// Assert
 		assert.Error(t, err)
-		assert.Nil(t, result)
+		assert.Nil(t, result.Operations)
 	})
 
 	t.Run("invalid operation payload", func(t *testing.T) {
 		// Arrange
 		opsRepo := &operationsRepository_mock.RepositoryMock{
-			PullImpl: func(uid operationsRepository.UserId, did operationsRepository.DeviceId, opType operationsRepository.OperationType) ([]operationsRepository.Operation, error) {
+			PullImpl: func(uid operationsRepository.UserId, did operationsRepository.DeviceId, after operationsRepository.SequenceNumber, limit int, opType operationsRepository.OperationType) ([]operationsRepository.Operation, error) {
 				return []operationsRepository.Operation{
 					{
 						OperationId: "op-1",
@@ -251,14 +1647,100 @@ func TestController_Pull(t *testing.T) {
 			},
 		}
 
-		controller := defaultController.New(opsRepo, nil, pushNotificationsService, pushNotificationsRepository, logger)
+		controller := defaultController.New(opsRepo, nil, nil, pushNotificationsService, pushNotificationsRepository, nil, inPlaceDispatcher(), time.Now, logger)
+
+		// Act
+		result, err := controller.Pull("user-1", "device-1", openapi.REGULAR, 0, 0)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, result.Operations)
+	})
+}
+
+func TestController_PullSince(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+
+	t.Run("cursor points to the last pulled operation", func(t *testing.T) {
+		// Arrange
+		testOperation := openapi.SomeOperation{
+			OperationId: "op-1",
+			CreatedAt:   time.Now().UnixMilli(),
+			AuthorId:    "test-user",
+			CreateUser: openapi.CreateUserOperationCreateUser{
+				UserId:      "test-user",
+				DisplayName: "Test User",
+			},
+		}
+
+		operationData, err := json.Marshal(testOperation)
+		require.NoError(t, err)
+
+		opsRepo := &operationsRepository_mock.RepositoryMock{
+			PullSinceImpl: func(uid operationsRepository.UserId, since operationsRepository.SequenceNumber, limit int, opType operationsRepository.OperationType) ([]operationsRepository.Operation, error) {
+				assert.Equal(t, operationsRepository.SequenceNumber(10), since)
+				return []operationsRepository.Operation{
+					{
+						OperationId:    "op-1",
+						SequenceNumber: 42,
+						CreatedAt:      time.Now().UnixMilli(),
+						AuthorId:       "test-user",
+						Payload: mockOperationPayload{
+							dataImpl: func() ([]byte, error) {
+								return operationData, nil
+							},
+						},
+					},
+				}, nil
+			},
+		}
+
+		controller := defaultController.New(opsRepo, nil, nil, nil, nil, nil, inPlaceDispatcher(), time.Now, logger)
+
+		// Act
+		page, err := controller.PullSince("user-1", 10, openapi.REGULAR, 0)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, page.Operations, 1)
+		assert.Equal(t, testOperation.OperationId, page.Operations[0].OperationId)
+		assert.Equal(t, operations.SequenceNumber(42), page.Cursor)
+	})
+
+	t.Run("empty page keeps cursor", func(t *testing.T) {
+		// Arrange
+		opsRepo := &operationsRepository_mock.RepositoryMock{
+			PullSinceImpl: func(uid operationsRepository.UserId, since operationsRepository.SequenceNumber, limit int, opType operationsRepository.OperationType) ([]operationsRepository.Operation, error) {
+				return []operationsRepository.Operation{}, nil
+			},
+		}
+
+		controller := defaultController.New(opsRepo, nil, nil, nil, nil, nil, inPlaceDispatcher(), time.Now, logger)
+
+		// Act
+		page, err := controller.PullSince("user-1", 10, openapi.REGULAR, 0)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Empty(t, page.Operations)
+		assert.Equal(t, operations.SequenceNumber(10), page.Cursor)
+	})
+
+	t.Run("repository pull error", func(t *testing.T) {
+		// Arrange
+		opsRepo := &operationsRepository_mock.RepositoryMock{
+			PullSinceImpl: func(uid operationsRepository.UserId, since operationsRepository.SequenceNumber, limit int, opType operationsRepository.OperationType) ([]operationsRepository.Operation, error) {
+				return nil, errors.New("pull error")
+			},
+		}
+
+		controller := defaultController.New(opsRepo, nil, nil, nil, nil, nil, inPlaceDispatcher(), time.Now, logger)
 
 		// Act
-		result, err := controller.Pull("user-1", "device-1", openapi.REGULAR)
+		_, err := controller.PullSince("user-1", 10, openapi.REGULAR, 0)
 
 		// Assert
 		assert.Error(t, err)
-		assert.Nil(t, result)
 	})
 }
 
@@ -286,7 +1768,7 @@ func TestController_Confirm(t *testing.T) {
 			},
 		}
 
-		controller := defaultController.New(opsRepo, nil, pushNotificationsService, pushNotificationsRepository, logger)
+		controller := defaultController.New(opsRepo, nil, nil, pushNotificationsService, pushNotificationsRepository, nil, inPlaceDispatcher(), time.Now, logger)
 
 		// Act
 		err := controller.Confirm([]operations.OperationId{"op-1"}, "user-1", "device-1")
@@ -316,7 +1798,7 @@ func TestController_Confirm(t *testing.T) {
 			},
 		}
 
-		controller := defaultController.New(opsRepo, nil, pushNotificationsService, pushNotificationsRepository, logger)
+		controller := defaultController.New(opsRepo, nil, nil, pushNotificationsService, pushNotificationsRepository, nil, inPlaceDispatcher(), time.Now, logger)
 
 		// Act
 		err := controller.Confirm([]operations.OperationId{"op-1"}, "user-1", "device-1")
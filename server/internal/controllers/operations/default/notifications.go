@@ -0,0 +1,347 @@
+package defaultController
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"verni/internal/common"
+	"verni/internal/controllers/operations"
+	openapi "verni/internal/openapi/go"
+	operationsRepository "verni/internal/repositories/operations"
+	"verni/internal/services/pushNotifications"
+	"verni/internal/services/realtimeEvents"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// notificationLease is how long a notification belongs to the instance delivering it, other instances take
+	// it over once the lease expires
+	notificationLease       = time.Minute
+	notificationsBatch      = 100
+	notificationMaxAttempts = 10
+	notificationFirstRetry  = 10 * time.Second
+	notificationMaxRetry    = time.Hour
+)
+
+// notificationPayload is stored in the outbox, exactly one of the fields is set. A notification about pushed
+// operations is replaced with realtime updates and alerts once their recipients are known.
+type notificationPayload struct {
+	Pushed   *pushedPayload   `json:"pushed,omitempty"`
+	Realtime *realtimePayload `json:"realtime,omitempty"`
+	Alert    *alertPayload    `json:"alert,omitempty"`
+}
+
+type pushedPayload struct {
+	UserId     string                  `json:"userId"`
+	DeviceId   string                  `json:"deviceId"`
+	Operations []openapi.SomeOperation `json:"operations"`
+	// SpendingChanges keep spendings as they were before the push, keyed by identifiers of update operations
+	SpendingChanges map[string]storedSpendingChange `json:"spendingChanges,omitempty"`
+}
+
+type storedSpendingChange struct {
+	Before openapi.CreateSpendingOperationCreateSpending `json:"before"`
+	After  openapi.CreateSpendingOperationCreateSpending `json:"after"`
+}
+
+type realtimePayload struct {
+	UserId          string   `json:"userId"`
+	IgnoringDevices []string `json:"ignoringDevices"`
+	// Operations are null when devices should pull pending operations themselves
+	Operations []json.RawMessage `json:"operations"`
+}
+
+type alertPayload struct {
+	Token    string          `json:"token"`
+	Title    string          `json:"title"`
+	Subtitle *string         `json:"subtitle,omitempty"`
+	Body     *string         `json:"body,omitempty"`
+	Data     json.RawMessage `json:"data"`
+}
+
+func storedSpendingChanges(changes map[string]spendingChange) map[string]storedSpendingChange {
+	stored := make(map[string]storedSpendingChange, len(changes))
+	for operationId, change := range changes {
+		stored[operationId] = storedSpendingChange{Before: change.before, After: change.after}
+	}
+	return stored
+}
+
+// newNotification makes a pending notification leased to this instance, it is delivered by the relay only if
+// this instance does not deliver it in time
+func (c *defaultController) newNotification(payload notificationPayload) (operationsRepository.Notification, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return operationsRepository.Notification{}, fmt.Errorf("encoding notification payload: %w", err)
+	}
+	now := c.now()
+	return operationsRepository.Notification{
+		Id:            operationsRepository.NotificationId(uuid.New().String()),
+		CreatedAt:     now.UnixMilli(),
+		Payload:       data,
+		State:         operationsRepository.NotificationStatePending,
+		NextAttemptAt: now.Add(notificationLease).UnixMilli(),
+	}, nil
+}
+
+func (c *defaultController) DeliverNotifications() (operations.NotificationsReport, error) {
+	const op = "controllers.operations.defaultController.DeliverNotifications"
+	c.logger.LogInfo("%s: start", op)
+	report := operations.NotificationsReport{}
+	for {
+		now := c.now()
+		claimed, err := c.operationsRepository.ClaimNotifications(
+			now.UnixMilli(),
+			now.Add(notificationLease).UnixMilli(),
+			notificationsBatch,
+		)
+		if err != nil {
+			return report, fmt.Errorf("%s: claiming notifications: %w", op, err)
+		}
+		for _, notification := range claimed {
+			c.deliver(notification, &report)
+		}
+		if len(claimed) < notificationsBatch {
+			break
+		}
+	}
+	c.logger.LogInfo("%s: success[delivered=%d retried=%d dead=%d dropped=%d]", op, report.Delivered, report.Retried, report.Dead, report.Dropped)
+	return report, nil
+}
+
+// deliverOwned delivers a notification this instance has just written
+func (c *defaultController) deliverOwned(notification operationsRepository.Notification) {
+	const op = "controllers.operations.defaultController.deliverOwned"
+	report := operations.NotificationsReport{}
+	c.deliver(notification, &report)
+	c.logger.LogInfo("%s: %s handled[delivered=%d retried=%d dead=%d dropped=%d]", op, notification.Id, report.Delivered, report.Retried, report.Dead, report.Dropped)
+}
+
+// deliver handles a notification owned by this instance, a notification about pushed operations is replaced with
+// notifications of its recipients which are delivered right away
+func (c *defaultController) deliver(notification operationsRepository.Notification, report *operations.NotificationsReport) {
+	const op = "controllers.operations.defaultController.deliver"
+	var payload notificationPayload
+	if err := json.Unmarshal(notification.Payload, &payload); err != nil {
+		c.fail(notification, fmt.Errorf("decoding payload: %w", err), true, report)
+		return
+	}
+	switch {
+	case payload.Pushed != nil:
+		recipients, err := c.recipientNotifications(*payload.Pushed)
+		if err != nil {
+			c.fail(notification, err, false, report)
+			return
+		}
+		if err := c.operationsRepository.ReplaceNotification(notification, recipients).Perform(); err != nil {
+			// the notification is taken again once the lease expires
+			c.logger.LogError("%s: replacing %s with %d notifications: %v", op, notification.Id, len(recipients), err)
+			return
+		}
+		for _, recipient := range recipients {
+			c.deliver(recipient, report)
+		}
+	case payload.Realtime != nil:
+		c.realtimeEvents.NotifyUpdate(
+			realtimeEvents.UserId(payload.Realtime.UserId),
+			common.Map(payload.Realtime.IgnoringDevices, func(id string) realtimeEvents.DeviceId {
+				return realtimeEvents.DeviceId(id)
+			}),
+			payload.Realtime.Operations,
+		)
+		c.complete(notification, report)
+	case payload.Alert != nil:
+		if err := c.pushNotifications.Alert(
+			pushNotifications.Token(payload.Alert.Token),
+			payload.Alert.Title,
+			payload.Alert.Subtitle,
+			payload.Alert.Body,
+			payload.Alert.Data,
+		); err != nil {
+			if errors.Is(err, pushNotifications.InvalidToken) {
+				c.drop(notification, payload.Alert.Token, err, report)
+				return
+			}
+			c.fail(notification, err, errors.Is(err, pushNotifications.Rejected), report)
+			return
+		}
+		c.complete(notification, report)
+	default:
+		c.fail(notification, errors.New("empty payload"), true, report)
+	}
+}
+
+func (c *defaultController) complete(notification operationsRepository.Notification, report *operations.NotificationsReport) {
+	const op = "controllers.operations.defaultController.complete"
+	report.Delivered++
+	if err := c.operationsRepository.ReplaceNotification(notification, nil).Perform(); err != nil {
+		// the notification is delivered again once the lease expires
+		c.logger.LogError("%s: removing delivered %s: %v", op, notification.Id, err)
+	}
+}
+
+// drop forgets a push token that will never receive alerts again along with the alert to it
+func (c *defaultController) drop(
+	notification operationsRepository.Notification,
+	token string,
+	cause error,
+	report *operations.NotificationsReport,
+) {
+	const op = "controllers.operations.defaultController.drop"
+	if err := c.pushTokensRepository.RemoveInvalidPushToken(token).Perform(); err != nil {
+		c.fail(notification, fmt.Errorf("removing invalid push token: %w", err), false, report)
+		return
+	}
+	c.logger.LogInfo("%s: removed invalid push token of %s: %v", op, notification.Id, cause)
+	report.Dropped++
+	if err := c.operationsRepository.ReplaceNotification(notification, nil).Perform(); err != nil {
+		// the alert is sent again once the lease expires and dropped again
+		c.logger.LogError("%s: removing dropped %s: %v", op, notification.Id, err)
+	}
+}
+
+// fail schedules the next attempt to deliver the notification, notifications that can never be delivered or ran out
+// of attempts are kept as dead
+func (c *defaultController) fail(
+	notification operationsRepository.Notification,
+	cause error,
+	permanent bool,
+	report *operations.NotificationsReport,
+) {
+	const op = "controllers.operations.defaultController.fail"
+	notification.Attempts++
+	notification.LastError = cause.Error()
+	if permanent || notification.Attempts >= notificationMaxAttempts {
+		notification.State = operationsRepository.NotificationStateDead
+		report.Dead++
+		c.logger.LogError("%s: %s is dead after %d attempts: %v", op, notification.Id, notification.Attempts, cause)
+	} else {
+		notification.NextAttemptAt = c.now().Add(retryDelay(notification.Attempts)).UnixMilli()
+		report.Retried++
+		c.logger.LogInfo("%s: %s is retried after %d attempts: %v", op, notification.Id, notification.Attempts, cause)
+	}
+	if err := c.operationsRepository.UpdateNotification(notification).Perform(); err != nil {
+		c.logger.LogError("%s: storing attempt of %s: %v", op, notification.Id, err)
+	}
+}
+
+// retryDelay doubles with every failed attempt
+func retryDelay(attempts int) time.Duration {
+	delay := notificationFirstRetry
+	for i := 1; i < attempts && delay < notificationMaxRetry; i++ {
+		delay *= 2
+	}
+	return min(delay, notificationMaxRetry)
+}
+
+// recipientNotifications makes realtime updates and alerts about pushed operations for users having access to them
+func (c *defaultController) recipientNotifications(pushed pushedPayload) ([]operationsRepository.Notification, error) {
+	// every user is notified once with all pushed operations they have access to, if any of them can not be
+	// encoded users are notified without operations and pull them
+	alerts := &alertsCollector{defaultController: c}
+	usersToNotify := []operationsRepository.UserId{}
+	updates := map[operationsRepository.UserId][]json.RawMessage{}
+	incomplete := false
+	for _, pushedOperation := range pushed.Operations {
+		operation := operationsRepository.CreateOperation(pushedOperation)
+		userIdsToNotify, err := c.operationsRepository.GetUsers(operation.Payload.TrackedEntities())
+		if err != nil {
+			return nil, fmt.Errorf("getting users to notify about %s: %w", operation.OperationId, err)
+		}
+		data, err := operation.Payload.Data()
+		if err != nil {
+			c.logger.LogError("getting data of operation %s to notify: %v", operation.OperationId, err)
+			incomplete = true
+		}
+		for _, userToNotify := range userIdsToNotify {
+			if _, exists := updates[userToNotify]; !exists {
+				usersToNotify = append(usersToNotify, userToNotify)
+			}
+			updates[userToNotify] = append(updates[userToNotify], data)
+		}
+		userToNotifyWithoutCurrentUser := common.Filter(userIdsToNotify, func(id operationsRepository.UserId) bool {
+			return id != operationsRepository.UserId(pushed.UserId)
+		})
+		switch operation.Payload.Type() {
+		case operationsRepository.CreateSpendingGroupOperationPayloadType:
+			if err := alerts.sendCreateSpendingGroupPush(
+				pushedOperation.CreateSpendingGroup,
+				userToNotifyWithoutCurrentUser,
+			); err != nil {
+				c.logger.LogError("sending create spending group push: %v", err)
+			}
+		case operationsRepository.AddGroupParticipantOperationPayloadType:
+			if err := alerts.sendGroupParticipantPush(
+				openapi.NEW_GROUP_PARTICIPANT,
+				pushedOperation.AddGroupParticipant.GroupId,
+				pushedOperation.AddGroupParticipant.UserId,
+				userToNotifyWithoutCurrentUser,
+			); err != nil {
+				c.logger.LogError("sending new group participant push: %v", err)
+			}
+		case operationsRepository.RemoveGroupParticipantOperationPayloadType:
+			if err := alerts.sendGroupParticipantPush(
+				openapi.REMOVED_GROUP_PARTICIPANT,
+				pushedOperation.RemoveGroupParticipant.GroupId,
+				pushedOperation.RemoveGroupParticipant.UserId,
+				userToNotifyWithoutCurrentUser,
+			); err != nil {
+				c.logger.LogError("sending removed group participant push: %v", err)
+			}
+		case operationsRepository.CreateSpendingOperationPayloadType:
+			if err := alerts.sendCreateSpendingPush(
+				pushedOperation.CreateSpending,
+				userToNotifyWithoutCurrentUser,
+			); err != nil {
+				c.logger.LogError("sending create spending push: %v", err)
+			}
+		case operationsRepository.UpdateSpendingOperationPayloadType:
+			change := pushed.SpendingChanges[pushedOperation.OperationId]
+			if err := alerts.sendUpdateSpendingPush(
+				spendingChange{before: change.Before, after: change.After},
+				userToNotifyWithoutCurrentUser,
+			); err != nil {
+				c.logger.LogError("sending update spending push: %v", err)
+			}
+		case operationsRepository.SettlementOperationPayloadType:
+			if err := alerts.sendSettlementPush(
+				pushedOperation.Settlement,
+				userToNotifyWithoutCurrentUser,
+			); err != nil {
+				c.logger.LogError("sending settlement push: %v", err)
+			}
+		}
+	}
+	notifications := []operationsRepository.Notification{}
+	for _, userToNotify := range usersToNotify {
+		devicesToIgnore := []string{}
+		if userToNotify == operationsRepository.UserId(pushed.UserId) {
+			devicesToIgnore = append(devicesToIgnore, pushed.DeviceId)
+		}
+		c.logger.LogInfo("notifying %s about update, devices to ignore: %v", userToNotify, devicesToIgnore)
+		update := updates[userToNotify]
+		if incomplete {
+			update = nil
+		}
+		notification, err := c.newNotification(notificationPayload{
+			Realtime: &realtimePayload{
+				UserId:          string(userToNotify),
+				IgnoringDevices: devicesToIgnore,
+				Operations:      update,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+	return append(notifications, alerts.alerts...), nil
+}
+
+// alertsCollector gathers alerts about pushed operations, they are sent through the outbox
+type alertsCollector struct {
+	*defaultController
+	alerts []operationsRepository.Notification
+}
@@ -0,0 +1,66 @@
+package defaultController
+
+import (
+	"encoding/json"
+	"fmt"
+	"verni/internal/controllers/operations"
+	openapi "verni/internal/openapi/go"
+	operationsRepository "verni/internal/repositories/operations"
+)
+
+const (
+	defaultPageSize = 500
+	maxPageSize     = 1000
+	// maxPageBytes bounds the payload size of a single page, a page always contains at least one operation
+	maxPageBytes = 1 << 20
+)
+
+func pageLimit(limit int) int {
+	if limit <= 0 {
+		return defaultPageSize
+	}
+	return min(limit, maxPageSize)
+}
+
+// makePage expects operations pulled with `limit + 1` so an extra one signals that there is more to pull.
+// Operations sharing a sequence number are never split since the cursor could not point between them, a page
+// consisting of one such group is kept whole even if it exceeds the limits.
+func makePage(pulled []operationsRepository.Operation, limit int, cursor operations.SequenceNumber) (operations.OperationsPage, error) {
+	page := operations.OperationsPage{
+		Operations: []openapi.SomeOperation{},
+		Cursor:     cursor,
+	}
+	converted := make([]openapi.SomeOperation, 0, min(len(pulled), limit))
+	size := 0
+	for index, operation := range pulled {
+		data, err := operation.Payload.Data()
+		if err != nil {
+			return operations.OperationsPage{}, fmt.Errorf("getting data from operation %v: %w", operation, err)
+		}
+		if index == limit || (index > 0 && size+len(data) > maxPageBytes) {
+			page.HasMore = true
+		}
+		if page.HasMore {
+			start := index
+			for start > 0 && pulled[start-1].SequenceNumber == operation.SequenceNumber {
+				start--
+			}
+			if start > 0 {
+				converted = converted[:start]
+				break
+			}
+		}
+		size += len(data)
+
+		var someOperation openapi.SomeOperation
+		if err := json.Unmarshal(data, &someOperation); err != nil {
+			return operations.OperationsPage{}, fmt.Errorf("parsing operation from %v payload data: %w", operation, err)
+		}
+		converted = append(converted, someOperation)
+	}
+	page.Operations = converted
+	if len(converted) > 0 {
+		page.Cursor = operations.SequenceNumber(pulled[len(converted)-1].SequenceNumber)
+	}
+	return page, nil
+}
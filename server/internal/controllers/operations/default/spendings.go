@@ -0,0 +1,121 @@
+package defaultController
+
+import (
+	"encoding/json"
+	"fmt"
+	openapi "verni/internal/openapi/go"
+	balancesRepository "verni/internal/repositories/balances"
+	operationsRepository "verni/internal/repositories/operations"
+)
+
+// spendingState is a spending with its updates merged field by field, every field keeps
+// the value of its latest change no matter in which order changes were pushed
+type spendingState struct {
+	spending        openapi.CreateSpendingOperationCreateSpending
+	nameVersion     balancesRepository.Version
+	currencyVersion balancesRepository.Version
+	amountVersion   balancesRepository.Version
+	sharesVersion   balancesRepository.Version
+	deleted         bool
+}
+
+// spendingChange is a spending before and after an update pushed by the client
+type spendingChange struct {
+	before openapi.CreateSpendingOperationCreateSpending
+	after  openapi.CreateSpendingOperationCreateSpending
+}
+
+func newSpendingState(operation openapi.SomeOperation) *spendingState {
+	version := operationVersion(operation)
+	return &spendingState{
+		spending:        operation.CreateSpending,
+		nameVersion:     version,
+		currencyVersion: version,
+		amountVersion:   version,
+		sharesVersion:   version,
+	}
+}
+
+func (s *spendingState) merge(operation openapi.SomeOperation) {
+	update := operation.UpdateSpending
+	version := operationVersion(operation)
+	if update.Name != "" && version.After(s.nameVersion) {
+		s.spending.Name = update.Name
+		s.nameVersion = version
+	}
+	if update.Currency != "" && version.After(s.currencyVersion) {
+		s.spending.Currency = update.Currency
+		s.currencyVersion = version
+	}
+	if update.Amount != 0 && version.After(s.amountVersion) {
+		s.spending.Amount = update.Amount
+		s.amountVersion = version
+	}
+	if len(update.Shares) > 0 && version.After(s.sharesVersion) {
+		s.spending.Shares = update.Shares
+		s.sharesVersion = version
+	}
+}
+
+func operationVersion(operation openapi.SomeOperation) balancesRepository.Version {
+	return balancesRepository.Version{
+		CreatedAt:   operation.CreatedAt,
+		OperationId: operation.OperationId,
+	}
+}
+
+// spending returns the current state of the spending, nil is returned if it was never created
+func (c *defaultController) spending(groupId string, spendingId string, state *pushState) (*spendingState, error) {
+	if spending, exists := state.spendings[spendingId]; exists {
+		return spending, nil
+	}
+	affecting, err := c.operationsRepository.Get([]operationsRepository.TrackedEntity{
+		{Id: groupId, Type: operationsRepository.EntityTypeSpendingGroup},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting operations of group %s: %w", groupId, err)
+	}
+	var spending *spendingState
+	updates := []openapi.SomeOperation{}
+	deleted := false
+	for _, operation := range affecting {
+		switch operation.Payload.Type() {
+		case operationsRepository.CreateSpendingOperationPayloadType,
+			operationsRepository.UpdateSpendingOperationPayloadType,
+			operationsRepository.DeleteSpendingOperationPayloadType:
+		default:
+			continue
+		}
+		data, err := operation.Payload.Data()
+		if err != nil {
+			return nil, fmt.Errorf("getting data from operation %v: %w", operation, err)
+		}
+		var converted openapi.SomeOperation
+		if err := json.Unmarshal(data, &converted); err != nil {
+			return nil, fmt.Errorf("parsing operation from %v payload data: %w", operation, err)
+		}
+		switch operation.Payload.Type() {
+		case operationsRepository.CreateSpendingOperationPayloadType:
+			if converted.CreateSpending.SpendingId == spendingId {
+				spending = newSpendingState(converted)
+			}
+		case operationsRepository.UpdateSpendingOperationPayloadType:
+			if converted.UpdateSpending.SpendingId == spendingId {
+				updates = append(updates, converted)
+			}
+		case operationsRepository.DeleteSpendingOperationPayloadType:
+			if converted.DeleteSpending.SpendingId == spendingId {
+				deleted = true
+			}
+		}
+	}
+	if spending == nil {
+		return nil, nil
+	}
+	for _, update := range updates {
+		spending.merge(update)
+	}
+	spending.deleted = deleted
+	state.spendings[spendingId] = spending
+	return spending, nil
+}
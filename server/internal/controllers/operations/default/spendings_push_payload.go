@@ -9,7 +9,6 @@ import (
 	openapi "verni/internal/openapi/go"
 	operationsRepository "verni/internal/repositories/operations"
 	pushTokens "verni/internal/repositories/pushNotifications"
-	"verni/internal/services/pushNotifications"
 )
 
 func (c *defaultController) getDisplayNames(userIds []operationsRepository.UserId) (map[string]string, error) {
@@ -78,7 +77,7 @@ func (c *defaultController) getSpendingGroupPayload(
 	return openapi.CreateSpendingGroupOperationCreateSpendingGroup{}, fmt.Errorf("no spending group operation found")
 }
 
-func (c *defaultController) sendCreateSpendingGroupPush(
+func (c *alertsCollector) sendCreateSpendingGroupPush(
 	operation openapi.CreateSpendingGroupOperationCreateSpendingGroup,
 	usersToNotify []operationsRepository.UserId,
 ) error {
@@ -103,7 +102,7 @@ func (c *defaultController) sendCreateSpendingGroupPush(
 	)
 }
 
-func (c *defaultController) sendCreateSpendingPush(
+func (c *alertsCollector) sendCreateSpendingPush(
 	operation openapi.CreateSpendingOperationCreateSpending,
 	usersToNotify []operationsRepository.UserId,
 ) error {
@@ -117,37 +116,233 @@ func (c *defaultController) sendCreateSpendingPush(
 	if err != nil {
 		return fmt.Errorf("getting display names: %w", err)
 	}
+	shares := map[string]int64{}
+	for _, share := range operation.Shares {
+		shares[share.UserId] += share.Amount
+	}
+	shares, err = c.bindShareHolders(shares)
+	if err != nil {
+		return err
+	}
 	for _, user := range usersToNotify {
-		for _, share := range operation.Shares {
-			if share.UserId != string(user) {
-				continue
-			}
-			if err := c.sendPush(
-				string(openapi.NEW_SPENDING),
-				nil,
-				nil,
-				openapi.CreateSpendingPushPayload{
-					Cs: openapi.CreateSpendingPushPayloadCs{
-						Gid:  operation.GroupId,
-						Gn:   group.DisplayName,
-						Sid:  operation.SpendingId,
-						Sn:   operation.Name,
-						Pdns: displayNames,
-						C:    operation.Currency,
-						A:    operation.Amount,
-						U:    share.Amount,
-					},
+		share, isShareHolder := shares[string(user)]
+		if !isShareHolder {
+			continue
+		}
+		if err := c.sendPush(
+			string(openapi.NEW_SPENDING),
+			nil,
+			nil,
+			openapi.CreateSpendingPushPayload{
+				Cs: openapi.CreateSpendingPushPayloadCs{
+					Gid:  operation.GroupId,
+					Gn:   group.DisplayName,
+					Sid:  operation.SpendingId,
+					Sn:   operation.Name,
+					Pdns: displayNames,
+					C:    operation.Currency,
+					A:    operation.Amount,
+					U:    share,
 				},
-				[]operationsRepository.UserId{user},
-			); err != nil {
-				return fmt.Errorf("error sending push: %w", err)
-			}
+			},
+			[]operationsRepository.UserId{user},
+		); err != nil {
+			return fmt.Errorf("error sending push: %w", err)
 		}
 	}
 	return nil
 }
 
-func (c *defaultController) sendPush(
+// bindShareHolders moves amounts of sandbox users to registered users they are bound to,
+// shares keep identifiers of sandbox users after the binding
+func (c *defaultController) bindShareHolders(amounts map[string]int64) (map[string]int64, error) {
+	holders := make([]operationsRepository.UserId, 0, len(amounts))
+	for userId := range amounts {
+		holders = append(holders, operationsRepository.UserId(userId))
+	}
+	bound, err := operationsRepository.BoundUsers(c.operationsRepository, holders)
+	if err != nil {
+		return nil, fmt.Errorf("getting bound share holders: %w", err)
+	}
+	result := map[string]int64{}
+	for userId, amount := range amounts {
+		if boundId, isBound := bound[operationsRepository.UserId(userId)]; isBound {
+			userId = string(boundId)
+		}
+		result[userId] += amount
+	}
+	return result, nil
+}
+
+// sendGroupParticipantPush notifies about a participant who joined or left the group,
+// display names include the participant in both cases
+func (c *alertsCollector) sendGroupParticipantPush(
+	title openapi.PushTitle,
+	groupId string,
+	userId string,
+	usersToNotify []operationsRepository.UserId,
+) error {
+	if len(usersToNotify) == 0 {
+		return nil
+	}
+	group, err := c.getSpendingGroupPayload(groupId)
+	if err != nil {
+		return fmt.Errorf("getting spending group payload: %w", err)
+	}
+	participants, err := c.operationsRepository.GetUsers([]operationsRepository.TrackedEntity{
+		{Id: groupId, Type: operationsRepository.EntityTypeSpendingGroup},
+	})
+	if err != nil {
+		return fmt.Errorf("getting participants of group %s: %w", groupId, err)
+	}
+	if !slices.Contains(participants, operationsRepository.UserId(userId)) {
+		participants = append(participants, operationsRepository.UserId(userId))
+	}
+	displayNames, err := c.getDisplayNames(participants)
+	if err != nil {
+		return fmt.Errorf("getting display names: %w", err)
+	}
+	return c.sendPush(
+		string(title),
+		nil,
+		nil,
+		openapi.GroupParticipantPushPayload{
+			Gp: openapi.GroupParticipantPushPayloadGp{
+				Gid:  groupId,
+				Gn:   group.DisplayName,
+				Pdns: displayNames,
+				U:    userId,
+			},
+		},
+		usersToNotify,
+	)
+}
+
+// sendUpdateSpendingPush notifies share holders whose share has changed, every share holder
+// is notified if the spending itself has changed
+func (c *alertsCollector) sendUpdateSpendingPush(
+	change spendingChange,
+	usersToNotify []operationsRepository.UserId,
+) error {
+	affected, err := c.bindShareHolders(affectedShareHolders(change))
+	if err != nil {
+		return err
+	}
+	if len(affected) == 0 {
+		return nil
+	}
+	group, err := c.getSpendingGroupPayload(change.after.GroupId)
+	if err != nil {
+		return fmt.Errorf("getting spending group payload: %w", err)
+	}
+	displayNames, err := c.getDisplayNames(common.Map(group.Participants, func(id string) operationsRepository.UserId {
+		return operationsRepository.UserId(id)
+	}))
+	if err != nil {
+		return fmt.Errorf("getting display names: %w", err)
+	}
+	for _, user := range usersToNotify {
+		share, isAffected := affected[string(user)]
+		if !isAffected {
+			continue
+		}
+		if err := c.sendPush(
+			string(openapi.UPDATED_SPENDING),
+			nil,
+			nil,
+			openapi.UpdateSpendingPushPayload{
+				Us: openapi.UpdateSpendingPushPayloadUs{
+					Gid:  change.after.GroupId,
+					Gn:   group.DisplayName,
+					Sid:  change.after.SpendingId,
+					Sn:   change.after.Name,
+					Pdns: displayNames,
+					C:    change.after.Currency,
+					A:    change.after.Amount,
+					U:    share,
+				},
+			},
+			[]operationsRepository.UserId{user},
+		); err != nil {
+			return fmt.Errorf("error sending push: %w", err)
+		}
+	}
+	return nil
+}
+
+// affectedShareHolders returns share amounts after the change of users affected by it,
+// users that are no longer share holders get zero
+func affectedShareHolders(change spendingChange) map[string]int64 {
+	before := map[string]int64{}
+	for _, share := range change.before.Shares {
+		before[share.UserId] = share.Amount
+	}
+	after := map[string]int64{}
+	for _, share := range change.after.Shares {
+		after[share.UserId] = share.Amount
+	}
+	detailsChanged := change.before.Name != change.after.Name ||
+		change.before.Currency != change.after.Currency ||
+		change.before.Amount != change.after.Amount
+	affected := map[string]int64{}
+	for userId, amount := range after {
+		if previous, existed := before[userId]; detailsChanged || !existed || previous != amount {
+			affected[userId] = amount
+		}
+	}
+	for userId := range before {
+		if _, exists := after[userId]; !exists {
+			affected[userId] = 0
+		}
+	}
+	return affected
+}
+
+// sendSettlementPush notifies the recipient of the payment only
+func (c *alertsCollector) sendSettlementPush(
+	operation openapi.SettlementOperationSettlement,
+	usersToNotify []operationsRepository.UserId,
+) error {
+	to := operationsRepository.UserId(operation.To)
+	bound, err := operationsRepository.BoundUsers(c.operationsRepository, []operationsRepository.UserId{to})
+	if err != nil {
+		return fmt.Errorf("getting bound recipient: %w", err)
+	}
+	if boundId, isBound := bound[to]; isBound {
+		to = boundId
+	}
+	if !slices.Contains(usersToNotify, to) {
+		return nil
+	}
+	group, err := c.getSpendingGroupPayload(operation.GroupId)
+	if err != nil {
+		return fmt.Errorf("getting spending group payload: %w", err)
+	}
+	displayNames, err := c.getDisplayNames(common.Map(group.Participants, func(id string) operationsRepository.UserId {
+		return operationsRepository.UserId(id)
+	}))
+	if err != nil {
+		return fmt.Errorf("getting display names: %w", err)
+	}
+	return c.sendPush(
+		string(openapi.NEW_SETTLEMENT),
+		nil,
+		nil,
+		openapi.SettlementPushPayload{
+			St: openapi.SettlementPushPayloadSt{
+				Gid:  operation.GroupId,
+				Gn:   group.DisplayName,
+				Pdns: displayNames,
+				F:    operation.From,
+				C:    operation.Currency,
+				A:    operation.Amount,
+			},
+		},
+		[]operationsRepository.UserId{to},
+	)
+}
+
+func (c *alertsCollector) sendPush(
 	title string,
 	subtitle *string,
 	body *string,
@@ -161,10 +356,26 @@ func (c *defaultController) sendPush(
 	if err != nil {
 		return fmt.Errorf("getting push tokens: %w", err)
 	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encoding push payload: %w", err)
+	}
 	c.logger.LogInfo("sending push notification %v to tokens %v", payload, tokens)
 	for _, tokens := range tokens {
 		for _, token := range tokens {
-			c.pushNotifications.Alert(pushNotifications.Token(token), title, subtitle, body, payload)
+			notification, err := c.newNotification(notificationPayload{
+				Alert: &alertPayload{
+					Token:    token,
+					Title:    title,
+					Subtitle: subtitle,
+					Body:     body,
+					Data:     data,
+				},
+			})
+			if err != nil {
+				return err
+			}
+			c.alerts = append(c.alerts, notification)
 		}
 	}
 	return nil
@@ -0,0 +1,243 @@
+package defaultController
+
+import (
+	"fmt"
+	"slices"
+	"verni/internal/common"
+	"verni/internal/controllers/operations"
+	openapi "verni/internal/openapi/go"
+	operationsRepository "verni/internal/repositories/operations"
+	"verni/internal/services/formatValidation"
+)
+
+func (c *defaultController) validateOperation(operation openapi.SomeOperation, state *pushState) error {
+	payload := operationsRepository.OpenApiOperation{SomeOperation: operation}
+	switch payload.Type() {
+	case operationsRepository.BindUserOperationPayloadType:
+		return c.validateUserBinding(operation.BindUser, state)
+	case operationsRepository.CreateSpendingGroupOperationPayloadType:
+		return c.validateSpendingGroup(operation.CreateSpendingGroup, state)
+	case operationsRepository.AddGroupParticipantOperationPayloadType:
+		return c.validateParticipantAddition(operation.AddGroupParticipant, state)
+	case operationsRepository.RemoveGroupParticipantOperationPayloadType:
+		return c.validateParticipantRemoval(operation.RemoveGroupParticipant, state)
+	case operationsRepository.CreateSpendingOperationPayloadType:
+		return c.validateSpending(operation.CreateSpending, state)
+	case operationsRepository.UpdateSpendingOperationPayloadType:
+		return c.validateSpendingUpdate(operation, state)
+	case operationsRepository.DeleteSpendingOperationPayloadType:
+		return c.validateSpendingDeletion(operation.DeleteSpending, state)
+	case operationsRepository.SettlementOperationPayloadType:
+		return c.validateSettlement(operation.Settlement, state)
+	default:
+		return nil
+	}
+}
+
+// validateUserBinding loads what the sandbox user tracks and who watches it, a sandbox user is bound once
+func (c *defaultController) validateUserBinding(binding openapi.BindUserOperationBindUser, state *pushState) error {
+	if boundId, err := c.boundUser(binding.OldId, state); err != nil {
+		return err
+	} else if boundId != binding.OldId {
+		return fmt.Errorf("user %s is already bound to %s: %w", binding.OldId, boundId, operations.BadFormat)
+	}
+	tracked, err := c.operationsRepository.GetEntities(operationsRepository.UserId(binding.OldId))
+	if err != nil {
+		return fmt.Errorf("getting entities tracked by %s: %w", binding.OldId, err)
+	}
+	watchers, err := c.operationsRepository.GetUsers([]operationsRepository.TrackedEntity{
+		{Id: binding.OldId, Type: operationsRepository.EntityTypeUser},
+	})
+	if err != nil {
+		return fmt.Errorf("getting watchers of %s: %w", binding.OldId, err)
+	}
+	sandbox := sandboxUser{
+		tracked: tracked,
+		watchers: common.Map(watchers, func(watcher operationsRepository.UserId) string {
+			return string(watcher)
+		}),
+	}
+	// groups joined earlier in the same push are not in the repository yet
+	for groupId, participants := range state.groupParticipants {
+		if !slices.Contains(participants, binding.OldId) {
+			continue
+		}
+		group := operationsRepository.TrackedEntity{Id: groupId, Type: operationsRepository.EntityTypeSpendingGroup}
+		if !slices.Contains(sandbox.tracked, group) {
+			sandbox.tracked = append(sandbox.tracked, group)
+		}
+		for _, participant := range participants {
+			if !slices.Contains(sandbox.watchers, participant) {
+				sandbox.watchers = append(sandbox.watchers, participant)
+			}
+		}
+	}
+	state.sandboxUsers[binding.OldId] = sandbox
+	return nil
+}
+
+func (c *defaultController) validateSpendingGroup(group openapi.CreateSpendingGroupOperationCreateSpendingGroup, state *pushState) error {
+	participants := map[string]struct{}{}
+	for _, participant := range group.Participants {
+		if _, duplicate := participants[participant]; duplicate {
+			return fmt.Errorf("participant %s is listed twice: %w", participant, operations.BadFormat)
+		}
+		participants[participant] = struct{}{}
+		if _, exists, err := c.userOwner(participant, state); err != nil {
+			return err
+		} else if !exists {
+			return fmt.Errorf("participant %s does not exist: %w", participant, operations.BadFormat)
+		}
+	}
+	return nil
+}
+
+func (c *defaultController) validateParticipantAddition(addition openapi.AddGroupParticipantOperationAddGroupParticipant, state *pushState) error {
+	if err := c.checkGroupActive(addition.GroupId, state); err != nil {
+		return err
+	}
+	if _, exists, err := c.userOwner(addition.UserId, state); err != nil {
+		return err
+	} else if !exists {
+		return fmt.Errorf("participant %s does not exist: %w", addition.UserId, operations.BadFormat)
+	}
+	if boundId, err := c.boundUser(addition.UserId, state); err != nil {
+		return err
+	} else if boundId != addition.UserId {
+		return fmt.Errorf("participant %s is bound to %s: %w", addition.UserId, boundId, operations.BadFormat)
+	}
+	participants, _, err := c.groupParticipants(addition.GroupId, state)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(participants, addition.UserId) {
+		return fmt.Errorf("%s is already a participant of group %s: %w", addition.UserId, addition.GroupId, operations.BadFormat)
+	}
+	return nil
+}
+
+// validateParticipantRemoval keeps at least one participant, groups without participants are deleted instead
+func (c *defaultController) validateParticipantRemoval(removal openapi.RemoveGroupParticipantOperationRemoveGroupParticipant, state *pushState) error {
+	if err := c.checkGroupActive(removal.GroupId, state); err != nil {
+		return err
+	}
+	participants, _, err := c.groupParticipants(removal.GroupId, state)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(participants, removal.UserId) {
+		return fmt.Errorf("%s is not a participant of group %s: %w", removal.UserId, removal.GroupId, operations.BadFormat)
+	}
+	if len(participants) == 1 {
+		return fmt.Errorf("%s is the last participant of group %s: %w", removal.UserId, removal.GroupId, operations.BadFormat)
+	}
+	return nil
+}
+
+func (c *defaultController) validateSpending(spending openapi.CreateSpendingOperationCreateSpending, state *pushState) error {
+	if err := c.formatValidation.ValidateCurrencyFormat(spending.Currency); err != nil {
+		return fmt.Errorf("%v: %w", err, operations.BadFormat)
+	}
+	if err := c.formatValidation.ValidateSharesFormat(
+		spending.Amount,
+		common.Map(spending.Shares, func(share openapi.SpendingShare) formatValidation.Share {
+			return formatValidation.Share{
+				UserId: share.UserId,
+				Amount: share.Amount,
+			}
+		}),
+	); err != nil {
+		return fmt.Errorf("%v: %w", err, operations.BadFormat)
+	}
+	if err := c.checkGroupActive(spending.GroupId, state); err != nil {
+		return err
+	}
+	participants, _, err := c.groupParticipants(spending.GroupId, state)
+	if err != nil {
+		return err
+	}
+	for _, share := range spending.Shares {
+		// shares of sandbox users are kept after they are bound to registered participants
+		holder, err := c.boundUser(share.UserId, state)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(participants, holder) {
+			return fmt.Errorf("%s is not a participant of group %s: %w", share.UserId, spending.GroupId, operations.BadFormat)
+		}
+	}
+	return nil
+}
+
+// validateSpendingUpdate checks the spending as it would be after the update is merged
+func (c *defaultController) validateSpendingUpdate(operation openapi.SomeOperation, state *pushState) error {
+	update := operation.UpdateSpending
+	if update.Name == "" && update.Currency == "" && update.Amount == 0 && len(update.Shares) == 0 {
+		return fmt.Errorf("update of spending %s changes nothing: %w", update.SpendingId, operations.BadFormat)
+	}
+	if update.Currency != "" {
+		if err := c.formatValidation.ValidateCurrencyFormat(update.Currency); err != nil {
+			return fmt.Errorf("%v: %w", err, operations.BadFormat)
+		}
+	}
+	if err := c.checkGroupActive(update.GroupId, state); err != nil {
+		return err
+	}
+	spending, err := c.spending(update.GroupId, update.SpendingId, state)
+	if err != nil {
+		return err
+	}
+	if spending == nil || spending.spending.GroupId != update.GroupId {
+		return fmt.Errorf("spending %s does not exist in group %s: %w", update.SpendingId, update.GroupId, operations.BadFormat)
+	}
+	if spending.deleted {
+		return fmt.Errorf("spending %s is deleted: %w", update.SpendingId, operations.BadFormat)
+	}
+	merged := *spending
+	merged.merge(operation)
+	return c.validateSpending(merged.spending, state)
+}
+
+// validateSpendingDeletion accepts only spendings created in the group, settlements are projected as spendings
+// with their operation id but are never deleted
+func (c *defaultController) validateSpendingDeletion(deletion openapi.DeleteSpendingOperationDeleteSpending, state *pushState) error {
+	if err := c.checkGroupActive(deletion.GroupId, state); err != nil {
+		return err
+	}
+	spending, err := c.spending(deletion.GroupId, deletion.SpendingId, state)
+	if err != nil {
+		return err
+	}
+	if spending == nil || spending.spending.GroupId != deletion.GroupId {
+		return fmt.Errorf("spending %s does not exist in group %s: %w", deletion.SpendingId, deletion.GroupId, operations.BadFormat)
+	}
+	return nil
+}
+
+// validateSettlement checks a settlement the same way as a spending where the payer is owed the amount
+func (c *defaultController) validateSettlement(settlement openapi.SettlementOperationSettlement, state *pushState) error {
+	return c.validateSpending(openapi.CreateSpendingOperationCreateSpending{
+		GroupId:  settlement.GroupId,
+		Currency: settlement.Currency,
+		Amount:   settlement.Amount,
+		Shares: []openapi.SpendingShare{
+			{UserId: settlement.From, Amount: settlement.Amount},
+			{UserId: settlement.To, Amount: -settlement.Amount},
+		},
+	}, state)
+}
+
+// checkGroupActive fails for groups that were never created or are already deleted
+func (c *defaultController) checkGroupActive(groupId string, state *pushState) error {
+	if _, exists, err := c.groupParticipants(groupId, state); err != nil {
+		return err
+	} else if !exists {
+		return fmt.Errorf("group %s does not exist: %w", groupId, operations.BadFormat)
+	}
+	if deleted, err := c.isGroupDeleted(groupId, state); err != nil {
+		return err
+	} else if deleted {
+		return fmt.Errorf("group %s is deleted: %w", groupId, operations.BadFormat)
+	}
+	return nil
+}
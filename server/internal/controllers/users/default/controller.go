@@ -54,6 +54,22 @@ func (c *defaultController) Search(query string) ([]openapi.SomeOperation, error
 	for _, operation := range updateDisplayNameOperations {
 		displayNames[UserId(operation.UserId)] = operation.DisplayName
 	}
+	matched := make([]operationsRepository.UserId, 0, len(displayNames))
+	for userId := range displayNames {
+		matched = append(matched, operationsRepository.UserId(userId))
+	}
+	// sandbox users bound to registered ones are found by their former display names
+	// but represented by the registered user
+	boundUsers, err := operationsRepository.BoundUsers(c.operationsRepository, matched)
+	if err != nil {
+		err := fmt.Errorf("getting bound users: %w", err)
+		c.logger.LogInfo("%s: %v", op, err)
+		return []openapi.SomeOperation{}, err
+	}
+	for oldId, newId := range boundUsers {
+		delete(displayNames, UserId(oldId))
+		displayNames[UserId(newId)] = ""
+	}
 	entities := make([]operationsRepository.TrackedEntity, 0, len(displayNames))
 	for userId := range displayNames {
 		entities = append(
@@ -173,6 +173,67 @@ func TestController_Search(t *testing.T) {
 		assert.Equal(t, updateDisplayNameOp.UpdateDisplayName.DisplayName, result[0].UpdateDisplayName.DisplayName)
 	})
 
+	t.Run("bound sandbox user resolves to registered user", func(t *testing.T) {
+		// Arrange
+		operation := func(operation openapi.SomeOperation, payloadType operations.OperationPayloadType) operations.Operation {
+			data, err := json.Marshal(operation)
+			require.NoError(t, err)
+			return operations.Operation{
+				OperationId: operations.OperationId(operation.OperationId),
+				Payload: mockOperationPayload{
+					typeImpl: payloadType,
+					dataImpl: func() ([]byte, error) {
+						return data, nil
+					},
+				},
+			}
+		}
+		createSandbox := operation(openapi.SomeOperation{
+			OperationId: "create-sandbox",
+			CreateUser:  openapi.CreateUserOperationCreateUser{UserId: "sandbox", DisplayName: "Test User"},
+		}, operations.CreateUserOperationPayloadType)
+		bindSandbox := operation(openapi.SomeOperation{
+			OperationId: "bind-sandbox",
+			BindUser:    openapi.BindUserOperationBindUser{OldId: "sandbox", NewId: "registered"},
+		}, operations.BindUserOperationPayloadType)
+		createRegistered := operation(openapi.SomeOperation{
+			OperationId: "create-registered",
+			CreateUser:  openapi.CreateUserOperationCreateUser{UserId: "registered", DisplayName: "Registered"},
+		}, operations.CreateUserOperationPayloadType)
+
+		opsRepo := &operationsRepository_mock.RepositoryMock{
+			SearchImpl: func(payloadType operations.OperationPayloadType, hint string) ([]operations.Operation, error) {
+				if payloadType == operations.CreateUserOperationPayloadType {
+					return []operations.Operation{createSandbox}, nil
+				}
+				return []operations.Operation{}, nil
+			},
+			GetImpl: func(entities []operations.TrackedEntity) ([]operations.Operation, error) {
+				switch entities[0].Id {
+				case "sandbox":
+					return []operations.Operation{createSandbox, bindSandbox}, nil
+				case "registered":
+					return []operations.Operation{createRegistered, bindSandbox}, nil
+				default:
+					return []operations.Operation{}, nil
+				}
+			},
+		}
+
+		controller := defaultController.New(opsRepo, logger)
+
+		// Act
+		result, err := controller.Search("Test")
+
+		// Assert
+		assert.NoError(t, err)
+		ids := []string{}
+		for _, operation := range result {
+			ids = append(ids, operation.OperationId)
+		}
+		assert.ElementsMatch(t, []string{"create-registered", "bind-sandbox"}, ids)
+	})
+
 	t.Run("search repository error", func(t *testing.T) {
 		// Arrange
 		opsRepo := &operationsRepository_mock.RepositoryMock{
@@ -0,0 +1,48 @@
+package migrations
+
+import "errors"
+
+type Version int
+
+type Migration struct {
+	Version Version
+	Name    string
+	Up      string
+	Down    string
+}
+
+type AppliedMigration struct {
+	Version   Version
+	Name      string
+	AppliedAt int64
+}
+
+type Status struct {
+	Current Version
+	Latest  Version
+	Applied []AppliedMigration
+	Pending []Migration
+}
+
+var (
+	ErrOutdatedSchema = errors.New("database schema is out of date")
+	ErrUnknownVersion = errors.New("database schema has migrations unknown to this build")
+)
+
+type Migrator interface {
+	// Migrate applies every pending migration in version order, each one in its own transaction.
+	Migrate() error
+	// Rollback reverts applied migrations with a version greater than `to`, newest first.
+	Rollback(to Version) error
+	Status() (Status, error)
+	// Check fails with ErrOutdatedSchema or ErrUnknownVersion unless the database is exactly at Latest().
+	Check() error
+}
+
+func Latest() Version {
+	all := Migrations()
+	if len(all) == 0 {
+		return 0
+	}
+	return all[len(all)-1].Version
+}
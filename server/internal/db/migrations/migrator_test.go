@@ -0,0 +1,28 @@
+package migrations_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"verni/internal/db/migrations"
+)
+
+func TestMigrations_Ordered(t *testing.T) {
+	all := migrations.Migrations()
+	assert.NotEmpty(t, all)
+
+	names := map[string]struct{}{}
+	for i, migration := range all {
+		assert.Equal(t, migrations.Version(i+1), migration.Version, "versions should be sequential starting from 1")
+		assert.NotEmpty(t, strings.TrimSpace(migration.Name))
+		assert.NotEmpty(t, strings.TrimSpace(migration.Up), "migration %d has no up script", migration.Version)
+		assert.NotEmpty(t, strings.TrimSpace(migration.Down), "migration %d has no down script", migration.Version)
+
+		_, duplicate := names[migration.Name]
+		assert.False(t, duplicate, "migration name %s is used twice", migration.Name)
+		names[migration.Name] = struct{}{}
+	}
+	assert.Equal(t, all[len(all)-1].Version, migrations.Latest())
+}
@@ -0,0 +1,239 @@
+package postgresMigrator
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"verni/internal/db"
+	"verni/internal/db/migrations"
+	"verni/internal/services/logging"
+)
+
+// arbitrary application-wide key for pg_advisory_xact_lock, serializes concurrent migrators
+const migrationsLockKey = 4206931
+
+func New(db db.DB, logger logging.Service) migrations.Migrator {
+	return &postgresMigrator{
+		db:         db,
+		migrations: migrations.Migrations(),
+		logger:     logger,
+	}
+}
+
+type postgresMigrator struct {
+	db         db.DB
+	migrations []migrations.Migration
+	logger     logging.Service
+}
+
+func (c *postgresMigrator) Migrate() error {
+	const op = "db.migrations.postgresMigrator.Migrate"
+	c.logger.LogInfo("%s: start", op)
+
+	if err := c.createMigrationsTable(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	for _, migration := range c.migrations {
+		if err := c.apply(migration); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	c.logger.LogInfo("%s: success", op)
+	return nil
+}
+
+func (c *postgresMigrator) apply(migration migrations.Migration) (err error) {
+	const op = "db.migrations.postgresMigrator.apply"
+
+	tx, err := c.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	if _, err = tx.Exec(`SELECT pg_advisory_xact_lock($1);`, migrationsLockKey); err != nil {
+		return fmt.Errorf("%s: failed to acquire migrations lock: %w", op, err)
+	}
+	var applied bool
+	if err = tx.QueryRow(
+		`SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = $1);`,
+		migration.Version,
+	).Scan(&applied); err != nil {
+		return fmt.Errorf("%s: failed to check migration %d: %w", op, migration.Version, err)
+	}
+	if applied {
+		return nil
+	}
+
+	c.logger.LogInfo("%s: applying %d_%s", op, migration.Version, migration.Name)
+	if _, err = tx.Exec(migration.Up); err != nil {
+		return fmt.Errorf("%s: failed to apply migration %d_%s: %w", op, migration.Version, migration.Name, err)
+	}
+	if _, err = tx.Exec(
+		`INSERT INTO schema_migrations(version, name, appliedAt) VALUES ($1, $2, $3);`,
+		migration.Version,
+		migration.Name,
+		time.Now().UnixMilli(),
+	); err != nil {
+		return fmt.Errorf("%s: failed to record migration %d: %w", op, migration.Version, err)
+	}
+	return nil
+}
+
+func (c *postgresMigrator) Rollback(to migrations.Version) error {
+	const op = "db.migrations.postgresMigrator.Rollback"
+	c.logger.LogInfo("%s: start[to=%d]", op, to)
+
+	if err := c.createMigrationsTable(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	applied, err := c.applied()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	for i := len(applied) - 1; i >= 0; i-- {
+		if applied[i].Version <= to {
+			break
+		}
+		index := slices.IndexFunc(c.migrations, func(migration migrations.Migration) bool {
+			return migration.Version == applied[i].Version
+		})
+		if index < 0 {
+			return fmt.Errorf("%s: no down script for version %d: %w", op, applied[i].Version, migrations.ErrUnknownVersion)
+		}
+		if err := c.revert(c.migrations[index]); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	c.logger.LogInfo("%s: success[to=%d]", op, to)
+	return nil
+}
+
+func (c *postgresMigrator) revert(migration migrations.Migration) (err error) {
+	const op = "db.migrations.postgresMigrator.revert"
+
+	tx, err := c.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	if _, err = tx.Exec(`SELECT pg_advisory_xact_lock($1);`, migrationsLockKey); err != nil {
+		return fmt.Errorf("%s: failed to acquire migrations lock: %w", op, err)
+	}
+	result, err := tx.Exec(`DELETE FROM schema_migrations WHERE version = $1;`, migration.Version)
+	if err != nil {
+		return fmt.Errorf("%s: failed to unrecord migration %d: %w", op, migration.Version, err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return nil
+	}
+
+	c.logger.LogInfo("%s: reverting %d_%s", op, migration.Version, migration.Name)
+	if _, err = tx.Exec(migration.Down); err != nil {
+		return fmt.Errorf("%s: failed to revert migration %d_%s: %w", op, migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+func (c *postgresMigrator) Status() (migrations.Status, error) {
+	const op = "db.migrations.postgresMigrator.Status"
+	c.logger.LogInfo("%s: start", op)
+
+	applied, err := c.applied()
+	if err != nil {
+		return migrations.Status{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	status := migrations.Status{
+		Applied: applied,
+		Latest:  migrations.Latest(),
+	}
+	appliedVersions := map[migrations.Version]struct{}{}
+	for _, migration := range applied {
+		appliedVersions[migration.Version] = struct{}{}
+		status.Current = max(status.Current, migration.Version)
+	}
+	for _, migration := range c.migrations {
+		if _, found := appliedVersions[migration.Version]; !found {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+
+	c.logger.LogInfo("%s: success[current=%d latest=%d]", op, status.Current, status.Latest)
+	return status, nil
+}
+
+func (c *postgresMigrator) Check() error {
+	const op = "db.migrations.postgresMigrator.Check"
+
+	status, err := c.Status()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if status.Current > status.Latest {
+		return fmt.Errorf("%s: database is at version %d, latest known is %d: %w", op, status.Current, status.Latest, migrations.ErrUnknownVersion)
+	}
+	if len(status.Pending) > 0 {
+		return fmt.Errorf("%s: %d pending migrations, database is at version %d, latest is %d: %w", op, len(status.Pending), status.Current, status.Latest, migrations.ErrOutdatedSchema)
+	}
+	return nil
+}
+
+func (c *postgresMigrator) createMigrationsTable() error {
+	query := `
+CREATE TABLE IF NOT EXISTS schema_migrations(
+	version integer NOT NULL PRIMARY KEY,
+	name text NOT NULL,
+	appliedAt bigint NOT NULL
+);`
+	if _, err := c.db.Exec(query); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+func (c *postgresMigrator) applied() ([]migrations.AppliedMigration, error) {
+	var exists bool
+	if err := c.db.QueryRow(`SELECT to_regclass('schema_migrations') IS NOT NULL;`).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to check schema_migrations table: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	rows, err := c.db.Query(`SELECT version, name, appliedAt FROM schema_migrations ORDER BY version;`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	var result []migrations.AppliedMigration
+	for rows.Next() {
+		var migration migrations.AppliedMigration
+		if err := rows.Scan(&migration.Version, &migration.Name, &migration.AppliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		result = append(result, migration)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error occurred during row iteration: %w", err)
+	}
+	return result, nil
+}
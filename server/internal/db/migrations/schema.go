@@ -0,0 +1,211 @@
+package migrations
+
+// Migrations returns the full ordered history of the schema. Entries are never edited
+// or removed once released, schema changes are made by appending a new version.
+func Migrations() []Migration {
+	return []Migration{
+		{
+			// Tables are created with IF NOT EXISTS so databases bootstrapped by the
+			// former create-tables command get baselined instead of failing.
+			Version: 1,
+			Name:    "initial_schema",
+			Up: `
+CREATE TABLE IF NOT EXISTS credentials(
+	userId text NOT NULL PRIMARY KEY,
+	email text NOT NULL,
+	password text NOT NULL,
+	emailVerified bool NOT NULL
+);
+CREATE TABLE IF NOT EXISTS refreshTokens(
+	userId text NOT NULL,
+	deviceId text NOT NULL,
+	refreshToken text NOT NULL,
+	PRIMARY KEY(userId, deviceId)
+);
+CREATE TABLE IF NOT EXISTS operations(
+	operationId text NOT NULL PRIMARY KEY,
+	createdAt bigint NOT NULL,
+	authorId text NOT NULL,
+	operationType text NOT NULL,
+	isLarge text NOT NULL,
+	data BYTEA NOT NULL,
+	searchHint text
+);
+CREATE TABLE IF NOT EXISTS confirmedOperations(
+	userId text NOT NULL,
+	deviceId text NOT NULL,
+	operationId text NOT NULL,
+	PRIMARY KEY(userId, deviceId, operationId)
+);
+CREATE TABLE IF NOT EXISTS trackedEntities(
+	userId text NOT NULL,
+	entityId text NOT NULL,
+	entityType text NOT NULL,
+	operationId text NOT NULL,
+	PRIMARY KEY(userId, entityId, entityType, operationId)
+);
+CREATE TABLE IF NOT EXISTS operationsAffectingEntity(
+	operationId text NOT NULL,
+	entityId text NOT NULL,
+	entityType text NOT NULL,
+	PRIMARY KEY(operationId, entityId, entityType)
+);
+CREATE TABLE IF NOT EXISTS images(
+	id text NOT NULL PRIMARY KEY,
+	base64 text NOT NULL
+);
+CREATE TABLE IF NOT EXISTS pushTokens(
+	userId text NOT NULL,
+	deviceId text NOT NULL,
+	token text NOT NULL,
+	PRIMARY KEY(userId, deviceId)
+);
+CREATE TABLE IF NOT EXISTS emailVerification(
+	email text NOT NULL PRIMARY KEY,
+	code text
+);`,
+			Down: `
+DROP TABLE IF EXISTS emailVerification;
+DROP TABLE IF EXISTS pushTokens;
+DROP TABLE IF EXISTS images;
+DROP TABLE IF EXISTS operationsAffectingEntity;
+DROP TABLE IF EXISTS trackedEntities;
+DROP TABLE IF EXISTS confirmedOperations;
+DROP TABLE IF EXISTS operations;
+DROP TABLE IF EXISTS refreshTokens;
+DROP TABLE IF EXISTS credentials;`,
+		},
+		{
+			// existing rows are numbered in physical order, new rows in insertion order
+			Version: 2,
+			Name:    "operations_sequence_number",
+			Up: `
+ALTER TABLE operations ADD COLUMN sequenceNumber BIGSERIAL NOT NULL;
+CREATE UNIQUE INDEX operations_sequenceNumber_idx ON operations(sequenceNumber);
+CREATE INDEX trackedEntities_userId_idx ON trackedEntities(userId);`,
+			Down: `
+DROP INDEX IF EXISTS trackedEntities_userId_idx;
+DROP INDEX IF EXISTS operations_sequenceNumber_idx;
+ALTER TABLE operations DROP COLUMN IF EXISTS sequenceNumber;`,
+		},
+		{
+			// tables are empty after migration, fill them with the rebuild-balances utility command
+			Version: 3,
+			Name:    "balances_projection",
+			Up: `
+CREATE TABLE spendings(
+	spendingId text NOT NULL PRIMARY KEY,
+	groupId text NOT NULL,
+	currency text NOT NULL,
+	removed bool NOT NULL
+);
+CREATE INDEX spendings_groupId_idx ON spendings(groupId);
+CREATE TABLE spendingShares(
+	spendingId text NOT NULL,
+	userId text NOT NULL,
+	amount bigint NOT NULL,
+	PRIMARY KEY(spendingId, userId)
+);
+CREATE TABLE groupBalances(
+	groupId text NOT NULL,
+	userId text NOT NULL,
+	currency text NOT NULL,
+	amount bigint NOT NULL,
+	PRIMARY KEY(groupId, userId, currency)
+);
+CREATE INDEX groupBalances_userId_idx ON groupBalances(userId);
+CREATE TABLE counterpartyBalances(
+	userId text NOT NULL,
+	counterpartyId text NOT NULL,
+	currency text NOT NULL,
+	amount bigint NOT NULL,
+	PRIMARY KEY(userId, counterpartyId, currency)
+);`,
+			Down: `
+DROP TABLE IF EXISTS counterpartyBalances;
+DROP TABLE IF EXISTS groupBalances;
+DROP TABLE IF EXISTS spendingShares;
+DROP TABLE IF EXISTS spendings;`,
+		},
+		{
+			// every field of a spending keeps the version of its latest update, existing
+			// rows are versioned as the oldest possible change until balances are rebuilt
+			Version: 4,
+			Name:    "spendings_field_versions",
+			Up: `
+ALTER TABLE spendings ADD COLUMN currencyCreatedAt bigint NOT NULL DEFAULT 0;
+ALTER TABLE spendings ADD COLUMN currencyOperationId text NOT NULL DEFAULT '';
+ALTER TABLE spendings ADD COLUMN sharesCreatedAt bigint NOT NULL DEFAULT 0;
+ALTER TABLE spendings ADD COLUMN sharesOperationId text NOT NULL DEFAULT '';`,
+			Down: `
+ALTER TABLE spendings DROP COLUMN IF EXISTS sharesOperationId;
+ALTER TABLE spendings DROP COLUMN IF EXISTS sharesCreatedAt;
+ALTER TABLE spendings DROP COLUMN IF EXISTS currencyOperationId;
+ALTER TABLE spendings DROP COLUMN IF EXISTS currencyCreatedAt;`,
+		},
+		{
+			Version: 5,
+			Name:    "group_invitations",
+			Up: `
+CREATE TABLE invitations(
+	token text NOT NULL PRIMARY KEY,
+	groupId text NOT NULL,
+	createdBy text NOT NULL,
+	expiresAt bigint NOT NULL,
+	maxUses int NOT NULL,
+	uses int NOT NULL
+);`,
+			Down: `
+DROP TABLE IF EXISTS invitations;`,
+		},
+		{
+			// devices known before the migration get an unknown last seen moment
+			Version: 6,
+			Name:    "device_lifecycle",
+			Up: `
+CREATE TABLE devices(
+	userId text NOT NULL,
+	deviceId text NOT NULL,
+	lastSeen bigint NOT NULL,
+	revokedAt bigint,
+	PRIMARY KEY(userId, deviceId)
+);
+INSERT INTO devices(userId, deviceId, lastSeen)
+SELECT userId, deviceId, 0 FROM refreshTokens
+UNION SELECT userId, deviceId, 0 FROM confirmedOperations
+UNION SELECT userId, deviceId, 0 FROM pushTokens;`,
+			Down: `
+DROP TABLE IF EXISTS devices;`,
+		},
+		{
+			// image bytes live in the image storage, the table was never written to
+			Version: 7,
+			Name:    "drop_images",
+			Up: `
+DROP TABLE IF EXISTS images;`,
+			Down: `
+CREATE TABLE IF NOT EXISTS images(
+	id text NOT NULL PRIMARY KEY,
+	base64 text NOT NULL
+);`,
+		},
+		{
+			// notifications about pushed operations are written with them and delivered by a relay
+			Version: 8,
+			Name:    "notifications_outbox",
+			Up: `
+CREATE TABLE notificationsOutbox(
+	id text NOT NULL PRIMARY KEY,
+	createdAt bigint NOT NULL,
+	payload BYTEA NOT NULL,
+	state text NOT NULL,
+	attempts int NOT NULL,
+	nextAttemptAt bigint NOT NULL,
+	lastError text NOT NULL
+);
+CREATE INDEX notificationsOutbox_state_nextAttemptAt_idx ON notificationsOutbox(state, nextAttemptAt);`,
+			Down: `
+DROP TABLE IF EXISTS notificationsOutbox;`,
+		},
+	}
+}
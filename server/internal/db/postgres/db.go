@@ -18,9 +18,10 @@ type PostgresConfig struct {
 	DbName   string `json:"dbName"`
 }
 
-func Postgres(config PostgresConfig, logger logging.Service) (db.DB, error) {
-	const op = "repositories.friends.PostgresRepository"
-	psqlConnection := fmt.Sprintf(
+// ConnectionString is the libpq connection string of the database, connections that can not be taken from
+// the pool like LISTEN ones are opened with it
+func (config PostgresConfig) ConnectionString() string {
+	return fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
 		config.Host,
 		config.Port,
@@ -28,7 +29,11 @@ func Postgres(config PostgresConfig, logger logging.Service) (db.DB, error) {
 		config.Password,
 		config.DbName,
 	)
-	db, err := sql.Open("postgres", psqlConnection)
+}
+
+func Postgres(config PostgresConfig, logger logging.Service) (db.DB, error) {
+	const op = "repositories.friends.PostgresRepository"
+	db, err := sql.Open("postgres", config.ConnectionString())
 	if err != nil {
 		logger.LogInfo("%s: open db failed err: %v", op, err)
 		return nil, err
@@ -32,6 +32,10 @@ type DefaultAPIRouter interface {
 	PullOperations(http.ResponseWriter, *http.Request)
 	PushOperations(http.ResponseWriter, *http.Request)
 	ConfirmOperations(http.ResponseWriter, *http.Request)
+	GetBalances(http.ResponseWriter, *http.Request)
+	GetSettlement(http.ResponseWriter, *http.Request)
+	CreateInvitation(http.ResponseWriter, *http.Request)
+	RedeemInvitation(http.ResponseWriter, *http.Request)
 }
 
 // DefaultAPIServicer defines the api actions for the DefaultAPI service
@@ -45,11 +49,15 @@ type DefaultAPIServicer interface {
 	UpdateEmail(context.Context, string, UpdateEmailRequest) (ImplResponse, error)
 	UpdatePassword(context.Context, string, UpdatePasswordRequest) (ImplResponse, error)
 	RegisterForPushNotifications(context.Context, string, RegisterForPushNotificationsRequest) (ImplResponse, error)
-	GetAvatars(context.Context, string, []string) (ImplResponse, error)
+	GetAvatars(context.Context, string, []string, int32) (ImplResponse, error)
 	SearchUsers(context.Context, string, string) (ImplResponse, error)
 	ConfirmEmail(context.Context, string, ConfirmEmailRequest) (ImplResponse, error)
 	SendEmailConfirmationCode(context.Context, string) (ImplResponse, error)
-	PullOperations(context.Context, string, OperationType) (ImplResponse, error)
+	PullOperations(context.Context, string, OperationType, string, int32, string) (ImplResponse, error)
 	PushOperations(context.Context, string, PushOperationsRequest) (ImplResponse, error)
 	ConfirmOperations(context.Context, string, ConfirmOperationsRequest) (ImplResponse, error)
+	GetBalances(context.Context, string) (ImplResponse, error)
+	GetSettlement(context.Context, string, string) (ImplResponse, error)
+	CreateInvitation(context.Context, string, CreateInvitationRequest) (ImplResponse, error)
+	RedeemInvitation(context.Context, string, RedeemInvitationRequest) (ImplResponse, error)
 }
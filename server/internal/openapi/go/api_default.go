@@ -114,6 +114,26 @@ func (c *DefaultAPIController) Routes() Routes {
 			"/operations/confirm",
 			c.ConfirmOperations,
 		},
+		"GetBalances": Route{
+			strings.ToUpper("Get"),
+			"/spendings/balances",
+			c.GetBalances,
+		},
+		"GetSettlement": Route{
+			strings.ToUpper("Get"),
+			"/spendings/settlement",
+			c.GetSettlement,
+		},
+		"CreateInvitation": Route{
+			strings.ToUpper("Post"),
+			"/spendings/invitations/create",
+			c.CreateInvitation,
+		},
+		"RedeemInvitation": Route{
+			strings.ToUpper("Post"),
+			"/spendings/invitations/redeem",
+			c.RedeemInvitation,
+		},
 	}
 }
 
@@ -296,7 +316,22 @@ func (c *DefaultAPIController) GetAvatars(w http.ResponseWriter, r *http.Request
 	if query.Has("ids") {
 		idsParam = strings.Split(query.Get("ids"), ",")
 	}
-	result, err := c.service.GetAvatars(r.Context(), authorizationParam, idsParam)
+	var sizeParam int32
+	if query.Has("size") {
+		param, err := parseNumericParameter[int32](
+			query.Get("size"),
+			WithParse[int32](parseInt32),
+			WithMinimum[int32](1),
+		)
+		if err != nil {
+			c.errorHandler(w, r, &ParsingError{Param: "size", Err: err}, nil)
+			return
+		}
+
+		sizeParam = param
+	} else {
+	}
+	result, err := c.service.GetAvatars(r.Context(), authorizationParam, idsParam, sizeParam)
 	// If an error occurred, encode the error with the status code
 	if err != nil {
 		c.errorHandler(w, r, err, &result)
@@ -391,7 +426,37 @@ func (c *DefaultAPIController) PullOperations(w http.ResponseWriter, r *http.Req
 		c.errorHandler(w, r, &RequiredError{Field: "type"}, nil)
 		return
 	}
-	result, err := c.service.PullOperations(r.Context(), authorizationParam, type_Param)
+	var sinceParam string
+	if query.Has("since") {
+		param := query.Get("since")
+
+		sinceParam = param
+	} else {
+	}
+	var limitParam int32
+	if query.Has("limit") {
+		param, err := parseNumericParameter[int32](
+			query.Get("limit"),
+			WithParse[int32](parseInt32),
+			WithMinimum[int32](1),
+			WithMaximum[int32](1000),
+		)
+		if err != nil {
+			c.errorHandler(w, r, &ParsingError{Param: "limit", Err: err}, nil)
+			return
+		}
+
+		limitParam = param
+	} else {
+	}
+	var pageTokenParam string
+	if query.Has("pageToken") {
+		param := query.Get("pageToken")
+
+		pageTokenParam = param
+	} else {
+	}
+	result, err := c.service.PullOperations(r.Context(), authorizationParam, type_Param, sinceParam, limitParam, pageTokenParam)
 	// If an error occurred, encode the error with the status code
 	if err != nil {
 		c.errorHandler(w, r, err, &result)
@@ -456,3 +521,99 @@ func (c *DefaultAPIController) ConfirmOperations(w http.ResponseWriter, r *http.
 	// If no error, encode the body and the result code
 	_ = EncodeJSONResponse(result.Body, &result.Code, w)
 }
+
+// GetBalances -
+func (c *DefaultAPIController) GetBalances(w http.ResponseWriter, r *http.Request) {
+	authorizationParam := r.Header.Get("Authorization")
+	result, err := c.service.GetBalances(r.Context(), authorizationParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	_ = EncodeJSONResponse(result.Body, &result.Code, w)
+}
+
+// GetSettlement -
+func (c *DefaultAPIController) GetSettlement(w http.ResponseWriter, r *http.Request) {
+	query, err := parseQuery(r.URL.RawQuery)
+	if err != nil {
+		c.errorHandler(w, r, &ParsingError{Err: err}, nil)
+		return
+	}
+	authorizationParam := r.Header.Get("Authorization")
+	var groupIdParam string
+	if query.Has("groupId") {
+		param := query.Get("groupId")
+
+		groupIdParam = param
+	} else {
+		c.errorHandler(w, r, &RequiredError{Field: "groupId"}, nil)
+		return
+	}
+	result, err := c.service.GetSettlement(r.Context(), authorizationParam, groupIdParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	_ = EncodeJSONResponse(result.Body, &result.Code, w)
+}
+
+// CreateInvitation -
+func (c *DefaultAPIController) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	authorizationParam := r.Header.Get("Authorization")
+	createInvitationRequestParam := CreateInvitationRequest{}
+	d := json.NewDecoder(r.Body)
+	d.DisallowUnknownFields()
+	if err := d.Decode(&createInvitationRequestParam); err != nil {
+		c.errorHandler(w, r, &ParsingError{Err: err}, nil)
+		return
+	}
+	if err := AssertCreateInvitationRequestRequired(createInvitationRequestParam); err != nil {
+		c.errorHandler(w, r, err, nil)
+		return
+	}
+	if err := AssertCreateInvitationRequestConstraints(createInvitationRequestParam); err != nil {
+		c.errorHandler(w, r, err, nil)
+		return
+	}
+	result, err := c.service.CreateInvitation(r.Context(), authorizationParam, createInvitationRequestParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	_ = EncodeJSONResponse(result.Body, &result.Code, w)
+}
+
+// RedeemInvitation -
+func (c *DefaultAPIController) RedeemInvitation(w http.ResponseWriter, r *http.Request) {
+	authorizationParam := r.Header.Get("Authorization")
+	redeemInvitationRequestParam := RedeemInvitationRequest{}
+	d := json.NewDecoder(r.Body)
+	d.DisallowUnknownFields()
+	if err := d.Decode(&redeemInvitationRequestParam); err != nil {
+		c.errorHandler(w, r, &ParsingError{Err: err}, nil)
+		return
+	}
+	if err := AssertRedeemInvitationRequestRequired(redeemInvitationRequestParam); err != nil {
+		c.errorHandler(w, r, err, nil)
+		return
+	}
+	if err := AssertRedeemInvitationRequestConstraints(redeemInvitationRequestParam); err != nil {
+		c.errorHandler(w, r, err, nil)
+		return
+	}
+	result, err := c.service.RedeemInvitation(r.Context(), authorizationParam, redeemInvitationRequestParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	_ = EncodeJSONResponse(result.Body, &result.Code, w)
+}
@@ -36,6 +36,9 @@ const (
 	ALREADY_CONFIRMED     ErrorReason = "alreadyConfirmed"
 	INCORRECT_CREDENTIALS ErrorReason = "incorrectCredentials"
 	PRIVACY_VIOLATION     ErrorReason = "privacyViolation"
+	NO_SUCH_INVITATION    ErrorReason = "noSuchInvitation"
+	INVITATION_EXPIRED    ErrorReason = "invitationExpired"
+	NO_SUCH_IMAGE         ErrorReason = "noSuchImage"
 )
 
 // AllowedErrorReasonEnumValues is all the allowed values of ErrorReason enum
@@ -58,6 +61,9 @@ var AllowedErrorReasonEnumValues = []ErrorReason{
 	"alreadyConfirmed",
 	"incorrectCredentials",
 	"privacyViolation",
+	"noSuchInvitation",
+	"invitationExpired",
+	"noSuchImage",
 }
 
 // validErrorReasonEnumValue provides a map of ErrorReasons for fast verification of use input
@@ -80,6 +86,9 @@ var validErrorReasonEnumValues = map[ErrorReason]struct{}{
 	"alreadyConfirmed":     {},
 	"incorrectCredentials": {},
 	"privacyViolation":     {},
+	"noSuchInvitation":     {},
+	"invitationExpired":    {},
+	"noSuchImage":          {},
 }
 
 // IsValid return true if the value is valid for the enum, false otherwise
@@ -12,12 +12,22 @@ package openapi
 
 type PullOperationsSucceededResponse struct {
 	Response []SomeOperation `json:"response"`
+
+	// Cursor to pass as `since` to get operations after this page. Present only when `since` was set.
+	Cursor *string `json:"cursor,omitempty"`
+
+	// Set when there are more operations to be pulled.
+	HasMore bool `json:"hasMore"`
+
+	// Token to pass as `pageToken` to get the next page. Present only when `hasMore` is set and `since` was not.
+	NextPageToken *string `json:"nextPageToken,omitempty"`
 }
 
 // AssertPullOperationsSucceededResponseRequired checks if the required fields are not zero-ed
 func AssertPullOperationsSucceededResponseRequired(obj PullOperationsSucceededResponse) error {
 	elements := map[string]interface{}{
 		"response": obj.Response,
+		"hasMore":  obj.HasMore,
 	}
 	for name, el := range elements {
 		if isZero := IsZeroValue(el); isZero {
@@ -18,20 +18,32 @@ type PushTitle string
 
 // List of PushTitle
 const (
-	NEW_SPENDINGS_GROUP PushTitle = "newSpendingsGroup"
-	NEW_SPENDING        PushTitle = "newSpending"
+	NEW_SPENDINGS_GROUP       PushTitle = "newSpendingsGroup"
+	NEW_SPENDING              PushTitle = "newSpending"
+	NEW_SETTLEMENT            PushTitle = "newSettlement"
+	UPDATED_SPENDING          PushTitle = "updatedSpending"
+	NEW_GROUP_PARTICIPANT     PushTitle = "newGroupParticipant"
+	REMOVED_GROUP_PARTICIPANT PushTitle = "removedGroupParticipant"
 )
 
 // AllowedPushTitleEnumValues is all the allowed values of PushTitle enum
 var AllowedPushTitleEnumValues = []PushTitle{
 	"newSpendingsGroup",
 	"newSpending",
+	"newSettlement",
+	"updatedSpending",
+	"newGroupParticipant",
+	"removedGroupParticipant",
 }
 
 // validPushTitleEnumValue provides a map of PushTitles for fast verification of use input
 var validPushTitleEnumValues = map[PushTitle]struct{}{
-	"newSpendingsGroup": {},
-	"newSpending":       {},
+	"newSpendingsGroup":       {},
+	"newSpending":             {},
+	"newSettlement":           {},
+	"updatedSpending":         {},
+	"newGroupParticipant":     {},
+	"removedGroupParticipant": {},
 }
 
 // IsValid return true if the value is valid for the enum, false otherwise
@@ -29,10 +29,18 @@ type SomeOperation struct {
 
 	DeleteSpendingGroup DeleteSpendingGroupOperationDeleteSpendingGroup `json:"deleteSpendingGroup"`
 
+	AddGroupParticipant AddGroupParticipantOperationAddGroupParticipant `json:"addGroupParticipant"`
+
+	RemoveGroupParticipant RemoveGroupParticipantOperationRemoveGroupParticipant `json:"removeGroupParticipant"`
+
 	CreateSpending CreateSpendingOperationCreateSpending `json:"createSpending"`
 
+	UpdateSpending UpdateSpendingOperationUpdateSpending `json:"updateSpending"`
+
 	DeleteSpending DeleteSpendingOperationDeleteSpending `json:"deleteSpending"`
 
+	Settlement SettlementOperationSettlement `json:"settlement"`
+
 	UpdateEmail UpdateEmailOperationUpdateEmail `json:"updateEmail"`
 
 	VerifyEmail VerifyEmailOperationVerifyEmail `json:"verifyEmail"`
@@ -59,20 +67,24 @@ func AssertSomeOperationConstraints(obj SomeOperation) error {
 	}
 
 	elements := map[string]interface{}{
-		"operationId":         obj.OperationId,
-		"createdAt":           obj.CreatedAt,
-		"authorId":            obj.AuthorId,
-		"createUser":          obj.CreateUser,
-		"bindUser":            obj.BindUser,
-		"updateAvatar":        obj.UpdateAvatar,
-		"updateDisplayName":   obj.UpdateDisplayName,
-		"createSpendingGroup": obj.CreateSpendingGroup,
-		"deleteSpendingGroup": obj.DeleteSpendingGroup,
-		"createSpending":      obj.CreateSpending,
-		"deleteSpending":      obj.DeleteSpending,
-		"updateEmail":         obj.UpdateEmail,
-		"verifyEmail":         obj.VerifyEmail,
-		"uploadImage":         obj.UploadImage,
+		"operationId":            obj.OperationId,
+		"createdAt":              obj.CreatedAt,
+		"authorId":               obj.AuthorId,
+		"createUser":             obj.CreateUser,
+		"bindUser":               obj.BindUser,
+		"updateAvatar":           obj.UpdateAvatar,
+		"updateDisplayName":      obj.UpdateDisplayName,
+		"createSpendingGroup":    obj.CreateSpendingGroup,
+		"deleteSpendingGroup":    obj.DeleteSpendingGroup,
+		"addGroupParticipant":    obj.AddGroupParticipant,
+		"removeGroupParticipant": obj.RemoveGroupParticipant,
+		"createSpending":         obj.CreateSpending,
+		"updateSpending":         obj.UpdateSpending,
+		"deleteSpending":         obj.DeleteSpending,
+		"settlement":             obj.Settlement,
+		"updateEmail":            obj.UpdateEmail,
+		"verifyEmail":            obj.VerifyEmail,
+		"uploadImage":            obj.UploadImage,
 	}
 
 	matchesCount := 0
@@ -104,23 +116,43 @@ func AssertSomeOperationConstraints(obj SomeOperation) error {
 		case "createSpendingGroup":
 			if err := AssertCreateSpendingGroupOperationCreateSpendingGroupRequired(obj.CreateSpendingGroup); err != nil {
 				return err
-			}	
+			}
 			matchesCount++
 		case "deleteSpendingGroup":
 			if err := AssertDeleteSpendingGroupOperationDeleteSpendingGroupRequired(obj.DeleteSpendingGroup); err != nil {
 				return err
 			}
 			matchesCount++
+		case "addGroupParticipant":
+			if err := AssertAddGroupParticipantOperationAddGroupParticipantRequired(obj.AddGroupParticipant); err != nil {
+				return err
+			}
+			matchesCount++
+		case "removeGroupParticipant":
+			if err := AssertRemoveGroupParticipantOperationRemoveGroupParticipantRequired(obj.RemoveGroupParticipant); err != nil {
+				return err
+			}
+			matchesCount++
 		case "createSpending":
 			if err := AssertCreateSpendingOperationCreateSpendingRequired(obj.CreateSpending); err != nil {
 				return err
 			}
 			matchesCount++
+		case "updateSpending":
+			if err := AssertUpdateSpendingOperationUpdateSpendingRequired(obj.UpdateSpending); err != nil {
+				return err
+			}
+			matchesCount++
 		case "deleteSpending":
 			if err := AssertDeleteSpendingOperationDeleteSpendingRequired(obj.DeleteSpending); err != nil {
 				return err
 			}
 			matchesCount++
+		case "settlement":
+			if err := AssertSettlementOperationSettlementRequired(obj.Settlement); err != nil {
+				return err
+			}
+			matchesCount++
 		case "updateEmail":
 			if err := AssertUpdateEmailOperationUpdateEmailRequired(obj.UpdateEmail); err != nil {
 				return err
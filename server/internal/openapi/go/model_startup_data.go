@@ -15,6 +15,9 @@ type StartupData struct {
 	Session Session `json:"session"`
 
 	Operations []SomeOperation `json:"operations"`
+
+	// Set when there are more operations to be pulled with `/operations/pull` than returned here.
+	HasMore bool `json:"hasMore"`
 }
 
 // AssertStartupDataRequired checks if the required fields are not zero-ed
@@ -22,6 +25,7 @@ func AssertStartupDataRequired(obj StartupData) error {
 	elements := map[string]interface{}{
 		"session":    obj.Session,
 		"operations": obj.Operations,
+		"hasMore":    obj.HasMore,
 	}
 	for name, el := range elements {
 		if isZero := IsZeroValue(el); isZero {
@@ -16,15 +16,20 @@ type UploadImageOperationUploadImage struct {
 	// image identifier
 	ImageId string `json:"imageId"`
 
-	// base64 string representation of the image.
-	Base64 string `json:"base64"`
+	// base64 string representation of the image, sent by clients uploading the image. Stored operations only reference the image, the server fills it with the stored original when serving operations.
+	Base64 string `json:"base64,omitempty"`
+
+	// key of the image in the image storage, bytes are served by GET /images/{imageId}.
+	StorageKey string `json:"storageKey,omitempty"`
+
+	// keys of thumbnails in the image storage by the longest side in pixels.
+	Thumbnails map[string]string `json:"thumbnails,omitempty"`
 }
 
 // AssertUploadImageOperationUploadImageRequired checks if the required fields are not zero-ed
 func AssertUploadImageOperationUploadImageRequired(obj UploadImageOperationUploadImage) error {
 	elements := map[string]interface{}{
 		"imageId": obj.ImageId,
-		"base64":  obj.Base64,
 	}
 	for name, el := range elements {
 		if isZero := IsZeroValue(el); isZero {
@@ -2,7 +2,9 @@ package openapiImplementation
 
 import (
 	"verni/internal/controllers/auth"
+	"verni/internal/controllers/balances"
 	"verni/internal/controllers/images"
+	"verni/internal/controllers/invitations"
 	"verni/internal/controllers/operations"
 	"verni/internal/controllers/users"
 	"verni/internal/controllers/verification"
@@ -16,6 +18,8 @@ func New(
 	users users.Controller,
 	images images.Controller,
 	operations operations.Controller,
+	balances balances.Controller,
+	invitations invitations.Controller,
 	logger logging.Service,
 ) openapi.DefaultAPIServicer {
 	return &DefaultAPIService{
@@ -24,6 +28,8 @@ func New(
 		users:        users,
 		images:       images,
 		operations:   operations,
+		balances:     balances,
+		invitations:  invitations,
 		logger:       logger,
 	}
 }
@@ -37,5 +43,7 @@ type DefaultAPIService struct {
 	users        users.Controller
 	images       images.Controller
 	operations   operations.Controller
+	balances     balances.Controller
+	invitations  invitations.Controller
 	logger       logging.Service
 }
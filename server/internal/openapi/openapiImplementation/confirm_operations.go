@@ -6,6 +6,7 @@ import (
 	"verni/internal/common"
 	"verni/internal/controllers/operations"
 	openapi "verni/internal/openapi/go"
+	"verni/internal/services/logging"
 )
 
 func (s *DefaultAPIService) ConfirmOperations(
@@ -25,7 +26,7 @@ func (s *DefaultAPIService) ConfirmOperations(
 		operations.UserId(sessionInfo.User),
 		operations.DeviceId(sessionInfo.Device),
 	); err != nil {
-		return s.handleConfirmOperationsError(err, request.Ids)
+		return handleConfirmOperationsError(s.logger, err, request.Ids), nil
 	}
 
 	return openapi.Response(200, openapi.ConfirmOperationsSucceededResponse{
@@ -33,8 +34,8 @@ func (s *DefaultAPIService) ConfirmOperations(
 	}), nil
 }
 
-func (s *DefaultAPIService) handleConfirmOperationsError(err error, ids []string) (openapi.ImplResponse, error) {
-	s.logger.LogError("confirm operations %v failed: %v", ids, err)
+func handleConfirmOperationsError(logger logging.Service, err error, ids []string) openapi.ImplResponse {
+	logger.LogError("confirm operations %v failed: %v", ids, err)
 
 	description := fmt.Errorf("confirm operations error: %w", err).Error()
 	return openapi.Response(500, openapi.ErrorResponse{
@@ -42,5 +43,5 @@ func (s *DefaultAPIService) handleConfirmOperationsError(err error, ids []string
 			Reason:      openapi.INTERNAL,
 			Description: &description,
 		},
-	}), nil
+	})
 }
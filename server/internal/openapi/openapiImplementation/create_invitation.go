@@ -0,0 +1,65 @@
+package openapiImplementation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"verni/internal/controllers/invitations"
+	openapi "verni/internal/openapi/go"
+)
+
+func (s *DefaultAPIService) CreateInvitation(
+	ctx context.Context,
+	token string,
+	request openapi.CreateInvitationRequest,
+) (openapi.ImplResponse, error) {
+	sessionInfo, earlyResponse := s.validateToken(token)
+	if earlyResponse != nil {
+		return *earlyResponse, nil
+	}
+
+	invitation, err := s.invitations.Create(
+		invitations.UserId(sessionInfo.User),
+		invitations.GroupId(request.GroupId),
+		time.Duration(request.Lifetime)*time.Second,
+		int(request.MaxUses),
+	)
+	if err != nil {
+		return s.handleCreateInvitationError(err, request)
+	}
+
+	return openapi.Response(200, openapi.CreateInvitationSucceededResponse{
+		Response: invitation,
+	}), nil
+}
+
+func (s *DefaultAPIService) handleCreateInvitationError(err error, request openapi.CreateInvitationRequest) (openapi.ImplResponse, error) {
+	var reason openapi.ErrorReason
+	var statusCode int
+	var description string
+
+	switch {
+	// unknown groups are not distinguished from foreign ones to not reveal their existence
+	case errors.Is(err, invitations.NotAParticipant), errors.Is(err, invitations.NoSuchGroup):
+		reason = openapi.PRIVACY_VIOLATION
+		statusCode = 403
+		description = "create invitation error: group is not available"
+	case errors.Is(err, invitations.BadFormat):
+		reason = openapi.WRONG_FORMAT
+		statusCode = 422
+		description = fmt.Errorf("create invitation error: %w", err).Error()
+	default:
+		s.logger.LogError("create invitation %v failed: %v", request, err)
+		reason = openapi.INTERNAL
+		statusCode = 500
+		description = fmt.Errorf("create invitation error: %w", err).Error()
+	}
+
+	return openapi.Response(statusCode, openapi.ErrorResponse{
+		Error: openapi.Error{
+			Reason:      reason,
+			Description: &description,
+		},
+	}), nil
+}
@@ -12,6 +12,7 @@ func (s *DefaultAPIService) GetAvatars(
 	ctx context.Context,
 	token string,
 	ids []string,
+	size int32,
 ) (openapi.ImplResponse, error) {
 	if _, earlyResponse := s.validateToken(token); earlyResponse != nil {
 		return *earlyResponse, nil
@@ -21,7 +22,7 @@ func (s *DefaultAPIService) GetAvatars(
 		return images.ImageId(id)
 	})
 
-	result, err := s.images.GetImages(imageIDs)
+	result, err := s.images.GetImages(imageIDs, images.Size(size))
 	if err != nil {
 		return s.handleGetAvatarsError(err, ids)
 	}
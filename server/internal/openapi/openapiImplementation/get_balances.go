@@ -0,0 +1,40 @@
+package openapiImplementation
+
+import (
+	"context"
+	"fmt"
+	"verni/internal/controllers/auth"
+	"verni/internal/controllers/balances"
+	openapi "verni/internal/openapi/go"
+)
+
+func (s *DefaultAPIService) GetBalances(
+	ctx context.Context,
+	token string,
+) (openapi.ImplResponse, error) {
+	sessionInfo, earlyResponse := s.validateToken(token)
+	if earlyResponse != nil {
+		return *earlyResponse, nil
+	}
+
+	result, err := s.balances.Get(balances.UserId(sessionInfo.User))
+	if err != nil {
+		return s.handleGetBalancesError(err, sessionInfo.User)
+	}
+
+	return openapi.Response(200, openapi.GetBalancesSucceededResponse{
+		Response: result,
+	}), nil
+}
+
+func (s *DefaultAPIService) handleGetBalancesError(err error, request auth.UserId) (openapi.ImplResponse, error) {
+	s.logger.LogError("get balances request %v failed: %v", request, err)
+
+	description := fmt.Errorf("get balances error: %w", err).Error()
+	return openapi.Response(500, openapi.ErrorResponse{
+		Error: openapi.Error{
+			Reason:      openapi.INTERNAL,
+			Description: &description,
+		},
+	}), nil
+}
@@ -0,0 +1,55 @@
+package openapiImplementation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"verni/internal/controllers/balances"
+	openapi "verni/internal/openapi/go"
+)
+
+func (s *DefaultAPIService) GetSettlement(
+	ctx context.Context,
+	token string,
+	groupId string,
+) (openapi.ImplResponse, error) {
+	sessionInfo, earlyResponse := s.validateToken(token)
+	if earlyResponse != nil {
+		return *earlyResponse, nil
+	}
+
+	result, err := s.balances.Settle(balances.UserId(sessionInfo.User), balances.GroupId(groupId))
+	if err != nil {
+		return s.handleGetSettlementError(err, groupId)
+	}
+
+	return openapi.Response(200, openapi.GetSettlementSucceededResponse{
+		Response: result,
+	}), nil
+}
+
+func (s *DefaultAPIService) handleGetSettlementError(err error, groupId string) (openapi.ImplResponse, error) {
+	var reason openapi.ErrorReason
+	var statusCode int
+	var description string
+
+	switch {
+	// unknown groups are not distinguished from foreign ones to not reveal their existence
+	case errors.Is(err, balances.NotAParticipant), errors.Is(err, balances.NoSuchGroup):
+		reason = openapi.PRIVACY_VIOLATION
+		statusCode = 403
+		description = "get settlement error: group is not available"
+	default:
+		s.logger.LogError("get settlement of group %s failed: %v", groupId, err)
+		reason = openapi.INTERNAL
+		statusCode = 500
+		description = fmt.Errorf("get settlement error: %w", err).Error()
+	}
+
+	return openapi.Response(statusCode, openapi.ErrorResponse{
+		Error: openapi.Error{
+			Reason:      reason,
+			Description: &description,
+		},
+	}), nil
+}
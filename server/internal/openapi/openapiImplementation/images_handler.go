@@ -0,0 +1,121 @@
+package openapiImplementation
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"verni/internal/controllers/auth"
+	"verni/internal/controllers/images"
+	openapi "verni/internal/openapi/go"
+	"verni/internal/services/logging"
+
+	"github.com/gorilla/mux"
+)
+
+const imageCacheControl = "private, max-age=31536000, immutable"
+
+type imagesHandler struct {
+	auth   auth.Controller
+	images images.Controller
+	logger logging.Service
+}
+
+// NewImagesHandler serves bytes of uploaded images with their content type at /images/{imageId}, optional size
+// query parameter selects a thumbnail the same way it does for /avatars/get. Responses are cacheable forever and
+// carry an etag, requests with a matching If-None-Match get 304 without the body. The generated api encodes every response as json so raw bytes are served by a dedicated handler
+func NewImagesHandler(
+	auth auth.Controller,
+	images images.Controller,
+	logger logging.Service,
+) func(w http.ResponseWriter, r *http.Request) {
+	handler := &imagesHandler{
+		auth:   auth,
+		images: images,
+		logger: logger,
+	}
+	return handler.Handle
+}
+
+func (h *imagesHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	const op = "openapiImplementation.imagesHandler.Handle"
+	if _, earlyResponse := validateToken(h.logger, h.auth, r.Header.Get("Authorization")); earlyResponse != nil {
+		h.logger.LogInfo("%s: unauthorized: %v", op, earlyResponse.Body)
+		openapi.EncodeJSONResponse(earlyResponse.Body, &earlyResponse.Code, w)
+		return
+	}
+	id := images.ImageId(mux.Vars(r)["imageId"])
+	size := images.OriginalSize
+	if query := r.URL.Query(); query.Has("size") {
+		parsed, err := strconv.Atoi(query.Get("size"))
+		if err != nil || parsed < 1 {
+			description := fmt.Sprintf("size should be a positive integer, got %q", query.Get("size"))
+			code := http.StatusBadRequest
+			openapi.EncodeJSONResponse(openapi.ErrorResponse{
+				Error: openapi.Error{
+					Reason:      openapi.BAD_REQUEST,
+					Description: &description,
+				},
+			}, &code, w)
+			return
+		}
+		size = images.Size(parsed)
+	}
+	blob, err := h.images.GetImage(id, size)
+	if err != nil {
+		response := h.handleGetImageError(err, id)
+		openapi.EncodeJSONResponse(response.Body, &response.Code, w)
+		return
+	}
+	// an image id never gets other content, the response is private since it needs authorization
+	w.Header().Set("Cache-Control", imageCacheControl)
+	w.Header().Set("ETag", blob.ETag)
+	if etagMatches(r.Header.Get("If-None-Match"), blob.ETag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", blob.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(blob.Data)))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(blob.Data); err != nil {
+		h.logger.LogInfo("%s: writing image %s: %v", op, id, err)
+	}
+}
+
+// etagMatches compares If-None-Match with the etag using the weak comparison required for it
+func etagMatches(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *imagesHandler) handleGetImageError(err error, id images.ImageId) openapi.ImplResponse {
+	var reason openapi.ErrorReason
+	var statusCode int
+
+	switch {
+	case errors.Is(err, images.NoSuchImage):
+		reason = openapi.NO_SUCH_IMAGE
+		statusCode = 404
+	default:
+		h.logger.LogError("get image %s failed: %v", id, err)
+		reason = openapi.INTERNAL
+		statusCode = 500
+	}
+
+	description := fmt.Errorf("get image error: %w", err).Error()
+	return openapi.Response(statusCode, openapi.ErrorResponse{
+		Error: openapi.Error{
+			Reason:      reason,
+			Description: &description,
+		},
+	})
+}
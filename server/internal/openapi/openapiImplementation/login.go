@@ -21,11 +21,16 @@ func (s *DefaultAPIService) Login(
 	if err != nil {
 		return s.handleLoginError(err, request)
 	}
+	operations, err := s.images.InlineUploads(startupData.Operations)
+	if err != nil {
+		return s.handleLoginError(err, request)
+	}
 
 	return openapi.Response(200, openapi.LoginSucceededResponse{
 		Response: openapi.StartupData{
 			Session:    sessionToOpenapi(startupData.Session),
-			Operations: startupData.Operations,
+			Operations: operations,
+			HasMore:    startupData.HasMore,
 		},
 	}), nil
 }
@@ -0,0 +1,280 @@
+package openapiImplementation
+
+import (
+	"encoding/json"
+	"slices"
+	"sync"
+	"time"
+	"verni/internal/controllers/images"
+	"verni/internal/controllers/operations"
+	openapi "verni/internal/openapi/go"
+	"verni/internal/services/logging"
+	"verni/internal/services/realtimeEvents"
+
+	"github.com/google/uuid"
+)
+
+type connectionDescriptor struct {
+	userId realtimeEvents.UserId
+	device realtimeEvents.DeviceId
+}
+
+const (
+	// channelBuffer is how many events a connection may fall behind before it is closed
+	channelBuffer = 10
+)
+
+// names of events sent to clients, the data of every event is a json object with the type field
+const (
+	connectedEvent        = "connected"
+	disconnectedEvent     = "disconnected"
+	resyncRequiredEvent   = "resyncRequired"
+	operationsPulledEvent = "operationsPulled"
+	operationsPushedEvent = "operationsPushed"
+	errorEvent            = "error"
+)
+
+// OperationsQueue keeps connections of devices streaming updates and delivers realtime updates to them, every
+// transport registers its connections here so a device gets the same events whichever one it uses
+type OperationsQueue struct {
+	logger           logging.Service
+	operations       operations.Controller
+	images           images.Controller
+	now              func() time.Time
+	connectionsMutex sync.RWMutex
+	connections      map[connectionDescriptor][]chan queueEvent
+	devicesPerUser   map[realtimeEvents.UserId]map[realtimeEvents.DeviceId]struct{}
+	// eventsMutex orders events of every device, it is locked after connectionsMutex
+	eventsMutex          sync.Mutex
+	epoch                string
+	lastEventId          uint64
+	replay               map[connectionDescriptor]*replayBuffer
+	replayDevicesPerUser map[realtimeEvents.UserId]map[realtimeEvents.DeviceId]struct{}
+}
+
+func NewOperationsQueue(
+	service realtimeEvents.Service,
+	operations operations.Controller,
+	images images.Controller,
+	now func() time.Time,
+	logger logging.Service,
+) *OperationsQueue {
+	queue := &OperationsQueue{
+		logger:               logger,
+		operations:           operations,
+		images:               images,
+		now:                  now,
+		connections:          make(map[connectionDescriptor][]chan queueEvent),
+		devicesPerUser:       make(map[realtimeEvents.UserId]map[realtimeEvents.DeviceId]struct{}),
+		epoch:                uuid.New().String(),
+		replay:               make(map[connectionDescriptor]*replayBuffer),
+		replayDevicesPerUser: make(map[realtimeEvents.UserId]map[realtimeEvents.DeviceId]struct{}),
+	}
+	service.AddListener(queue.handleUpdate)
+	return queue
+}
+
+// connect registers a connection of the device, events missed since lastEventId are taken in the same critical
+// section so none of them is lost or sent twice. False means the client has to pull everything again. The
+// channel is closed when the connection can not keep up, transports should close the connection then.
+func (q *OperationsQueue) connect(descriptor connectionDescriptor, lastEventId string) (chan queueEvent, []queueEvent, bool) {
+	messageChan := make(chan queueEvent, channelBuffer)
+	q.connectionsMutex.Lock()
+	defer q.connectionsMutex.Unlock()
+	if q.devicesPerUser[descriptor.userId] == nil {
+		q.devicesPerUser[descriptor.userId] = make(map[realtimeEvents.DeviceId]struct{})
+	}
+	q.connections[descriptor] = append(q.connections[descriptor], messageChan)
+	q.devicesPerUser[descriptor.userId][descriptor.device] = struct{}{}
+	q.eventsMutex.Lock()
+	defer q.eventsMutex.Unlock()
+	missed, resumed := q.resume(descriptor, lastEventId)
+	return messageChan, missed, resumed
+}
+
+func (q *OperationsQueue) disconnect(descriptor connectionDescriptor, messageChan chan queueEvent) {
+	q.removeChannels(descriptor, []chan queueEvent{messageChan})
+}
+
+// handleUpdate sends pushed operations to connected devices of the user, devices pull pending operations
+// instead when the update comes without them
+func (q *OperationsQueue) handleUpdate(
+	userId realtimeEvents.UserId,
+	ignoringDevices []realtimeEvents.DeviceId,
+	pushed []json.RawMessage,
+) {
+	const op = "openapiImplementation.OperationsQueue.handleUpdate"
+	q.logger.LogInfo("%s: handling update for %s with %d operations, ignoring devices: %v", op, userId, len(pushed), ignoringDevices)
+
+	ignoringDevicesMap := make(map[realtimeEvents.DeviceId]bool)
+	for _, device := range ignoringDevices {
+		ignoringDevicesMap[device] = true
+	}
+	q.connectionsMutex.RLock()
+	targets := []connectionDescriptor{}
+	for deviceId := range q.devicesPerUser[userId] {
+		if !ignoringDevicesMap[deviceId] {
+			targets = append(targets, connectionDescriptor{userId: userId, device: deviceId})
+		}
+	}
+	// pending operations are pulled by every device once it connects, so only pushed ones are kept for replay
+	if pushed != nil {
+		targets = append(targets, q.disconnectedReplayDevices(userId, ignoringDevicesMap)...)
+	}
+	q.connectionsMutex.RUnlock()
+	if len(targets) == 0 {
+		q.logger.LogInfo("%s: no devices to notify for user %s", op, userId)
+		return
+	}
+
+	var event queueEvent
+	if pushed != nil {
+		event = q.pushed(pushed)
+	}
+	for _, descriptor := range targets {
+		if pushed != nil {
+			q.send(descriptor, event)
+		} else {
+			q.send(descriptor, q.pulled(descriptor))
+		}
+	}
+}
+
+// pushed makes an event with operations pushed by another device of the user
+func (q *OperationsQueue) pushed(pushed []json.RawMessage) queueEvent {
+	decoded := make([]openapi.SomeOperation, len(pushed))
+	for index, data := range pushed {
+		if err := json.Unmarshal(data, &decoded[index]); err != nil {
+			return q.errorEvent(err)
+		}
+	}
+	inlined, err := q.images.InlineUploads(decoded)
+	if err != nil {
+		return q.errorEvent(err)
+	}
+	return q.encode(operationsPushedEvent, map[string]interface{}{
+		"type":    "update",
+		"update":  operationsPushedEvent,
+		"payload": inlined,
+	})
+}
+
+// pulled makes an event with the first page of operations pending for the device
+func (q *OperationsQueue) pulled(descriptor connectionDescriptor) queueEvent {
+	page, err := q.operations.Pull(operations.UserId(descriptor.userId), operations.DeviceId(descriptor.device), openapi.REGULAR, 0, 0)
+	if err != nil {
+		return q.errorEvent(err)
+	}
+	inlined, err := q.images.InlineUploads(page.Operations)
+	if err != nil {
+		return q.errorEvent(err)
+	}
+	return q.encode(operationsPulledEvent, map[string]interface{}{
+		"type":    "update",
+		"update":  operationsPulledEvent,
+		"payload": inlined,
+		"hasMore": page.HasMore,
+	})
+}
+
+func (q *OperationsQueue) errorEvent(err error) queueEvent {
+	return q.encode(errorEvent, map[string]interface{}{
+		"type":  "error",
+		"error": handlePullOperationsError(q.logger, err),
+	})
+}
+
+func (q *OperationsQueue) encode(name string, payload map[string]interface{}) queueEvent {
+	const op = "openapiImplementation.OperationsQueue.encode"
+	data, err := json.Marshal(payload)
+	if err != nil {
+		q.logger.LogError("%s: marshalling %s event: %v", op, name, err)
+		return queueEvent{name: errorEvent, data: `{"type": "error"}`}
+	}
+	return queueEvent{name: name, data: string(data)}
+}
+
+// send assigns the next id to the event, keeps it for replay and sends it to every connection of the device
+// without waiting, connections that fell behind are closed and their clients resume from the replay buffer.
+// Connections are taken under connectionsMutex, eventsMutex is kept until the event is sent so connections get
+// events in the order of their ids and are not closed meanwhile.
+func (q *OperationsQueue) send(descriptor connectionDescriptor, event queueEvent) {
+	const op = "openapiImplementation.OperationsQueue.send"
+	var stale []chan queueEvent
+	q.connectionsMutex.RLock()
+	q.eventsMutex.Lock()
+	q.lastEventId++
+	event.id = q.lastEventId
+	if buffer, exists := q.replay[descriptor]; exists {
+		buffer.append(event)
+	}
+	channels := slices.Clone(q.connections[descriptor])
+	q.connectionsMutex.RUnlock()
+	for _, ch := range channels {
+		select {
+		case ch <- event:
+			q.logger.LogInfo("%s: successfully sent %s for %v", op, event.name, descriptor)
+		default:
+			q.logger.LogInfo("%s: connection fell behind for %v - marking for cleanup", op, descriptor)
+			stale = append(stale, ch)
+		}
+	}
+	q.eventsMutex.Unlock()
+	if len(stale) > 0 {
+		q.removeChannels(descriptor, stale)
+	}
+}
+
+// removeChannels unregisters and closes channels of the device that are still registered
+func (q *OperationsQueue) removeChannels(descriptor connectionDescriptor, channels []chan queueEvent) {
+	q.connectionsMutex.Lock()
+	defer q.connectionsMutex.Unlock()
+	// channels are closed with eventsMutex locked, send may still hold them after releasing connectionsMutex
+	q.eventsMutex.Lock()
+	defer q.eventsMutex.Unlock()
+	remaining := make([]chan queueEvent, 0, len(q.connections[descriptor]))
+	for _, ch := range q.connections[descriptor] {
+		removed := false
+		for _, candidate := range channels {
+			if ch == candidate {
+				removed = true
+				break
+			}
+		}
+		if removed {
+			close(ch)
+		} else {
+			remaining = append(remaining, ch)
+		}
+	}
+	if len(remaining) > 0 {
+		q.connections[descriptor] = remaining
+		return
+	}
+	if _, exists := q.connections[descriptor]; !exists {
+		return
+	}
+	delete(q.connections, descriptor)
+	q.disconnected(descriptor)
+	// Only remove from devicesPerUser if this was the last channel for this device
+	delete(q.devicesPerUser[descriptor.userId], descriptor.device)
+	if len(q.devicesPerUser[descriptor.userId]) == 0 {
+		delete(q.devicesPerUser, descriptor.userId)
+	}
+}
+
+// disconnectedReplayDevices returns devices of the user that keep events for replay but have no connection now,
+// events sent to them are only kept in their buffers. Should be called with connectionsMutex locked.
+func (q *OperationsQueue) disconnectedReplayDevices(userId realtimeEvents.UserId, ignoringDevices map[realtimeEvents.DeviceId]bool) []connectionDescriptor {
+	q.eventsMutex.Lock()
+	defer q.eventsMutex.Unlock()
+	result := []connectionDescriptor{}
+	for deviceId := range q.replayDevicesPerUser[userId] {
+		descriptor := connectionDescriptor{userId: userId, device: deviceId}
+		if _, connected := q.connections[descriptor]; connected || ignoringDevices[deviceId] {
+			continue
+		}
+		result = append(result, descriptor)
+	}
+	return result
+}
@@ -0,0 +1,116 @@
+package openapiImplementation
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"verni/internal/services/realtimeEvents"
+)
+
+const (
+	// replayBufferSize is the number of latest events kept per device to replay them on reconnect
+	replayBufferSize = 32
+	// replayRetention is how long events of a device are kept after its last connection is closed
+	replayRetention = 10 * time.Minute
+)
+
+// queueEvent is a message of the stream, only events with ids are kept for replay
+type queueEvent struct {
+	id   uint64
+	name string
+	data string
+}
+
+// replayBuffer keeps latest events of a device, it holds every event of the device with id greater than since
+type replayBuffer struct {
+	since          uint64
+	events         []queueEvent
+	disconnectedAt time.Time
+}
+
+func (b *replayBuffer) append(event queueEvent) {
+	if len(b.events) == replayBufferSize {
+		b.since = b.events[0].id
+		copy(b.events, b.events[1:])
+		b.events = b.events[:len(b.events)-1]
+	}
+	b.events = append(b.events, event)
+}
+
+// after returns events following the last received one, false when some of them are not kept
+func (b *replayBuffer) after(lastEventId uint64) ([]queueEvent, bool) {
+	if lastEventId < b.since {
+		return nil, false
+	}
+	result := []queueEvent{}
+	for _, event := range b.events {
+		if event.id > lastEventId {
+			result = append(result, event)
+		}
+	}
+	return result, true
+}
+
+// eventId is unique across restarts of the server and instances behind a load balancer, ids of other epochs
+// can not be replayed
+func (q *OperationsQueue) eventId(event queueEvent) string {
+	return fmt.Sprintf("%s:%d", q.epoch, event.id)
+}
+
+func (q *OperationsQueue) parseEventId(value string) (uint64, bool) {
+	epoch, sequence, found := strings.Cut(value, ":")
+	if !found || epoch != q.epoch {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(sequence, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// resume marks the device connected and returns events it missed after lastEventId, false means the client has
+// to pull everything again. Should be called with eventsMutex locked.
+func (q *OperationsQueue) resume(descriptor connectionDescriptor, lastEventId string) ([]queueEvent, bool) {
+	q.forgetExpiredBuffers()
+	buffer, exists := q.replay[descriptor]
+	if !exists {
+		buffer = &replayBuffer{since: q.lastEventId}
+		q.replay[descriptor] = buffer
+		if q.replayDevicesPerUser[descriptor.userId] == nil {
+			q.replayDevicesPerUser[descriptor.userId] = map[realtimeEvents.DeviceId]struct{}{}
+		}
+		q.replayDevicesPerUser[descriptor.userId][descriptor.device] = struct{}{}
+	}
+	buffer.disconnectedAt = time.Time{}
+	if lastEventId == "" {
+		return nil, true
+	}
+	id, ok := q.parseEventId(lastEventId)
+	if !ok {
+		return nil, false
+	}
+	return buffer.after(id)
+}
+
+// disconnected starts the retention of events of a device without connections. Should be called with
+// eventsMutex locked.
+func (q *OperationsQueue) disconnected(descriptor connectionDescriptor) {
+	if buffer, exists := q.replay[descriptor]; exists {
+		buffer.disconnectedAt = q.now()
+	}
+}
+
+func (q *OperationsQueue) forgetExpiredBuffers() {
+	for descriptor, buffer := range q.replay {
+		if buffer.disconnectedAt.IsZero() || q.now().Sub(buffer.disconnectedAt) < replayRetention {
+			continue
+		}
+		delete(q.replay, descriptor)
+		delete(q.replayDevicesPerUser[descriptor.userId], descriptor.device)
+		if len(q.replayDevicesPerUser[descriptor.userId]) == 0 {
+			delete(q.replayDevicesPerUser, descriptor.userId)
+		}
+	}
+}
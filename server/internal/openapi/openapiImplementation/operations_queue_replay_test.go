@@ -0,0 +1,249 @@
+package openapiImplementation
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"verni/internal/controllers/images"
+	"verni/internal/controllers/operations"
+	openapi "verni/internal/openapi/go"
+	standartOutputLoggingService "verni/internal/services/logging/standartOutput"
+	"verni/internal/services/realtimeEvents"
+	realtimeEvents_mock "verni/internal/services/realtimeEvents/mock"
+)
+
+// imagesStub serves operations as they are, other methods of the controller are not used by the queue
+type imagesStub struct {
+	images.Controller
+}
+
+func (imagesStub) InlineUploads(operations []openapi.SomeOperation) ([]openapi.SomeOperation, error) {
+	return operations, nil
+}
+
+// operationsStub has nothing pending, other methods of the controller are not used by the queue
+type operationsStub struct {
+	operations.Controller
+}
+
+func (operationsStub) Pull(
+	userId operations.UserId,
+	deviceId operations.DeviceId,
+	operationsType openapi.OperationType,
+	pageToken operations.SequenceNumber,
+	limit int,
+) (operations.OperationsPage, error) {
+	return operations.OperationsPage{Operations: []openapi.SomeOperation{}}, nil
+}
+
+type testQueue struct {
+	*OperationsQueue
+	listener realtimeEvents.Listener
+	clock    time.Time
+}
+
+func newTestQueue(t *testing.T) *testQueue {
+	queue := &testQueue{clock: time.Unix(1700000000, 0)}
+	queue.OperationsQueue = NewOperationsQueue(
+		&realtimeEvents_mock.ServiceMock{
+			AddListenerImpl: func(listener realtimeEvents.Listener) {
+				queue.listener = listener
+			},
+		},
+		operationsStub{},
+		imagesStub{},
+		func() time.Time {
+			return queue.clock
+		},
+		standartOutputLoggingService.New(),
+	)
+	require.NotNil(t, queue.listener)
+	return queue
+}
+
+// push notifies the user about an operation pushed by another device
+func (q *testQueue) push(t *testing.T, userId realtimeEvents.UserId, operationId string) {
+	data, err := json.Marshal(openapi.SomeOperation{OperationId: operationId, AuthorId: string(userId)})
+	require.NoError(t, err)
+	q.listener(userId, []realtimeEvents.DeviceId{"pusher"}, []json.RawMessage{data})
+}
+
+func pushedOperationIds(t *testing.T, events []queueEvent) []string {
+	result := []string{}
+	for _, event := range events {
+		require.Equal(t, operationsPushedEvent, event.name)
+		var decoded struct {
+			Payload []openapi.SomeOperation `json:"payload"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(event.data), &decoded))
+		for _, operation := range decoded.Payload {
+			result = append(result, operation.OperationId)
+		}
+	}
+	return result
+}
+
+func TestReplayBuffer(t *testing.T) {
+	t.Run("oldest events are evicted beyond the buffer size", func(t *testing.T) {
+		// Arrange
+		buffer := &replayBuffer{}
+
+		// Act
+		for id := uint64(1); id <= replayBufferSize+1; id++ {
+			buffer.append(queueEvent{id: id, name: operationsPushedEvent})
+		}
+
+		// Assert
+		assert.Len(t, buffer.events, replayBufferSize)
+		_, ok := buffer.after(0)
+		assert.False(t, ok)
+		missed, ok := buffer.after(1)
+		assert.True(t, ok)
+		assert.Len(t, missed, replayBufferSize)
+		assert.Equal(t, uint64(2), missed[0].id)
+		assert.Equal(t, uint64(replayBufferSize+1), missed[len(missed)-1].id)
+	})
+
+	t.Run("events after the last received one", func(t *testing.T) {
+		// Arrange
+		buffer := &replayBuffer{}
+		for id := uint64(1); id <= 3; id++ {
+			buffer.append(queueEvent{id: id, name: operationsPushedEvent})
+		}
+
+		// Act
+		missed, ok := buffer.after(2)
+
+		// Assert
+		assert.True(t, ok)
+		assert.Equal(t, []queueEvent{{id: 3, name: operationsPushedEvent}}, missed)
+	})
+}
+
+func TestOperationsQueue_Resume(t *testing.T) {
+	phone := connectionDescriptor{userId: "alice", device: "phone"}
+
+	t.Run("events pushed while disconnected are replayed", func(t *testing.T) {
+		// Arrange
+		queue := newTestQueue(t)
+		messageChan, _, resumed := queue.connect(phone, "")
+		require.True(t, resumed)
+		queue.push(t, "alice", "received")
+		received := <-messageChan
+		queue.disconnect(phone, messageChan)
+		queue.push(t, "alice", "missed")
+
+		// Act
+		_, missed, resumed := queue.connect(phone, queue.eventId(received))
+
+		// Assert
+		assert.True(t, resumed)
+		assert.Equal(t, []string{"missed"}, pushedOperationIds(t, missed))
+	})
+
+	t.Run("updates without operations are not kept while disconnected", func(t *testing.T) {
+		// Arrange
+		queue := newTestQueue(t)
+		messageChan, _, _ := queue.connect(phone, "")
+		queue.push(t, "alice", "received")
+		received := <-messageChan
+		queue.disconnect(phone, messageChan)
+		queue.listener("alice", []realtimeEvents.DeviceId{}, nil)
+
+		// Act
+		_, missed, resumed := queue.connect(phone, queue.eventId(received))
+
+		// Assert
+		assert.True(t, resumed)
+		assert.Empty(t, missed)
+	})
+
+	t.Run("events are kept for the retention period", func(t *testing.T) {
+		// Arrange
+		queue := newTestQueue(t)
+		messageChan, _, _ := queue.connect(phone, "")
+		queue.push(t, "alice", "received")
+		received := <-messageChan
+		queue.disconnect(phone, messageChan)
+		queue.push(t, "alice", "missed")
+		queue.clock = queue.clock.Add(replayRetention - time.Second)
+
+		// Act
+		_, missed, resumed := queue.connect(phone, queue.eventId(received))
+
+		// Assert
+		assert.True(t, resumed)
+		assert.Equal(t, []string{"missed"}, pushedOperationIds(t, missed))
+	})
+
+	t.Run("events are forgotten after the retention period", func(t *testing.T) {
+		// Arrange
+		queue := newTestQueue(t)
+		messageChan, _, _ := queue.connect(phone, "")
+		queue.push(t, "alice", "received")
+		received := <-messageChan
+		queue.disconnect(phone, messageChan)
+		queue.push(t, "alice", "missed")
+		queue.clock = queue.clock.Add(replayRetention)
+
+		// Act
+		_, missed, resumed := queue.connect(phone, queue.eventId(received))
+
+		// Assert
+		assert.False(t, resumed)
+		assert.Empty(t, missed)
+	})
+
+	t.Run("events evicted while disconnected require resync", func(t *testing.T) {
+		// Arrange
+		queue := newTestQueue(t)
+		messageChan, _, _ := queue.connect(phone, "")
+		queue.push(t, "alice", "received")
+		received := <-messageChan
+		queue.disconnect(phone, messageChan)
+		for index := 0; index <= replayBufferSize; index++ {
+			queue.push(t, "alice", fmt.Sprintf("missed-%d", index))
+		}
+
+		// Act
+		_, _, resumed := queue.connect(phone, queue.eventId(received))
+
+		// Assert
+		assert.False(t, resumed)
+	})
+
+	t.Run("event id of another epoch requires resync", func(t *testing.T) {
+		// Arrange
+		queue := newTestQueue(t)
+		previous := newTestQueue(t)
+		messageChan, _, _ := previous.connect(phone, "")
+		previous.push(t, "alice", "received")
+		received := <-messageChan
+
+		// Act
+		_, missed, resumed := queue.connect(phone, previous.eventId(received))
+
+		// Assert
+		assert.False(t, resumed)
+		assert.Empty(t, missed)
+	})
+
+	t.Run("unknown event id requires resync", func(t *testing.T) {
+		// Arrange
+		queue := newTestQueue(t)
+
+		for _, lastEventId := range []string{"unknown", "1", ":1", queue.epoch + ":unknown"} {
+			// Act
+			_, missed, resumed := queue.connect(phone, lastEventId)
+
+			// Assert
+			assert.False(t, resumed, lastEventId)
+			assert.Empty(t, missed, lastEventId)
+		}
+	})
+}
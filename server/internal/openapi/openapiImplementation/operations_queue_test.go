@@ -0,0 +1,32 @@
+package openapiImplementation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperationsQueue_Send(t *testing.T) {
+	t.Run("connection that fell behind is closed and resumes from the replay buffer", func(t *testing.T) {
+		// Arrange
+		queue := newTestQueue(t)
+		phone := connectionDescriptor{userId: "alice", device: "phone"}
+		messageChan, _, _ := queue.connect(phone, "")
+
+		// Act
+		for index := 0; index <= channelBuffer; index++ {
+			queue.push(t, "alice", "pushed")
+		}
+
+		// Assert
+		received := []queueEvent{}
+		for event := range messageChan {
+			received = append(received, event)
+		}
+		assert.Len(t, received, channelBuffer)
+		assert.NotContains(t, queue.connections, phone)
+		_, missed, resumed := queue.connect(phone, queue.eventId(received[len(received)-1]))
+		assert.True(t, resumed)
+		assert.Len(t, missed, 1)
+	})
+}
@@ -3,6 +3,8 @@ package openapiImplementation
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"verni/internal/controllers/auth"
 	"verni/internal/controllers/operations"
 	openapi "verni/internal/openapi/go"
 	"verni/internal/services/logging"
@@ -12,24 +14,100 @@ func (s *DefaultAPIService) PullOperations(
 	ctx context.Context,
 	token string,
 	operationsType openapi.OperationType,
+	since string,
+	limit int32,
+	pageToken string,
 ) (openapi.ImplResponse, error) {
 	sessionInfo, earlyResponse := s.validateToken(token)
 	if earlyResponse != nil {
 		return *earlyResponse, nil
 	}
 
-	result, err := s.operations.Pull(
+	if since != "" {
+		return s.pullOperationsSince(sessionInfo, operationsType, since, limit), nil
+	}
+
+	after, earlyResponse := parseCursor(pageToken, "page token")
+	if earlyResponse != nil {
+		return *earlyResponse, nil
+	}
+
+	page, err := s.operations.Pull(
 		operations.UserId(sessionInfo.User),
 		operations.DeviceId(sessionInfo.Device),
 		operationsType,
+		after,
+		int(limit),
 	)
 	if err != nil {
 		return handlePullOperationsError(s.logger, err), nil
 	}
+	pulled, err := s.images.InlineUploads(page.Operations)
+	if err != nil {
+		return handlePullOperationsError(s.logger, err), nil
+	}
+
+	response := openapi.PullOperationsSucceededResponse{
+		Response: pulled,
+		HasMore:  page.HasMore,
+	}
+	if page.HasMore {
+		nextPageToken := strconv.FormatInt(int64(page.Cursor), 10)
+		response.NextPageToken = &nextPageToken
+	}
+	return openapi.Response(200, response), nil
+}
+
+func (s *DefaultAPIService) pullOperationsSince(
+	sessionInfo auth.UserDevice,
+	operationsType openapi.OperationType,
+	since string,
+	limit int32,
+) openapi.ImplResponse {
+	cursor, earlyResponse := parseCursor(since, "cursor")
+	if earlyResponse != nil {
+		return *earlyResponse
+	}
 
+	page, err := s.operations.PullSince(
+		operations.UserId(sessionInfo.User),
+		cursor,
+		operationsType,
+		int(limit),
+	)
+	if err != nil {
+		return handlePullOperationsError(s.logger, err)
+	}
+	pulled, err := s.images.InlineUploads(page.Operations)
+	if err != nil {
+		return handlePullOperationsError(s.logger, err)
+	}
+
+	nextCursor := strconv.FormatInt(int64(page.Cursor), 10)
 	return openapi.Response(200, openapi.PullOperationsSucceededResponse{
-		Response: result,
-	}), nil
+		Response: pulled,
+		Cursor:   &nextCursor,
+		HasMore:  page.HasMore,
+	})
+}
+
+// parseCursor treats an empty value as the beginning of the history
+func parseCursor(value string, name string) (operations.SequenceNumber, *openapi.ImplResponse) {
+	if value == "" {
+		return 0, nil
+	}
+	cursor, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || cursor < 0 {
+		description := fmt.Sprintf("malformed %s %s", name, value)
+		response := openapi.Response(400, openapi.ErrorResponse{
+			Error: openapi.Error{
+				Reason:      openapi.WRONG_FORMAT,
+				Description: &description,
+			},
+		})
+		return 0, &response
+	}
+	return operations.SequenceNumber(cursor), nil
 }
 
 func handlePullOperationsError(logger logging.Service, err error) openapi.ImplResponse {
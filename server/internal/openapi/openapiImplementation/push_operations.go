@@ -2,9 +2,14 @@ package openapiImplementation
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"verni/internal/controllers/auth"
+	"verni/internal/controllers/images"
 	"verni/internal/controllers/operations"
 	openapi "verni/internal/openapi/go"
+	"verni/internal/services/logging"
 )
 
 func (s *DefaultAPIService) PushOperations(
@@ -17,27 +22,68 @@ func (s *DefaultAPIService) PushOperations(
 		return *earlyResponse, nil
 	}
 
-	if err := s.operations.Push(
-		request.Operations,
-		operations.UserId(sessionInfo.User),
-		operations.DeviceId(sessionInfo.Device),
-	); err != nil {
-		return s.handlePushOperationsError(err, request)
-	}
+	return pushOperations(s.images, s.operations, s.logger, request, sessionInfo), nil
+}
 
+// pushOperations stores uploaded images only after the operations pass the checks of the push, so rejected
+// pushes leave no images behind. Pushes without uploads are checked by the push alone.
+func pushOperations(
+	imagesController images.Controller,
+	operationsController operations.Controller,
+	logger logging.Service,
+	request openapi.PushOperationsRequest,
+	sessionInfo auth.UserDevice,
+) openapi.ImplResponse {
+	userId := operations.UserId(sessionInfo.User)
+	if hasUploads(request.Operations) {
+		if err := operationsController.Check(request.Operations, userId); err != nil {
+			return handlePushOperationsError(logger, err, request)
+		}
+	}
+	stored, err := imagesController.StoreUploads(request.Operations)
+	if err != nil {
+		return handlePushOperationsError(logger, err, request)
+	}
+	if err := operationsController.Push(stored, userId, operations.DeviceId(sessionInfo.Device)); err != nil {
+		return handlePushOperationsError(logger, err, request)
+	}
+	pushed, err := imagesController.InlineUploads(stored)
+	if err != nil {
+		return handlePushOperationsError(logger, err, request)
+	}
 	return openapi.Response(200, openapi.PushOperationsSucceededResponse{
-		Response: request.Operations,
-	}), nil
+		Response: pushed,
+	})
+}
+
+func hasUploads(pushed []openapi.SomeOperation) bool {
+	return slices.ContainsFunc(pushed, func(operation openapi.SomeOperation) bool {
+		return !openapi.IsZeroValue(operation.UploadImage)
+	})
 }
 
-func (s *DefaultAPIService) handlePushOperationsError(err error, request openapi.PushOperationsRequest) (openapi.ImplResponse, error) {
-	s.logger.LogError("push operations %v failed: %v", request, err)
+func handlePushOperationsError(logger logging.Service, err error, request openapi.PushOperationsRequest) openapi.ImplResponse {
+	var reason openapi.ErrorReason
+	var statusCode int
+
+	switch {
+	case errors.Is(err, operations.PrivacyViolation):
+		reason = openapi.PRIVACY_VIOLATION
+		statusCode = 403
+	case errors.Is(err, operations.BadFormat), errors.Is(err, images.BadFormat):
+		reason = openapi.WRONG_FORMAT
+		statusCode = 422
+	default:
+		logger.LogError("push operations %v failed: %v", request, err)
+		reason = openapi.INTERNAL
+		statusCode = 500
+	}
 
 	description := fmt.Errorf("push operations error: %w", err).Error()
-	return openapi.Response(500, openapi.ErrorResponse{
+	return openapi.Response(statusCode, openapi.ErrorResponse{
 		Error: openapi.Error{
-			Reason:      openapi.INTERNAL,
+			Reason:      reason,
 			Description: &description,
 		},
-	}), nil
+	})
 }
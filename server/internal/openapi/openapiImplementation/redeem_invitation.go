@@ -0,0 +1,59 @@
+package openapiImplementation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"verni/internal/controllers/invitations"
+	openapi "verni/internal/openapi/go"
+)
+
+func (s *DefaultAPIService) RedeemInvitation(
+	ctx context.Context,
+	token string,
+	request openapi.RedeemInvitationRequest,
+) (openapi.ImplResponse, error) {
+	sessionInfo, earlyResponse := s.validateToken(token)
+	if earlyResponse != nil {
+		return *earlyResponse, nil
+	}
+
+	groupId, err := s.invitations.Redeem(
+		invitations.UserId(sessionInfo.User),
+		invitations.Token(request.Token),
+	)
+	if err != nil {
+		return s.handleRedeemInvitationError(err)
+	}
+
+	return openapi.Response(200, openapi.RedeemInvitationSucceededResponse{
+		Response: string(groupId),
+	}), nil
+}
+
+func (s *DefaultAPIService) handleRedeemInvitationError(err error) (openapi.ImplResponse, error) {
+	var reason openapi.ErrorReason
+	var statusCode int
+
+	switch {
+	case errors.Is(err, invitations.NoSuchInvitation):
+		reason = openapi.NO_SUCH_INVITATION
+		statusCode = 404
+	case errors.Is(err, invitations.InvitationExpired):
+		reason = openapi.INVITATION_EXPIRED
+		statusCode = 410
+	default:
+		// tokens are secrets, so the request is not logged
+		s.logger.LogError("redeem invitation failed: %v", err)
+		reason = openapi.INTERNAL
+		statusCode = 500
+	}
+
+	description := fmt.Errorf("redeem invitation error: %w", err).Error()
+	return openapi.Response(statusCode, openapi.ErrorResponse{
+		Error: openapi.Error{
+			Reason:      reason,
+			Description: &description,
+		},
+	}), nil
+}
@@ -22,11 +22,16 @@ func (s *DefaultAPIService) Signup(
 	if err != nil {
 		return s.handleSignupError(err, request)
 	}
+	operations, err := s.images.InlineUploads(startupData.Operations)
+	if err != nil {
+		return s.handleSignupError(err, request)
+	}
 
 	return openapi.Response(200, openapi.SignupSucceededResponse{
 		Response: openapi.StartupData{
 			Session:    sessionToOpenapi(startupData.Session),
-			Operations: startupData.Operations,
+			Operations: operations,
+			HasMore:    startupData.HasMore,
 		},
 	}), nil
 }
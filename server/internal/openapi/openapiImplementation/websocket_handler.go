@@ -0,0 +1,296 @@
+package openapiImplementation
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	"verni/internal/common"
+	"verni/internal/controllers/auth"
+	"verni/internal/controllers/images"
+	"verni/internal/controllers/operations"
+	openapi "verni/internal/openapi/go"
+	"verni/internal/services/logging"
+	"verni/internal/services/realtimeEvents"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// maxWebSocketMessageBytes fits a push with an image upload
+	maxWebSocketMessageBytes = 16 << 20
+	// pongWait is how long a connection may stay silent, clients answer pings sent every heartbeatInterval
+	pongWait  = 2 * heartbeatInterval
+	writeWait = 10 * time.Second
+	// tokenCheckInterval is how often the token of an open connection is checked again, so connections of
+	// expired tokens and closed sessions do not outlive them
+	tokenCheckInterval = time.Minute
+)
+
+// kinds of messages sent by clients
+const (
+	pushMessage    = "push"
+	confirmMessage = "confirm"
+)
+
+// webSocketRequest is a message sent by a client, requestId is returned with the response to match them
+type webSocketRequest struct {
+	Type       string                  `json:"type"`
+	RequestId  string                  `json:"requestId"`
+	Operations []openapi.SomeOperation `json:"operations,omitempty"`
+	Ids        []string                `json:"ids,omitempty"`
+}
+
+// webSocketMessage is a message sent to a client, either an event of the operations queue or a response
+// with the status code and body the same request gets over http
+type webSocketMessage struct {
+	Id        string          `json:"id,omitempty"`
+	Event     string          `json:"event"`
+	RequestId string          `json:"requestId,omitempty"`
+	Code      int             `json:"code,omitempty"`
+	Data      json.RawMessage `json:"data"`
+}
+
+const responseEvent = "response"
+
+type webSocketHandler struct {
+	queue      *OperationsQueue
+	auth       auth.Controller
+	images     images.Controller
+	operations operations.Controller
+	logger     logging.Service
+	upgrader   websocket.Upgrader
+	// tokenCheckInterval is kept per handler so tests do not wait for the default one
+	tokenCheckInterval time.Duration
+}
+
+// NewWebSocketHandler serves the operations queue over a websocket: clients push and confirm operations with
+// messages and receive the same events as over sse. Browsers can not set headers of websocket requests, so the
+// token may be passed as the access_token query parameter and the last event id as lastEventId.
+func NewWebSocketHandler(
+	queue *OperationsQueue,
+	auth auth.Controller,
+	images images.Controller,
+	operations operations.Controller,
+	logger logging.Service,
+) func(w http.ResponseWriter, r *http.Request) {
+	return newWebSocketHandler(queue, auth, images, operations, logger).Handle
+}
+
+func newWebSocketHandler(
+	queue *OperationsQueue,
+	auth auth.Controller,
+	images images.Controller,
+	operations operations.Controller,
+	logger logging.Service,
+) *webSocketHandler {
+	return &webSocketHandler{
+		queue:      queue,
+		auth:       auth,
+		images:     images,
+		operations: operations,
+		logger:     logger,
+		upgrader: websocket.Upgrader{
+			// requests are authorized by the token rather than cookies, so any origin is allowed like for sse
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		tokenCheckInterval: tokenCheckInterval,
+	}
+}
+
+func (h *webSocketHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	const op = "openapiImplementation.webSocketHandler.Handle"
+	token := r.Header.Get("Authorization")
+	if token == "" && r.URL.Query().Has("access_token") {
+		token = "Bearer " + r.URL.Query().Get("access_token")
+	}
+	sessionInfo, earlyResponse := validateToken(h.logger, h.auth, token)
+	if earlyResponse != nil {
+		h.logger.LogInfo("%s: unable to open websocket connection: %v", op, earlyResponse.Body)
+		openapi.EncodeJSONResponse(earlyResponse.Body, &earlyResponse.Code, w)
+		return
+	}
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader has already responded with an error
+		h.logger.LogInfo("%s: upgrading connection for %s: %v", op, sessionInfo.User, err)
+		return
+	}
+	defer conn.Close()
+	h.logger.LogInfo("%s: opening websocket connection for %s", op, sessionInfo.User)
+
+	descriptor := connectionDescriptor{
+		userId: realtimeEvents.UserId(sessionInfo.User),
+		device: realtimeEvents.DeviceId(sessionInfo.Device),
+	}
+	lastEventId := r.Header.Get("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = r.URL.Query().Get("lastEventId")
+	}
+	messageChan, missed, resumed := h.queue.connect(descriptor, lastEventId)
+	defer func() {
+		h.logger.LogInfo("%s: cleaning up connection for descriptor: %v", op, descriptor)
+		h.queue.disconnect(descriptor, messageChan)
+	}()
+
+	// only this goroutine writes to the connection, responses of the reading one are passed here
+	responses := make(chan webSocketMessage, channelBuffer)
+	readerDone := make(chan struct{})
+	writerDone := make(chan struct{})
+	defer close(writerDone)
+	go h.read(conn, sessionInfo, responses, readerDone, writerDone)
+
+	if !h.write(conn, webSocketMessage{Event: connectedEvent, Data: json.RawMessage(`{"type": "connected"}`)}) {
+		return
+	}
+	if resumed {
+		for _, event := range missed {
+			if !h.writeEvent(conn, event) {
+				return
+			}
+		}
+	} else {
+		h.logger.LogInfo("%s: unable to replay events after %s for %v", op, lastEventId, descriptor)
+		if !h.write(conn, webSocketMessage{Event: resyncRequiredEvent, Data: json.RawMessage(`{"type": "resyncRequired"}`)}) {
+			return
+		}
+	}
+	h.queue.send(descriptor, h.queue.pulled(descriptor))
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	tokenCheck := time.NewTicker(h.tokenCheckInterval)
+	defer tokenCheck.Stop()
+	for {
+		select {
+		case event, ok := <-messageChan:
+			if !ok {
+				h.logger.LogInfo("%s: connection is stale for descriptor %v", op, descriptor)
+				return
+			}
+			if !h.writeEvent(conn, event) {
+				return
+			}
+		case response := <-responses:
+			if !h.write(conn, response) {
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				h.logger.LogInfo("%s: ping failed for %v: %v", op, descriptor, err)
+				return
+			}
+		case <-tokenCheck.C:
+			if _, earlyResponse := validateToken(h.logger, h.auth, token); earlyResponse != nil {
+				h.logger.LogInfo("%s: token is no longer valid for %v: %v", op, descriptor, earlyResponse.Body)
+				message := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "token is no longer valid")
+				conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(writeWait))
+				return
+			}
+		case <-readerDone:
+			h.logger.LogInfo("%s: connection closed for descriptor %v", op, descriptor)
+			return
+		}
+	}
+}
+
+func (h *webSocketHandler) writeEvent(conn *websocket.Conn, event queueEvent) bool {
+	return h.write(conn, webSocketMessage{
+		Id:    h.queue.eventId(event),
+		Event: event.name,
+		Data:  json.RawMessage(event.data),
+	})
+}
+
+func (h *webSocketHandler) write(conn *websocket.Conn, message webSocketMessage) bool {
+	const op = "openapiImplementation.webSocketHandler.write"
+	conn.SetWriteDeadline(time.Now().Add(writeWait))
+	if err := conn.WriteJSON(message); err != nil {
+		h.logger.LogInfo("%s: writing %s: %v", op, message.Event, err)
+		return false
+	}
+	return true
+}
+
+// read handles requests of the client one by one until the connection or the writing goroutine is closed
+func (h *webSocketHandler) read(
+	conn *websocket.Conn,
+	sessionInfo auth.UserDevice,
+	responses chan<- webSocketMessage,
+	done chan<- struct{},
+	writerDone <-chan struct{},
+) {
+	const op = "openapiImplementation.webSocketHandler.read"
+	defer close(done)
+	conn.SetReadLimit(maxWebSocketMessageBytes)
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			h.logger.LogInfo("%s: reading message of %s: %v", op, sessionInfo.User, err)
+			return
+		}
+		var request webSocketRequest
+		var response webSocketMessage
+		if err := json.Unmarshal(data, &request); err != nil {
+			response = h.response(request.RequestId, badRequest(fmt.Sprintf("malformed message: %v", err)))
+		} else {
+			response = h.response(request.RequestId, h.handle(request, sessionInfo))
+		}
+		select {
+		case responses <- response:
+		case <-writerDone:
+			return
+		}
+	}
+}
+
+func (h *webSocketHandler) handle(request webSocketRequest, sessionInfo auth.UserDevice) openapi.ImplResponse {
+	switch request.Type {
+	case pushMessage:
+		return pushOperations(h.images, h.operations, h.logger, openapi.PushOperationsRequest{Operations: request.Operations}, sessionInfo)
+	case confirmMessage:
+		if err := h.operations.Confirm(
+			common.Map(request.Ids, func(id string) operations.OperationId {
+				return operations.OperationId(id)
+			}),
+			operations.UserId(sessionInfo.User),
+			operations.DeviceId(sessionInfo.Device),
+		); err != nil {
+			return handleConfirmOperationsError(h.logger, err, request.Ids)
+		}
+		return openapi.Response(200, openapi.ConfirmOperationsSucceededResponse{
+			Response: map[string]interface{}{},
+		})
+	default:
+		return badRequest(fmt.Sprintf("unknown message type %q", request.Type))
+	}
+}
+
+func (h *webSocketHandler) response(requestId string, response openapi.ImplResponse) webSocketMessage {
+	const op = "openapiImplementation.webSocketHandler.response"
+	data, err := json.Marshal(response.Body)
+	if err != nil {
+		h.logger.LogError("%s: marshalling response to %s: %v", op, requestId, err)
+		response = openapi.Response(500, openapi.ErrorResponse{Error: openapi.Error{Reason: openapi.INTERNAL}})
+		data, _ = json.Marshal(response.Body)
+	}
+	return webSocketMessage{
+		Event:     responseEvent,
+		RequestId: requestId,
+		Code:      response.Code,
+		Data:      data,
+	}
+}
+
+func badRequest(description string) openapi.ImplResponse {
+	return openapi.Response(400, openapi.ErrorResponse{
+		Error: openapi.Error{
+			Reason:      openapi.BAD_REQUEST,
+			Description: &description,
+		},
+	})
+}
@@ -0,0 +1,140 @@
+package openapiImplementation
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"verni/internal/controllers/auth"
+	standartOutputLoggingService "verni/internal/services/logging/standartOutput"
+)
+
+// authStub accepts a single token until it is expired, other methods of the controller are not used by the handler
+type authStub struct {
+	auth.Controller
+	expired atomic.Bool
+}
+
+func (a *authStub) CheckToken(accessToken string) (auth.UserDevice, error) {
+	if accessToken != "token" || a.expired.Load() {
+		return auth.UserDevice{}, auth.TokenExpired
+	}
+	return auth.UserDevice{User: "alice", Device: "phone"}, nil
+}
+
+func serveWebSocket(t *testing.T, queue *testQueue, authController auth.Controller) *httptest.Server {
+	handler := newWebSocketHandler(queue.OperationsQueue, authController, imagesStub{}, operationsStub{}, standartOutputLoggingService.New())
+	handler.tokenCheckInterval = 20 * time.Millisecond
+	server := httptest.NewServer(http.HandlerFunc(handler.Handle))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func dialWebSocket(server *httptest.Server, query string) (*websocket.Conn, *http.Response, error) {
+	return websocket.DefaultDialer.Dial(strings.Replace(server.URL, "http", "ws", 1)+"?"+query, nil)
+}
+
+func readWebSocketMessage(t *testing.T, conn *websocket.Conn) webSocketMessage {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	var message webSocketMessage
+	require.NoError(t, conn.ReadJSON(&message))
+	return message
+}
+
+func TestWebSocketHandler(t *testing.T) {
+	t.Run("pushed events are received until the token expires", func(t *testing.T) {
+		// Arrange
+		queue := newTestQueue(t)
+		authController := &authStub{}
+		server := serveWebSocket(t, queue, authController)
+		conn, _, err := dialWebSocket(server, "access_token=token")
+		require.NoError(t, err)
+		defer conn.Close()
+		assert.Equal(t, connectedEvent, readWebSocketMessage(t, conn).Event)
+		assert.Equal(t, operationsPulledEvent, readWebSocketMessage(t, conn).Event)
+
+		// Act
+		queue.push(t, "alice", "pushed")
+		pushed := readWebSocketMessage(t, conn)
+		authController.expired.Store(true)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		_, _, closeErr := conn.ReadMessage()
+
+		// Assert
+		assert.Equal(t, operationsPushedEvent, pushed.Event)
+		assert.NotEmpty(t, pushed.Id)
+		assert.Equal(t, []string{"pushed"}, pushedOperationIds(t, []queueEvent{{name: pushed.Event, data: string(pushed.Data)}}))
+		assert.True(t, websocket.IsCloseError(closeErr, websocket.ClosePolicyViolation), closeErr)
+		assert.Eventually(t, func() bool {
+			queue.connectionsMutex.RLock()
+			defer queue.connectionsMutex.RUnlock()
+			return len(queue.connections) == 0
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("missed events are replayed on reconnect", func(t *testing.T) {
+		// Arrange
+		queue := newTestQueue(t)
+		server := serveWebSocket(t, queue, &authStub{})
+		conn, _, err := dialWebSocket(server, "access_token=token")
+		require.NoError(t, err)
+		readWebSocketMessage(t, conn)
+		pulled := readWebSocketMessage(t, conn)
+		conn.Close()
+		assert.Eventually(t, func() bool {
+			queue.connectionsMutex.RLock()
+			defer queue.connectionsMutex.RUnlock()
+			return len(queue.connections) == 0
+		}, 5*time.Second, 10*time.Millisecond)
+		queue.push(t, "alice", "missed")
+
+		// Act
+		conn, _, err = dialWebSocket(server, "access_token=token&lastEventId="+pulled.Id)
+		require.NoError(t, err)
+		defer conn.Close()
+		connected := readWebSocketMessage(t, conn)
+		missed := readWebSocketMessage(t, conn)
+
+		// Assert
+		assert.Equal(t, connectedEvent, connected.Event)
+		assert.Equal(t, []string{"missed"}, pushedOperationIds(t, []queueEvent{{name: missed.Event, data: string(missed.Data)}}))
+	})
+
+	t.Run("unknown last event id requires resync", func(t *testing.T) {
+		// Arrange
+		queue := newTestQueue(t)
+		server := serveWebSocket(t, queue, &authStub{})
+
+		// Act
+		conn, _, err := dialWebSocket(server, "access_token=token&lastEventId=unknown")
+		require.NoError(t, err)
+		defer conn.Close()
+		connected := readWebSocketMessage(t, conn)
+		resync := readWebSocketMessage(t, conn)
+
+		// Assert
+		assert.Equal(t, connectedEvent, connected.Event)
+		assert.Equal(t, resyncRequiredEvent, resync.Event)
+	})
+
+	t.Run("connection is refused with an invalid token", func(t *testing.T) {
+		// Arrange
+		queue := newTestQueue(t)
+		server := serveWebSocket(t, queue, &authStub{})
+
+		// Act
+		_, response, err := dialWebSocket(server, "access_token=other")
+
+		// Assert
+		assert.ErrorIs(t, err, websocket.ErrBadHandshake)
+		require.NotNil(t, response)
+		assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+	})
+}
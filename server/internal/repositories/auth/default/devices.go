@@ -0,0 +1,169 @@
+package defaultRepository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"verni/internal/repositories"
+	"verni/internal/repositories/auth"
+)
+
+func (c *defaultRepository) revokeDevices(user auth.UserId, devices []auth.DeviceId, revokedAt int64) error {
+	const op = "repositories.auth.defaultRepository.revokeDevices"
+	c.logger.LogInfo("%s: start[user=%s]", op, user)
+
+	if len(devices) == 0 {
+		c.logger.LogInfo("%s: no devices passed, early empty return", op)
+		return nil
+	}
+
+	placeholders := make([]string, len(devices))
+	args := []interface{}{string(user), revokedAt}
+	for i, device := range devices {
+		placeholders[i] = fmt.Sprintf("$%d", i+3)
+		args = append(args, string(device))
+	}
+
+	query := fmt.Sprintf("UPDATE devices SET revokedAt = $2 WHERE userId = $1 AND deviceId IN (%s)", strings.Join(placeholders, ", "))
+	if _, err := c.db.Exec(query, args...); err != nil {
+		return fmt.Errorf("%s: failed to perform query: %w", op, err)
+	}
+
+	c.logger.LogInfo("%s: success[user=%s]", op, user)
+	return nil
+}
+
+func (c *defaultRepository) GetDevicesWithSession() ([]auth.Device, error) {
+	const op = "repositories.auth.defaultRepository.GetDevicesWithSession"
+	c.logger.LogInfo("%s: start", op)
+
+	query := `
+SELECT r.userId, r.deviceId, COALESCE(d.lastSeen, 0), d.revokedAt
+FROM refreshTokens r
+LEFT JOIN devices d ON d.userId = r.userId AND d.deviceId = r.deviceId;`
+	rows, err := c.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to perform query: %w", op, err)
+	}
+	defer rows.Close()
+
+	result := []auth.Device{}
+	for rows.Next() {
+		var device auth.Device
+		var revokedAt sql.NullInt64
+		if err := rows.Scan(&device.User, &device.Device, &device.LastSeen, &revokedAt); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+		if revokedAt.Valid {
+			device.RevokedAt = &revokedAt.Int64
+		}
+		result = append(result, device)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: error encountered during iteration: %w", op, err)
+	}
+
+	c.logger.LogInfo("%s: success[count=%d]", op, len(result))
+	return result, nil
+}
+
+func (c *defaultRepository) GetDevicesWithoutSession() ([]auth.Device, error) {
+	const op = "repositories.auth.defaultRepository.GetDevicesWithoutSession"
+	c.logger.LogInfo("%s: start", op)
+
+	query := `
+SELECT d.userId, d.deviceId, d.lastSeen, d.revokedAt
+FROM devices d
+WHERE NOT EXISTS(
+	SELECT 1 FROM refreshTokens r WHERE r.userId = d.userId AND r.deviceId = d.deviceId
+)
+ORDER BY d.lastSeen;`
+	rows, err := c.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to perform query: %w", op, err)
+	}
+	defer rows.Close()
+
+	result := []auth.Device{}
+	for rows.Next() {
+		var device auth.Device
+		var revokedAt sql.NullInt64
+		if err := rows.Scan(&device.User, &device.Device, &device.LastSeen, &revokedAt); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+		if revokedAt.Valid {
+			device.RevokedAt = &revokedAt.Int64
+		}
+		result = append(result, device)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: error encountered during iteration: %w", op, err)
+	}
+
+	c.logger.LogInfo("%s: success[count=%d]", op, len(result))
+	return result, nil
+}
+
+func (c *defaultRepository) ForgetDevice(user auth.UserId, device auth.DeviceId) repositories.UnitOfWork {
+	var forgotten *auth.Device
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			var err error
+			forgotten, err = c.forgetDevice(user, device)
+			return err
+		},
+		Rollback: func() error {
+			if forgotten == nil {
+				return nil
+			}
+			return c.restoreDevice(*forgotten)
+		},
+	}
+}
+
+func (c *defaultRepository) forgetDevice(user auth.UserId, device auth.DeviceId) (*auth.Device, error) {
+	const op = "repositories.auth.defaultRepository.forgetDevice"
+	c.logger.LogInfo("%s: start[user=%s device=%s]", op, user, device)
+
+	query := `
+DELETE FROM devices d
+WHERE d.userId = $1 AND d.deviceId = $2 AND NOT EXISTS(
+	SELECT 1 FROM refreshTokens r WHERE r.userId = d.userId AND r.deviceId = d.deviceId
+)
+RETURNING d.lastSeen, d.revokedAt;`
+	forgotten := auth.Device{
+		User:   user,
+		Device: device,
+	}
+	var revokedAt sql.NullInt64
+	if err := c.db.QueryRow(query, string(user), string(device)).Scan(&forgotten.LastSeen, &revokedAt); err != nil {
+		if err == sql.ErrNoRows {
+			c.logger.LogInfo("%s: device has a session or is already forgotten", op)
+			return nil, fmt.Errorf("%s: %w", op, auth.ErrNotForgotten)
+		}
+		return nil, fmt.Errorf("%s: failed to perform query: %w", op, err)
+	}
+	if revokedAt.Valid {
+		forgotten.RevokedAt = &revokedAt.Int64
+	}
+
+	c.logger.LogInfo("%s: success[user=%s device=%s]", op, user, device)
+	return &forgotten, nil
+}
+
+func (c *defaultRepository) restoreDevice(device auth.Device) error {
+	const op = "repositories.auth.defaultRepository.restoreDevice"
+	c.logger.LogInfo("%s: start[user=%s device=%s]", op, device.User, device.Device)
+
+	query := `
+INSERT INTO devices(userId, deviceId, lastSeen, revokedAt)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (userId, deviceId) DO NOTHING;`
+	if _, err := c.db.Exec(query, string(device.User), string(device.Device), device.LastSeen, device.RevokedAt); err != nil {
+		return fmt.Errorf("%s: failed to perform query: %w", op, err)
+	}
+
+	c.logger.LogInfo("%s: success[user=%s device=%s]", op, device.User, device.Device)
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"verni/internal/db"
 	"verni/internal/repositories"
@@ -147,7 +148,10 @@ func (c *defaultRepository) ExclusiveSession(user auth.UserId, device auth.Devic
 			for device := range tokensData {
 				devices = append(devices, device)
 			}
-			return c.removeTokenData(user, devices)
+			if err := c.removeTokenData(user, devices); err != nil {
+				return err
+			}
+			return c.revokeDevices(user, devices, time.Now().UnixMilli())
 		},
 		Rollback: func() error {
 			var result error = nil
@@ -287,12 +291,17 @@ func (c *defaultRepository) updateRefreshToken(user auth.UserId, device auth.Dev
 	c.logger.LogInfo("%s: start[user=%s]", op, user)
 
 	query := `
-INSERT INTO refreshTokens(userId, deviceId, refreshToken)
-VALUES ($1, $2, $3)
-ON CONFLICT (userId, deviceId) DO UPDATE SET refreshToken = EXCLUDED.refreshToken;
+WITH session AS (
+	INSERT INTO refreshTokens(userId, deviceId, refreshToken)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (userId, deviceId) DO UPDATE SET refreshToken = EXCLUDED.refreshToken
+)
+INSERT INTO devices(userId, deviceId, lastSeen)
+VALUES ($1, $2, $4)
+ON CONFLICT (userId, deviceId) DO UPDATE SET lastSeen = EXCLUDED.lastSeen, revokedAt = NULL;
 `
 
-	_, err := c.db.Exec(query, string(user), string(device), token)
+	_, err := c.db.Exec(query, string(user), string(device), token, time.Now().UnixMilli())
 	if err != nil {
 		return fmt.Errorf("%s: failed to perform query: %w", op, err)
 	}
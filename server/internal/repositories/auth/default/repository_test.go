@@ -3,6 +3,7 @@ package defaultRepository_test
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"os"
 	"testing"
 
@@ -24,6 +25,9 @@ func setupTestDB(t *testing.T) *sql.DB {
 	path := pathProvider.AbsolutePath("./config/test/postgres_storage.json")
 
 	configFile, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Skipf("no postgres test config at %s, see scripts/test.sh", path)
+	}
 	require.NoError(t, err)
 
 	err = json.Unmarshal(configFile, &testConfig)
@@ -35,6 +39,8 @@ func setupTestDB(t *testing.T) *sql.DB {
 	// Clear test data
 	_, err = db.Exec("DELETE FROM refreshTokens")
 	require.NoError(t, err)
+	_, err = db.Exec("DELETE FROM devices")
+	require.NoError(t, err)
 	_, err = db.Exec("DELETE FROM credentials")
 	require.NoError(t, err)
 
@@ -549,6 +555,118 @@ func TestRepository_ExclusiveSession_Rollback(t *testing.T) {
 	})
 }
 
+func TestRepository_Devices(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	logger := standartOutputLoggingService.New()
+	repo := defaultRepository.New(db, logger)
+
+	t.Run("revoked device has no session until forgotten", func(t *testing.T) {
+		// Arrange
+		userId := auth.UserId("test-user-16")
+		phone := auth.DeviceId("phone")
+		laptop := auth.DeviceId("laptop")
+
+		work := repo.UpdateRefreshToken(userId, phone, "token1")
+		require.NoError(t, work.Perform())
+		work = repo.UpdateRefreshToken(userId, laptop, "token2")
+		require.NoError(t, work.Perform())
+
+		work = repo.ExclusiveSession(userId, phone)
+		require.NoError(t, work.Perform())
+
+		// Act
+		withoutSession, err := repo.GetDevicesWithoutSession()
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, withoutSession, 1)
+		assert.Equal(t, userId, withoutSession[0].User)
+		assert.Equal(t, laptop, withoutSession[0].Device)
+		assert.NotZero(t, withoutSession[0].LastSeen)
+		assert.NotNil(t, withoutSession[0].RevokedAt)
+
+		withSession, err := repo.GetDevicesWithSession()
+		require.NoError(t, err)
+		devicesOfUser := []auth.DeviceId{}
+		for _, device := range withSession {
+			if device.User == userId {
+				devicesOfUser = append(devicesOfUser, device.Device)
+			}
+		}
+		assert.Equal(t, []auth.DeviceId{phone}, devicesOfUser)
+
+		// Act - Forget both devices
+		work = repo.ForgetDevice(userId, laptop)
+		require.NoError(t, work.Perform())
+		work = repo.ForgetDevice(userId, phone)
+		assert.ErrorIs(t, work.Perform(), auth.ErrNotForgotten)
+		work = repo.ForgetDevice(userId, laptop)
+		assert.ErrorIs(t, work.Perform(), auth.ErrNotForgotten)
+
+		// Assert - Device with session is kept
+		withoutSession, err = repo.GetDevicesWithoutSession()
+		require.NoError(t, err)
+		assert.Empty(t, withoutSession)
+
+		exists, err := repo.IsSessionExists(userId, phone)
+		assert.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("rollback forget device", func(t *testing.T) {
+		// Arrange
+		userId := auth.UserId("test-user-17")
+		phone := auth.DeviceId("phone")
+		laptop := auth.DeviceId("laptop")
+
+		work := repo.UpdateRefreshToken(userId, phone, "token1")
+		require.NoError(t, work.Perform())
+		work = repo.UpdateRefreshToken(userId, laptop, "token2")
+		require.NoError(t, work.Perform())
+		work = repo.ExclusiveSession(userId, phone)
+		require.NoError(t, work.Perform())
+
+		// Act
+		work = repo.ForgetDevice(userId, laptop)
+		require.NoError(t, work.Perform())
+		require.NoError(t, work.Rollback())
+
+		// Assert
+		withoutSession, err := repo.GetDevicesWithoutSession()
+		require.NoError(t, err)
+		require.Len(t, withoutSession, 1)
+		assert.Equal(t, laptop, withoutSession[0].Device)
+		assert.NotNil(t, withoutSession[0].RevokedAt)
+	})
+
+	t.Run("signing in again clears revocation", func(t *testing.T) {
+		// Arrange
+		userId := auth.UserId("test-user-18")
+		phone := auth.DeviceId("phone")
+		laptop := auth.DeviceId("laptop")
+
+		work := repo.UpdateRefreshToken(userId, phone, "token1")
+		require.NoError(t, work.Perform())
+		work = repo.UpdateRefreshToken(userId, laptop, "token2")
+		require.NoError(t, work.Perform())
+		work = repo.ExclusiveSession(userId, phone)
+		require.NoError(t, work.Perform())
+
+		// Act
+		work = repo.UpdateRefreshToken(userId, laptop, "token3")
+		require.NoError(t, work.Perform())
+
+		// Assert
+		withoutSession, err := repo.GetDevicesWithoutSession()
+		require.NoError(t, err)
+		for _, device := range withoutSession {
+			assert.NotEqual(t, userId, device.User)
+		}
+	})
+}
+
 func TestMain(m *testing.M) {
 	// Setup code (create database, tables, etc.)
 	code := m.Run()
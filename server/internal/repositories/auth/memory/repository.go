@@ -0,0 +1,490 @@
+package memoryRepository
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"verni/internal/repositories"
+	"verni/internal/repositories/auth"
+	"verni/internal/services/logging"
+)
+
+func New(logger logging.Service) auth.Repository {
+	return &memoryRepository{
+		credentials:   map[auth.UserId]auth.UserInfo{},
+		refreshTokens: map[auth.UserId]map[auth.DeviceId]string{},
+		devices:       map[auth.UserId]map[auth.DeviceId]auth.Device{},
+		logger:        logger,
+	}
+}
+
+type memoryRepository struct {
+	mutex         sync.RWMutex
+	credentials   map[auth.UserId]auth.UserInfo
+	refreshTokens map[auth.UserId]map[auth.DeviceId]string
+	devices       map[auth.UserId]map[auth.DeviceId]auth.Device
+	logger        logging.Service
+}
+
+func (c *memoryRepository) CreateUser(user auth.UserId, email string, password string) repositories.UnitOfWork {
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			return c.createUser(user, email, password)
+		},
+		Rollback: func() error {
+			return c.deleteUser(user)
+		},
+	}
+}
+
+func (c *memoryRepository) createUser(user auth.UserId, email, password string) error {
+	const op = "repositories.auth.memoryRepository.createUser"
+	c.logger.LogInfo("%s: start[user=%s email=%s]", op, user, email)
+
+	passwordHash, err := hashPassword(password)
+	if err != nil {
+		return fmt.Errorf("%s: cannot hash password: %w", op, err)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if userWithEmail := c.userIdByEmail(email); userWithEmail != nil {
+		return fmt.Errorf("%s: user with email %s already exists", op, email)
+	}
+	if _, exists := c.credentials[user]; exists {
+		return fmt.Errorf("%s: user %s already exists", op, user)
+	}
+	c.credentials[user] = auth.UserInfo{
+		UserId:        user,
+		Email:         email,
+		PasswordHash:  passwordHash,
+		EmailVerified: false,
+	}
+
+	c.logger.LogInfo("%s: success[uid=%s email=%s]", op, user, email)
+	return nil
+}
+
+func (c *memoryRepository) deleteUser(user auth.UserId) error {
+	const op = "repositories.auth.memoryRepository.deleteUser"
+	c.logger.LogInfo("%s: start[user=%s]", op, user)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.credentials, user)
+
+	c.logger.LogInfo("%s: success[uid=%s]", op, user)
+	return nil
+}
+
+func (c *memoryRepository) MarkUserEmailValidated(user auth.UserId) repositories.UnitOfWork {
+	const op = "repositories.auth.memoryRepository.MarkUserEmailValidated"
+
+	existed, err := c.GetUserInfo(user)
+	if err != nil {
+		c.logger.LogInfo("%s: failed to get current credentials: %v", op, err)
+		return repositories.UnitOfWork{
+			Perform:  func() error { return err },
+			Rollback: func() error { return err },
+		}
+	}
+
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			if existed.EmailVerified {
+				return nil
+			}
+			return c.updateEmail(user, existed.Email, true)
+		},
+		Rollback: func() error {
+			if existed.EmailVerified {
+				return nil
+			}
+			return c.updateEmail(user, existed.Email, false)
+		},
+	}
+}
+
+func (c *memoryRepository) IsUserExists(user auth.UserId) (bool, error) {
+	const op = "repositories.auth.memoryRepository.IsUserExists"
+	c.logger.LogInfo("%s: start[user=%s]", op, user)
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	_, exists := c.credentials[user]
+
+	c.logger.LogInfo("%s: success[uid=%s]", op, user)
+	return exists, nil
+}
+
+func (c *memoryRepository) IsSessionExists(user auth.UserId, device auth.DeviceId) (bool, error) {
+	const op = "repositories.auth.memoryRepository.IsSessionExists"
+	c.logger.LogInfo("%s: start[user=%s]", op, user)
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	_, exists := c.refreshTokens[user][device]
+
+	c.logger.LogInfo("%s: success[user=%s]", op, user)
+	return exists, nil
+}
+
+func (c *memoryRepository) ExclusiveSession(user auth.UserId, device auth.DeviceId) repositories.UnitOfWork {
+	tokensData := c.getTokenDataPerDevice(user)
+	delete(tokensData, device)
+
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+
+			revokedAt := time.Now().UnixMilli()
+			for device := range tokensData {
+				delete(c.refreshTokens[user], device)
+				if known, exists := c.devices[user][device]; exists {
+					known.RevokedAt = &revokedAt
+					c.devices[user][device] = known
+				}
+			}
+			return nil
+		},
+		Rollback: func() error {
+			for device, token := range tokensData {
+				c.updateRefreshToken(user, device, token)
+			}
+			return nil
+		},
+	}
+}
+
+func (c *memoryRepository) getTokenDataPerDevice(user auth.UserId) map[auth.DeviceId]string {
+	const op = "repositories.auth.memoryRepository.getTokenDataPerDevice"
+	c.logger.LogInfo("%s: start[user=%s]", op, user)
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	tokensMap := map[auth.DeviceId]string{}
+	for device, token := range c.refreshTokens[user] {
+		tokensMap[device] = token
+	}
+	return tokensMap
+}
+
+func (c *memoryRepository) CheckCredentials(email, password string) (bool, error) {
+	const op = "repositories.auth.memoryRepository.CheckCredentials"
+	c.logger.LogInfo("%s: start[email=%s]", op, email)
+
+	c.mutex.RLock()
+	userId := c.userIdByEmail(email)
+	if userId == nil {
+		c.mutex.RUnlock()
+		c.logger.LogInfo("%s: no user associated with email", op)
+		return false, nil
+	}
+	passwordHash := c.credentials[*userId].PasswordHash
+	c.mutex.RUnlock()
+
+	return checkPasswordHash(password, passwordHash), nil
+}
+
+func (c *memoryRepository) GetUserIdByEmail(email string) (*auth.UserId, error) {
+	const op = "repositories.auth.memoryRepository.GetUserIdByEmail"
+	c.logger.LogInfo("%s: start[email=%s]", op, email)
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	userId := c.userIdByEmail(email)
+	if userId == nil {
+		c.logger.LogInfo("%s: no user found for email=%s", op, email)
+		return nil, nil
+	}
+
+	c.logger.LogInfo("%s: success[email=%s]", op, email)
+	return userId, nil
+}
+
+func (c *memoryRepository) userIdByEmail(email string) *auth.UserId {
+	for userId, info := range c.credentials {
+		if info.Email == email {
+			return &userId
+		}
+	}
+	return nil
+}
+
+func (c *memoryRepository) UpdateRefreshToken(user auth.UserId, device auth.DeviceId, token string) repositories.UnitOfWork {
+	const op = "repositories.auth.memoryRepository.UpdateRefreshToken"
+	c.logger.LogInfo("%s: start[uid=%s]", op, user)
+
+	existed := c.getTokenDataPerDevice(user)
+
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			c.updateRefreshToken(user, device, token)
+			return nil
+		},
+		Rollback: func() error {
+			if previousToken, ok := existed[device]; ok {
+				c.updateRefreshToken(user, device, previousToken)
+				return nil
+			}
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+
+			delete(c.refreshTokens[user], device)
+			return nil
+		},
+	}
+}
+
+func (c *memoryRepository) updateRefreshToken(user auth.UserId, device auth.DeviceId, token string) {
+	const op = "repositories.auth.memoryRepository.updateRefreshToken"
+	c.logger.LogInfo("%s: start[user=%s]", op, user)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, ok := c.refreshTokens[user]; !ok {
+		c.refreshTokens[user] = map[auth.DeviceId]string{}
+	}
+	c.refreshTokens[user][device] = token
+	if _, ok := c.devices[user]; !ok {
+		c.devices[user] = map[auth.DeviceId]auth.Device{}
+	}
+	c.devices[user][device] = auth.Device{
+		User:     user,
+		Device:   device,
+		LastSeen: time.Now().UnixMilli(),
+	}
+
+	c.logger.LogInfo("%s: success[user=%s]", op, user)
+}
+
+func (c *memoryRepository) CheckRefreshToken(user auth.UserId, device auth.DeviceId, token string) (bool, error) {
+	const op = "repositories.auth.memoryRepository.CheckRefreshToken"
+	c.logger.LogInfo("%s: start[user=%s]", op, user)
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	current, exists := c.refreshTokens[user][device]
+
+	c.logger.LogInfo("%s: success[user=%s]", op, user)
+	return exists && current == token, nil
+}
+
+func (c *memoryRepository) UpdatePassword(user auth.UserId, password string) repositories.UnitOfWork {
+	const op = "repositories.auth.memoryRepository.UpdatePassword"
+	c.logger.LogInfo("%s: start[user=%s]", op, user)
+
+	existed, err := c.GetUserInfo(user)
+	if err != nil {
+		c.logger.LogInfo("%s: failed to get current credentials: %v", op, err)
+		return repositories.UnitOfWork{
+			Perform:  func() error { return err },
+			Rollback: func() error { return err },
+		}
+	}
+
+	passwordHash, err := hashPassword(password)
+	if err != nil {
+		c.logger.LogInfo("%s: cannot hash password: %v", op, err)
+		return repositories.UnitOfWork{
+			Perform:  func() error { return err },
+			Rollback: func() error { return err },
+		}
+	}
+
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			return c.updatePassword(user, passwordHash)
+		},
+		Rollback: func() error {
+			return c.updatePassword(user, existed.PasswordHash)
+		},
+	}
+}
+
+func (c *memoryRepository) updatePassword(user auth.UserId, passwordHash string) error {
+	const op = "repositories.auth.memoryRepository.updatePassword"
+	c.logger.LogInfo("%s: start[user=%s]", op, user)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	info, exists := c.credentials[user]
+	if !exists {
+		return nil
+	}
+	info.PasswordHash = passwordHash
+	c.credentials[user] = info
+
+	c.logger.LogInfo("%s: success[user=%s]", op, user)
+	return nil
+}
+
+func (c *memoryRepository) UpdateEmail(user auth.UserId, newEmail string) repositories.UnitOfWork {
+	const op = "repositories.auth.memoryRepository.UpdateEmail"
+	c.logger.LogInfo("%s: start[user=%s]", op, user)
+
+	existed, err := c.GetUserInfo(user)
+	if err != nil {
+		c.logger.LogInfo("%s: failed to get current credentials: %v", op, err)
+		return repositories.UnitOfWork{
+			Perform:  func() error { return err },
+			Rollback: func() error { return err },
+		}
+	}
+
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			return c.updateEmail(user, newEmail, false)
+		},
+		Rollback: func() error {
+			return c.updateEmail(user, existed.Email, existed.EmailVerified)
+		},
+	}
+}
+
+func (c *memoryRepository) updateEmail(user auth.UserId, newEmail string, verified bool) error {
+	const op = "repositories.auth.memoryRepository.updateEmail"
+	c.logger.LogInfo("%s: start[user=%s]", op, user)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if userWithEmail := c.userIdByEmail(newEmail); userWithEmail != nil && *userWithEmail != user {
+		return fmt.Errorf("%s: user with email %s already exists", op, newEmail)
+	}
+	info, exists := c.credentials[user]
+	if !exists {
+		return nil
+	}
+	info.Email = newEmail
+	info.EmailVerified = verified
+	c.credentials[user] = info
+
+	c.logger.LogInfo("%s: success[user=%s]", op, user)
+	return nil
+}
+
+func (c *memoryRepository) GetUserInfo(user auth.UserId) (auth.UserInfo, error) {
+	const op = "repositories.auth.memoryRepository.GetUserInfo"
+	c.logger.LogInfo("%s: start[user=%s]", op, user)
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	info, exists := c.credentials[user]
+	if !exists {
+		return auth.UserInfo{}, fmt.Errorf("%s: no credentials found for user %s", op, user)
+	}
+
+	c.logger.LogInfo("%s: success[user=%s]", op, user)
+	return info, nil
+}
+
+func (c *memoryRepository) GetDevicesWithSession() ([]auth.Device, error) {
+	const op = "repositories.auth.memoryRepository.GetDevicesWithSession"
+	c.logger.LogInfo("%s: start", op)
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	result := []auth.Device{}
+	for user, devices := range c.refreshTokens {
+		for device := range devices {
+			known, exists := c.devices[user][device]
+			if !exists {
+				known = auth.Device{User: user, Device: device}
+			}
+			result = append(result, known)
+		}
+	}
+
+	c.logger.LogInfo("%s: success[count=%d]", op, len(result))
+	return result, nil
+}
+
+func (c *memoryRepository) GetDevicesWithoutSession() ([]auth.Device, error) {
+	const op = "repositories.auth.memoryRepository.GetDevicesWithoutSession"
+	c.logger.LogInfo("%s: start", op)
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	result := []auth.Device{}
+	for user, devices := range c.devices {
+		for device, known := range devices {
+			if _, exists := c.refreshTokens[user][device]; exists {
+				continue
+			}
+			result = append(result, known)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LastSeen < result[j].LastSeen
+	})
+
+	c.logger.LogInfo("%s: success[count=%d]", op, len(result))
+	return result, nil
+}
+
+func (c *memoryRepository) ForgetDevice(user auth.UserId, device auth.DeviceId) repositories.UnitOfWork {
+	const op = "repositories.auth.memoryRepository.ForgetDevice"
+	var forgotten *auth.Device
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			forgotten = c.forgetDevice(user, device)
+			if forgotten == nil {
+				return fmt.Errorf("%s: %w", op, auth.ErrNotForgotten)
+			}
+			return nil
+		},
+		Rollback: func() error {
+			if forgotten == nil {
+				return nil
+			}
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+
+			if _, ok := c.devices[user]; !ok {
+				c.devices[user] = map[auth.DeviceId]auth.Device{}
+			}
+			if _, exists := c.devices[user][device]; !exists {
+				c.devices[user][device] = *forgotten
+			}
+			return nil
+		},
+	}
+}
+
+func (c *memoryRepository) forgetDevice(user auth.UserId, device auth.DeviceId) *auth.Device {
+	const op = "repositories.auth.memoryRepository.forgetDevice"
+	c.logger.LogInfo("%s: start[user=%s device=%s]", op, user, device)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	known, exists := c.devices[user][device]
+	if !exists {
+		c.logger.LogInfo("%s: device is already forgotten", op)
+		return nil
+	}
+	if _, hasSession := c.refreshTokens[user][device]; hasSession {
+		c.logger.LogInfo("%s: device has a session", op)
+		return nil
+	}
+	delete(c.devices[user], device)
+
+	c.logger.LogInfo("%s: success[user=%s device=%s]", op, user, device)
+	return &known
+}
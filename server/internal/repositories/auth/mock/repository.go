@@ -6,18 +6,21 @@ import (
 )
 
 type RepositoryMock struct {
-	CreateUserImpl             func(user auth.UserId, email string, password string) repositories.UnitOfWork
-	MarkUserEmailValidatedImpl func(user auth.UserId) repositories.UnitOfWork
-	IsUserExistsImpl           func(user auth.UserId) (bool, error)
-	IsSessionExistsImpl        func(user auth.UserId, device auth.DeviceId) (bool, error)
-	ExclusiveSessionImpl       func(user auth.UserId, device auth.DeviceId) repositories.UnitOfWork
-	CheckCredentialsImpl       func(email string, password string) (bool, error)
-	GetUserIdByEmailImpl       func(email string) (*auth.UserId, error)
-	UpdateRefreshTokenImpl     func(user auth.UserId, device auth.DeviceId, token string) repositories.UnitOfWork
-	CheckRefreshTokenImpl      func(user auth.UserId, device auth.DeviceId, token string) (bool, error)
-	UpdatePasswordImpl         func(user auth.UserId, newPassword string) repositories.UnitOfWork
-	UpdateEmailImpl            func(user auth.UserId, newEmail string) repositories.UnitOfWork
-	GetUserInfoImpl            func(user auth.UserId) (auth.UserInfo, error)
+	CreateUserImpl               func(user auth.UserId, email string, password string) repositories.UnitOfWork
+	MarkUserEmailValidatedImpl   func(user auth.UserId) repositories.UnitOfWork
+	IsUserExistsImpl             func(user auth.UserId) (bool, error)
+	IsSessionExistsImpl          func(user auth.UserId, device auth.DeviceId) (bool, error)
+	ExclusiveSessionImpl         func(user auth.UserId, device auth.DeviceId) repositories.UnitOfWork
+	CheckCredentialsImpl         func(email string, password string) (bool, error)
+	GetUserIdByEmailImpl         func(email string) (*auth.UserId, error)
+	UpdateRefreshTokenImpl       func(user auth.UserId, device auth.DeviceId, token string) repositories.UnitOfWork
+	CheckRefreshTokenImpl        func(user auth.UserId, device auth.DeviceId, token string) (bool, error)
+	UpdatePasswordImpl           func(user auth.UserId, newPassword string) repositories.UnitOfWork
+	UpdateEmailImpl              func(user auth.UserId, newEmail string) repositories.UnitOfWork
+	GetUserInfoImpl              func(user auth.UserId) (auth.UserInfo, error)
+	GetDevicesWithSessionImpl    func() ([]auth.Device, error)
+	GetDevicesWithoutSessionImpl func() ([]auth.Device, error)
+	ForgetDeviceImpl             func(user auth.UserId, device auth.DeviceId) repositories.UnitOfWork
 }
 
 func (c *RepositoryMock) CreateUser(user auth.UserId, email string, password string) repositories.UnitOfWork {
@@ -67,3 +70,15 @@ func (c *RepositoryMock) UpdateEmail(user auth.UserId, newEmail string) reposito
 func (c *RepositoryMock) GetUserInfo(user auth.UserId) (auth.UserInfo, error) {
 	return c.GetUserInfoImpl(user)
 }
+
+func (c *RepositoryMock) GetDevicesWithSession() ([]auth.Device, error) {
+	return c.GetDevicesWithSessionImpl()
+}
+
+func (c *RepositoryMock) GetDevicesWithoutSession() ([]auth.Device, error) {
+	return c.GetDevicesWithoutSessionImpl()
+}
+
+func (c *RepositoryMock) ForgetDevice(user auth.UserId, device auth.DeviceId) repositories.UnitOfWork {
+	return c.ForgetDeviceImpl(user, device)
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"errors"
 	"verni/internal/repositories"
 )
 
@@ -13,6 +14,19 @@ type UserInfo struct {
 	EmailVerified bool
 }
 
+// Device is a device that has ever had a session
+type Device struct {
+	User   UserId
+	Device DeviceId
+	// LastSeen is the moment in unix millis the device got its latest refresh token, zero when unknown
+	LastSeen int64
+	// RevokedAt is the moment in unix millis the session of the device was ended by another device
+	RevokedAt *int64
+}
+
+// ErrNotForgotten is returned by ForgetDevice for devices that have a session or are already forgotten
+var ErrNotForgotten = errors.New("device is not forgotten")
+
 type Repository interface {
 	CreateUser(user UserId, email string, password string) repositories.UnitOfWork
 
@@ -22,12 +36,14 @@ type Repository interface {
 
 	IsSessionExists(user UserId, device DeviceId) (bool, error)
 
+	// ExclusiveSession removes sessions of every other device of the user and marks them revoked
 	ExclusiveSession(user UserId, device DeviceId) repositories.UnitOfWork
 
 	CheckCredentials(email string, password string) (bool, error)
 
 	GetUserIdByEmail(email string) (*UserId, error)
 
+	// UpdateRefreshToken starts or prolongs the session of the device and marks the device seen
 	UpdateRefreshToken(user UserId, device DeviceId, token string) repositories.UnitOfWork
 
 	CheckRefreshToken(user UserId, device DeviceId, token string) (bool, error)
@@ -37,4 +53,13 @@ type Repository interface {
 	UpdateEmail(user UserId, newEmail string) repositories.UnitOfWork
 
 	GetUserInfo(user UserId) (UserInfo, error)
+
+	// GetDevicesWithSession returns devices that have a session now
+	GetDevicesWithSession() ([]Device, error)
+
+	// GetDevicesWithoutSession returns devices that had a session once but have none now
+	GetDevicesWithoutSession() ([]Device, error)
+
+	// ForgetDevice deletes the device if it still has no session, it fails with ErrNotForgotten otherwise
+	ForgetDevice(user UserId, device DeviceId) repositories.UnitOfWork
 }
@@ -0,0 +1,52 @@
+package balances
+
+import (
+	"cmp"
+	"slices"
+)
+
+type Debt struct {
+	Debtor   UserId
+	Creditor UserId
+	Amount   int64
+}
+
+// Debts splits shares of a spending into debts between pairs of participants, it is the way
+// a spending affects counterparty balances. The split is deterministic, so reverting a spending
+// subtracts exactly what adding it added. Debtors are matched with creditors in the order
+// of their identifiers, for two participants it is the only possible split.
+func (s Spending) Debts() []Debt {
+	creditors := []Share{}
+	debtors := []Share{}
+	for _, share := range s.Shares {
+		if share.Amount > 0 {
+			creditors = append(creditors, share)
+		} else if share.Amount < 0 {
+			debtors = append(debtors, Share{UserId: share.UserId, Amount: -share.Amount})
+		}
+	}
+	byUser := func(a, b Share) int {
+		return cmp.Compare(a.UserId, b.UserId)
+	}
+	slices.SortFunc(creditors, byUser)
+	slices.SortFunc(debtors, byUser)
+
+	debts := []Debt{}
+	for creditor, debtor := 0, 0; creditor < len(creditors) && debtor < len(debtors); {
+		amount := min(creditors[creditor].Amount, debtors[debtor].Amount)
+		debts = append(debts, Debt{
+			Debtor:   debtors[debtor].UserId,
+			Creditor: creditors[creditor].UserId,
+			Amount:   amount,
+		})
+		creditors[creditor].Amount -= amount
+		debtors[debtor].Amount -= amount
+		if creditors[creditor].Amount == 0 {
+			creditor++
+		}
+		if debtors[debtor].Amount == 0 {
+			debtor++
+		}
+	}
+	return debts
+}
@@ -0,0 +1,336 @@
+package defaultRepository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"verni/internal/db"
+	"verni/internal/repositories"
+	"verni/internal/repositories/balances"
+	"verni/internal/services/logging"
+)
+
+func New(db db.DB, logger logging.Service) balances.Repository {
+	return &defaultRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+type defaultRepository struct {
+	db     db.DB
+	logger logging.Service
+}
+
+func (c *defaultRepository) AddSpending(spending balances.Spending) repositories.UnitOfWork {
+	const op = "repositories.balances.defaultRepository.AddSpending"
+
+	// the spending is read in the transaction that accounts it, so concurrent changes of it wait for each other
+	added := false
+	return c.unitOfWork(
+		op,
+		func(tx *sql.Tx) error {
+			added = false
+			existing, err := lockSpending(tx, spending.Id)
+			if err != nil {
+				return fmt.Errorf("getting spending info: %w", err)
+			}
+			if existing != nil {
+				c.logger.LogInfo("%s: spending %s is already accounted", op, spending.Id)
+				return nil
+			}
+			if err := insertSpending(tx, newStoredSpending(spending)); err != nil {
+				return err
+			}
+			added = true
+			return applySpending(tx, spending, 1)
+		},
+		func() error {
+			if !added {
+				return nil
+			}
+			return c.inTransaction(op, func(tx *sql.Tx) error {
+				if err := deleteSpending(tx, spending.Id); err != nil {
+					return err
+				}
+				return applySpending(tx, spending, -1)
+			})
+		},
+	)
+}
+
+func (c *defaultRepository) UpdateSpending(update balances.SpendingUpdate) repositories.UnitOfWork {
+	const op = "repositories.balances.defaultRepository.UpdateSpending"
+
+	// states of the spending around the update, both are nil if nothing was changed
+	var existing, updated *storedSpending
+	return c.unitOfWork(
+		op,
+		func(tx *sql.Tx) error {
+			existing, updated = nil, nil
+			stored, err := lockSpending(tx, update.Id)
+			if err != nil {
+				return fmt.Errorf("getting spending info: %w", err)
+			}
+			if stored == nil || stored.removed || stored.GroupId != update.GroupId {
+				c.logger.LogInfo("%s: spending %s of group %s is not accounted", op, update.Id, update.GroupId)
+				return nil
+			}
+			result, changed := stored.updated(update)
+			if !changed {
+				c.logger.LogInfo("%s: spending %s has newer changes than %s", op, update.Id, update.Version.OperationId)
+				return nil
+			}
+			if err := replaceSpending(tx, *stored, result); err != nil {
+				return err
+			}
+			existing, updated = stored, &result
+			return nil
+		},
+		func() error {
+			if existing == nil {
+				return nil
+			}
+			return c.inTransaction(op, func(tx *sql.Tx) error {
+				return replaceSpending(tx, *updated, *existing)
+			})
+		},
+	)
+}
+
+func (c *defaultRepository) RemoveSpending(groupId balances.GroupId, spendingId balances.SpendingId) repositories.UnitOfWork {
+	const op = "repositories.balances.defaultRepository.RemoveSpending"
+
+	return c.removeSpendings(op, func(tx *sql.Tx) ([]storedSpending, error) {
+		existing, err := lockSpending(tx, spendingId)
+		if err != nil {
+			return nil, fmt.Errorf("getting spending info: %w", err)
+		}
+		if existing == nil || existing.removed || existing.GroupId != groupId {
+			c.logger.LogInfo("%s: spending %s of group %s is not accounted", op, spendingId, groupId)
+			return nil, nil
+		}
+		return []storedSpending{*existing}, nil
+	})
+}
+
+func (c *defaultRepository) RemoveGroup(groupId balances.GroupId) repositories.UnitOfWork {
+	const op = "repositories.balances.defaultRepository.RemoveGroup"
+
+	return c.removeSpendings(op, func(tx *sql.Tx) ([]storedSpending, error) {
+		spendings, err := getSpendings(tx, `WHERE s.groupId = $1 AND NOT s.removed`, lockRows, string(groupId))
+		if err != nil {
+			return nil, fmt.Errorf("getting group spendings: %w", err)
+		}
+		return spendings, nil
+	})
+}
+
+func (c *defaultRepository) Reset() repositories.UnitOfWork {
+	const op = "repositories.balances.defaultRepository.Reset"
+
+	var snapshot []storedSpending
+	return c.unitOfWork(
+		op,
+		func(tx *sql.Tx) error {
+			spendings, err := getSpendings(tx, ``, lockRows)
+			if err != nil {
+				return fmt.Errorf("getting spendings: %w", err)
+			}
+			snapshot = spendings
+			return truncate(tx)
+		},
+		func() error {
+			return c.inTransaction(op, func(tx *sql.Tx) error {
+				if err := truncate(tx); err != nil {
+					return err
+				}
+				for _, spending := range snapshot {
+					if err := insertSpending(tx, spending); err != nil {
+						return err
+					}
+					if spending.removed {
+						continue
+					}
+					if err := applySpending(tx, spending.Spending, 1); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		},
+	)
+}
+
+func (c *defaultRepository) GetGroupBalances(userId balances.UserId) ([]balances.GroupBalance, error) {
+	const op = "repositories.balances.defaultRepository.GetGroupBalances"
+	c.logger.LogInfo("%s: start[user=%s]", op, userId)
+
+	query := `
+SELECT groupId, currency, amount
+FROM groupBalances
+WHERE userId = $1 AND amount <> 0
+ORDER BY groupId, currency;`
+	rows, err := c.db.Query(query, string(userId))
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to perform query: %w", op, err)
+	}
+	defer rows.Close()
+
+	result := []balances.GroupBalance{}
+	for rows.Next() {
+		var balance balances.GroupBalance
+		if err := rows.Scan(&balance.GroupId, &balance.Currency, &balance.Amount); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+		result = append(result, balance)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: error occurred during row iteration: %w", op, err)
+	}
+
+	c.logger.LogInfo("%s: success[user=%s count=%d]", op, userId, len(result))
+	return result, nil
+}
+
+func (c *defaultRepository) GetParticipantBalances(groupId balances.GroupId) ([]balances.ParticipantBalance, error) {
+	const op = "repositories.balances.defaultRepository.GetParticipantBalances"
+	c.logger.LogInfo("%s: start[group=%s]", op, groupId)
+
+	query := `
+SELECT userId, currency, amount
+FROM groupBalances
+WHERE groupId = $1 AND amount <> 0
+ORDER BY currency, userId;`
+	rows, err := c.db.Query(query, string(groupId))
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to perform query: %w", op, err)
+	}
+	defer rows.Close()
+
+	result := []balances.ParticipantBalance{}
+	for rows.Next() {
+		var balance balances.ParticipantBalance
+		if err := rows.Scan(&balance.UserId, &balance.Currency, &balance.Amount); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+		result = append(result, balance)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: error occurred during row iteration: %w", op, err)
+	}
+
+	c.logger.LogInfo("%s: success[group=%s count=%d]", op, groupId, len(result))
+	return result, nil
+}
+
+func (c *defaultRepository) GetCounterpartyBalances(userId balances.UserId) ([]balances.CounterpartyBalance, error) {
+	const op = "repositories.balances.defaultRepository.GetCounterpartyBalances"
+	c.logger.LogInfo("%s: start[user=%s]", op, userId)
+
+	query := `
+SELECT counterpartyId, currency, amount
+FROM counterpartyBalances
+WHERE userId = $1 AND amount <> 0
+ORDER BY counterpartyId, currency;`
+	rows, err := c.db.Query(query, string(userId))
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to perform query: %w", op, err)
+	}
+	defer rows.Close()
+
+	result := []balances.CounterpartyBalance{}
+	for rows.Next() {
+		var balance balances.CounterpartyBalance
+		if err := rows.Scan(&balance.CounterpartyId, &balance.Currency, &balance.Amount); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+		result = append(result, balance)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: error occurred during row iteration: %w", op, err)
+	}
+
+	c.logger.LogInfo("%s: success[user=%s count=%d]", op, userId, len(result))
+	return result, nil
+}
+
+// removeSpendings reverts shares of spendings picked in the same transaction
+func (c *defaultRepository) removeSpendings(op string, pick func(tx *sql.Tx) ([]storedSpending, error)) repositories.UnitOfWork {
+	var removed []storedSpending
+	return c.unitOfWork(
+		op,
+		func(tx *sql.Tx) error {
+			removed = nil
+			spendings, err := pick(tx)
+			if err != nil {
+				return err
+			}
+			for _, spending := range spendings {
+				if err := markRemoved(tx, spending.Id, true); err != nil {
+					return err
+				}
+				if err := applySpending(tx, spending.Spending, -1); err != nil {
+					return err
+				}
+			}
+			removed = spendings
+			return nil
+		},
+		func() error {
+			if len(removed) == 0 {
+				return nil
+			}
+			return c.inTransaction(op, func(tx *sql.Tx) error {
+				for _, spending := range removed {
+					if err := markRemoved(tx, spending.Id, false); err != nil {
+						return err
+					}
+					if err := applySpending(tx, spending.Spending, 1); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		},
+	)
+}
+
+// unitOfWork performs the work in its own transaction or in a transaction of another repository
+func (c *defaultRepository) unitOfWork(op string, perform func(tx *sql.Tx) error, rollback func() error) repositories.UnitOfWork {
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			return c.inTransaction(op, perform)
+		},
+		Rollback: rollback,
+		PerformIn: func(tx *sql.Tx) error {
+			if err := perform(tx); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+			return nil
+		},
+	}
+}
+
+func (c *defaultRepository) inTransaction(op string, body func(tx *sql.Tx) error) (err error) {
+	c.logger.LogInfo("%s: start", op)
+
+	tx, err := c.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	if err = body(tx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	c.logger.LogInfo("%s: success", op)
+	return nil
+}
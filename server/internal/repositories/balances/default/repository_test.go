@@ -0,0 +1,331 @@
+package defaultRepository_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	postgresDb "verni/internal/db/postgres"
+	"verni/internal/repositories/balances"
+	defaultRepository "verni/internal/repositories/balances/default"
+	standartOutputLoggingService "verni/internal/services/logging/standartOutput"
+	defaultPathProvider "verni/internal/services/pathProvider/default"
+)
+
+var testConfig postgresDb.PostgresConfig
+
+func setupTestDB(t *testing.T) *sql.DB {
+	logger := standartOutputLoggingService.New()
+	pathProvider := defaultPathProvider.New(logger)
+	path := pathProvider.AbsolutePath("./config/test/postgres_storage.json")
+
+	configFile, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Skipf("no postgres test config at %s, see scripts/test.sh", path)
+	}
+	require.NoError(t, err)
+
+	err = json.Unmarshal(configFile, &testConfig)
+	require.NoError(t, err)
+
+	db, err := postgresDb.Postgres(testConfig, logger)
+	require.NoError(t, err)
+
+	// Clear test data
+	_, err = db.Exec("TRUNCATE spendings, spendingShares, groupBalances, counterpartyBalances")
+	require.NoError(t, err)
+
+	return db.(*sql.DB)
+}
+
+func dinner() balances.Spending {
+	return balances.Spending{
+		Id:       "dinner",
+		GroupId:  "trip",
+		Currency: "EUR",
+		Shares: []balances.Share{
+			{UserId: "alice", Amount: 200},
+			{UserId: "bob", Amount: -100},
+			{UserId: "carol", Amount: -100},
+		},
+		Version: balances.Version{CreatedAt: 10, OperationId: "create-dinner"},
+	}
+}
+
+func TestRepository_AddSpending(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	logger := standartOutputLoggingService.New()
+	repo := defaultRepository.New(db, logger)
+
+	require.NoError(t, repo.AddSpending(dinner()).Perform())
+
+	groups, err := repo.GetGroupBalances("alice")
+	require.NoError(t, err)
+	assert.Equal(t, []balances.GroupBalance{{GroupId: "trip", Currency: "EUR", Amount: 200}}, groups)
+
+	counterparties, err := repo.GetCounterpartyBalances("alice")
+	require.NoError(t, err)
+	assert.Equal(t, []balances.CounterpartyBalance{
+		{CounterpartyId: "bob", Currency: "EUR", Amount: 100},
+		{CounterpartyId: "carol", Currency: "EUR", Amount: 100},
+	}, counterparties)
+
+	counterparties, err = repo.GetCounterpartyBalances("bob")
+	require.NoError(t, err)
+	assert.Equal(t, []balances.CounterpartyBalance{{CounterpartyId: "alice", Currency: "EUR", Amount: -100}}, counterparties)
+
+	t.Run("same spending is accounted once", func(t *testing.T) {
+		require.NoError(t, repo.AddSpending(dinner()).Perform())
+
+		groups, err := repo.GetGroupBalances("alice")
+		require.NoError(t, err)
+		assert.Equal(t, []balances.GroupBalance{{GroupId: "trip", Currency: "EUR", Amount: 200}}, groups)
+	})
+}
+
+func TestRepository_AddSpendingRollback(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	logger := standartOutputLoggingService.New()
+	repo := defaultRepository.New(db, logger)
+
+	work := repo.AddSpending(dinner())
+	require.NoError(t, work.Perform())
+	require.NoError(t, work.Rollback())
+
+	groups, err := repo.GetGroupBalances("alice")
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+
+	counterparties, err := repo.GetCounterpartyBalances("bob")
+	require.NoError(t, err)
+	assert.Empty(t, counterparties)
+}
+
+func TestRepository_ConcurrentUnits(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	logger := standartOutputLoggingService.New()
+	repo := defaultRepository.New(db, logger)
+
+	first := repo.AddSpending(dinner())
+	second := repo.AddSpending(dinner())
+	require.NoError(t, first.Perform())
+	require.NoError(t, second.Perform())
+	require.NoError(t, second.Rollback())
+
+	groups, err := repo.GetGroupBalances("alice")
+	require.NoError(t, err)
+	assert.Equal(t, []balances.GroupBalance{{GroupId: "trip", Currency: "EUR", Amount: 200}}, groups)
+
+	t.Run("spending removed after the unit is made is reverted once", func(t *testing.T) {
+		first := repo.RemoveSpending("trip", "dinner")
+		second := repo.RemoveSpending("trip", "dinner")
+		require.NoError(t, first.Perform())
+		require.NoError(t, second.Perform())
+		require.NoError(t, second.Rollback())
+
+		groups, err := repo.GetGroupBalances("alice")
+		require.NoError(t, err)
+		assert.Empty(t, groups)
+	})
+}
+
+func TestRepository_UpdateSpending(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	logger := standartOutputLoggingService.New()
+	repo := defaultRepository.New(db, logger)
+	require.NoError(t, repo.AddSpending(dinner()).Perform())
+
+	update := balances.SpendingUpdate{
+		Id:      "dinner",
+		GroupId: "trip",
+		Shares: []balances.Share{
+			{UserId: "alice", Amount: 100},
+			{UserId: "bob", Amount: -100},
+		},
+		Version: balances.Version{CreatedAt: 20, OperationId: "split-with-bob"},
+	}
+	work := repo.UpdateSpending(update)
+	require.NoError(t, work.Perform())
+
+	balancesOf := func(userId balances.UserId) []balances.GroupBalance {
+		groups, err := repo.GetGroupBalances(userId)
+		require.NoError(t, err)
+		return groups
+	}
+	assert.Equal(t, []balances.GroupBalance{{GroupId: "trip", Currency: "EUR", Amount: 100}}, balancesOf("alice"))
+	assert.Empty(t, balancesOf("carol"))
+
+	t.Run("older change of the same field is ignored", func(t *testing.T) {
+		outdated := update
+		outdated.Shares = dinner().Shares
+		outdated.Version = balances.Version{CreatedAt: 15, OperationId: "outdated"}
+		require.NoError(t, repo.UpdateSpending(outdated).Perform())
+
+		assert.Equal(t, []balances.GroupBalance{{GroupId: "trip", Currency: "EUR", Amount: 100}}, balancesOf("alice"))
+	})
+
+	t.Run("older change of another field is applied", func(t *testing.T) {
+		currency := balances.Currency("USD")
+		changeCurrency := repo.UpdateSpending(balances.SpendingUpdate{
+			Id:       "dinner",
+			GroupId:  "trip",
+			Currency: &currency,
+			Version:  balances.Version{CreatedAt: 15, OperationId: "change-currency"},
+		})
+		require.NoError(t, changeCurrency.Perform())
+		assert.Equal(t, []balances.GroupBalance{{GroupId: "trip", Currency: "USD", Amount: 100}}, balancesOf("alice"))
+
+		require.NoError(t, changeCurrency.Rollback())
+		assert.Equal(t, []balances.GroupBalance{{GroupId: "trip", Currency: "EUR", Amount: 100}}, balancesOf("alice"))
+	})
+
+	require.NoError(t, work.Rollback())
+	assert.Equal(t, []balances.GroupBalance{{GroupId: "trip", Currency: "EUR", Amount: 200}}, balancesOf("alice"))
+	assert.Equal(t, []balances.GroupBalance{{GroupId: "trip", Currency: "EUR", Amount: -100}}, balancesOf("carol"))
+
+	t.Run("removed spending is ignored", func(t *testing.T) {
+		require.NoError(t, repo.RemoveSpending("trip", "dinner").Perform())
+		require.NoError(t, repo.UpdateSpending(update).Perform())
+
+		assert.Empty(t, balancesOf("alice"))
+	})
+}
+
+func TestRepository_RemoveSpending(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	logger := standartOutputLoggingService.New()
+	repo := defaultRepository.New(db, logger)
+	require.NoError(t, repo.AddSpending(dinner()).Perform())
+
+	t.Run("spending of another group is ignored", func(t *testing.T) {
+		require.NoError(t, repo.RemoveSpending("other", "dinner").Perform())
+
+		groups, err := repo.GetGroupBalances("bob")
+		require.NoError(t, err)
+		assert.Equal(t, []balances.GroupBalance{{GroupId: "trip", Currency: "EUR", Amount: -100}}, groups)
+	})
+
+	work := repo.RemoveSpending("trip", "dinner")
+	require.NoError(t, work.Perform())
+
+	groups, err := repo.GetGroupBalances("bob")
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+
+	t.Run("removed spending is not accounted again", func(t *testing.T) {
+		require.NoError(t, repo.AddSpending(dinner()).Perform())
+
+		groups, err := repo.GetGroupBalances("bob")
+		require.NoError(t, err)
+		assert.Empty(t, groups)
+	})
+
+	require.NoError(t, work.Rollback())
+	counterparties, err := repo.GetCounterpartyBalances("bob")
+	require.NoError(t, err)
+	assert.Equal(t, []balances.CounterpartyBalance{{CounterpartyId: "alice", Currency: "EUR", Amount: -100}}, counterparties)
+}
+
+func TestRepository_RemoveGroup(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	logger := standartOutputLoggingService.New()
+	repo := defaultRepository.New(db, logger)
+	require.NoError(t, repo.AddSpending(dinner()).Perform())
+	require.NoError(t, repo.AddSpending(balances.Spending{
+		Id:       "taxi",
+		GroupId:  "trip",
+		Currency: "USD",
+		Shares: []balances.Share{
+			{UserId: "bob", Amount: 30},
+			{UserId: "alice", Amount: -30},
+		},
+	}).Perform())
+
+	work := repo.RemoveGroup("trip")
+	require.NoError(t, work.Perform())
+
+	for _, user := range []balances.UserId{"alice", "bob", "carol"} {
+		groups, err := repo.GetGroupBalances(user)
+		require.NoError(t, err)
+		assert.Empty(t, groups)
+	}
+
+	require.NoError(t, work.Rollback())
+	counterparties, err := repo.GetCounterpartyBalances("alice")
+	require.NoError(t, err)
+	assert.Equal(t, []balances.CounterpartyBalance{
+		{CounterpartyId: "bob", Currency: "EUR", Amount: 100},
+		{CounterpartyId: "bob", Currency: "USD", Amount: -30},
+		{CounterpartyId: "carol", Currency: "EUR", Amount: 100},
+	}, counterparties)
+}
+
+func TestRepository_Reset(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	logger := standartOutputLoggingService.New()
+	repo := defaultRepository.New(db, logger)
+	require.NoError(t, repo.AddSpending(dinner()).Perform())
+
+	work := repo.Reset()
+	require.NoError(t, work.Perform())
+
+	groups, err := repo.GetGroupBalances("alice")
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+
+	require.NoError(t, work.Rollback())
+	groups, err = repo.GetGroupBalances("alice")
+	require.NoError(t, err)
+	assert.Equal(t, []balances.GroupBalance{{GroupId: "trip", Currency: "EUR", Amount: 200}}, groups)
+}
+
+func TestRepository_GetParticipantBalances(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	logger := standartOutputLoggingService.New()
+	repo := defaultRepository.New(db, logger)
+	require.NoError(t, repo.AddSpending(dinner()).Perform())
+	require.NoError(t, repo.AddSpending(balances.Spending{
+		Id:       "taxi",
+		GroupId:  "trip",
+		Currency: "USD",
+		Shares: []balances.Share{
+			{UserId: "bob", Amount: 30},
+			{UserId: "alice", Amount: -30},
+		},
+	}).Perform())
+
+	participants, err := repo.GetParticipantBalances("trip")
+	require.NoError(t, err)
+	assert.Equal(t, []balances.ParticipantBalance{
+		{UserId: "alice", Currency: "EUR", Amount: 200},
+		{UserId: "bob", Currency: "EUR", Amount: -100},
+		{UserId: "carol", Currency: "EUR", Amount: -100},
+		{UserId: "alice", Currency: "USD", Amount: -30},
+		{UserId: "bob", Currency: "USD", Amount: 30},
+	}, participants)
+
+	participants, err = repo.GetParticipantBalances("other")
+	require.NoError(t, err)
+	assert.Empty(t, participants)
+}
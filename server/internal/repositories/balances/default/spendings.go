@@ -0,0 +1,198 @@
+package defaultRepository
+
+import (
+	"database/sql"
+	"fmt"
+	"verni/internal/repositories/balances"
+)
+
+type storedSpending struct {
+	balances.Spending
+	removed         bool
+	currencyVersion balances.Version
+	sharesVersion   balances.Version
+}
+
+func newStoredSpending(spending balances.Spending) storedSpending {
+	return storedSpending{
+		Spending:        spending,
+		currencyVersion: spending.Version,
+		sharesVersion:   spending.Version,
+	}
+}
+
+// updated returns the spending with fields of the update that are newer than the stored ones
+func (s storedSpending) updated(update balances.SpendingUpdate) (storedSpending, bool) {
+	changed := false
+	if update.Currency != nil && update.Version.After(s.currencyVersion) {
+		s.Currency = *update.Currency
+		s.currencyVersion = update.Version
+		changed = true
+	}
+	if update.Shares != nil && update.Version.After(s.sharesVersion) {
+		s.Shares = update.Shares
+		s.sharesVersion = update.Version
+		changed = true
+	}
+	return s, changed
+}
+
+// lockRows keeps read spendings locked until the end of the transaction
+const lockRows = `FOR UPDATE OF s`
+
+// lockSpending reads the spending locking it until the end of the transaction
+func lockSpending(tx *sql.Tx, spendingId balances.SpendingId) (*storedSpending, error) {
+	spendings, err := getSpendings(tx, `WHERE s.spendingId = $1`, lockRows, string(spendingId))
+	if err != nil {
+		return nil, err
+	}
+	if len(spendings) == 0 {
+		return nil, nil
+	}
+	return &spendings[0], nil
+}
+
+func getSpendings(tx *sql.Tx, filter string, lock string, args ...any) ([]storedSpending, error) {
+	query := fmt.Sprintf(`
+SELECT
+	s.spendingId, s.groupId, s.currency, s.removed,
+	s.currencyCreatedAt, s.currencyOperationId, s.sharesCreatedAt, s.sharesOperationId,
+	sh.userId, sh.amount
+FROM spendings s
+JOIN spendingShares sh ON sh.spendingId = s.spendingId
+%s
+ORDER BY s.spendingId, sh.userId
+%s;`, filter, lock)
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to perform query: %w", err)
+	}
+	defer rows.Close()
+
+	result := []storedSpending{}
+	for rows.Next() {
+		var spending storedSpending
+		var share balances.Share
+		if err := rows.Scan(
+			&spending.Id,
+			&spending.GroupId,
+			&spending.Currency,
+			&spending.removed,
+			&spending.currencyVersion.CreatedAt,
+			&spending.currencyVersion.OperationId,
+			&spending.sharesVersion.CreatedAt,
+			&spending.sharesVersion.OperationId,
+			&share.UserId,
+			&share.Amount,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		// rows are ordered by spending, so shares of the same spending are adjacent
+		if last := len(result) - 1; last >= 0 && result[last].Id == spending.Id {
+			result[last].Shares = append(result[last].Shares, share)
+			continue
+		}
+		spending.Shares = []balances.Share{share}
+		result = append(result, spending)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error occurred during row iteration: %w", err)
+	}
+	return result, nil
+}
+
+func insertSpending(tx *sql.Tx, spending storedSpending) error {
+	query := `
+INSERT INTO spendings(
+	spendingId, groupId, currency, removed,
+	currencyCreatedAt, currencyOperationId, sharesCreatedAt, sharesOperationId
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8);`
+	if _, err := tx.Exec(
+		query,
+		string(spending.Id),
+		string(spending.GroupId),
+		string(spending.Currency),
+		spending.removed,
+		spending.currencyVersion.CreatedAt,
+		spending.currencyVersion.OperationId,
+		spending.sharesVersion.CreatedAt,
+		spending.sharesVersion.OperationId,
+	); err != nil {
+		return fmt.Errorf("failed to insert spending %s: %w", spending.Id, err)
+	}
+	for _, share := range spending.Shares {
+		query := `INSERT INTO spendingShares(spendingId, userId, amount) VALUES ($1, $2, $3);`
+		if _, err := tx.Exec(query, string(spending.Id), string(share.UserId), share.Amount); err != nil {
+			return fmt.Errorf("failed to insert share of spending %s: %w", spending.Id, err)
+		}
+	}
+	return nil
+}
+
+func deleteSpending(tx *sql.Tx, spendingId balances.SpendingId) error {
+	if _, err := tx.Exec(`DELETE FROM spendingShares WHERE spendingId = $1;`, string(spendingId)); err != nil {
+		return fmt.Errorf("failed to delete shares of spending %s: %w", spendingId, err)
+	}
+	if _, err := tx.Exec(`DELETE FROM spendings WHERE spendingId = $1;`, string(spendingId)); err != nil {
+		return fmt.Errorf("failed to delete spending %s: %w", spendingId, err)
+	}
+	return nil
+}
+
+// replaceSpending reverts shares of the old spending and accounts the updated one instead
+func replaceSpending(tx *sql.Tx, old storedSpending, updated storedSpending) error {
+	if err := applySpending(tx, old.Spending, -1); err != nil {
+		return err
+	}
+	if err := deleteSpending(tx, old.Id); err != nil {
+		return err
+	}
+	if err := insertSpending(tx, updated); err != nil {
+		return err
+	}
+	return applySpending(tx, updated.Spending, 1)
+}
+
+func markRemoved(tx *sql.Tx, spendingId balances.SpendingId, removed bool) error {
+	if _, err := tx.Exec(`UPDATE spendings SET removed = $2 WHERE spendingId = $1;`, string(spendingId), removed); err != nil {
+		return fmt.Errorf("failed to update spending %s: %w", spendingId, err)
+	}
+	return nil
+}
+
+func truncate(tx *sql.Tx) error {
+	if _, err := tx.Exec(`TRUNCATE spendings, spendingShares, groupBalances, counterpartyBalances;`); err != nil {
+		return fmt.Errorf("failed to truncate balances: %w", err)
+	}
+	return nil
+}
+
+// applySpending adds shares of the spending to balances multiplied by sign
+func applySpending(tx *sql.Tx, spending balances.Spending, sign int64) error {
+	for _, share := range spending.Shares {
+		query := `
+INSERT INTO groupBalances(groupId, userId, currency, amount)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (groupId, userId, currency) DO UPDATE SET amount = groupBalances.amount + EXCLUDED.amount;`
+		if _, err := tx.Exec(query, string(spending.GroupId), string(share.UserId), string(spending.Currency), sign*share.Amount); err != nil {
+			return fmt.Errorf("failed to update group balance of %s: %w", share.UserId, err)
+		}
+	}
+	for _, debt := range spending.Debts() {
+		query := `
+INSERT INTO counterpartyBalances(userId, counterpartyId, currency, amount)
+VALUES ($1, $2, $4, $5), ($2, $1, $4, $3)
+ON CONFLICT (userId, counterpartyId, currency) DO UPDATE SET amount = counterpartyBalances.amount + EXCLUDED.amount;`
+		if _, err := tx.Exec(
+			query,
+			string(debt.Creditor),
+			string(debt.Debtor),
+			-sign*debt.Amount,
+			string(spending.Currency),
+			sign*debt.Amount,
+		); err != nil {
+			return fmt.Errorf("failed to update balance between %s and %s: %w", debt.Creditor, debt.Debtor, err)
+		}
+	}
+	return nil
+}
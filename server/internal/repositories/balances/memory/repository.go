@@ -0,0 +1,330 @@
+package memoryRepository
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+
+	"verni/internal/repositories"
+	"verni/internal/repositories/balances"
+	"verni/internal/services/logging"
+)
+
+func New(logger logging.Service) balances.Repository {
+	return &memoryRepository{
+		spendings:            map[balances.SpendingId]storedSpending{},
+		groupBalances:        map[groupBalanceKey]int64{},
+		counterpartyBalances: map[counterpartyBalanceKey]int64{},
+		logger:               logger,
+	}
+}
+
+type storedSpending struct {
+	balances.Spending
+	removed         bool
+	currencyVersion balances.Version
+	sharesVersion   balances.Version
+}
+
+// updated returns the spending with fields of the update that are newer than the stored ones
+func (s storedSpending) updated(update balances.SpendingUpdate) (storedSpending, bool) {
+	changed := false
+	if update.Currency != nil && update.Version.After(s.currencyVersion) {
+		s.Currency = *update.Currency
+		s.currencyVersion = update.Version
+		changed = true
+	}
+	if update.Shares != nil && update.Version.After(s.sharesVersion) {
+		s.Shares = update.Shares
+		s.sharesVersion = update.Version
+		changed = true
+	}
+	return s, changed
+}
+
+type groupBalanceKey struct {
+	groupId  balances.GroupId
+	userId   balances.UserId
+	currency balances.Currency
+}
+
+type counterpartyBalanceKey struct {
+	userId         balances.UserId
+	counterpartyId balances.UserId
+	currency       balances.Currency
+}
+
+type memoryRepository struct {
+	mutex                sync.RWMutex
+	spendings            map[balances.SpendingId]storedSpending
+	groupBalances        map[groupBalanceKey]int64
+	counterpartyBalances map[counterpartyBalanceKey]int64
+	logger               logging.Service
+}
+
+func (c *memoryRepository) AddSpending(spending balances.Spending) repositories.UnitOfWork {
+	const op = "repositories.balances.memoryRepository.AddSpending"
+
+	added := false
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+
+			added = false
+			if _, exists := c.spendings[spending.Id]; exists {
+				c.logger.LogInfo("%s: spending %s is already accounted", op, spending.Id)
+				return nil
+			}
+			c.spendings[spending.Id] = storedSpending{
+				Spending:        spending,
+				currencyVersion: spending.Version,
+				sharesVersion:   spending.Version,
+			}
+			c.applySpending(spending, 1)
+			added = true
+			return nil
+		},
+		Rollback: func() error {
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+
+			if !added {
+				return nil
+			}
+			delete(c.spendings, spending.Id)
+			c.applySpending(spending, -1)
+			return nil
+		},
+	}
+}
+
+func (c *memoryRepository) UpdateSpending(update balances.SpendingUpdate) repositories.UnitOfWork {
+	const op = "repositories.balances.memoryRepository.UpdateSpending"
+
+	// states of the spending around the update, both are nil if nothing was changed
+	var existing, updated *storedSpending
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+
+			existing, updated = nil, nil
+			stored, exists := c.spendings[update.Id]
+			if !exists || stored.removed || stored.GroupId != update.GroupId {
+				c.logger.LogInfo("%s: spending %s of group %s is not accounted", op, update.Id, update.GroupId)
+				return nil
+			}
+			result, changed := stored.updated(update)
+			if !changed {
+				c.logger.LogInfo("%s: spending %s has newer changes than %s", op, update.Id, update.Version.OperationId)
+				return nil
+			}
+			c.applySpending(stored.Spending, -1)
+			c.spendings[update.Id] = result
+			c.applySpending(result.Spending, 1)
+			existing, updated = &stored, &result
+			return nil
+		},
+		Rollback: func() error {
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+
+			if existing == nil {
+				return nil
+			}
+			c.applySpending(updated.Spending, -1)
+			c.spendings[update.Id] = *existing
+			c.applySpending(existing.Spending, 1)
+			return nil
+		},
+	}
+}
+
+func (c *memoryRepository) RemoveSpending(groupId balances.GroupId, spendingId balances.SpendingId) repositories.UnitOfWork {
+	const op = "repositories.balances.memoryRepository.RemoveSpending"
+
+	return c.removeSpendings(func() []balances.Spending {
+		existing, exists := c.spendings[spendingId]
+		if !exists || existing.removed || existing.GroupId != groupId {
+			c.logger.LogInfo("%s: spending %s of group %s is not accounted", op, spendingId, groupId)
+			return nil
+		}
+		return []balances.Spending{existing.Spending}
+	})
+}
+
+func (c *memoryRepository) RemoveGroup(groupId balances.GroupId) repositories.UnitOfWork {
+	return c.removeSpendings(func() []balances.Spending {
+		spendings := []balances.Spending{}
+		for _, spending := range c.spendings {
+			if spending.GroupId == groupId && !spending.removed {
+				spendings = append(spendings, spending.Spending)
+			}
+		}
+		return spendings
+	})
+}
+
+func (c *memoryRepository) Reset() repositories.UnitOfWork {
+	var spendings map[balances.SpendingId]storedSpending
+	var groupBalances map[groupBalanceKey]int64
+	var counterpartyBalances map[counterpartyBalanceKey]int64
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+
+			spendings, groupBalances, counterpartyBalances = c.spendings, c.groupBalances, c.counterpartyBalances
+			c.spendings = map[balances.SpendingId]storedSpending{}
+			c.groupBalances = map[groupBalanceKey]int64{}
+			c.counterpartyBalances = map[counterpartyBalanceKey]int64{}
+			return nil
+		},
+		Rollback: func() error {
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+
+			c.spendings = spendings
+			c.groupBalances = groupBalances
+			c.counterpartyBalances = counterpartyBalances
+			return nil
+		},
+	}
+}
+
+func (c *memoryRepository) GetGroupBalances(userId balances.UserId) ([]balances.GroupBalance, error) {
+	const op = "repositories.balances.memoryRepository.GetGroupBalances"
+	c.logger.LogInfo("%s: start[user=%s]", op, userId)
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	result := []balances.GroupBalance{}
+	for key, amount := range c.groupBalances {
+		if key.userId != userId || amount == 0 {
+			continue
+		}
+		result = append(result, balances.GroupBalance{
+			GroupId:  key.groupId,
+			Currency: key.currency,
+			Amount:   amount,
+		})
+	}
+	slices.SortFunc(result, func(a, b balances.GroupBalance) int {
+		return cmp.Or(cmp.Compare(a.GroupId, b.GroupId), cmp.Compare(a.Currency, b.Currency))
+	})
+
+	c.logger.LogInfo("%s: success[user=%s count=%d]", op, userId, len(result))
+	return result, nil
+}
+
+func (c *memoryRepository) GetParticipantBalances(groupId balances.GroupId) ([]balances.ParticipantBalance, error) {
+	const op = "repositories.balances.memoryRepository.GetParticipantBalances"
+	c.logger.LogInfo("%s: start[group=%s]", op, groupId)
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	result := []balances.ParticipantBalance{}
+	for key, amount := range c.groupBalances {
+		if key.groupId != groupId || amount == 0 {
+			continue
+		}
+		result = append(result, balances.ParticipantBalance{
+			UserId:   key.userId,
+			Currency: key.currency,
+			Amount:   amount,
+		})
+	}
+	slices.SortFunc(result, func(a, b balances.ParticipantBalance) int {
+		return cmp.Or(cmp.Compare(a.Currency, b.Currency), cmp.Compare(a.UserId, b.UserId))
+	})
+
+	c.logger.LogInfo("%s: success[group=%s count=%d]", op, groupId, len(result))
+	return result, nil
+}
+
+func (c *memoryRepository) GetCounterpartyBalances(userId balances.UserId) ([]balances.CounterpartyBalance, error) {
+	const op = "repositories.balances.memoryRepository.GetCounterpartyBalances"
+	c.logger.LogInfo("%s: start[user=%s]", op, userId)
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	result := []balances.CounterpartyBalance{}
+	for key, amount := range c.counterpartyBalances {
+		if key.userId != userId || amount == 0 {
+			continue
+		}
+		result = append(result, balances.CounterpartyBalance{
+			CounterpartyId: key.counterpartyId,
+			Currency:       key.currency,
+			Amount:         amount,
+		})
+	}
+	slices.SortFunc(result, func(a, b balances.CounterpartyBalance) int {
+		return cmp.Or(cmp.Compare(a.CounterpartyId, b.CounterpartyId), cmp.Compare(a.Currency, b.Currency))
+	})
+
+	c.logger.LogInfo("%s: success[user=%s count=%d]", op, userId, len(result))
+	return result, nil
+}
+
+// removeSpendings reverts shares of spendings picked under the same lock, pick expects the mutex to be locked
+func (c *memoryRepository) removeSpendings(pick func() []balances.Spending) repositories.UnitOfWork {
+	var removed []balances.Spending
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+
+			removed = pick()
+			for _, spending := range removed {
+				c.setRemoved(spending.Id, true)
+				c.applySpending(spending, -1)
+			}
+			return nil
+		},
+		Rollback: func() error {
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+
+			for _, spending := range removed {
+				c.setRemoved(spending.Id, false)
+				c.applySpending(spending, 1)
+			}
+			return nil
+		},
+	}
+}
+
+// setRemoved marks the spending keeping its field versions, expects the mutex to be locked
+func (c *memoryRepository) setRemoved(spendingId balances.SpendingId, removed bool) {
+	spending := c.spendings[spendingId]
+	spending.removed = removed
+	c.spendings[spendingId] = spending
+}
+
+// applySpending adds shares of the spending to balances multiplied by sign, expects the mutex to be locked
+func (c *memoryRepository) applySpending(spending balances.Spending, sign int64) {
+	for _, share := range spending.Shares {
+		c.groupBalances[groupBalanceKey{
+			groupId:  spending.GroupId,
+			userId:   share.UserId,
+			currency: spending.Currency,
+		}] += sign * share.Amount
+	}
+	for _, debt := range spending.Debts() {
+		c.counterpartyBalances[counterpartyBalanceKey{
+			userId:         debt.Creditor,
+			counterpartyId: debt.Debtor,
+			currency:       spending.Currency,
+		}] += sign * debt.Amount
+		c.counterpartyBalances[counterpartyBalanceKey{
+			userId:         debt.Debtor,
+			counterpartyId: debt.Creditor,
+			currency:       spending.Currency,
+		}] -= sign * debt.Amount
+	}
+}
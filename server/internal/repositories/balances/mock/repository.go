@@ -0,0 +1,49 @@
+package balances_mock
+
+import (
+	"verni/internal/repositories"
+	"verni/internal/repositories/balances"
+)
+
+type RepositoryMock struct {
+	AddSpendingImpl             func(spending balances.Spending) repositories.UnitOfWork
+	UpdateSpendingImpl          func(update balances.SpendingUpdate) repositories.UnitOfWork
+	RemoveSpendingImpl          func(groupId balances.GroupId, spendingId balances.SpendingId) repositories.UnitOfWork
+	RemoveGroupImpl             func(groupId balances.GroupId) repositories.UnitOfWork
+	ResetImpl                   func() repositories.UnitOfWork
+	GetGroupBalancesImpl        func(userId balances.UserId) ([]balances.GroupBalance, error)
+	GetParticipantBalancesImpl  func(groupId balances.GroupId) ([]balances.ParticipantBalance, error)
+	GetCounterpartyBalancesImpl func(userId balances.UserId) ([]balances.CounterpartyBalance, error)
+}
+
+func (r *RepositoryMock) AddSpending(spending balances.Spending) repositories.UnitOfWork {
+	return r.AddSpendingImpl(spending)
+}
+
+func (r *RepositoryMock) UpdateSpending(update balances.SpendingUpdate) repositories.UnitOfWork {
+	return r.UpdateSpendingImpl(update)
+}
+
+func (r *RepositoryMock) RemoveSpending(groupId balances.GroupId, spendingId balances.SpendingId) repositories.UnitOfWork {
+	return r.RemoveSpendingImpl(groupId, spendingId)
+}
+
+func (r *RepositoryMock) RemoveGroup(groupId balances.GroupId) repositories.UnitOfWork {
+	return r.RemoveGroupImpl(groupId)
+}
+
+func (r *RepositoryMock) Reset() repositories.UnitOfWork {
+	return r.ResetImpl()
+}
+
+func (r *RepositoryMock) GetGroupBalances(userId balances.UserId) ([]balances.GroupBalance, error) {
+	return r.GetGroupBalancesImpl(userId)
+}
+
+func (r *RepositoryMock) GetParticipantBalances(groupId balances.GroupId) ([]balances.ParticipantBalance, error) {
+	return r.GetParticipantBalancesImpl(groupId)
+}
+
+func (r *RepositoryMock) GetCounterpartyBalances(userId balances.UserId) ([]balances.CounterpartyBalance, error) {
+	return r.GetCounterpartyBalancesImpl(userId)
+}
@@ -0,0 +1,74 @@
+package balances
+
+import (
+	"verni/internal/common"
+	openapi "verni/internal/openapi/go"
+	"verni/internal/repositories"
+)
+
+// Project returns a unit of work accounting the operation in balances,
+// false is returned for operations that do not affect balances
+func Project(repository Repository, operation openapi.SomeOperation) (repositories.UnitOfWork, bool) {
+	switch {
+	case !openapi.IsZeroValue(operation.CreateSpending):
+		return repository.AddSpending(Spending{
+			Id:       SpendingId(operation.CreateSpending.SpendingId),
+			GroupId:  GroupId(operation.CreateSpending.GroupId),
+			Currency: Currency(operation.CreateSpending.Currency),
+			Shares: common.Map(operation.CreateSpending.Shares, func(share openapi.SpendingShare) Share {
+				return Share{
+					UserId: UserId(share.UserId),
+					Amount: share.Amount,
+				}
+			}),
+			Version: version(operation),
+		}), true
+	case !openapi.IsZeroValue(operation.UpdateSpending):
+		update := SpendingUpdate{
+			Id:      SpendingId(operation.UpdateSpending.SpendingId),
+			GroupId: GroupId(operation.UpdateSpending.GroupId),
+			Version: version(operation),
+		}
+		if operation.UpdateSpending.Currency != "" {
+			currency := Currency(operation.UpdateSpending.Currency)
+			update.Currency = &currency
+		}
+		if len(operation.UpdateSpending.Shares) > 0 {
+			update.Shares = common.Map(operation.UpdateSpending.Shares, func(share openapi.SpendingShare) Share {
+				return Share{
+					UserId: UserId(share.UserId),
+					Amount: share.Amount,
+				}
+			})
+		}
+		return repository.UpdateSpending(update), true
+	case !openapi.IsZeroValue(operation.Settlement):
+		// the payer gets back what they owed, so a settlement is accounted as a spending paid by them
+		return repository.AddSpending(Spending{
+			Id:       SpendingId(operation.OperationId),
+			GroupId:  GroupId(operation.Settlement.GroupId),
+			Currency: Currency(operation.Settlement.Currency),
+			Shares: []Share{
+				{UserId: UserId(operation.Settlement.From), Amount: operation.Settlement.Amount},
+				{UserId: UserId(operation.Settlement.To), Amount: -operation.Settlement.Amount},
+			},
+			Version: version(operation),
+		}), true
+	case !openapi.IsZeroValue(operation.DeleteSpending):
+		return repository.RemoveSpending(
+			GroupId(operation.DeleteSpending.GroupId),
+			SpendingId(operation.DeleteSpending.SpendingId),
+		), true
+	case !openapi.IsZeroValue(operation.DeleteSpendingGroup):
+		return repository.RemoveGroup(GroupId(operation.DeleteSpendingGroup.GroupId)), true
+	default:
+		return repositories.UnitOfWork{}, false
+	}
+}
+
+func version(operation openapi.SomeOperation) Version {
+	return Version{
+		CreatedAt:   operation.CreatedAt,
+		OperationId: operation.OperationId,
+	}
+}
@@ -0,0 +1,86 @@
+package balances
+
+import (
+	"verni/internal/repositories"
+)
+
+type UserId string
+type GroupId string
+type SpendingId string
+type Currency string
+
+type Share struct {
+	UserId UserId
+	// Amount is positive for participants who are owed money and negative for those who owe
+	Amount int64
+}
+
+// Version orders changes of a spending, a field keeps the value of its latest change
+type Version struct {
+	CreatedAt   int64
+	OperationId string
+}
+
+func (v Version) After(other Version) bool {
+	if v.CreatedAt != other.CreatedAt {
+		return v.CreatedAt > other.CreatedAt
+	}
+	return v.OperationId > other.OperationId
+}
+
+type Spending struct {
+	Id       SpendingId
+	GroupId  GroupId
+	Currency Currency
+	Shares   []Share
+	Version  Version
+}
+
+// SpendingUpdate carries changed fields only, nil fields are left as is
+type SpendingUpdate struct {
+	Id       SpendingId
+	GroupId  GroupId
+	Currency *Currency
+	Shares   []Share
+	Version  Version
+}
+
+type GroupBalance struct {
+	GroupId  GroupId
+	Currency Currency
+	Amount   int64
+}
+
+type ParticipantBalance struct {
+	UserId   UserId
+	Currency Currency
+	Amount   int64
+}
+
+type CounterpartyBalance struct {
+	CounterpartyId UserId
+	Currency       Currency
+	// Amount is positive if the counterparty owes the user
+	Amount int64
+}
+
+type Repository interface {
+	// AddSpending accounts shares of the spending, a spending that is already accounted is ignored
+	AddSpending(spending Spending) repositories.UnitOfWork
+	// UpdateSpending reaccounts the spending with fields that are newer than the accounted ones,
+	// unknown or removed spendings are ignored
+	UpdateSpending(update SpendingUpdate) repositories.UnitOfWork
+	// RemoveSpending reverts shares of the spending, unknown or already removed spendings are ignored
+	RemoveSpending(groupId GroupId, spendingId SpendingId) repositories.UnitOfWork
+	// RemoveGroup reverts shares of every spending of the group
+	RemoveGroup(groupId GroupId) repositories.UnitOfWork
+	// Reset forgets every accounted spending, used to rebuild balances from the operations log
+	Reset() repositories.UnitOfWork
+
+	// GetGroupBalances returns non-zero balances of the user in every group
+	GetGroupBalances(userId UserId) ([]GroupBalance, error)
+	// GetParticipantBalances returns non-zero balances of every participant of the group
+	GetParticipantBalances(groupId GroupId) ([]ParticipantBalance, error)
+	// GetCounterpartyBalances returns non-zero balances of the user with every counterparty
+	GetCounterpartyBalances(userId UserId) ([]CounterpartyBalance, error)
+}
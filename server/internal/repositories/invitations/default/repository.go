@@ -0,0 +1,146 @@
+package defaultRepository
+
+import (
+	"database/sql"
+	"fmt"
+	"verni/internal/db"
+	"verni/internal/repositories"
+	"verni/internal/repositories/invitations"
+	"verni/internal/services/logging"
+)
+
+func New(db db.DB, logger logging.Service) invitations.Repository {
+	return &postgresRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+type postgresRepository struct {
+	db     db.DB
+	logger logging.Service
+}
+
+func (c *postgresRepository) CreateInvitation(invitation invitations.Invitation) repositories.UnitOfWork {
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			return c.createInvitation(invitation)
+		},
+		Rollback: func() error {
+			return c.removeInvitation(invitation.Token)
+		},
+	}
+}
+
+func (c *postgresRepository) createInvitation(invitation invitations.Invitation) error {
+	const op = "repositories.invitations.defaultRepository.createInvitation"
+	c.logger.LogInfo("%s: start[group=%s]", op, invitation.GroupId)
+
+	query := `
+INSERT INTO invitations(token, groupId, createdBy, expiresAt, maxUses, uses)
+VALUES ($1, $2, $3, $4, $5, $6);
+`
+	if _, err := c.db.Exec(
+		query,
+		string(invitation.Token),
+		string(invitation.GroupId),
+		string(invitation.CreatedBy),
+		invitation.ExpiresAt,
+		invitation.MaxUses,
+		invitation.Uses,
+	); err != nil {
+		return fmt.Errorf("%s: failed to perform query: %w", op, err)
+	}
+
+	c.logger.LogInfo("%s: success[group=%s]", op, invitation.GroupId)
+	return nil
+}
+
+func (c *postgresRepository) removeInvitation(token invitations.Token) error {
+	const op = "repositories.invitations.defaultRepository.removeInvitation"
+	c.logger.LogInfo("%s: start", op)
+
+	query := `DELETE FROM invitations WHERE token = $1;`
+	if _, err := c.db.Exec(query, string(token)); err != nil {
+		return fmt.Errorf("%s: failed to perform query: %w", op, err)
+	}
+
+	c.logger.LogInfo("%s: success", op)
+	return nil
+}
+
+func (c *postgresRepository) GetInvitation(token invitations.Token) (*invitations.Invitation, error) {
+	const op = "repositories.invitations.defaultRepository.GetInvitation"
+	c.logger.LogInfo("%s: start", op)
+
+	query := `
+SELECT groupId, createdBy, expiresAt, maxUses, uses
+FROM invitations
+WHERE token = $1;
+`
+	invitation := invitations.Invitation{
+		Token: token,
+	}
+	if err := c.db.QueryRow(query, string(token)).Scan(
+		&invitation.GroupId,
+		&invitation.CreatedBy,
+		&invitation.ExpiresAt,
+		&invitation.MaxUses,
+		&invitation.Uses,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			c.logger.LogInfo("%s: no invitation found", op)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+	}
+
+	c.logger.LogInfo("%s: success[group=%s]", op, invitation.GroupId)
+	return &invitation, nil
+}
+
+func (c *postgresRepository) UseInvitation(token invitations.Token) repositories.UnitOfWork {
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			return c.useInvitation(token)
+		},
+		Rollback: func() error {
+			return c.unuseInvitation(token)
+		},
+	}
+}
+
+func (c *postgresRepository) useInvitation(token invitations.Token) error {
+	const op = "repositories.invitations.defaultRepository.useInvitation"
+	c.logger.LogInfo("%s: start", op)
+
+	// the condition is checked by the update itself, so concurrent uses can not exceed the limit
+	query := `UPDATE invitations SET uses = uses + 1 WHERE token = $1 AND uses < maxUses;`
+	result, err := c.db.Exec(query, string(token))
+	if err != nil {
+		return fmt.Errorf("%s: failed to perform query: %w", op, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get affected rows: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, invitations.ErrNoUsesLeft)
+	}
+
+	c.logger.LogInfo("%s: success", op)
+	return nil
+}
+
+func (c *postgresRepository) unuseInvitation(token invitations.Token) error {
+	const op = "repositories.invitations.defaultRepository.unuseInvitation"
+	c.logger.LogInfo("%s: start", op)
+
+	query := `UPDATE invitations SET uses = uses - 1 WHERE token = $1 AND uses > 0;`
+	if _, err := c.db.Exec(query, string(token)); err != nil {
+		return fmt.Errorf("%s: failed to perform query: %w", op, err)
+	}
+
+	c.logger.LogInfo("%s: success", op)
+	return nil
+}
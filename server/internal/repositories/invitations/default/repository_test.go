@@ -0,0 +1,96 @@
+package defaultRepository_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	postgresDb "verni/internal/db/postgres"
+	"verni/internal/repositories/invitations"
+	defaultRepository "verni/internal/repositories/invitations/default"
+	standartOutputLoggingService "verni/internal/services/logging/standartOutput"
+	defaultPathProvider "verni/internal/services/pathProvider/default"
+)
+
+var testConfig postgresDb.PostgresConfig
+
+func setupTestDB(t *testing.T) *sql.DB {
+	logger := standartOutputLoggingService.New()
+	pathProvider := defaultPathProvider.New(logger)
+	path := pathProvider.AbsolutePath("./config/test/postgres_storage.json")
+
+	configFile, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Skipf("no postgres test config at %s, see scripts/test.sh", path)
+	}
+	require.NoError(t, err)
+
+	err = json.Unmarshal(configFile, &testConfig)
+	require.NoError(t, err)
+
+	db, err := postgresDb.Postgres(testConfig, logger)
+	require.NoError(t, err)
+
+	// Clear test data
+	_, err = db.Exec("DELETE FROM invitations")
+	require.NoError(t, err)
+
+	return db.(*sql.DB)
+}
+
+func invitation(token invitations.Token, maxUses int) invitations.Invitation {
+	return invitations.Invitation{
+		Token:     token,
+		GroupId:   "trip",
+		CreatedBy: "alice",
+		ExpiresAt: 1000,
+		MaxUses:   maxUses,
+	}
+}
+
+func TestRepository_CreateInvitation(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	logger := standartOutputLoggingService.New()
+	repo := defaultRepository.New(db, logger)
+
+	work := repo.CreateInvitation(invitation("token", 1))
+	require.NoError(t, work.Perform())
+
+	stored, err := repo.GetInvitation("token")
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, invitation("token", 1), *stored)
+
+	require.NoError(t, work.Rollback())
+	stored, err = repo.GetInvitation("token")
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+}
+
+func TestRepository_UseInvitation(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	logger := standartOutputLoggingService.New()
+	repo := defaultRepository.New(db, logger)
+	require.NoError(t, repo.CreateInvitation(invitation("token", 2)).Perform())
+
+	work := repo.UseInvitation("token")
+	require.NoError(t, work.Perform())
+	require.NoError(t, repo.UseInvitation("token").Perform())
+	assert.ErrorIs(t, repo.UseInvitation("token").Perform(), invitations.ErrNoUsesLeft)
+
+	require.NoError(t, work.Rollback())
+	stored, err := repo.GetInvitation("token")
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.Uses)
+
+	assert.ErrorIs(t, repo.UseInvitation("unknown").Perform(), invitations.ErrNoUsesLeft)
+}
@@ -0,0 +1,109 @@
+package memoryRepository
+
+import (
+	"fmt"
+	"sync"
+
+	"verni/internal/repositories"
+	"verni/internal/repositories/invitations"
+	"verni/internal/services/logging"
+)
+
+func New(logger logging.Service) invitations.Repository {
+	return &memoryRepository{
+		invitations: map[invitations.Token]invitations.Invitation{},
+		logger:      logger,
+	}
+}
+
+type memoryRepository struct {
+	mutex       sync.RWMutex
+	invitations map[invitations.Token]invitations.Invitation
+	logger      logging.Service
+}
+
+func (c *memoryRepository) CreateInvitation(invitation invitations.Invitation) repositories.UnitOfWork {
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			return c.createInvitation(invitation)
+		},
+		Rollback: func() error {
+			c.removeInvitation(invitation.Token)
+			return nil
+		},
+	}
+}
+
+func (c *memoryRepository) createInvitation(invitation invitations.Invitation) error {
+	const op = "repositories.invitations.memoryRepository.createInvitation"
+	c.logger.LogInfo("%s: start[group=%s]", op, invitation.GroupId)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, exists := c.invitations[invitation.Token]; exists {
+		return fmt.Errorf("%s: invitation token is already taken", op)
+	}
+	c.invitations[invitation.Token] = invitation
+
+	c.logger.LogInfo("%s: success[group=%s]", op, invitation.GroupId)
+	return nil
+}
+
+func (c *memoryRepository) removeInvitation(token invitations.Token) {
+	const op = "repositories.invitations.memoryRepository.removeInvitation"
+	c.logger.LogInfo("%s: start", op)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.invitations, token)
+
+	c.logger.LogInfo("%s: success", op)
+}
+
+func (c *memoryRepository) GetInvitation(token invitations.Token) (*invitations.Invitation, error) {
+	const op = "repositories.invitations.memoryRepository.GetInvitation"
+	c.logger.LogInfo("%s: start", op)
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	invitation, exists := c.invitations[token]
+	if !exists {
+		c.logger.LogInfo("%s: no invitation found", op)
+		return nil, nil
+	}
+
+	c.logger.LogInfo("%s: success[group=%s]", op, invitation.GroupId)
+	return &invitation, nil
+}
+
+func (c *memoryRepository) UseInvitation(token invitations.Token) repositories.UnitOfWork {
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			return c.addUses(token, 1)
+		},
+		Rollback: func() error {
+			return c.addUses(token, -1)
+		},
+	}
+}
+
+func (c *memoryRepository) addUses(token invitations.Token, uses int) error {
+	const op = "repositories.invitations.memoryRepository.addUses"
+	c.logger.LogInfo("%s: start[uses=%d]", op, uses)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	invitation, exists := c.invitations[token]
+	if !exists || invitation.Uses+uses > invitation.MaxUses {
+		return fmt.Errorf("%s: %w", op, invitations.ErrNoUsesLeft)
+	}
+	invitation.Uses = max(invitation.Uses+uses, 0)
+	c.invitations[token] = invitation
+
+	c.logger.LogInfo("%s: success[uses=%d]", op, uses)
+	return nil
+}
@@ -0,0 +1,105 @@
+package memoryRepository_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"verni/internal/repositories/invitations"
+	memoryRepository "verni/internal/repositories/invitations/memory"
+	standartOutputLoggingService "verni/internal/services/logging/standartOutput"
+)
+
+func invitation(token invitations.Token, maxUses int) invitations.Invitation {
+	return invitations.Invitation{
+		Token:     token,
+		GroupId:   "trip",
+		CreatedBy: "alice",
+		ExpiresAt: 1000,
+		MaxUses:   maxUses,
+	}
+}
+
+func TestRepository_CreateInvitation(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	repo := memoryRepository.New(logger)
+
+	t.Run("create and rollback", func(t *testing.T) {
+		// Arrange
+		work := repo.CreateInvitation(invitation("token1", 1))
+
+		// Act
+		require.NoError(t, work.Perform())
+		stored, err := repo.GetInvitation("token1")
+
+		// Assert
+		require.NoError(t, err)
+		require.NotNil(t, stored)
+		assert.Equal(t, invitation("token1", 1), *stored)
+
+		require.NoError(t, work.Rollback())
+		stored, err = repo.GetInvitation("token1")
+		require.NoError(t, err)
+		assert.Nil(t, stored)
+	})
+
+	t.Run("taken token", func(t *testing.T) {
+		// Arrange
+		require.NoError(t, repo.CreateInvitation(invitation("token2", 1)).Perform())
+
+		// Act
+		err := repo.CreateInvitation(invitation("token2", 5)).Perform()
+
+		// Assert
+		assert.Error(t, err)
+	})
+}
+
+func TestRepository_UseInvitation(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	repo := memoryRepository.New(logger)
+
+	t.Run("uses are limited", func(t *testing.T) {
+		// Arrange
+		require.NoError(t, repo.CreateInvitation(invitation("token1", 2)).Perform())
+
+		// Act
+		first := repo.UseInvitation("token1").Perform()
+		second := repo.UseInvitation("token1").Perform()
+		third := repo.UseInvitation("token1").Perform()
+
+		// Assert
+		assert.NoError(t, first)
+		assert.NoError(t, second)
+		assert.ErrorIs(t, third, invitations.ErrNoUsesLeft)
+		stored, err := repo.GetInvitation("token1")
+		require.NoError(t, err)
+		assert.Equal(t, 2, stored.Uses)
+	})
+
+	t.Run("rollback use", func(t *testing.T) {
+		// Arrange
+		require.NoError(t, repo.CreateInvitation(invitation("token2", 1)).Perform())
+		work := repo.UseInvitation("token2")
+		require.NoError(t, work.Perform())
+
+		// Act
+		err := work.Rollback()
+
+		// Assert
+		require.NoError(t, err)
+		stored, err := repo.GetInvitation("token2")
+		require.NoError(t, err)
+		assert.Equal(t, 0, stored.Uses)
+		assert.NoError(t, repo.UseInvitation("token2").Perform())
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		// Act
+		err := repo.UseInvitation("unknown").Perform()
+
+		// Assert
+		assert.ErrorIs(t, err, invitations.ErrNoUsesLeft)
+	})
+}
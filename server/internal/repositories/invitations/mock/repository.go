@@ -0,0 +1,22 @@
+package invitations_mock
+
+import (
+	"verni/internal/repositories"
+	"verni/internal/repositories/invitations"
+)
+
+type RepositoryMock struct {
+	CreateInvitationImpl func(invitation invitations.Invitation) repositories.UnitOfWork
+	GetInvitationImpl    func(token invitations.Token) (*invitations.Invitation, error)
+	UseInvitationImpl    func(token invitations.Token) repositories.UnitOfWork
+}
+
+func (c *RepositoryMock) CreateInvitation(invitation invitations.Invitation) repositories.UnitOfWork {
+	return c.CreateInvitationImpl(invitation)
+}
+func (c *RepositoryMock) GetInvitation(token invitations.Token) (*invitations.Invitation, error) {
+	return c.GetInvitationImpl(token)
+}
+func (c *RepositoryMock) UseInvitation(token invitations.Token) repositories.UnitOfWork {
+	return c.UseInvitationImpl(token)
+}
@@ -0,0 +1,33 @@
+package invitations
+
+import (
+	"errors"
+	"verni/internal/repositories"
+)
+
+type Token string
+type GroupId string
+type UserId string
+
+var (
+	ErrNoUsesLeft = errors.New("invitation has no uses left")
+)
+
+type Invitation struct {
+	Token     Token
+	GroupId   GroupId
+	CreatedBy UserId
+	// ExpiresAt is a unix timestamp in milliseconds
+	ExpiresAt int64
+	MaxUses   int
+	Uses      int
+}
+
+type Repository interface {
+	CreateInvitation(invitation Invitation) repositories.UnitOfWork
+	// GetInvitation returns nil if there is no invitation with the token
+	GetInvitation(token Token) (*Invitation, error)
+	// UseInvitation counts one more use of the invitation, it fails with ErrNoUsesLeft if the invitation
+	// is missing or used up. Concurrent uses never exceed the limit.
+	UseInvitation(token Token) repositories.UnitOfWork
+}
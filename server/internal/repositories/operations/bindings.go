@@ -0,0 +1,72 @@
+package operations
+
+import (
+	"encoding/json"
+	"fmt"
+	openapi "verni/internal/openapi/go"
+)
+
+// BoundUsers maps sandbox users among `userIds` to registered users they were bound to with BindUser,
+// users that were not bound are missing in the result
+func BoundUsers(repository Repository, userIds []UserId) (map[UserId]UserId, error) {
+	bound := map[UserId]UserId{}
+	bindings, err := getBindings(repository, userIds)
+	if err != nil {
+		return nil, err
+	}
+	requested := map[UserId]struct{}{}
+	for _, userId := range userIds {
+		requested[userId] = struct{}{}
+	}
+	for _, binding := range bindings {
+		if _, found := requested[UserId(binding.OldId)]; found {
+			bound[UserId(binding.OldId)] = UserId(binding.NewId)
+		}
+	}
+	return bound, nil
+}
+
+// SandboxUsers returns sandbox users bound to the registered user with BindUser
+func SandboxUsers(repository Repository, userId UserId) ([]UserId, error) {
+	bindings, err := getBindings(repository, []UserId{userId})
+	if err != nil {
+		return nil, err
+	}
+	sandboxUsers := []UserId{}
+	for _, binding := range bindings {
+		if UserId(binding.NewId) == userId {
+			sandboxUsers = append(sandboxUsers, UserId(binding.OldId))
+		}
+	}
+	return sandboxUsers, nil
+}
+
+func getBindings(repository Repository, userIds []UserId) ([]openapi.BindUserOperationBindUser, error) {
+	if len(userIds) == 0 {
+		return nil, nil
+	}
+	entities := make([]TrackedEntity, len(userIds))
+	for index, userId := range userIds {
+		entities[index] = TrackedEntity{Id: string(userId), Type: EntityTypeUser}
+	}
+	affecting, err := repository.Get(entities)
+	if err != nil {
+		return nil, fmt.Errorf("getting operations of users %v: %w", userIds, err)
+	}
+	bindings := []openapi.BindUserOperationBindUser{}
+	for _, operation := range affecting {
+		if operation.Payload.Type() != BindUserOperationPayloadType {
+			continue
+		}
+		data, err := operation.Payload.Data()
+		if err != nil {
+			return nil, fmt.Errorf("getting data from operation %s: %w", operation.OperationId, err)
+		}
+		var converted openapi.SomeOperation
+		if err := json.Unmarshal(data, &converted); err != nil {
+			return nil, fmt.Errorf("parsing operation %s: %w", operation.OperationId, err)
+		}
+		bindings = append(bindings, converted.BindUser)
+	}
+	return bindings, nil
+}
@@ -0,0 +1,106 @@
+package defaultRepository
+
+import (
+	"fmt"
+	"strings"
+	"verni/internal/repositories"
+	"verni/internal/repositories/operations"
+)
+
+func (c *defaultRepository) GetConfirmations(operationIds []operations.OperationId) ([]operations.Confirmation, error) {
+	const op = "repositories.operations.defaultRepository.GetConfirmations"
+	c.logger.LogInfo("%s: start[count=%d]", op, len(operationIds))
+
+	if len(operationIds) == 0 {
+		c.logger.LogInfo("%s: no operations provided, early return", op)
+		return []operations.Confirmation{}, nil
+	}
+
+	placeholders := make([]string, len(operationIds))
+	args := make([]interface{}, len(operationIds))
+	for i, operationId := range operationIds {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = operationId
+	}
+	query := fmt.Sprintf(`
+SELECT operationId, userId, deviceId
+FROM confirmedOperations
+WHERE operationId IN (%s);`, strings.Join(placeholders, ", "))
+	rows, err := c.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to perform query: %w", op, err)
+	}
+	defer rows.Close()
+
+	result := []operations.Confirmation{}
+	for rows.Next() {
+		var confirmation operations.Confirmation
+		if err := rows.Scan(&confirmation.OperationId, &confirmation.UserId, &confirmation.DeviceId); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+		result = append(result, confirmation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: error encountered during iteration: %w", op, err)
+	}
+
+	c.logger.LogInfo("%s: success[count=%d]", op, len(result))
+	return result, nil
+}
+
+func (c *defaultRepository) CountConfirmations(userId operations.UserId, deviceId operations.DeviceId) (int, error) {
+	const op = "repositories.operations.defaultRepository.CountConfirmations"
+	c.logger.LogInfo("%s: start[user=%s device=%s]", op, userId, deviceId)
+
+	query := `SELECT COUNT(*) FROM confirmedOperations WHERE userId = $1 AND deviceId = $2;`
+	var count int
+	if err := c.db.QueryRow(query, userId, deviceId).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: failed to count confirmations: %w", op, err)
+	}
+
+	c.logger.LogInfo("%s: success[count=%d]", op, count)
+	return count, nil
+}
+
+func (c *defaultRepository) RemoveConfirmations(userId operations.UserId, deviceId operations.DeviceId) repositories.UnitOfWork {
+	var removed removedRows
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			var err error
+			removed.confirmations, err = c.removeConfirmations(userId, deviceId)
+			return err
+		},
+		Rollback: func() error {
+			return c.restore(removed)
+		},
+	}
+}
+
+func (c *defaultRepository) removeConfirmations(userId operations.UserId, deviceId operations.DeviceId) ([]confirmedOperationRow, error) {
+	const op = "repositories.operations.defaultRepository.removeConfirmations"
+	c.logger.LogInfo("%s: start[user=%s device=%s]", op, userId, deviceId)
+
+	rows, err := c.db.Query(`
+DELETE FROM confirmedOperations
+WHERE userId = $1 AND deviceId = $2
+RETURNING userId, deviceId, operationId;`, userId, deviceId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to delete confirmations: %w", op, err)
+	}
+	defer rows.Close()
+
+	removed := []confirmedOperationRow{}
+	for rows.Next() {
+		var row confirmedOperationRow
+		if err := rows.Scan(&row.userId, &row.deviceId, &row.operationId); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan deleted confirmation: %w", op, err)
+		}
+		removed = append(removed, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: error occurred during deleted confirmations iteration: %w", op, err)
+	}
+
+	c.logger.LogInfo("%s: success[removed=%d]", op, len(removed))
+	return removed, nil
+}
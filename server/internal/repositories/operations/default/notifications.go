@@ -0,0 +1,217 @@
+package defaultRepository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"verni/internal/repositories"
+	"verni/internal/repositories/operations"
+)
+
+func (c *defaultRepository) ClaimNotifications(now int64, until int64, limit int) ([]operations.Notification, error) {
+	const op = "repositories.operations.defaultRepository.ClaimNotifications"
+	c.logger.LogInfo("%s: start[now=%d until=%d limit=%d]", op, now, until, limit)
+
+	// rows locked by another claim are skipped, so concurrent relays never take the same notification
+	query := `
+UPDATE notificationsOutbox SET nextAttemptAt = $2
+WHERE id IN (
+	SELECT id FROM notificationsOutbox
+	WHERE state = $1 AND nextAttemptAt <= $3
+	ORDER BY createdAt, id
+	LIMIT $4
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING id, createdAt, payload, state, attempts, nextAttemptAt, lastError;`
+
+	rows, err := c.db.Query(query, operations.NotificationStatePending, until, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to execute query: %w", op, err)
+	}
+	result, err := scanNotifications(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c.logger.LogInfo("%s: success[claimed=%d]", op, len(result))
+	return result, nil
+}
+
+func (c *defaultRepository) ReplaceNotification(
+	notification operations.Notification,
+	with []operations.Notification,
+) repositories.UnitOfWork {
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			return c.replaceNotifications([]operations.Notification{notification}, with)
+		},
+		Rollback: func() error {
+			return c.replaceNotifications(with, []operations.Notification{notification})
+		},
+	}
+}
+
+func (c *defaultRepository) replaceNotifications(removed []operations.Notification, added []operations.Notification) (err error) {
+	const op = "repositories.operations.defaultRepository.replaceNotifications"
+	c.logger.LogInfo("%s: start[removed=%d added=%d]", op, len(removed), len(added))
+
+	tx, err := c.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	for _, notification := range removed {
+		if err = deleteNotification(tx, notification.Id); err != nil {
+			return err
+		}
+	}
+	for _, notification := range added {
+		if err = insertNotification(tx, notification); err != nil {
+			return err
+		}
+	}
+
+	c.logger.LogInfo("%s: success[removed=%d added=%d]", op, len(removed), len(added))
+	return nil
+}
+
+func (c *defaultRepository) UpdateNotification(notification operations.Notification) repositories.UnitOfWork {
+	var previous *operations.Notification
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			var err error
+			previous, err = c.updateNotification(notification)
+			return err
+		},
+		Rollback: func() error {
+			if previous == nil {
+				return nil
+			}
+			_, err := c.updateNotification(*previous)
+			return err
+		},
+	}
+}
+
+// updateNotification returns the notification as it was before the update
+func (c *defaultRepository) updateNotification(notification operations.Notification) (*operations.Notification, error) {
+	const op = "repositories.operations.defaultRepository.updateNotification"
+	c.logger.LogInfo("%s: start[id=%s state=%s attempts=%d]", op, notification.Id, notification.State, notification.Attempts)
+
+	query := `
+UPDATE notificationsOutbox AS updated
+SET state = $2, attempts = $3, nextAttemptAt = $4, lastError = $5
+FROM notificationsOutbox AS previous
+WHERE updated.id = $1 AND previous.id = updated.id
+RETURNING previous.id, previous.createdAt, previous.payload, previous.state, previous.attempts, previous.nextAttemptAt, previous.lastError;`
+
+	var previous operations.Notification
+	if err := c.db.QueryRow(query,
+		notification.Id,
+		notification.State,
+		notification.Attempts,
+		notification.NextAttemptAt,
+		notification.LastError,
+	).Scan(
+		&previous.Id,
+		&previous.CreatedAt,
+		&previous.Payload,
+		&previous.State,
+		&previous.Attempts,
+		&previous.NextAttemptAt,
+		&previous.LastError,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: notification %s does not exist", op, notification.Id)
+		}
+		return nil, fmt.Errorf("%s: failed to execute query: %w", op, err)
+	}
+
+	c.logger.LogInfo("%s: success[id=%s]", op, notification.Id)
+	return &previous, nil
+}
+
+func (c *defaultRepository) DeadNotifications(limit int) ([]operations.Notification, error) {
+	const op = "repositories.operations.defaultRepository.DeadNotifications"
+	c.logger.LogInfo("%s: start[limit=%d]", op, limit)
+
+	query := `
+SELECT id, createdAt, payload, state, attempts, nextAttemptAt, lastError FROM notificationsOutbox
+WHERE state = $1
+ORDER BY createdAt, id
+LIMIT $2;`
+
+	rows, err := c.db.Query(query, operations.NotificationStateDead, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to execute query: %w", op, err)
+	}
+	result, err := scanNotifications(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c.logger.LogInfo("%s: success[dead=%d]", op, len(result))
+	return result, nil
+}
+
+func scanNotifications(rows *sql.Rows) ([]operations.Notification, error) {
+	defer rows.Close()
+	result := []operations.Notification{}
+	for rows.Next() {
+		var notification operations.Notification
+		if err := rows.Scan(
+			&notification.Id,
+			&notification.CreatedAt,
+			&notification.Payload,
+			&notification.State,
+			&notification.Attempts,
+			&notification.NextAttemptAt,
+			&notification.LastError,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		result = append(result, notification)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error occurred during notifications iteration: %w", err)
+	}
+	return result, nil
+}
+
+func insertNotification(tx *sql.Tx, notification operations.Notification) error {
+	query := `
+INSERT INTO notificationsOutbox (id, createdAt, payload, state, attempts, nextAttemptAt, lastError)
+VALUES ($1, $2, $3, $4, $5, $6, $7);`
+
+	if _, err := tx.Exec(query,
+		notification.Id,
+		notification.CreatedAt,
+		notification.Payload,
+		notification.State,
+		notification.Attempts,
+		notification.NextAttemptAt,
+		notification.LastError,
+	); err != nil {
+		return fmt.Errorf("failed to insert notification %s: %w", notification.Id, err)
+	}
+	return nil
+}
+
+func deleteNotification(tx *sql.Tx, id operations.NotificationId) error {
+	query := `
+DELETE FROM notificationsOutbox
+WHERE id = $1;`
+
+	if _, err := tx.Exec(query, id); err != nil {
+		return fmt.Errorf("failed to delete notification %s: %w", id, err)
+	}
+	return nil
+}
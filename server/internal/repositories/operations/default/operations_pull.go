@@ -0,0 +1,260 @@
+package defaultRepository
+
+import (
+	"database/sql"
+	"fmt"
+	"verni/internal/repositories/operations"
+)
+
+func (c *defaultRepository) Pull(
+	userId operations.UserId,
+	deviceId operations.DeviceId,
+	after operations.SequenceNumber,
+	limit int,
+	operationsType operations.OperationType,
+) ([]operations.Operation, error) {
+	const op = "repositories.operations.defaultRepository.Pull"
+	c.logger.LogInfo("%s: start[user=%s device=%s after=%d limit=%d]", op, userId, deviceId, after, limit)
+
+	query := `
+WITH page AS (
+    SELECT
+        o.sequenceNumber,
+        o.operationId,
+        o.createdAt,
+        o.authorId,
+        o.data,
+        o.searchHint,
+        o.operationType
+    FROM operations o
+    WHERE o.sequenceNumber > $3
+      AND o.isLarge = $4
+      AND EXISTS (
+        SELECT 1
+        FROM operationsAffectingEntity oe
+        JOIN trackedEntities te ON te.entityId = oe.entityId AND te.entityType = oe.entityType
+        WHERE oe.operationId = o.operationId AND te.userId = $1
+      )
+      AND NOT EXISTS (
+        SELECT 1
+        FROM confirmedOperations co
+        WHERE co.operationId = o.operationId AND co.userId = $1 AND co.deviceId = $2
+      )
+    ORDER BY o.sequenceNumber
+    LIMIT $5
+)
+SELECT
+    p.sequenceNumber,
+    p.operationId,
+    p.createdAt,
+    p.authorId,
+    p.data,
+    p.searchHint,
+    p.operationType,
+    ae.entityId,
+    ae.entityType
+FROM page p
+JOIN operationsAffectingEntity ae ON ae.operationId = p.operationId
+ORDER BY p.sequenceNumber;`
+	isLarge := operationsType == operations.OperationTypeLarge
+	result, err := c.queryPage(isLarge, query, userId, deviceId, after, isLarge, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c.logger.LogInfo("%s: success[user=%s device=%s count=%d]", op, userId, deviceId, len(result))
+	return result, nil
+}
+
+func (c *defaultRepository) PullSince(
+	userId operations.UserId,
+	since operations.SequenceNumber,
+	limit int,
+	operationsType operations.OperationType,
+) ([]operations.Operation, error) {
+	const op = "repositories.operations.defaultRepository.PullSince"
+	c.logger.LogInfo("%s: start[user=%s since=%d limit=%d]", op, userId, since, limit)
+
+	// an operation appears at the latest of its own sequence number and the binding of its entity to the user,
+	// taking the earliest such position among its entities. Bindings made by operations removed from the log are
+	// taken as made at the start of it.
+	query := `
+WITH bound AS (
+    SELECT
+        te.entityId,
+        te.entityType,
+        MIN(COALESCE(bo.sequenceNumber, 0)) AS boundAt
+    FROM trackedEntities te
+    LEFT JOIN operations bo ON bo.operationId = te.operationId
+    WHERE te.userId = $1
+    GROUP BY te.entityId, te.entityType
+),
+visible AS (
+    SELECT
+        o.operationId,
+        o.sequenceNumber,
+        MIN(GREATEST(o.sequenceNumber, b.boundAt)) AS pullPosition
+    FROM operations o
+    JOIN operationsAffectingEntity oe ON oe.operationId = o.operationId
+    JOIN bound b ON b.entityId = oe.entityId AND b.entityType = oe.entityType
+    WHERE o.isLarge = $3
+      AND (
+        o.sequenceNumber > $2
+        OR EXISTS (
+          SELECT 1
+          FROM operationsAffectingEntity ne
+          JOIN bound nb ON nb.entityId = ne.entityId AND nb.entityType = ne.entityType
+          WHERE ne.operationId = o.operationId AND nb.boundAt > $2
+        )
+      )
+    GROUP BY o.operationId, o.sequenceNumber
+    HAVING MIN(GREATEST(o.sequenceNumber, b.boundAt)) > $2
+),
+numbered AS (
+    SELECT
+        v.operationId,
+        v.sequenceNumber,
+        v.pullPosition,
+        ROW_NUMBER() OVER (ORDER BY v.pullPosition, v.sequenceNumber) AS rowNumber
+    FROM visible v
+),
+page AS (
+    SELECT
+        n.pullPosition,
+        n.sequenceNumber,
+        o.operationId,
+        o.createdAt,
+        o.authorId,
+        o.data,
+        o.searchHint,
+        o.operationType
+    FROM numbered n
+    JOIN operations o ON o.operationId = n.operationId
+    WHERE n.pullPosition <= (SELECT MAX(pullPosition) FROM numbered WHERE rowNumber <= $4)
+)
+SELECT
+    p.pullPosition,
+    p.operationId,
+    p.createdAt,
+    p.authorId,
+    p.data,
+    p.searchHint,
+    p.operationType,
+    ae.entityId,
+    ae.entityType
+FROM page p
+JOIN operationsAffectingEntity ae ON ae.operationId = p.operationId
+ORDER BY p.pullPosition, p.sequenceNumber;`
+	isLarge := operationsType == operations.OperationTypeLarge
+	result, err := c.queryPage(isLarge, query, userId, since, isLarge, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c.logger.LogInfo("%s: success[user=%s since=%d count=%d]", op, userId, since, len(result))
+	return result, nil
+}
+
+func (c *defaultRepository) Log(
+	after operations.SequenceNumber,
+	limit int,
+	operationsType operations.OperationType,
+) ([]operations.Operation, error) {
+	const op = "repositories.operations.defaultRepository.Log"
+	c.logger.LogInfo("%s: start[after=%d limit=%d]", op, after, limit)
+
+	query := `
+WITH page AS (
+    SELECT
+        o.sequenceNumber,
+        o.operationId,
+        o.createdAt,
+        o.authorId,
+        o.data,
+        o.searchHint,
+        o.operationType
+    FROM operations o
+    WHERE o.sequenceNumber > $1
+      AND o.isLarge = $2
+    ORDER BY o.sequenceNumber
+    LIMIT $3
+)
+SELECT
+    p.sequenceNumber,
+    p.operationId,
+    p.createdAt,
+    p.authorId,
+    p.data,
+    p.searchHint,
+    p.operationType,
+    ae.entityId,
+    ae.entityType
+FROM page p
+JOIN operationsAffectingEntity ae ON ae.operationId = p.operationId
+ORDER BY p.sequenceNumber;`
+	isLarge := operationsType == operations.OperationTypeLarge
+	result, err := c.queryPage(isLarge, query, after, isLarge, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c.logger.LogInfo("%s: success[after=%d count=%d]", op, after, len(result))
+	return result, nil
+}
+
+// queryPage expects rows of (sequenceNumber, operationId, createdAt, authorId, data, searchHint, operationType, entityId, entityType)
+// ordered by sequence number
+func (c *defaultRepository) queryPage(isLarge bool, query string, args ...any) ([]operations.Operation, error) {
+	rows, err := c.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	result := []operations.Operation{}
+	for rows.Next() {
+		var operation operations.Operation
+		var payload rawPayload
+		var entityID, entityType string
+		var searchHint sql.NullString
+
+		if err := rows.Scan(
+			&operation.SequenceNumber,
+			&operation.OperationId,
+			&operation.CreatedAt,
+			&operation.AuthorId,
+			&payload.data,
+			&searchHint,
+			&payload.payloadType,
+			&entityID,
+			&entityType,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		entity := operations.TrackedEntity{
+			Id:   entityID,
+			Type: operations.EntityType(entityType),
+		}
+
+		// rows are ordered by sequence number, so entities of the same operation are adjacent
+		if last := len(result) - 1; last >= 0 && result[last].OperationId == operation.OperationId {
+			current := result[last].Payload.(*rawPayload)
+			current.trackedEntities = append(current.trackedEntities, entity)
+			continue
+		}
+
+		if searchHint.Valid {
+			payload.searchHint = &searchHint.String
+		}
+		payload.isLarge = isLarge
+		payload.trackedEntities = []operations.TrackedEntity{entity}
+		operation.Payload = &payload
+		result = append(result, operation)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error occurred during row iteration: %w", err)
+	}
+	return result, nil
+}
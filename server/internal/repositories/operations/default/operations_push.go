@@ -7,55 +7,112 @@ import (
 	"verni/internal/repositories/operations"
 )
 
+// arbitrary application-wide key for pg_advisory_xact_lock
+const pushLockKey = 7390211
+
+type trackedEntityRow struct {
+	userId      operations.UserId
+	entity      operations.TrackedEntity
+	operationId operations.OperationId
+}
+
+// push returns bindings removed by unbind actions of the operations
+// pushed is what a push has stored, bindings removed by unbind actions are kept to restore them
+type pushed struct {
+	operations    []operations.PushOperation
+	notifications []operations.Notification
+	unbound       []trackedEntityRow
+}
+
 func (c *defaultRepository) push(
-	operations []operations.PushOperation,
+	prepare operations.PreparePush,
 	userId operations.UserId,
 	deviceId operations.DeviceId,
 	confirm bool,
-) error {
+) (result pushed, err error) {
 	const op = "repositories.operations.defaultRepository.push"
 	c.logger.LogInfo("%s: start[user=%s device=%s]", op, userId, deviceId)
 
 	tx, err := c.db.BeginTx(context.Background(), nil)
 	if err != nil {
-		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+		return pushed{}, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
 	}
 	defer func() {
 		if err != nil {
 			tx.Rollback()
+			result = pushed{}
 		} else {
 			err = tx.Commit()
 		}
 	}()
 
+	// sequence numbers are taken from a BIGSERIAL at insert time, serializing pushes makes
+	// them visible in commit order so a reader never skips a number that commits later
+	if _, err = tx.Exec(`SELECT pg_advisory_xact_lock($1);`, pushLockKey); err != nil {
+		return pushed{}, fmt.Errorf("%s: failed to acquire push lock: %w", op, err)
+	}
+
+	// the lock is held while the push is prepared, so reads of prepare see every push committed before this
+	// one and none committed after it
+	operations, notifications, err := prepare()
+	if err != nil {
+		return pushed{}, fmt.Errorf("%s: preparing push: %w", op, err)
+	}
+	result = pushed{operations: operations, notifications: notifications}
+
 	for _, operation := range operations {
-		if err := insertOperation(tx, operation); err != nil {
-			return err
+		if err = insertOperation(tx, operation); err != nil {
+			return pushed{}, err
 		}
 
 		for _, entity := range operation.Payload.TrackedEntities() {
-			if err := insertEntity(tx, operation.OperationId, entity); err != nil {
-				return err
+			if err = insertEntity(tx, operation.OperationId, entity); err != nil {
+				return pushed{}, err
 			}
 		}
 
 		for _, action := range operation.EntityBindActions {
 			for _, watcher := range action.Watchers {
-				if err := insertTrackedEntity(tx, operation.OperationId, watcher, action.Entity); err != nil {
-					return err
+				if err = insertTrackedEntity(tx, operation.OperationId, watcher, action.Entity); err != nil {
+					return pushed{}, err
+				}
+			}
+		}
+
+		for _, action := range operation.EntityUnbindActions {
+			for _, watcher := range action.Watchers {
+				var rows []trackedEntityRow
+				if rows, err = deleteTrackedEntities(tx, watcher, action.Entity); err != nil {
+					return pushed{}, err
 				}
+				result.unbound = append(result.unbound, rows...)
+			}
+		}
+
+		for _, projection := range operation.Projections {
+			if projection.PerformIn == nil {
+				return pushed{}, fmt.Errorf("%s: projection of operation %s can not join the push transaction", op, operation.OperationId)
+			}
+			if err = projection.PerformIn(tx); err != nil {
+				return pushed{}, fmt.Errorf("%s: projecting operation %s: %w", op, operation.OperationId, err)
 			}
 		}
 
 		if confirm {
-			if err := insertConfirmedOperation(tx, userId, deviceId, operation.OperationId); err != nil {
-				return err
+			if err = insertConfirmedOperation(tx, userId, deviceId, operation.OperationId); err != nil {
+				return pushed{}, err
 			}
 		}
 	}
 
+	for _, notification := range notifications {
+		if err = insertNotification(tx, notification); err != nil {
+			return pushed{}, err
+		}
+	}
+
 	c.logger.LogInfo("%s: success[user=%s device=%s]", op, userId, deviceId)
-	return nil
+	return result, nil
 }
 
 func insertOperation(tx *sql.Tx, operation operations.PushOperation) error {
@@ -109,6 +166,36 @@ VALUES ($1, $2, $3, $4);`
 	return nil
 }
 
+// deleteTrackedEntities removes every binding of the watcher to the entity and returns removed rows
+func deleteTrackedEntities(tx *sql.Tx, userId operations.UserId, entity operations.TrackedEntity) ([]trackedEntityRow, error) {
+	query := `
+DELETE FROM trackedEntities
+WHERE userId = $1 AND entityId = $2 AND entityType = $3
+RETURNING operationId;`
+
+	rows, err := tx.Query(query, userId, entity.Id, entity.Type)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete tracked entities of user %s: %w", userId, err)
+	}
+	defer rows.Close()
+
+	result := []trackedEntityRow{}
+	for rows.Next() {
+		row := trackedEntityRow{
+			userId: userId,
+			entity: entity,
+		}
+		if err := rows.Scan(&row.operationId); err != nil {
+			return nil, fmt.Errorf("failed to scan deleted tracked entity of user %s: %w", userId, err)
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error occurred during deleted tracked entities iteration: %w", err)
+	}
+	return result, nil
+}
+
 func insertConfirmedOperation(tx *sql.Tx, userId operations.UserId, deviceId operations.DeviceId, operationId operations.OperationId) error {
 	query := `
 INSERT INTO confirmedOperations (userId, deviceId, operationId)
@@ -9,13 +9,25 @@ import (
 
 func (c *defaultRepository) pushRollback(
 	operations []operations.PushOperation,
+	unbound []trackedEntityRow,
 	userId operations.UserId,
 	deviceId operations.DeviceId,
 	confirm bool,
+	notifications []operations.Notification,
 ) error {
 	const op = "repositories.operations.defaultRepository.pushRollback"
 	c.logger.LogInfo("%s: start[user=%s device=%s]", op, userId, deviceId)
 
+	// projections were performed in the push transaction, but their rollbacks run on their own
+	for operationIndex := len(operations) - 1; operationIndex >= 0; operationIndex-- {
+		operation := operations[operationIndex]
+		for index := len(operation.Projections) - 1; index >= 0; index-- {
+			if err := operation.Projections[index].Rollback(); err != nil {
+				return fmt.Errorf("%s: rolling back projection of operation %s: %w", op, operation.OperationId, err)
+			}
+		}
+	}
+
 	tx, err := c.db.BeginTx(context.Background(), nil)
 	if err != nil {
 		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
@@ -52,6 +64,26 @@ func (c *defaultRepository) pushRollback(
 		}
 	}
 
+	// bindings made by the rolled back operations themselves are gone for good
+	pushed := map[string]struct{}{}
+	for _, operation := range operations {
+		pushed[string(operation.OperationId)] = struct{}{}
+	}
+	for _, row := range unbound {
+		if _, found := pushed[string(row.operationId)]; found {
+			continue
+		}
+		if err := insertTrackedEntity(tx, row.operationId, row.userId, row.entity); err != nil {
+			return err
+		}
+	}
+
+	for _, notification := range notifications {
+		if err := deleteNotification(tx, notification.Id); err != nil {
+			return err
+		}
+	}
+
 	c.logger.LogInfo("%s: success[user=%s device=%s]", op, userId, deviceId)
 	return nil
 }
@@ -0,0 +1,224 @@
+package defaultRepository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"verni/internal/repositories"
+	"verni/internal/repositories/operations"
+)
+
+// removedRows keep everything deleted with operations to restore it on rollback
+type removedRows struct {
+	operations    []operationRow
+	entities      []affectedEntityRow
+	confirmations []confirmedOperationRow
+}
+
+type operationRow struct {
+	operationId    operations.OperationId
+	sequenceNumber operations.SequenceNumber
+	createdAt      int64
+	authorId       operations.UserId
+	operationType  string
+	isLarge        string
+	data           []byte
+	searchHint     sql.NullString
+}
+
+type affectedEntityRow struct {
+	operationId operations.OperationId
+	entity      operations.TrackedEntity
+}
+
+type confirmedOperationRow struct {
+	userId      operations.UserId
+	deviceId    operations.DeviceId
+	operationId operations.OperationId
+}
+
+func (c *defaultRepository) Remove(operationIds []operations.OperationId) repositories.UnitOfWork {
+	var removed removedRows
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			var err error
+			removed, err = c.remove(operationIds)
+			return err
+		},
+		Rollback: func() error {
+			return c.restore(removed)
+		},
+	}
+}
+
+func (c *defaultRepository) remove(operationIds []operations.OperationId) (removed removedRows, err error) {
+	const op = "repositories.operations.defaultRepository.remove"
+	c.logger.LogInfo("%s: start[count=%d]", op, len(operationIds))
+
+	if len(operationIds) == 0 {
+		return removedRows{}, nil
+	}
+
+	tx, err := c.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return removedRows{}, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			removed = removedRows{}
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	placeholders := make([]string, len(operationIds))
+	args := make([]interface{}, len(operationIds))
+	for i, operationId := range operationIds {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = operationId
+	}
+	in := strings.Join(placeholders, ", ")
+
+	if removed.confirmations, err = deleteConfirmations(tx, in, args); err != nil {
+		return removedRows{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if removed.entities, err = deleteAffectedEntities(tx, in, args); err != nil {
+		return removedRows{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if removed.operations, err = deleteOperations(tx, in, args); err != nil {
+		return removedRows{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c.logger.LogInfo("%s: success[removed=%d]", op, len(removed.operations))
+	return removed, nil
+}
+
+func deleteConfirmations(tx *sql.Tx, in string, args []interface{}) ([]confirmedOperationRow, error) {
+	rows, err := tx.Query(fmt.Sprintf(`
+DELETE FROM confirmedOperations
+WHERE operationId IN (%s)
+RETURNING userId, deviceId, operationId;`, in), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete confirmations: %w", err)
+	}
+	defer rows.Close()
+
+	result := []confirmedOperationRow{}
+	for rows.Next() {
+		var row confirmedOperationRow
+		if err := rows.Scan(&row.userId, &row.deviceId, &row.operationId); err != nil {
+			return nil, fmt.Errorf("failed to scan deleted confirmation: %w", err)
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error occurred during deleted confirmations iteration: %w", err)
+	}
+	return result, nil
+}
+
+func deleteAffectedEntities(tx *sql.Tx, in string, args []interface{}) ([]affectedEntityRow, error) {
+	rows, err := tx.Query(fmt.Sprintf(`
+DELETE FROM operationsAffectingEntity
+WHERE operationId IN (%s)
+RETURNING operationId, entityId, entityType;`, in), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete affected entities: %w", err)
+	}
+	defer rows.Close()
+
+	result := []affectedEntityRow{}
+	for rows.Next() {
+		var row affectedEntityRow
+		if err := rows.Scan(&row.operationId, &row.entity.Id, &row.entity.Type); err != nil {
+			return nil, fmt.Errorf("failed to scan deleted affected entity: %w", err)
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error occurred during deleted affected entities iteration: %w", err)
+	}
+	return result, nil
+}
+
+func deleteOperations(tx *sql.Tx, in string, args []interface{}) ([]operationRow, error) {
+	rows, err := tx.Query(fmt.Sprintf(`
+DELETE FROM operations
+WHERE operationId IN (%s)
+RETURNING operationId, sequenceNumber, createdAt, authorId, operationType, isLarge, data, searchHint;`, in), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete operations: %w", err)
+	}
+	defer rows.Close()
+
+	result := []operationRow{}
+	for rows.Next() {
+		var row operationRow
+		if err := rows.Scan(
+			&row.operationId,
+			&row.sequenceNumber,
+			&row.createdAt,
+			&row.authorId,
+			&row.operationType,
+			&row.isLarge,
+			&row.data,
+			&row.searchHint,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan deleted operation: %w", err)
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error occurred during deleted operations iteration: %w", err)
+	}
+	return result, nil
+}
+
+func (c *defaultRepository) restore(removed removedRows) (err error) {
+	const op = "repositories.operations.defaultRepository.restore"
+	c.logger.LogInfo("%s: start[count=%d]", op, len(removed.operations))
+
+	tx, err := c.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	for _, row := range removed.operations {
+		if _, err = tx.Exec(`
+INSERT INTO operations (operationId, sequenceNumber, createdAt, authorId, operationType, isLarge, data, searchHint)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);`,
+			row.operationId,
+			row.sequenceNumber,
+			row.createdAt,
+			row.authorId,
+			row.operationType,
+			row.isLarge,
+			row.data,
+			row.searchHint,
+		); err != nil {
+			return fmt.Errorf("%s: failed to restore operation %s: %w", op, row.operationId, err)
+		}
+	}
+	for _, row := range removed.entities {
+		if err = insertEntity(tx, row.operationId, row.entity); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	for _, row := range removed.confirmations {
+		if err = insertConfirmedOperation(tx, row.userId, row.deviceId, row.operationId); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	c.logger.LogInfo("%s: success[count=%d]", op, len(removed.operations))
+	return nil
+}
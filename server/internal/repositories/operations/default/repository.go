@@ -23,116 +23,37 @@ type defaultRepository struct {
 }
 
 func (c *defaultRepository) Push(
-	operations []operations.PushOperation,
+	pushOperations []operations.PushOperation,
 	userId operations.UserId,
 	deviceId operations.DeviceId,
 	confirm bool,
+	notifications []operations.Notification,
 ) repositories.UnitOfWork {
+	return c.PushPrepared(func() ([]operations.PushOperation, []operations.Notification, error) {
+		return pushOperations, notifications, nil
+	}, userId, deviceId, confirm)
+}
+
+func (c *defaultRepository) PushPrepared(
+	prepare operations.PreparePush,
+	userId operations.UserId,
+	deviceId operations.DeviceId,
+	confirm bool,
+) repositories.UnitOfWork {
+	// prepared operations and bindings removed by their unbind actions are restored on rollback
+	var prepared pushed
 	return repositories.UnitOfWork{
 		Perform: func() error {
-			return c.push(operations, userId, deviceId, confirm)
+			var err error
+			prepared, err = c.push(prepare, userId, deviceId, confirm)
+			return err
 		},
 		Rollback: func() error {
-			return c.pushRollback(operations, userId, deviceId, confirm)
+			return c.pushRollback(prepared.operations, prepared.unbound, userId, deviceId, confirm, prepared.notifications)
 		},
 	}
 }
 
-func (c *defaultRepository) Pull(
-	userId operations.UserId,
-	deviceId operations.DeviceId,
-	operationsType operations.OperationType,
-) ([]operations.Operation, error) {
-	const op = "repositories.operations.defaultRepository.Pull"
-	c.logger.LogInfo("%s: start[user=%s device=%s]", op, userId, deviceId)
-
-	query := `
-SELECT
-    o.operationId,
-    o.createdAt,
-    o.data,
-    o.searchHint,
-    o.operationType,
-    ae.entityId,
-    ae.entityType
-FROM operations o
-JOIN operationsAffectingEntity ae ON o.operationId = ae.operationId
-WHERE ae.operationId IN (
-    SELECT oe.operationId
-    FROM operationsAffectingEntity oe
-    WHERE oe.operationId NOT IN (
-        SELECT co.operationId
-        FROM confirmedOperations co
-        WHERE co.userId = $1 AND co.deviceId = $2
-    )
-) AND (ae.entityId, ae.entityType) IN (
-    SELECT te.entityId, te.entityType
-    FROM trackedEntities te
-    WHERE te.userId = $1
-) AND o.isLarge = $3;`
-	isLarge := operationsType == operations.OperationTypeLarge
-	rows, err := c.db.Query(query, userId, deviceId, isLarge)
-	if err != nil {
-		return nil, fmt.Errorf("%s: failed to execute query: %w", op, err)
-	}
-	defer rows.Close()
-
-	payloadMap := make(map[operations.OperationId]rawPayload)
-	operationsMap := make(map[operations.OperationId]operations.Operation)
-
-	for rows.Next() {
-		var operation operations.Operation
-		var payload rawPayload
-
-		var entityID string
-		var entityType string
-		var searchHint sql.NullString
-
-		if err := rows.Scan(
-			&operation.OperationId,
-			&operation.CreatedAt,
-			&payload.data,
-			&searchHint,
-			&payload.payloadType,
-			&entityID,
-			&entityType,
-		); err != nil {
-			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
-		}
-
-		if searchHint.Valid {
-			payload.searchHint = &searchHint.String
-		}
-
-		payload.isLarge = isLarge
-
-		payload.trackedEntities = append(payload.trackedEntities, operations.TrackedEntity{
-			Id:   entityID,
-			Type: operations.EntityType(entityType),
-		})
-
-		if existingPayload, exists := payloadMap[operation.OperationId]; exists {
-			payload = existingPayload
-		}
-
-		operation.Payload = &payload
-		operationsMap[operation.OperationId] = operation
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("%s: error occurred during row iteration: %w", op, err)
-	}
-
-	result := make([]operations.Operation, 0, len(operationsMap))
-	for _, operation := range operationsMap {
-		result = append(result, operation)
-	}
-
-	c.logger.LogInfo("%s: success[user=%s device=%s]", op, userId, deviceId)
-
-	return result, nil
-}
-
 func (c *defaultRepository) Confirm(
 	operationIds []operations.OperationId,
 	user operations.UserId,
@@ -484,3 +405,41 @@ WHERE
 	c.logger.LogInfo("%s: success", op)
 	return results, nil
 }
+
+func (c *defaultRepository) GetEntities(userId operations.UserId) ([]operations.TrackedEntity, error) {
+	const op = "repositories.operations.defaultRepository.GetEntities"
+	c.logger.LogInfo("%s: start[user=%s]", op, userId)
+
+	query := `
+SELECT DISTINCT
+	te.entityId,
+	te.entityType
+FROM
+	trackedEntities te
+WHERE
+	te.userId = $1
+ORDER BY
+	te.entityType, te.entityId;`
+
+	rows, err := c.db.Query(query, string(userId))
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to execute query: %w", op, err)
+	}
+	defer rows.Close()
+
+	var results []operations.TrackedEntity
+	for rows.Next() {
+		var entity operations.TrackedEntity
+		if err := rows.Scan(&entity.Id, &entity.Type); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+		results = append(results, entity)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: error occurred during row iteration: %w", op, err)
+	}
+
+	c.logger.LogInfo("%s: success[user=%s]", op, userId)
+	return results, nil
+}
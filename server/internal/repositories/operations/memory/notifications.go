@@ -0,0 +1,135 @@
+package memoryRepository
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"verni/internal/repositories"
+	"verni/internal/repositories/operations"
+)
+
+func (c *memoryRepository) ClaimNotifications(now int64, until int64, limit int) ([]operations.Notification, error) {
+	const op = "repositories.operations.memoryRepository.ClaimNotifications"
+	c.logger.LogInfo("%s: start[now=%d until=%d limit=%d]", op, now, until, limit)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	due := c.notificationsWhere(func(notification operations.Notification) bool {
+		return notification.State == operations.NotificationStatePending && notification.NextAttemptAt <= now
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	for i := range due {
+		due[i].NextAttemptAt = until
+		c.notifications[due[i].Id] = cloneNotification(due[i])
+	}
+
+	c.logger.LogInfo("%s: success[claimed=%d]", op, len(due))
+	return due, nil
+}
+
+func (c *memoryRepository) ReplaceNotification(
+	notification operations.Notification,
+	with []operations.Notification,
+) repositories.UnitOfWork {
+	const op = "repositories.operations.memoryRepository.ReplaceNotification"
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			c.logger.LogInfo("%s: start[id=%s with=%d]", op, notification.Id, len(with))
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+			for _, replacement := range with {
+				if _, exists := c.notifications[replacement.Id]; exists {
+					return fmt.Errorf("%s: notification %s already exists", op, replacement.Id)
+				}
+			}
+			delete(c.notifications, notification.Id)
+			for _, replacement := range with {
+				c.notifications[replacement.Id] = cloneNotification(replacement)
+			}
+			c.logger.LogInfo("%s: success[id=%s]", op, notification.Id)
+			return nil
+		},
+		Rollback: func() error {
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+			for _, replacement := range with {
+				delete(c.notifications, replacement.Id)
+			}
+			c.notifications[notification.Id] = cloneNotification(notification)
+			return nil
+		},
+	}
+}
+
+func (c *memoryRepository) UpdateNotification(notification operations.Notification) repositories.UnitOfWork {
+	const op = "repositories.operations.memoryRepository.UpdateNotification"
+	var previous operations.Notification
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			c.logger.LogInfo("%s: start[id=%s state=%s attempts=%d]", op, notification.Id, notification.State, notification.Attempts)
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+			stored, exists := c.notifications[notification.Id]
+			if !exists {
+				return fmt.Errorf("%s: notification %s does not exist", op, notification.Id)
+			}
+			previous = stored
+			stored.State = notification.State
+			stored.Attempts = notification.Attempts
+			stored.NextAttemptAt = notification.NextAttemptAt
+			stored.LastError = notification.LastError
+			c.notifications[notification.Id] = stored
+			c.logger.LogInfo("%s: success[id=%s]", op, notification.Id)
+			return nil
+		},
+		Rollback: func() error {
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+			if _, exists := c.notifications[notification.Id]; exists {
+				c.notifications[notification.Id] = previous
+			}
+			return nil
+		},
+	}
+}
+
+func (c *memoryRepository) DeadNotifications(limit int) ([]operations.Notification, error) {
+	const op = "repositories.operations.memoryRepository.DeadNotifications"
+	c.logger.LogInfo("%s: start[limit=%d]", op, limit)
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	dead := c.notificationsWhere(func(notification operations.Notification) bool {
+		return notification.State == operations.NotificationStateDead
+	})
+	if len(dead) > limit {
+		dead = dead[:limit]
+	}
+
+	c.logger.LogInfo("%s: success[dead=%d]", op, len(dead))
+	return dead, nil
+}
+
+// notificationsWhere returns copies of matching notifications ordered by creation
+func (c *memoryRepository) notificationsWhere(matches func(operations.Notification) bool) []operations.Notification {
+	result := []operations.Notification{}
+	for _, notification := range c.notifications {
+		if matches(notification) {
+			result = append(result, cloneNotification(notification))
+		}
+	}
+	slices.SortFunc(result, func(a, b operations.Notification) int {
+		return cmp.Or(cmp.Compare(a.CreatedAt, b.CreatedAt), cmp.Compare(a.Id, b.Id))
+	})
+	return result
+}
+
+func cloneNotification(notification operations.Notification) operations.Notification {
+	notification.Payload = slices.Clone(notification.Payload)
+	return notification
+}
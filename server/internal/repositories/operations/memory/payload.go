@@ -0,0 +1,31 @@
+package memoryRepository
+
+import "verni/internal/repositories/operations"
+
+type payload struct {
+	payloadType     operations.OperationPayloadType
+	data            []byte
+	trackedEntities []operations.TrackedEntity
+	isLarge         bool
+	searchHint      *string
+}
+
+func (c *payload) Type() operations.OperationPayloadType {
+	return c.payloadType
+}
+
+func (c *payload) Data() ([]byte, error) {
+	return c.data, nil
+}
+
+func (c *payload) TrackedEntities() []operations.TrackedEntity {
+	return c.trackedEntities
+}
+
+func (c *payload) IsLarge() bool {
+	return c.isLarge
+}
+
+func (c *payload) SearchHint() *string {
+	return c.searchHint
+}
@@ -0,0 +1,766 @@
+package memoryRepository
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"verni/internal/repositories"
+	"verni/internal/repositories/operations"
+	"verni/internal/services/logging"
+)
+
+func New(logger logging.Service) operations.Repository {
+	return &memoryRepository{
+		operations:      map[operations.OperationId]operations.Operation{},
+		trackedEntities: map[trackedEntity]struct{}{},
+		confirmed:       map[confirmedOperation]struct{}{},
+		notifications:   map[operations.NotificationId]operations.Notification{},
+		logger:          logger,
+	}
+}
+
+type trackedEntity struct {
+	userId      operations.UserId
+	entity      operations.TrackedEntity
+	operationId operations.OperationId
+}
+
+type confirmedOperation struct {
+	userId      operations.UserId
+	deviceId    operations.DeviceId
+	operationId operations.OperationId
+}
+
+type memoryRepository struct {
+	// pushMutex serializes pushes while they are prepared and stored, reads of preparations take mutex themselves
+	pushMutex       sync.Mutex
+	mutex           sync.RWMutex
+	sequence        operations.SequenceNumber
+	order           []operations.OperationId
+	operations      map[operations.OperationId]operations.Operation
+	trackedEntities map[trackedEntity]struct{}
+	confirmed       map[confirmedOperation]struct{}
+	notifications   map[operations.NotificationId]operations.Notification
+	logger          logging.Service
+}
+
+func (c *memoryRepository) Push(
+	pushOperations []operations.PushOperation,
+	userId operations.UserId,
+	deviceId operations.DeviceId,
+	confirm bool,
+	notifications []operations.Notification,
+) repositories.UnitOfWork {
+	return c.PushPrepared(func() ([]operations.PushOperation, []operations.Notification, error) {
+		return pushOperations, notifications, nil
+	}, userId, deviceId, confirm)
+}
+
+func (c *memoryRepository) PushPrepared(
+	prepare operations.PreparePush,
+	userId operations.UserId,
+	deviceId operations.DeviceId,
+	confirm bool,
+) repositories.UnitOfWork {
+	const op = "repositories.operations.memoryRepository.PushPrepared"
+	// prepared operations and bindings removed by their unbind actions are restored on rollback
+	var prepared []operations.PushOperation
+	var notifications []operations.Notification
+	var unbound []trackedEntity
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			c.pushMutex.Lock()
+			defer c.pushMutex.Unlock()
+			var err error
+			prepared, notifications, err = prepare()
+			if err != nil {
+				return fmt.Errorf("%s: preparing push: %w", op, err)
+			}
+			unbound, err = c.push(prepared, userId, deviceId, confirm, notifications)
+			return err
+		},
+		Rollback: func() error {
+			return c.pushRollback(prepared, unbound, userId, deviceId, confirm, notifications)
+		},
+	}
+}
+
+// push returns bindings removed by unbind actions of the operations
+func (c *memoryRepository) push(
+	pushOperations []operations.PushOperation,
+	userId operations.UserId,
+	deviceId operations.DeviceId,
+	confirm bool,
+	notifications []operations.Notification,
+) ([]trackedEntity, error) {
+	const op = "repositories.operations.memoryRepository.push"
+	c.logger.LogInfo("%s: start[user=%s device=%s]", op, userId, deviceId)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	stored := make([]operations.Operation, 0, len(pushOperations))
+	pushed := map[operations.OperationId]struct{}{}
+	for _, operation := range pushOperations {
+		if _, exists := c.operations[operation.OperationId]; exists {
+			return nil, fmt.Errorf("%s: operation %s: %w", op, operation.OperationId, operations.ErrConflict)
+		}
+		if _, exists := pushed[operation.OperationId]; exists {
+			return nil, fmt.Errorf("%s: operation %s: %w", op, operation.OperationId, operations.ErrConflict)
+		}
+		pushed[operation.OperationId] = struct{}{}
+
+		data, err := operation.Payload.Data()
+		if err != nil {
+			return nil, fmt.Errorf("%s: getting data of operation %s: %w", op, operation.OperationId, err)
+		}
+		stored = append(stored, operations.Operation{
+			OperationId: operation.OperationId,
+			CreatedAt:   operation.CreatedAt,
+			AuthorId:    operation.AuthorId,
+			Payload: &payload{
+				payloadType:     operation.Payload.Type(),
+				data:            data,
+				trackedEntities: slices.Clone(operation.Payload.TrackedEntities()),
+				isLarge:         operation.Payload.IsLarge(),
+				searchHint:      operation.Payload.SearchHint(),
+			},
+		})
+	}
+
+	for _, notification := range notifications {
+		if _, exists := c.notifications[notification.Id]; exists {
+			return nil, fmt.Errorf("%s: notification %s already exists", op, notification.Id)
+		}
+	}
+
+	// projections go first, so nothing is stored if one of them fails
+	if err := performProjections(pushOperations); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	unbound := []trackedEntity{}
+	for i, operation := range pushOperations {
+		c.sequence++
+		stored[i].SequenceNumber = c.sequence
+		c.operations[operation.OperationId] = stored[i]
+		c.order = append(c.order, operation.OperationId)
+
+		for _, action := range operation.EntityBindActions {
+			for _, watcher := range action.Watchers {
+				c.trackedEntities[trackedEntity{
+					userId:      watcher,
+					entity:      action.Entity,
+					operationId: operation.OperationId,
+				}] = struct{}{}
+			}
+		}
+
+		for _, action := range operation.EntityUnbindActions {
+			for _, watcher := range action.Watchers {
+				for tracked := range c.trackedEntities {
+					if tracked.userId == watcher && tracked.entity == action.Entity {
+						delete(c.trackedEntities, tracked)
+						unbound = append(unbound, tracked)
+					}
+				}
+			}
+		}
+
+		if confirm {
+			c.confirmed[confirmedOperation{
+				userId:      userId,
+				deviceId:    deviceId,
+				operationId: operation.OperationId,
+			}] = struct{}{}
+		}
+	}
+	for _, notification := range notifications {
+		c.notifications[notification.Id] = cloneNotification(notification)
+	}
+
+	c.logger.LogInfo("%s: success[user=%s device=%s]", op, userId, deviceId)
+	return unbound, nil
+}
+
+// performProjections performs projections of the operations, performed ones are rolled back if one of them fails
+func performProjections(pushOperations []operations.PushOperation) error {
+	performed := []repositories.UnitOfWork{}
+	for _, operation := range pushOperations {
+		for _, projection := range operation.Projections {
+			if err := projection.Perform(); err != nil {
+				for index := len(performed) - 1; index >= 0; index-- {
+					performed[index].Rollback()
+				}
+				return fmt.Errorf("projecting operation %s: %w", operation.OperationId, err)
+			}
+			performed = append(performed, projection)
+		}
+	}
+	return nil
+}
+
+func rollbackProjections(pushOperations []operations.PushOperation) error {
+	for operationIndex := len(pushOperations) - 1; operationIndex >= 0; operationIndex-- {
+		operation := pushOperations[operationIndex]
+		for index := len(operation.Projections) - 1; index >= 0; index-- {
+			if err := operation.Projections[index].Rollback(); err != nil {
+				return fmt.Errorf("rolling back projection of operation %s: %w", operation.OperationId, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *memoryRepository) pushRollback(
+	pushOperations []operations.PushOperation,
+	unbound []trackedEntity,
+	userId operations.UserId,
+	deviceId operations.DeviceId,
+	confirm bool,
+	notifications []operations.Notification,
+) error {
+	const op = "repositories.operations.memoryRepository.pushRollback"
+	c.logger.LogInfo("%s: start[user=%s device=%s]", op, userId, deviceId)
+
+	if err := rollbackProjections(pushOperations); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	removed := map[operations.OperationId]struct{}{}
+	for _, operation := range pushOperations {
+		if confirm {
+			delete(c.confirmed, confirmedOperation{
+				userId:      userId,
+				deviceId:    deviceId,
+				operationId: operation.OperationId,
+			})
+		}
+
+		delete(c.operations, operation.OperationId)
+		removed[operation.OperationId] = struct{}{}
+
+		for _, action := range operation.EntityBindActions {
+			for _, watcher := range action.Watchers {
+				delete(c.trackedEntities, trackedEntity{
+					userId:      watcher,
+					entity:      action.Entity,
+					operationId: operation.OperationId,
+				})
+			}
+		}
+	}
+	c.order = slices.DeleteFunc(c.order, func(operationId operations.OperationId) bool {
+		_, found := removed[operationId]
+		return found
+	})
+	// bindings made by the rolled back operations themselves are gone for good
+	for _, tracked := range unbound {
+		if _, found := removed[tracked.operationId]; found {
+			continue
+		}
+		c.trackedEntities[tracked] = struct{}{}
+	}
+	for _, notification := range notifications {
+		delete(c.notifications, notification.Id)
+	}
+
+	c.logger.LogInfo("%s: success[user=%s device=%s]", op, userId, deviceId)
+	return nil
+}
+
+func (c *memoryRepository) Pull(
+	userId operations.UserId,
+	deviceId operations.DeviceId,
+	after operations.SequenceNumber,
+	limit int,
+	operationsType operations.OperationType,
+) ([]operations.Operation, error) {
+	const op = "repositories.operations.memoryRepository.Pull"
+	c.logger.LogInfo("%s: start[user=%s device=%s after=%d limit=%d]", op, userId, deviceId, after, limit)
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	tracked := c.trackedBy(userId)
+	isLarge := operationsType == operations.OperationTypeLarge
+	result := []operations.Operation{}
+	for _, operationId := range c.order {
+		if len(result) >= limit {
+			break
+		}
+		operation := c.operations[operationId]
+		if operation.SequenceNumber <= after || operation.Payload.IsLarge() != isLarge {
+			continue
+		}
+		if _, confirmed := c.confirmed[confirmedOperation{
+			userId:      userId,
+			deviceId:    deviceId,
+			operationId: operationId,
+		}]; confirmed {
+			continue
+		}
+		if !affectsAny(operation, tracked) {
+			continue
+		}
+		result = append(result, operation)
+	}
+
+	c.logger.LogInfo("%s: success[user=%s device=%s]", op, userId, deviceId)
+	return result, nil
+}
+
+func (c *memoryRepository) PullSince(
+	userId operations.UserId,
+	since operations.SequenceNumber,
+	limit int,
+	operationsType operations.OperationType,
+) ([]operations.Operation, error) {
+	const op = "repositories.operations.memoryRepository.PullSince"
+	c.logger.LogInfo("%s: start[user=%s since=%d limit=%d]", op, userId, since, limit)
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	bound := c.boundAt(userId)
+	isLarge := operationsType == operations.OperationTypeLarge
+	result := []operations.Operation{}
+	for _, operationId := range c.order {
+		operation := c.operations[operationId]
+		if operation.Payload.IsLarge() != isLarge {
+			continue
+		}
+		position, visible := pullPosition(operation, bound)
+		if !visible || position <= since {
+			continue
+		}
+		operation.SequenceNumber = position
+		result = append(result, operation)
+	}
+	slices.SortStableFunc(result, func(a, b operations.Operation) int {
+		return cmp.Compare(a.SequenceNumber, b.SequenceNumber)
+	})
+	if len(result) > limit {
+		end := limit
+		for end < len(result) && result[end].SequenceNumber == result[limit-1].SequenceNumber {
+			end++
+		}
+		result = result[:end]
+	}
+
+	c.logger.LogInfo("%s: success[user=%s since=%d count=%d]", op, userId, since, len(result))
+	return result, nil
+}
+
+func (c *memoryRepository) Log(
+	after operations.SequenceNumber,
+	limit int,
+	operationsType operations.OperationType,
+) ([]operations.Operation, error) {
+	const op = "repositories.operations.memoryRepository.Log"
+	c.logger.LogInfo("%s: start[after=%d limit=%d]", op, after, limit)
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	isLarge := operationsType == operations.OperationTypeLarge
+	result := []operations.Operation{}
+	for _, operationId := range c.order {
+		if len(result) >= limit {
+			break
+		}
+		operation := c.operations[operationId]
+		if operation.SequenceNumber <= after || operation.Payload.IsLarge() != isLarge {
+			continue
+		}
+		result = append(result, operation)
+	}
+
+	c.logger.LogInfo("%s: success[after=%d count=%d]", op, after, len(result))
+	return result, nil
+}
+
+// boundAt returns entities tracked by the user with the sequence number of the earliest operation binding them,
+// bindings made by operations that are no longer in the log are taken as made at the start of it
+func (c *memoryRepository) boundAt(userId operations.UserId) map[operations.TrackedEntity]operations.SequenceNumber {
+	bound := map[operations.TrackedEntity]operations.SequenceNumber{}
+	for tracked := range c.trackedEntities {
+		if tracked.userId != userId {
+			continue
+		}
+		var sequenceNumber operations.SequenceNumber
+		if operation, exists := c.operations[tracked.operationId]; exists {
+			sequenceNumber = operation.SequenceNumber
+		}
+		if current, exists := bound[tracked.entity]; !exists || sequenceNumber < current {
+			bound[tracked.entity] = sequenceNumber
+		}
+	}
+	return bound
+}
+
+// pullPosition is where the operation appears in cursor pulls of a user tracking `bound` entities. Operations of
+// an entity appear no earlier than the entity was bound to the user, so its history reaches cursors taken before.
+func pullPosition(operation operations.Operation, bound map[operations.TrackedEntity]operations.SequenceNumber) (operations.SequenceNumber, bool) {
+	var position operations.SequenceNumber
+	visible := false
+	for _, entity := range operation.Payload.TrackedEntities() {
+		boundAt, found := bound[entity]
+		if !found {
+			continue
+		}
+		candidate := max(operation.SequenceNumber, boundAt)
+		if !visible || candidate < position {
+			position = candidate
+		}
+		visible = true
+	}
+	return position, visible
+}
+
+func (c *memoryRepository) trackedBy(userId operations.UserId) map[operations.TrackedEntity]struct{} {
+	tracked := map[operations.TrackedEntity]struct{}{}
+	for entity := range c.trackedEntities {
+		if entity.userId == userId {
+			tracked[entity.entity] = struct{}{}
+		}
+	}
+	return tracked
+}
+
+func (c *memoryRepository) Confirm(
+	operationIds []operations.OperationId,
+	user operations.UserId,
+	device operations.DeviceId,
+) repositories.UnitOfWork {
+	const op = "repositories.operations.memoryRepository.Confirm"
+
+	c.mutex.RLock()
+	var toConfirm []operations.OperationId
+	for _, operationId := range operationIds {
+		if _, found := c.confirmed[confirmedOperation{
+			userId:      user,
+			deviceId:    device,
+			operationId: operationId,
+		}]; !found && !slices.Contains(toConfirm, operationId) {
+			toConfirm = append(toConfirm, operationId)
+		}
+	}
+	c.mutex.RUnlock()
+
+	if len(toConfirm) == 0 {
+		c.logger.LogInfo("%s: nothing to confirm, early return", op)
+		return repositories.UnitOfWork{
+			Perform:  func() error { return nil },
+			Rollback: func() error { return nil },
+		}
+	}
+
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			c.markConfirmed(toConfirm, true, user, device)
+			return nil
+		},
+		Rollback: func() error {
+			c.markConfirmed(toConfirm, false, user, device)
+			return nil
+		},
+	}
+}
+
+func (c *memoryRepository) markConfirmed(
+	operationIds []operations.OperationId,
+	confirmed bool,
+	userId operations.UserId,
+	deviceId operations.DeviceId,
+) {
+	const op = "repositories.operations.memoryRepository.markConfirmed"
+	c.logger.LogInfo("%s: start[user=%s device=%s confirmed=%t]", op, userId, deviceId, confirmed)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, operationId := range operationIds {
+		key := confirmedOperation{
+			userId:      userId,
+			deviceId:    deviceId,
+			operationId: operationId,
+		}
+		if confirmed {
+			c.confirmed[key] = struct{}{}
+		} else {
+			delete(c.confirmed, key)
+		}
+	}
+
+	c.logger.LogInfo("%s: success[user=%s device=%s confirmed=%t]", op, userId, deviceId, confirmed)
+}
+
+func (c *memoryRepository) Remove(operationIds []operations.OperationId) repositories.UnitOfWork {
+	// removed operations and confirmations are restored on rollback
+	var removed []operations.Operation
+	var unconfirmed []confirmedOperation
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			removed, unconfirmed = c.remove(operationIds)
+			return nil
+		},
+		Rollback: func() error {
+			c.restore(removed, unconfirmed)
+			return nil
+		},
+	}
+}
+
+func (c *memoryRepository) remove(operationIds []operations.OperationId) ([]operations.Operation, []confirmedOperation) {
+	const op = "repositories.operations.memoryRepository.remove"
+	c.logger.LogInfo("%s: start[count=%d]", op, len(operationIds))
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	toRemove := map[operations.OperationId]struct{}{}
+	removed := []operations.Operation{}
+	for _, operationId := range operationIds {
+		if operation, exists := c.operations[operationId]; exists {
+			toRemove[operationId] = struct{}{}
+			removed = append(removed, operation)
+			delete(c.operations, operationId)
+		}
+	}
+	c.order = slices.DeleteFunc(c.order, func(operationId operations.OperationId) bool {
+		_, found := toRemove[operationId]
+		return found
+	})
+	unconfirmed := []confirmedOperation{}
+	for confirmed := range c.confirmed {
+		if _, found := toRemove[confirmed.operationId]; found {
+			unconfirmed = append(unconfirmed, confirmed)
+			delete(c.confirmed, confirmed)
+		}
+	}
+
+	c.logger.LogInfo("%s: success[removed=%d]", op, len(removed))
+	return removed, unconfirmed
+}
+
+func (c *memoryRepository) restore(removed []operations.Operation, unconfirmed []confirmedOperation) {
+	const op = "repositories.operations.memoryRepository.restore"
+	c.logger.LogInfo("%s: start[count=%d]", op, len(removed))
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, operation := range removed {
+		c.operations[operation.OperationId] = operation
+		c.order = append(c.order, operation.OperationId)
+	}
+	slices.SortFunc(c.order, func(a, b operations.OperationId) int {
+		return cmp.Compare(c.operations[a].SequenceNumber, c.operations[b].SequenceNumber)
+	})
+	for _, confirmed := range unconfirmed {
+		c.confirmed[confirmed] = struct{}{}
+	}
+
+	c.logger.LogInfo("%s: success[count=%d]", op, len(removed))
+}
+
+func (c *memoryRepository) GetConfirmations(operationIds []operations.OperationId) ([]operations.Confirmation, error) {
+	const op = "repositories.operations.memoryRepository.GetConfirmations"
+	c.logger.LogInfo("%s: start[count=%d]", op, len(operationIds))
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	result := []operations.Confirmation{}
+	for confirmed := range c.confirmed {
+		if !slices.Contains(operationIds, confirmed.operationId) {
+			continue
+		}
+		result = append(result, operations.Confirmation{
+			OperationId: confirmed.operationId,
+			UserId:      confirmed.userId,
+			DeviceId:    confirmed.deviceId,
+		})
+	}
+
+	c.logger.LogInfo("%s: success[count=%d]", op, len(result))
+	return result, nil
+}
+
+func (c *memoryRepository) CountConfirmations(userId operations.UserId, deviceId operations.DeviceId) (int, error) {
+	const op = "repositories.operations.memoryRepository.CountConfirmations"
+	c.logger.LogInfo("%s: start[user=%s device=%s]", op, userId, deviceId)
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	count := 0
+	for confirmed := range c.confirmed {
+		if confirmed.userId == userId && confirmed.deviceId == deviceId {
+			count++
+		}
+	}
+
+	c.logger.LogInfo("%s: success[count=%d]", op, count)
+	return count, nil
+}
+
+func (c *memoryRepository) RemoveConfirmations(userId operations.UserId, deviceId operations.DeviceId) repositories.UnitOfWork {
+	var unconfirmed []confirmedOperation
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			unconfirmed = c.removeConfirmations(userId, deviceId)
+			return nil
+		},
+		Rollback: func() error {
+			c.restore(nil, unconfirmed)
+			return nil
+		},
+	}
+}
+
+func (c *memoryRepository) removeConfirmations(userId operations.UserId, deviceId operations.DeviceId) []confirmedOperation {
+	const op = "repositories.operations.memoryRepository.removeConfirmations"
+	c.logger.LogInfo("%s: start[user=%s device=%s]", op, userId, deviceId)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	unconfirmed := []confirmedOperation{}
+	for confirmed := range c.confirmed {
+		if confirmed.userId == userId && confirmed.deviceId == deviceId {
+			unconfirmed = append(unconfirmed, confirmed)
+			delete(c.confirmed, confirmed)
+		}
+	}
+
+	c.logger.LogInfo("%s: success[removed=%d]", op, len(unconfirmed))
+	return unconfirmed
+}
+
+func (c *memoryRepository) Get(affectingEntities []operations.TrackedEntity) ([]operations.Operation, error) {
+	const op = "repositories.operations.memoryRepository.Get"
+	c.logger.LogInfo("%s: start", op)
+
+	if len(affectingEntities) == 0 {
+		c.logger.LogInfo("%s: no entities provided, early return", op)
+		return nil, nil
+	}
+
+	entities := map[operations.TrackedEntity]struct{}{}
+	for _, entity := range affectingEntities {
+		entities[entity] = struct{}{}
+	}
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	result := []operations.Operation{}
+	for _, operationId := range c.order {
+		operation := c.operations[operationId]
+		if affectsAny(operation, entities) {
+			result = append(result, operation)
+		}
+	}
+
+	c.logger.LogInfo("%s: success", op)
+	return result, nil
+}
+
+func (c *memoryRepository) Search(payloadType operations.OperationPayloadType, hint string) ([]operations.Operation, error) {
+	const op = "repositories.operations.memoryRepository.Search"
+	c.logger.LogInfo("%s: start[type=%s hint=%s]", op, payloadType, hint)
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	result := []operations.Operation{}
+	for _, operationId := range c.order {
+		operation := c.operations[operationId]
+		if operation.Payload.Type() != payloadType {
+			continue
+		}
+		searchHint := operation.Payload.SearchHint()
+		if searchHint == nil || !strings.Contains(*searchHint, hint) {
+			continue
+		}
+		result = append(result, operation)
+	}
+
+	c.logger.LogInfo("%s: success[type=%s hint=%s]", op, payloadType, hint)
+	return result, nil
+}
+
+func (c *memoryRepository) GetUsers(trackingEntities []operations.TrackedEntity) ([]operations.UserId, error) {
+	const op = "repositories.operations.memoryRepository.GetUsers"
+	c.logger.LogInfo("%s: start", op)
+
+	if len(trackingEntities) == 0 {
+		c.logger.LogInfo("%s: no entities provided, early return", op)
+		return nil, nil
+	}
+
+	entities := map[operations.TrackedEntity]struct{}{}
+	for _, entity := range trackingEntities {
+		entities[entity] = struct{}{}
+	}
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	users := map[operations.UserId]struct{}{}
+	for tracked := range c.trackedEntities {
+		if _, found := entities[tracked.entity]; found {
+			users[tracked.userId] = struct{}{}
+		}
+	}
+	var results []operations.UserId
+	for user := range users {
+		results = append(results, user)
+	}
+	slices.Sort(results)
+
+	c.logger.LogInfo("%s: success", op)
+	return results, nil
+}
+
+func (c *memoryRepository) GetEntities(userId operations.UserId) ([]operations.TrackedEntity, error) {
+	const op = "repositories.operations.memoryRepository.GetEntities"
+	c.logger.LogInfo("%s: start[user=%s]", op, userId)
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	entities := map[operations.TrackedEntity]struct{}{}
+	for tracked := range c.trackedEntities {
+		if tracked.userId == userId {
+			entities[tracked.entity] = struct{}{}
+		}
+	}
+	var results []operations.TrackedEntity
+	for entity := range entities {
+		results = append(results, entity)
+	}
+	slices.SortFunc(results, func(a, b operations.TrackedEntity) int {
+		return cmp.Or(cmp.Compare(a.Type, b.Type), cmp.Compare(a.Id, b.Id))
+	})
+
+	c.logger.LogInfo("%s: success[user=%s]", op, userId)
+	return results, nil
+}
+
+func affectsAny(operation operations.Operation, entities map[operations.TrackedEntity]struct{}) bool {
+	for _, entity := range operation.Payload.TrackedEntities() {
+		if _, found := entities[entity]; found {
+			return true
+		}
+	}
+	return false
+}
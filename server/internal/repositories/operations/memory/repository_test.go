@@ -0,0 +1,797 @@
+package memoryRepository_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"verni/internal/repositories"
+	"verni/internal/repositories/operations"
+	memoryRepository "verni/internal/repositories/operations/memory"
+	standartOutputLoggingService "verni/internal/services/logging/standartOutput"
+)
+
+// Helper function to create a test operation
+func createTestOperation(id string) operations.PushOperation {
+	return operations.PushOperation{
+		Operation: operations.Operation{
+			OperationId: operations.OperationId(id),
+			CreatedAt:   time.Now().Unix(),
+			AuthorId:    operations.UserId("test-author"),
+			Payload: &testPayload{
+				data:       []byte(`{"test":"data"}`),
+				entityType: operations.OperationPayloadType(operations.EntityTypeUser),
+				entities: []operations.TrackedEntity{
+					{Id: "test-entity", Type: operations.EntityTypeUser},
+				},
+			},
+		},
+		EntityBindActions: []operations.EntityBindAction{
+			{
+				Entity:   operations.TrackedEntity{Id: "test-entity", Type: operations.EntityTypeUser},
+				Watchers: []operations.UserId{"test-watcher"},
+			},
+		},
+	}
+}
+
+// Test payload implementation
+type testPayload struct {
+	data       []byte
+	entityType operations.OperationPayloadType
+	entities   []operations.TrackedEntity
+	isLarge    bool
+}
+
+func (p *testPayload) Type() operations.OperationPayloadType {
+	return p.entityType
+}
+
+func (p *testPayload) Data() ([]byte, error) {
+	return p.data, nil
+}
+
+func (p *testPayload) TrackedEntities() []operations.TrackedEntity {
+	return p.entities
+}
+
+func (p *testPayload) IsLarge() bool {
+	return p.isLarge
+}
+
+func (p *testPayload) SearchHint() *string {
+	hint := "test-hint"
+	return &hint
+}
+
+func TestRepository_Push(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	repo := memoryRepository.New(logger)
+
+	t.Run("successful push and rollback", func(t *testing.T) {
+		// Arrange
+		userId := operations.UserId("test-user")
+		deviceId := operations.DeviceId("test-device")
+		operation := createTestOperation("test-op-1")
+
+		// Act
+		work := repo.Push([]operations.PushOperation{operation}, userId, deviceId, true, nil)
+		err := work.Perform()
+
+		// Assert
+		assert.NoError(t, err)
+
+		// Verify operation exists
+		ops, err := repo.Get(operation.Payload.TrackedEntities())
+		assert.NoError(t, err)
+		assert.Len(t, ops, 1)
+		assert.Equal(t, operation.OperationId, ops[0].OperationId)
+
+		err = work.Rollback()
+		require.NoError(t, err)
+
+		// Assert - operation should not exist after rollback
+		ops, err = repo.Get(operation.Payload.TrackedEntities())
+		assert.NoError(t, err)
+		assert.Empty(t, ops)
+	})
+
+	t.Run("failed projection fails the push", func(t *testing.T) {
+		// Arrange
+		expectedErr := errors.New("projection error")
+		projected := 0
+		operation := createTestOperation("test-op-projected")
+		operation.Projections = []repositories.UnitOfWork{{
+			Perform: func() error {
+				projected++
+				return nil
+			},
+			Rollback: func() error {
+				projected--
+				return nil
+			},
+		}, {
+			Perform:  func() error { return expectedErr },
+			Rollback: func() error { return nil },
+		}}
+
+		// Act
+		err := repo.Push([]operations.PushOperation{operation}, "test-user", "test-device", true, nil).Perform()
+
+		// Assert
+		assert.ErrorIs(t, err, expectedErr)
+		assert.Equal(t, 0, projected)
+		ops, err := repo.Get(operation.Payload.TrackedEntities())
+		assert.NoError(t, err)
+		assert.Empty(t, ops)
+	})
+}
+
+func TestRepository_PushPrepared(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	repo := memoryRepository.New(logger)
+
+	t.Run("prepared operations are stored and rolled back", func(t *testing.T) {
+		// Arrange
+		operation := createTestOperation("test-op-prepared")
+		notification := operations.Notification{
+			Id:      "test-notification-prepared",
+			Payload: []byte(`{}`),
+			State:   operations.NotificationStatePending,
+		}
+
+		// Act
+		work := repo.PushPrepared(func() ([]operations.PushOperation, []operations.Notification, error) {
+			return []operations.PushOperation{operation}, []operations.Notification{notification}, nil
+		}, "test-user", "test-device", true)
+		err := work.Perform()
+
+		// Assert
+		require.NoError(t, err)
+		ops, err := repo.Get(operation.Payload.TrackedEntities())
+		assert.NoError(t, err)
+		assert.Len(t, ops, 1)
+		require.NoError(t, work.Rollback())
+		ops, err = repo.Get(operation.Payload.TrackedEntities())
+		assert.NoError(t, err)
+		assert.Empty(t, ops)
+	})
+
+	t.Run("nothing is stored if preparation fails", func(t *testing.T) {
+		// Arrange
+		expectedErr := errors.New("check error")
+
+		// Act
+		err := repo.PushPrepared(func() ([]operations.PushOperation, []operations.Notification, error) {
+			return nil, nil, expectedErr
+		}, "test-user", "test-device", true).Perform()
+
+		// Assert
+		assert.ErrorIs(t, err, expectedErr)
+		ops, err := repo.Log(0, 10, operations.OperationTypeRegular)
+		assert.NoError(t, err)
+		assert.Empty(t, ops)
+	})
+
+	t.Run("pushes wait for a push being prepared", func(t *testing.T) {
+		// Arrange
+		preparing := make(chan struct{})
+		release := make(chan struct{})
+		prepared := make(chan error)
+		go func() {
+			prepared <- repo.PushPrepared(func() ([]operations.PushOperation, []operations.Notification, error) {
+				close(preparing)
+				<-release
+				return []operations.PushOperation{createTestOperation("test-op-first")}, nil, nil
+			}, "test-user", "test-device", true).Perform()
+		}()
+		<-preparing
+
+		// Act
+		pushed := make(chan error)
+		go func() {
+			pushed <- repo.Push([]operations.PushOperation{createTestOperation("test-op-second")}, "test-user", "test-device", true, nil).Perform()
+		}()
+
+		// Assert
+		select {
+		case <-pushed:
+			t.Fatal("push did not wait for the push being prepared")
+		case <-time.After(50 * time.Millisecond):
+		}
+		close(release)
+		require.NoError(t, <-prepared)
+		require.NoError(t, <-pushed)
+		ops, err := repo.Log(0, 10, operations.OperationTypeRegular)
+		require.NoError(t, err)
+		require.Len(t, ops, 2)
+		assert.Equal(t, operations.OperationId("test-op-first"), ops[0].OperationId)
+		assert.Equal(t, operations.OperationId("test-op-second"), ops[1].OperationId)
+	})
+}
+
+func TestRepository_PullWithWatcher(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	repo := memoryRepository.New(logger)
+
+	t.Run("pull operations with watcher", func(t *testing.T) {
+		// Arrange
+		userId := operations.UserId("test-user")
+		deviceId := operations.DeviceId("test-device")
+		operation := createTestOperation("test-op-3")
+		operation.EntityBindActions = append(operation.EntityBindActions, operations.EntityBindAction{
+			Entity:   operations.TrackedEntity{Id: "test-entity", Type: operations.EntityTypeUser},
+			Watchers: []operations.UserId{userId},
+		})
+
+		// Push operation first
+		work := repo.Push([]operations.PushOperation{operation}, userId, deviceId, false, nil)
+		err := work.Perform()
+		require.NoError(t, err)
+
+		// Act
+		ops, err := repo.Pull(userId, deviceId, 0, 100, operations.OperationTypeRegular)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, ops, 1)
+		assert.Equal(t, operation.OperationId, ops[0].OperationId)
+	})
+}
+
+func TestRepository_PullWithoutWatcher(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	repo := memoryRepository.New(logger)
+
+	t.Run("pull operations without watcher", func(t *testing.T) {
+		// Arrange
+		userId := operations.UserId("test-user")
+		deviceId := operations.DeviceId("test-device")
+		operation := createTestOperation("test-op-3")
+
+		// Push operation first
+		work := repo.Push([]operations.PushOperation{operation}, userId, deviceId, false, nil)
+		err := work.Perform()
+		require.NoError(t, err)
+
+		// Act
+		ops, err := repo.Pull(userId, deviceId, 0, 100, operations.OperationTypeRegular)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Empty(t, ops)
+	})
+}
+
+func TestRepository_PullPages(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	repo := memoryRepository.New(logger)
+
+	t.Run("pull unconfirmed operations page by page", func(t *testing.T) {
+		// Arrange
+		userId := operations.UserId("test-paged-user")
+		deviceId := operations.DeviceId("test-device")
+		var pushed []operations.PushOperation
+		for _, id := range []string{"test-op-10", "test-op-11", "test-op-12"} {
+			operation := createTestOperation(id)
+			operation.EntityBindActions = append(operation.EntityBindActions, operations.EntityBindAction{
+				Entity:   operations.TrackedEntity{Id: "test-entity", Type: operations.EntityTypeUser},
+				Watchers: []operations.UserId{userId},
+			})
+			pushed = append(pushed, operation)
+		}
+		work := repo.Push(pushed, userId, deviceId, false, nil)
+		err := work.Perform()
+		require.NoError(t, err)
+
+		// Act
+		firstPage, err := repo.Pull(userId, deviceId, 0, 2, operations.OperationTypeRegular)
+		require.NoError(t, err)
+		require.Len(t, firstPage, 2)
+		secondPage, err := repo.Pull(userId, deviceId, firstPage[1].SequenceNumber, 2, operations.OperationTypeRegular)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, secondPage, 1)
+		assert.Equal(t, pushed[0].OperationId, firstPage[0].OperationId)
+		assert.Equal(t, pushed[1].OperationId, firstPage[1].OperationId)
+		assert.Equal(t, pushed[2].OperationId, secondPage[0].OperationId)
+	})
+}
+
+func TestRepository_PullSince(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	repo := memoryRepository.New(logger)
+
+	t.Run("pull pages in sequence order ignoring confirmations", func(t *testing.T) {
+		// Arrange
+		userId := operations.UserId("test-user")
+		deviceId := operations.DeviceId("test-device")
+		var pushed []operations.PushOperation
+		for _, id := range []string{"test-op-7", "test-op-8", "test-op-9"} {
+			operation := createTestOperation(id)
+			operation.EntityBindActions = append(operation.EntityBindActions, operations.EntityBindAction{
+				Entity:   operations.TrackedEntity{Id: "test-entity", Type: operations.EntityTypeUser},
+				Watchers: []operations.UserId{userId},
+			})
+			pushed = append(pushed, operation)
+		}
+		work := repo.Push(pushed, userId, deviceId, true, nil)
+		err := work.Perform()
+		require.NoError(t, err)
+
+		// Act
+		firstPage, err := repo.PullSince(userId, 0, 2, operations.OperationTypeRegular)
+		require.NoError(t, err)
+		require.Len(t, firstPage, 2)
+		secondPage, err := repo.PullSince(userId, firstPage[1].SequenceNumber, 2, operations.OperationTypeRegular)
+		require.NoError(t, err)
+		require.Len(t, secondPage, 1)
+		lastPage, err := repo.PullSince(userId, secondPage[0].SequenceNumber, 2, operations.OperationTypeRegular)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Empty(t, lastPage)
+		assert.Equal(t, pushed[0].OperationId, firstPage[0].OperationId)
+		assert.Equal(t, pushed[1].OperationId, firstPage[1].OperationId)
+		assert.Equal(t, pushed[2].OperationId, secondPage[0].OperationId)
+		assert.Less(t, firstPage[0].SequenceNumber, firstPage[1].SequenceNumber)
+		assert.Less(t, firstPage[1].SequenceNumber, secondPage[0].SequenceNumber)
+		assert.Equal(t, pushed[0].Payload.TrackedEntities(), firstPage[0].Payload.TrackedEntities())
+	})
+
+	t.Run("operations of untracked entities are not pulled", func(t *testing.T) {
+		// Act
+		ops, err := repo.PullSince("another-user", 0, 10, operations.OperationTypeRegular)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Empty(t, ops)
+	})
+
+	t.Run("history of an entity bound after the cursor is pulled with the binding", func(t *testing.T) {
+		// Arrange
+		userId := operations.UserId("late-user")
+		group := operations.TrackedEntity{Id: "late-group", Type: operations.EntityTypeSpendingGroup}
+		operation := func(id string, entity operations.TrackedEntity, watcher operations.UserId) operations.PushOperation {
+			operation := createTestOperation(id)
+			operation.Payload = &testPayload{
+				data:       []byte(`{"test":"data"}`),
+				entityType: operations.OperationPayloadType(entity.Type),
+				entities:   []operations.TrackedEntity{entity},
+			}
+			operation.EntityBindActions = []operations.EntityBindAction{{Entity: entity, Watchers: []operations.UserId{watcher}}}
+			return operation
+		}
+		history := []operations.PushOperation{
+			operation("late-history-1", group, "late-author"),
+			operation("late-history-2", group, "late-author"),
+		}
+		require.NoError(t, repo.Push(history, "late-author", "device", false, nil).Perform())
+		own := operation("late-own", operations.TrackedEntity{Id: string(userId), Type: operations.EntityTypeUser}, userId)
+		require.NoError(t, repo.Push([]operations.PushOperation{own}, userId, "device", false, nil).Perform())
+		upToDate, err := repo.PullSince(userId, 0, 10, operations.OperationTypeRegular)
+		require.NoError(t, err)
+		require.Len(t, upToDate, 1)
+		binding := operation("late-binding", group, userId)
+		require.NoError(t, repo.Push([]operations.PushOperation{binding}, "late-author", "device", false, nil).Perform())
+
+		// Act
+		page, err := repo.PullSince(userId, upToDate[0].SequenceNumber, 1, operations.OperationTypeRegular)
+
+		// Assert
+		assert.NoError(t, err)
+		require.Len(t, page, 3)
+		assert.Equal(t, history[0].OperationId, page[0].OperationId)
+		assert.Equal(t, history[1].OperationId, page[1].OperationId)
+		assert.Equal(t, binding.OperationId, page[2].OperationId)
+		assert.Greater(t, page[2].SequenceNumber, upToDate[0].SequenceNumber)
+		assert.Equal(t, page[2].SequenceNumber, page[0].SequenceNumber)
+		assert.Equal(t, page[2].SequenceNumber, page[1].SequenceNumber)
+		next, err := repo.PullSince(userId, page[2].SequenceNumber, 1, operations.OperationTypeRegular)
+		assert.NoError(t, err)
+		assert.Empty(t, next)
+	})
+}
+
+func TestRepository_Confirm(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	repo := memoryRepository.New(logger)
+
+	t.Run("confirm operations", func(t *testing.T) {
+		// Arrange
+		userId := operations.UserId("test-user")
+		deviceId := operations.DeviceId("test-device")
+		operation := createTestOperation("test-op-4")
+		operation.EntityBindActions = append(operation.EntityBindActions, operations.EntityBindAction{
+			Entity:   operations.TrackedEntity{Id: "test-entity", Type: operations.EntityTypeUser},
+			Watchers: []operations.UserId{userId},
+		})
+
+		// Push operation first
+		work := repo.Push([]operations.PushOperation{operation}, userId, deviceId, false, nil)
+		err := work.Perform()
+		require.NoError(t, err)
+
+		// Verify operation is not pulled before confirmation
+		ops, err := repo.Pull(userId, deviceId, 0, 100, operations.OperationTypeRegular)
+		assert.NoError(t, err)
+		assert.Len(t, ops, 1)
+
+		// Act - Confirm operation
+		work = repo.Confirm([]operations.OperationId{operation.OperationId}, userId, deviceId)
+		err = work.Perform()
+
+		// Assert
+		assert.NoError(t, err)
+
+		// Verify operation is not pulled after confirmation
+		ops, err = repo.Pull(userId, deviceId, 0, 100, operations.OperationTypeRegular)
+		assert.NoError(t, err)
+		assert.Empty(t, ops)
+	})
+}
+
+func TestRepository_Search(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	repo := memoryRepository.New(logger)
+
+	t.Run("search operations", func(t *testing.T) {
+		// Arrange
+		userId := operations.UserId("test-user")
+		deviceId := operations.DeviceId("test-device")
+		operation := createTestOperation("test-op-5")
+
+		// Push operation first
+		work := repo.Push([]operations.PushOperation{operation}, userId, deviceId, false, nil)
+		err := work.Perform()
+		require.NoError(t, err)
+
+		// Act
+		ops, err := repo.Search(operation.Payload.Type(), "test")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, ops, 1)
+		assert.Equal(t, operation.OperationId, ops[0].OperationId)
+	})
+}
+
+func TestRepository_GetUsers(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	repo := memoryRepository.New(logger)
+
+	t.Run("get users tracking entities", func(t *testing.T) {
+		// Arrange
+		userId := operations.UserId("test-user")
+		deviceId := operations.DeviceId("test-device")
+		operation := createTestOperation("test-op-6")
+
+		// Push operation first
+		work := repo.Push([]operations.PushOperation{operation}, userId, deviceId, false, nil)
+		err := work.Perform()
+		require.NoError(t, err)
+
+		// Act
+		users, err := repo.GetUsers(operation.Payload.TrackedEntities())
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Contains(t, users, operations.UserId("test-watcher"))
+	})
+}
+
+func TestRepository_GetEntities(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	repo := memoryRepository.New(logger)
+
+	t.Run("get entities tracked by user", func(t *testing.T) {
+		// Arrange
+		userId := operations.UserId("test-user")
+		deviceId := operations.DeviceId("test-device")
+		operation := createTestOperation("test-op-entities")
+
+		// Push operation first
+		work := repo.Push([]operations.PushOperation{operation}, userId, deviceId, false, nil)
+		err := work.Perform()
+		require.NoError(t, err)
+
+		// Act
+		entities, err := repo.GetEntities("test-watcher")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, operation.Payload.TrackedEntities(), entities)
+	})
+}
+
+func TestRepository_PushUnbind(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	repo := memoryRepository.New(logger)
+
+	t.Run("unbound watcher stops pulling and rollback restores it", func(t *testing.T) {
+		// Arrange
+		userId := operations.UserId("test-watcher")
+		deviceId := operations.DeviceId("test-device")
+		bind := createTestOperation("test-op-bind")
+		require.NoError(t, repo.Push([]operations.PushOperation{bind}, "test-author", deviceId, false, nil).Perform())
+		unbind := createTestOperation("test-op-unbind")
+		unbind.EntityUnbindActions = unbind.EntityBindActions
+		unbind.EntityBindActions = nil
+
+		// Act
+		work := repo.Push([]operations.PushOperation{unbind}, "test-author", deviceId, false, nil)
+		err := work.Perform()
+
+		// Assert
+		require.NoError(t, err)
+		users, err := repo.GetUsers(bind.Payload.TrackedEntities())
+		assert.NoError(t, err)
+		assert.NotContains(t, users, userId)
+		ops, err := repo.Pull(userId, deviceId, 0, 100, operations.OperationTypeRegular)
+		assert.NoError(t, err)
+		assert.Empty(t, ops)
+
+		require.NoError(t, work.Rollback())
+		ops, err = repo.Pull(userId, deviceId, 0, 100, operations.OperationTypeRegular)
+		assert.NoError(t, err)
+		assert.Len(t, ops, 1)
+		assert.Equal(t, bind.OperationId, ops[0].OperationId)
+	})
+}
+
+func TestRepository_Remove(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	repo := memoryRepository.New(logger)
+
+	t.Run("removed operation is gone with its confirmations and rollback restores it", func(t *testing.T) {
+		// Arrange
+		userId := operations.UserId("test-watcher")
+		deviceId := operations.DeviceId("test-device")
+		otherDeviceId := operations.DeviceId("test-other-device")
+		kept := createTestOperation("test-op-kept")
+		removed := createTestOperation("test-op-removed")
+		require.NoError(t, repo.Push([]operations.PushOperation{kept, removed}, userId, otherDeviceId, true, nil).Perform())
+		before, err := repo.Pull(userId, deviceId, 0, 100, operations.OperationTypeRegular)
+		require.NoError(t, err)
+		require.Len(t, before, 2)
+
+		// Act
+		work := repo.Remove([]operations.OperationId{removed.OperationId})
+		err = work.Perform()
+
+		// Assert
+		require.NoError(t, err)
+		ops, err := repo.Pull(userId, deviceId, 0, 100, operations.OperationTypeRegular)
+		assert.NoError(t, err)
+		assert.Len(t, ops, 1)
+		assert.Equal(t, kept.OperationId, ops[0].OperationId)
+		affecting, err := repo.Get(removed.Payload.TrackedEntities())
+		assert.NoError(t, err)
+		assert.Len(t, affecting, 1)
+
+		require.NoError(t, work.Rollback())
+		ops, err = repo.Pull(userId, deviceId, 0, 100, operations.OperationTypeRegular)
+		assert.NoError(t, err)
+		assert.Len(t, ops, 2)
+		assert.Equal(t, before[1].OperationId, ops[1].OperationId)
+		assert.Equal(t, before[1].SequenceNumber, ops[1].SequenceNumber)
+		ops, err = repo.Pull(userId, otherDeviceId, 0, 100, operations.OperationTypeRegular)
+		assert.NoError(t, err)
+		assert.Empty(t, ops)
+	})
+}
+
+func TestRepository_RemoveConfirmations(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	repo := memoryRepository.New(logger)
+
+	t.Run("confirmations of the device are removed and rollback restores them", func(t *testing.T) {
+		// Arrange
+		userId := operations.UserId("test-watcher")
+		deviceId := operations.DeviceId("test-forgotten-device")
+		otherDeviceId := operations.DeviceId("test-kept-device")
+		pushed := []operations.PushOperation{
+			createTestOperation("test-op-forgotten-1"),
+			createTestOperation("test-op-forgotten-2"),
+		}
+		require.NoError(t, repo.Push(pushed, userId, deviceId, true, nil).Perform())
+		require.NoError(t, repo.Confirm([]operations.OperationId{pushed[0].OperationId}, userId, otherDeviceId).Perform())
+
+		// Act
+		work := repo.RemoveConfirmations(userId, deviceId)
+		err := work.Perform()
+
+		// Assert
+		require.NoError(t, err)
+		count, err := repo.CountConfirmations(userId, deviceId)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, count)
+		count, err = repo.CountConfirmations(userId, otherDeviceId)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+		ops, err := repo.Pull(userId, deviceId, 0, 100, operations.OperationTypeRegular)
+		assert.NoError(t, err)
+		assert.Len(t, ops, 2)
+
+		require.NoError(t, work.Rollback())
+		count, err = repo.CountConfirmations(userId, deviceId)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+	})
+}
+
+func TestRepository_GetConfirmations(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	repo := memoryRepository.New(logger)
+
+	t.Run("confirmations of the operations by every device", func(t *testing.T) {
+		// Arrange
+		userId := operations.UserId("test-confirming-user")
+		phone := operations.DeviceId("test-confirming-phone")
+		laptop := operations.DeviceId("test-confirming-laptop")
+		pushed := []operations.PushOperation{
+			createTestOperation("test-op-confirmed-1"),
+			createTestOperation("test-op-confirmed-2"),
+			createTestOperation("test-op-confirmed-3"),
+		}
+		require.NoError(t, repo.Push(pushed, userId, phone, true, nil).Perform())
+		require.NoError(t, repo.Confirm([]operations.OperationId{pushed[0].OperationId}, userId, laptop).Perform())
+
+		// Act
+		confirmations, err := repo.GetConfirmations([]operations.OperationId{pushed[0].OperationId, pushed[1].OperationId})
+
+		// Assert
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []operations.Confirmation{
+			{OperationId: pushed[0].OperationId, UserId: userId, DeviceId: phone},
+			{OperationId: pushed[0].OperationId, UserId: userId, DeviceId: laptop},
+			{OperationId: pushed[1].OperationId, UserId: userId, DeviceId: phone},
+		}, confirmations)
+	})
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	os.Exit(code)
+}
+
+func TestRepository_Log(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	repo := memoryRepository.New(logger)
+
+	t.Run("log contains operations nobody tracks", func(t *testing.T) {
+		// Arrange
+		var pushed []operations.PushOperation
+		for _, id := range []string{"test-op-20", "test-op-21", "test-op-22"} {
+			pushed = append(pushed, createTestOperation(id))
+		}
+		work := repo.Push(pushed, "test-log-user", "test-device", true, nil)
+		err := work.Perform()
+		require.NoError(t, err)
+
+		// Act
+		firstPage, err := repo.Log(0, 2, operations.OperationTypeRegular)
+		require.NoError(t, err)
+		require.Len(t, firstPage, 2)
+		secondPage, err := repo.Log(firstPage[1].SequenceNumber, 2, operations.OperationTypeRegular)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, secondPage, 1)
+		assert.Equal(t, pushed[0].OperationId, firstPage[0].OperationId)
+		assert.Equal(t, pushed[1].OperationId, firstPage[1].OperationId)
+		assert.Equal(t, pushed[2].OperationId, secondPage[0].OperationId)
+	})
+}
+
+func TestRepository_Notifications(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	repo := memoryRepository.New(logger)
+	notification := func(id string, createdAt int64) operations.Notification {
+		return operations.Notification{
+			Id:            operations.NotificationId(id),
+			CreatedAt:     createdAt,
+			Payload:       []byte(`{"id":"` + id + `"}`),
+			State:         operations.NotificationStatePending,
+			NextAttemptAt: createdAt,
+		}
+	}
+
+	t.Run("notifications are pushed and rolled back with operations", func(t *testing.T) {
+		// Arrange
+		work := repo.Push(
+			[]operations.PushOperation{createTestOperation("test-notified-op")},
+			"test-user",
+			"test-device",
+			true,
+			[]operations.Notification{notification("pushed", 1)},
+		)
+
+		// Act
+		require.NoError(t, work.Perform())
+		require.NoError(t, work.Rollback())
+		claimed, err := repo.ClaimNotifications(100, 200, 10)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Empty(t, claimed)
+	})
+
+	t.Run("claimed notifications are postponed until delivered", func(t *testing.T) {
+		// Arrange
+		require.NoError(t, repo.Push(
+			[]operations.PushOperation{createTestOperation("test-claimed-op")},
+			"test-user",
+			"test-device",
+			true,
+			[]operations.Notification{notification("second", 2), notification("first", 1), notification("later", 50)},
+		).Perform())
+
+		// Act
+		claimed, err := repo.ClaimNotifications(10, 100, 10)
+		require.NoError(t, err)
+		claimedAgain, err := repo.ClaimNotifications(20, 120, 10)
+		require.NoError(t, err)
+		require.NoError(t, repo.ReplaceNotification(claimed[0], []operations.Notification{notification("replacement", 3)}).Perform())
+		delivered := claimed[1]
+		require.NoError(t, repo.ReplaceNotification(delivered, nil).Perform())
+		afterLease, err := repo.ClaimNotifications(100, 200, 10)
+		require.NoError(t, err)
+
+		// Assert
+		require.Len(t, claimed, 2)
+		assert.Equal(t, operations.NotificationId("first"), claimed[0].Id)
+		assert.Equal(t, operations.NotificationId("second"), claimed[1].Id)
+		assert.Equal(t, int64(100), claimed[0].NextAttemptAt)
+		assert.Equal(t, []byte(`{"id":"first"}`), claimed[0].Payload)
+		assert.Empty(t, claimedAgain)
+		require.Len(t, afterLease, 2)
+		assert.Equal(t, []operations.NotificationId{"replacement", "later"}, []operations.NotificationId{afterLease[0].Id, afterLease[1].Id})
+		for _, notification := range afterLease {
+			require.NoError(t, repo.ReplaceNotification(notification, nil).Perform())
+		}
+	})
+
+	t.Run("dead notifications are not claimed", func(t *testing.T) {
+		// Arrange
+		require.NoError(t, repo.Push(
+			[]operations.PushOperation{createTestOperation("test-dead-op")},
+			"test-user",
+			"test-device",
+			true,
+			[]operations.Notification{notification("failing", 1)},
+		).Perform())
+		claimed, err := repo.ClaimNotifications(10, 100, 10)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		dead := claimed[0]
+		dead.State = operations.NotificationStateDead
+		dead.Attempts = 10
+		dead.LastError = "unavailable"
+
+		// Act
+		update := repo.UpdateNotification(dead)
+		require.NoError(t, update.Perform())
+		deadNotifications, err := repo.DeadNotifications(10)
+		require.NoError(t, err)
+		claimedDead, err := repo.ClaimNotifications(1000, 2000, 10)
+		require.NoError(t, err)
+		require.NoError(t, update.Rollback())
+		restored, err := repo.ClaimNotifications(1000, 2000, 10)
+		require.NoError(t, err)
+
+		// Assert
+		require.Len(t, deadNotifications, 1)
+		assert.Equal(t, operations.NotificationId("failing"), deadNotifications[0].Id)
+		assert.Equal(t, 10, deadNotifications[0].Attempts)
+		assert.Equal(t, "unavailable", deadNotifications[0].LastError)
+		assert.Empty(t, claimedDead)
+		require.Len(t, restored, 1)
+		assert.Equal(t, 0, restored[0].Attempts)
+	})
+}
@@ -6,30 +6,110 @@ import (
 )
 
 type RepositoryMock struct {
-	PushImpl     func(operations []operations.PushOperation, userId operations.UserId, deviceId operations.DeviceId, confirm bool) repositories.UnitOfWork
-	PullImpl     func(userId operations.UserId, deviceId operations.DeviceId, operationType operations.OperationType) ([]operations.Operation, error)
-	ConfirmImpl  func(operations []operations.OperationId, userId operations.UserId, deviceId operations.DeviceId) repositories.UnitOfWork
-	GetUsersImpl func(trackingEntities []operations.TrackedEntity) ([]operations.UserId, error)
-	GetImpl      func(affectingEntities []operations.TrackedEntity) ([]operations.Operation, error)
-	SearchImpl   func(payloadType operations.OperationPayloadType, hint string) ([]operations.Operation, error)
+	PushImpl                func(operations []operations.PushOperation, userId operations.UserId, deviceId operations.DeviceId, confirm bool, notifications []operations.Notification) repositories.UnitOfWork
+	PushPreparedImpl        func(prepare operations.PreparePush, userId operations.UserId, deviceId operations.DeviceId, confirm bool) repositories.UnitOfWork
+	PullImpl                func(userId operations.UserId, deviceId operations.DeviceId, after operations.SequenceNumber, limit int, operationType operations.OperationType) ([]operations.Operation, error)
+	PullSinceImpl           func(userId operations.UserId, since operations.SequenceNumber, limit int, operationType operations.OperationType) ([]operations.Operation, error)
+	LogImpl                 func(after operations.SequenceNumber, limit int, operationType operations.OperationType) ([]operations.Operation, error)
+	ConfirmImpl             func(operations []operations.OperationId, userId operations.UserId, deviceId operations.DeviceId) repositories.UnitOfWork
+	RemoveImpl              func(operations []operations.OperationId) repositories.UnitOfWork
+	GetConfirmationsImpl    func(operations []operations.OperationId) ([]operations.Confirmation, error)
+	CountConfirmationsImpl  func(userId operations.UserId, deviceId operations.DeviceId) (int, error)
+	RemoveConfirmationsImpl func(userId operations.UserId, deviceId operations.DeviceId) repositories.UnitOfWork
+	ClaimNotificationsImpl  func(now int64, until int64, limit int) ([]operations.Notification, error)
+	ReplaceNotificationImpl func(notification operations.Notification, with []operations.Notification) repositories.UnitOfWork
+	UpdateNotificationImpl  func(notification operations.Notification) repositories.UnitOfWork
+	DeadNotificationsImpl   func(limit int) ([]operations.Notification, error)
+	GetUsersImpl            func(trackingEntities []operations.TrackedEntity) ([]operations.UserId, error)
+	GetEntitiesImpl         func(userId operations.UserId) ([]operations.TrackedEntity, error)
+	GetImpl                 func(affectingEntities []operations.TrackedEntity) ([]operations.Operation, error)
+	SearchImpl              func(payloadType operations.OperationPayloadType, hint string) ([]operations.Operation, error)
 }
 
-func (r *RepositoryMock) Push(operations []operations.PushOperation, userId operations.UserId, deviceId operations.DeviceId, confirm bool) repositories.UnitOfWork {
-	return r.PushImpl(operations, userId, deviceId, confirm)
+func (r *RepositoryMock) Push(operations []operations.PushOperation, userId operations.UserId, deviceId operations.DeviceId, confirm bool, notifications []operations.Notification) repositories.UnitOfWork {
+	return r.PushImpl(operations, userId, deviceId, confirm, notifications)
 }
 
-func (r *RepositoryMock) Pull(userId operations.UserId, deviceId operations.DeviceId, operationType operations.OperationType) ([]operations.Operation, error) {
-	return r.PullImpl(userId, deviceId, operationType)
+// PushPrepared prepares the push when it is performed and hands the result to PushImpl unless PushPreparedImpl is set
+func (r *RepositoryMock) PushPrepared(prepare operations.PreparePush, userId operations.UserId, deviceId operations.DeviceId, confirm bool) repositories.UnitOfWork {
+	if r.PushPreparedImpl != nil {
+		return r.PushPreparedImpl(prepare, userId, deviceId, confirm)
+	}
+	var push repositories.UnitOfWork
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			prepared, notifications, err := prepare()
+			if err != nil {
+				return err
+			}
+			push = r.PushImpl(prepared, userId, deviceId, confirm, notifications)
+			return push.Perform()
+		},
+		Rollback: func() error {
+			if push.Rollback == nil {
+				return nil
+			}
+			return push.Rollback()
+		},
+	}
+}
+
+func (r *RepositoryMock) Pull(userId operations.UserId, deviceId operations.DeviceId, after operations.SequenceNumber, limit int, operationType operations.OperationType) ([]operations.Operation, error) {
+	return r.PullImpl(userId, deviceId, after, limit, operationType)
+}
+
+func (r *RepositoryMock) PullSince(userId operations.UserId, since operations.SequenceNumber, limit int, operationType operations.OperationType) ([]operations.Operation, error) {
+	return r.PullSinceImpl(userId, since, limit, operationType)
+}
+
+func (r *RepositoryMock) Log(after operations.SequenceNumber, limit int, operationType operations.OperationType) ([]operations.Operation, error) {
+	return r.LogImpl(after, limit, operationType)
 }
 
 func (r *RepositoryMock) Confirm(operations []operations.OperationId, userId operations.UserId, deviceId operations.DeviceId) repositories.UnitOfWork {
 	return r.ConfirmImpl(operations, userId, deviceId)
 }
 
+func (r *RepositoryMock) Remove(operations []operations.OperationId) repositories.UnitOfWork {
+	return r.RemoveImpl(operations)
+}
+
+func (r *RepositoryMock) GetConfirmations(operations []operations.OperationId) ([]operations.Confirmation, error) {
+	return r.GetConfirmationsImpl(operations)
+}
+
+func (r *RepositoryMock) CountConfirmations(userId operations.UserId, deviceId operations.DeviceId) (int, error) {
+	return r.CountConfirmationsImpl(userId, deviceId)
+}
+
+func (r *RepositoryMock) RemoveConfirmations(userId operations.UserId, deviceId operations.DeviceId) repositories.UnitOfWork {
+	return r.RemoveConfirmationsImpl(userId, deviceId)
+}
+
+func (r *RepositoryMock) ClaimNotifications(now int64, until int64, limit int) ([]operations.Notification, error) {
+	return r.ClaimNotificationsImpl(now, until, limit)
+}
+
+func (r *RepositoryMock) ReplaceNotification(notification operations.Notification, with []operations.Notification) repositories.UnitOfWork {
+	return r.ReplaceNotificationImpl(notification, with)
+}
+
+func (r *RepositoryMock) UpdateNotification(notification operations.Notification) repositories.UnitOfWork {
+	return r.UpdateNotificationImpl(notification)
+}
+
+func (r *RepositoryMock) DeadNotifications(limit int) ([]operations.Notification, error) {
+	return r.DeadNotificationsImpl(limit)
+}
+
 func (r *RepositoryMock) GetUsers(trackingEntities []operations.TrackedEntity) ([]operations.UserId, error) {
 	return r.GetUsersImpl(trackingEntities)
 }
 
+func (r *RepositoryMock) GetEntities(userId operations.UserId) ([]operations.TrackedEntity, error) {
+	return r.GetEntitiesImpl(userId)
+}
+
 func (r *RepositoryMock) Get(affectingEntities []operations.TrackedEntity) ([]operations.Operation, error) {
 	return r.GetImpl(affectingEntities)
 }
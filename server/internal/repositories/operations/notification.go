@@ -0,0 +1,23 @@
+package operations
+
+type NotificationId string
+
+type NotificationState string
+
+const (
+	NotificationStatePending NotificationState = "pending"
+	// NotificationStateDead notifications ran out of delivery attempts, they are kept for inspection
+	NotificationStateDead NotificationState = "dead"
+)
+
+// Notification is an entry of the outbox of notifications about pushed operations, the payload is opaque to
+// the repository. Pending notifications are delivered once NextAttemptAt has passed.
+type Notification struct {
+	Id            NotificationId
+	CreatedAt     int64
+	Payload       []byte
+	State         NotificationState
+	Attempts      int
+	NextAttemptAt int64
+	LastError     string
+}
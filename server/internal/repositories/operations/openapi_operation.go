@@ -2,6 +2,7 @@ package operations
 
 import (
 	"encoding/json"
+	"slices"
 	openapi "verni/internal/openapi/go"
 )
 
@@ -20,6 +21,8 @@ func CreateOperation(operation openapi.SomeOperation) PushOperation {
 			},
 		},
 		EntityBindActions(OpenApiOperation{operation}),
+		EntityUnbindActions(OpenApiOperation{operation}),
+		nil,
 	}
 }
 
@@ -38,10 +41,18 @@ func (o *OpenApiOperation) Type() OperationPayloadType {
 		return CreateSpendingGroupOperationPayloadType
 	} else if !openapi.IsZeroValue(o.DeleteSpendingGroup) {
 		return DeleteSpendingGroupOperationPayloadType
+	} else if !openapi.IsZeroValue(o.AddGroupParticipant) {
+		return AddGroupParticipantOperationPayloadType
+	} else if !openapi.IsZeroValue(o.RemoveGroupParticipant) {
+		return RemoveGroupParticipantOperationPayloadType
 	} else if !openapi.IsZeroValue(o.CreateSpending) {
 		return CreateSpendingOperationPayloadType
+	} else if !openapi.IsZeroValue(o.UpdateSpending) {
+		return UpdateSpendingOperationPayloadType
 	} else if !openapi.IsZeroValue(o.DeleteSpending) {
 		return DeleteSpendingOperationPayloadType
+	} else if !openapi.IsZeroValue(o.Settlement) {
+		return SettlementOperationPayloadType
 	} else if !openapi.IsZeroValue(o.UpdateEmail) {
 		return UpdateEmailOperationPayloadType
 	} else if !openapi.IsZeroValue(o.VerifyEmail) {
@@ -74,6 +85,18 @@ func (o *OpenApiOperation) Data() ([]byte, error) {
 	return json.Marshal(o)
 }
 
+// CreatedGroupParticipants returns participants of the group created by the CreateSpendingGroup operation,
+// the author comes first and is a participant even if not listed
+func CreatedGroupParticipants(operation openapi.SomeOperation) []string {
+	participants := []string{operation.AuthorId}
+	for _, participant := range operation.CreateSpendingGroup.Participants {
+		if !slices.Contains(participants, participant) {
+			participants = append(participants, participant)
+		}
+	}
+	return participants
+}
+
 func EntityBindActions(o OpenApiOperation) []EntityBindAction {
 	unique := func(slice []UserId) []UserId {
 		uniqueMap := make(map[UserId]struct{})
@@ -115,6 +138,25 @@ func EntityBindActions(o OpenApiOperation) []EntityBindAction {
 			})
 		}
 		return actions
+	case AddGroupParticipantOperationPayloadType:
+		// tracking the group makes its whole history visible to the participant on the next pull,
+		// watching profiles of other participants depends on the group state and is bound by the caller
+		return []EntityBindAction{{
+			Watchers: []UserId{UserId(o.AddGroupParticipant.UserId)},
+			Entity:   TrackedEntity{Id: o.AddGroupParticipant.GroupId, Type: EntityTypeSpendingGroup},
+		}}
+	default:
+		return []EntityBindAction{}
+	}
+}
+
+func EntityUnbindActions(o OpenApiOperation) []EntityBindAction {
+	switch o.Type() {
+	case RemoveGroupParticipantOperationPayloadType:
+		return []EntityBindAction{{
+			Watchers: []UserId{UserId(o.RemoveGroupParticipant.UserId)},
+			Entity:   TrackedEntity{Id: o.RemoveGroupParticipant.GroupId, Type: EntityTypeSpendingGroup},
+		}}
 	default:
 		return []EntityBindAction{}
 	}
@@ -163,14 +205,33 @@ func (o *OpenApiOperation) TrackedEntities() []TrackedEntity {
 			Id:   o.DeleteSpendingGroup.GroupId,
 			Type: EntityTypeSpendingGroup,
 		}}
+	case AddGroupParticipantOperationPayloadType:
+		return []TrackedEntity{
+			{Id: o.AddGroupParticipant.GroupId, Type: EntityTypeSpendingGroup},
+			{Id: o.AddGroupParticipant.UserId, Type: EntityTypeUser},
+		}
+	case RemoveGroupParticipantOperationPayloadType:
+		// the removed participant no longer tracks the group but still learns about the removal through their profile
+		return []TrackedEntity{
+			{Id: o.RemoveGroupParticipant.GroupId, Type: EntityTypeSpendingGroup},
+			{Id: o.RemoveGroupParticipant.UserId, Type: EntityTypeUser},
+		}
 	case CreateSpendingOperationPayloadType:
 		return []TrackedEntity{
 			{Id: o.CreateSpending.GroupId, Type: EntityTypeSpendingGroup},
 		}
+	case UpdateSpendingOperationPayloadType:
+		return []TrackedEntity{
+			{Id: o.UpdateSpending.GroupId, Type: EntityTypeSpendingGroup},
+		}
 	case DeleteSpendingOperationPayloadType:
 		return []TrackedEntity{
 			{Id: o.DeleteSpending.GroupId, Type: EntityTypeSpendingGroup},
 		}
+	case SettlementOperationPayloadType:
+		return []TrackedEntity{
+			{Id: o.Settlement.GroupId, Type: EntityTypeSpendingGroup},
+		}
 	case UpdateEmailOperationPayloadType:
 		return []TrackedEntity{{
 			Id:   o.AuthorId,
@@ -13,9 +13,13 @@ type OperationPayload interface {
 	SearchHint() *string
 }
 
+// SequenceNumber is assigned by the server on push, it is strictly increasing in commit order.
+type SequenceNumber int64
+
 type Operation struct {
-	OperationId OperationId
-	CreatedAt   int64
-	AuthorId    UserId
-	Payload     OperationPayload
+	OperationId    OperationId
+	SequenceNumber SequenceNumber
+	CreatedAt      int64
+	AuthorId       UserId
+	Payload        OperationPayload
 }
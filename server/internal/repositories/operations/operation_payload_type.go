@@ -3,16 +3,20 @@ package operations
 type OperationPayloadType string
 
 const (
-	CreateUserOperationPayloadType          OperationPayloadType = "CreateUser"
-	UpdateDisplayNameOperationPayloadType   OperationPayloadType = "UpdateDisplayName"
-	UploadImageOperationPayloadType         OperationPayloadType = "UploadImage"
-	BindUserOperationPayloadType            OperationPayloadType = "BindUser"
-	UpdateAvatarOperationPayloadType        OperationPayloadType = "UpdateAvatar"
-	CreateSpendingGroupOperationPayloadType OperationPayloadType = "CreateSpendingGroup"
-	DeleteSpendingGroupOperationPayloadType OperationPayloadType = "DeleteSpendingGroup"
-	CreateSpendingOperationPayloadType      OperationPayloadType = "CreateSpending"
-	DeleteSpendingOperationPayloadType      OperationPayloadType = "DeleteSpending"
-	UpdateEmailOperationPayloadType         OperationPayloadType = "UpdateEmail"
-	VerifyEmailOperationPayloadType         OperationPayloadType = "VerifyEmail"
-	UnknownOperationPayloadType             OperationPayloadType = "Unknown"
+	CreateUserOperationPayloadType             OperationPayloadType = "CreateUser"
+	UpdateDisplayNameOperationPayloadType      OperationPayloadType = "UpdateDisplayName"
+	UploadImageOperationPayloadType            OperationPayloadType = "UploadImage"
+	BindUserOperationPayloadType               OperationPayloadType = "BindUser"
+	UpdateAvatarOperationPayloadType           OperationPayloadType = "UpdateAvatar"
+	CreateSpendingGroupOperationPayloadType    OperationPayloadType = "CreateSpendingGroup"
+	DeleteSpendingGroupOperationPayloadType    OperationPayloadType = "DeleteSpendingGroup"
+	AddGroupParticipantOperationPayloadType    OperationPayloadType = "AddGroupParticipant"
+	RemoveGroupParticipantOperationPayloadType OperationPayloadType = "RemoveGroupParticipant"
+	CreateSpendingOperationPayloadType         OperationPayloadType = "CreateSpending"
+	UpdateSpendingOperationPayloadType         OperationPayloadType = "UpdateSpending"
+	DeleteSpendingOperationPayloadType         OperationPayloadType = "DeleteSpending"
+	SettlementOperationPayloadType             OperationPayloadType = "Settlement"
+	UpdateEmailOperationPayloadType            OperationPayloadType = "UpdateEmail"
+	VerifyEmailOperationPayloadType            OperationPayloadType = "VerifyEmail"
+	UnknownOperationPayloadType                OperationPayloadType = "Unknown"
 )
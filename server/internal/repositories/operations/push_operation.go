@@ -1,5 +1,7 @@
 package operations
 
+import "verni/internal/repositories"
+
 type EntityBindAction struct {
 	Watchers []UserId
 	Entity   TrackedEntity
@@ -8,4 +10,9 @@ type EntityBindAction struct {
 type PushOperation struct {
 	Operation
 	EntityBindActions []EntityBindAction
+	// EntityUnbindActions stop watchers from tracking the entity, no matter which operation bound them
+	EntityUnbindActions []EntityBindAction
+	// Projections keep data derived from the operation, they are performed together with the push and fail it
+	// if one of them fails
+	Projections []repositories.UnitOfWork
 }
@@ -9,17 +9,66 @@ type UserId string
 type DeviceId string
 type OperationId string
 
+// Confirmation is an operation confirmed by a device of a user
+type Confirmation struct {
+	OperationId OperationId
+	UserId      UserId
+	DeviceId    DeviceId
+}
+
+// PreparePush builds operations of a push together with notifications about them
+type PreparePush func() ([]PushOperation, []Notification, error)
+
 var (
 	ErrBadOperation = errors.New("bad operation")
 	ErrConflict     = errors.New("operation identifier is already taken")
 )
 
 type Repository interface {
-	Push(operations []PushOperation, userId UserId, deviceId DeviceId, confirm bool) repositories.UnitOfWork
-	Pull(userId UserId, deviceId DeviceId, operationType OperationType) ([]Operation, error)
+	// Push stores operations together with notifications about them, notifications are written to the outbox
+	// in the same transaction so none of them is lost once the operations are stored
+	Push(operations []PushOperation, userId UserId, deviceId DeviceId, confirm bool, notifications []Notification) repositories.UnitOfWork
+	// PushPrepared runs prepare once pushes are serialized and stores what it returns like Push does, so what
+	// prepare reads is not changed by another push before the operations are stored. Nothing is stored if it fails.
+	PushPrepared(prepare PreparePush, userId UserId, deviceId DeviceId, confirm bool) repositories.UnitOfWork
+	// Pull returns up to `limit` operations visible to the user and not confirmed by the device
+	// with a sequence number greater than `after`, ordered by sequence number.
+	Pull(userId UserId, deviceId DeviceId, after SequenceNumber, limit int, operationType OperationType) ([]Operation, error)
+	// PullSince returns up to `limit` operations visible to the user with a sequence number greater than `since`,
+	// ordered by sequence number. Confirmations are not taken into account. Operations of an entity bound to the user
+	// after `since` are returned with the sequence number of the binding even if they are older, so a cursor taken
+	// before the binding still reaches the history of the entity. Operations sharing a sequence number are never
+	// split, the page is extended past `limit` to the last of them.
+	PullSince(userId UserId, since SequenceNumber, limit int, operationType OperationType) ([]Operation, error)
+	// Log returns up to `limit` operations of all users with a sequence number greater than `after`,
+	// ordered by sequence number. It is meant for server-side projections of the log.
+	Log(after SequenceNumber, limit int, operationType OperationType) ([]Operation, error)
 	Confirm(operations []OperationId, userId UserId, deviceId DeviceId) repositories.UnitOfWork
+	// Remove deletes operations from the log together with their affected entities and confirmations,
+	// bindings made by the operations are kept. Rollback restores operations with their sequence numbers.
+	Remove(operations []OperationId) repositories.UnitOfWork
+	// GetConfirmations returns every confirmation of the operations
+	GetConfirmations(operations []OperationId) ([]Confirmation, error)
+	// CountConfirmations returns the number of operations confirmed by the device
+	CountConfirmations(userId UserId, deviceId DeviceId) (int, error)
+	// RemoveConfirmations deletes every confirmation of the device, rollback restores them
+	RemoveConfirmations(userId UserId, deviceId DeviceId) repositories.UnitOfWork
+
+	// ClaimNotifications returns up to `limit` pending notifications due at `now`, ordered by creation, and postpones
+	// them to `until` so other instances do not take them while they are delivered
+	ClaimNotifications(now int64, until int64, limit int) ([]Notification, error)
+	// ReplaceNotification removes the notification from the outbox and adds `with` instead, delivered notifications
+	// are replaced with nothing. Rollback restores the notification.
+	ReplaceNotification(notification Notification, with []Notification) repositories.UnitOfWork
+	// UpdateNotification stores state, attempts, next attempt and last error of the notification, rollback restores
+	// the previous ones
+	UpdateNotification(notification Notification) repositories.UnitOfWork
+	// DeadNotifications returns up to `limit` notifications that ran out of attempts, ordered by creation
+	DeadNotifications(limit int) ([]Notification, error)
 
 	GetUsers(trackingEntities []TrackedEntity) ([]UserId, error)
+	// GetEntities returns entities tracked by the user
+	GetEntities(userId UserId) ([]TrackedEntity, error)
 	Get(affectingEntities []TrackedEntity) ([]Operation, error)
 	Search(payloadType OperationPayloadType, hint string) ([]Operation, error)
 }
@@ -66,6 +66,32 @@ ON CONFLICT (userId, deviceId) DO UPDATE SET token = EXCLUDED.token;
 	return nil
 }
 
+func (c *defaultRepository) RemovePushToken(user pushNotifications.UserId, device pushNotifications.DeviceId) repositories.UnitOfWork {
+	const op = "repositories.pushNotifications.defaultRepository.RemovePushToken"
+
+	currentToken, err := c.GetPushToken(user, device)
+	if err != nil {
+		err = fmt.Errorf("%s: getting token info: %w", op, err)
+		c.logger.LogInfo("%v", err)
+		return repositories.UnitOfWork{
+			Perform:  func() error { return err },
+			Rollback: func() error { return err },
+		}
+	}
+
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			return c.removePushToken(user, device)
+		},
+		Rollback: func() error {
+			if currentToken == nil {
+				return nil
+			}
+			return c.storePushToken(user, device, *currentToken)
+		},
+	}
+}
+
 func (c *defaultRepository) removePushToken(user pushNotifications.UserId, device pushNotifications.DeviceId) error {
 	const op = "repositories.pushNotifications.defaultRepository.removePushToken"
 	c.logger.LogInfo("%s: start[user=%v]", op, user)
@@ -79,6 +105,76 @@ func (c *defaultRepository) removePushToken(user pushNotifications.UserId, devic
 	return nil
 }
 
+func (c *defaultRepository) RemoveInvalidPushToken(token string) repositories.UnitOfWork {
+	var removed []storedPushToken
+
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			var err error
+			removed, err = c.removeInvalidPushToken(token)
+			return err
+		},
+		Rollback: func() error {
+			return c.restorePushTokens(removed, token)
+		},
+	}
+}
+
+type storedPushToken struct {
+	user   pushNotifications.UserId
+	device pushNotifications.DeviceId
+}
+
+func (c *defaultRepository) removeInvalidPushToken(token string) ([]storedPushToken, error) {
+	const op = "repositories.pushNotifications.defaultRepository.removeInvalidPushToken"
+	c.logger.LogInfo("%s: start", op)
+
+	query := `DELETE FROM pushTokens WHERE token = $1 RETURNING userId, deviceId;`
+	rows, err := c.db.Query(query, token)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to perform query: %w", op, err)
+	}
+	defer rows.Close()
+
+	removed := []storedPushToken{}
+	for rows.Next() {
+		var user, device string
+		if err := rows.Scan(&user, &device); err != nil {
+			return nil, fmt.Errorf("%s: scanning row: %w", op, err)
+		}
+		removed = append(removed, storedPushToken{
+			user:   pushNotifications.UserId(user),
+			device: pushNotifications.DeviceId(device),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: iterating rows: %w", op, err)
+	}
+
+	c.logger.LogInfo("%s: success[devices=%d]", op, len(removed))
+	return removed, nil
+}
+
+// restorePushTokens stores the token back for devices that have not stored another one
+func (c *defaultRepository) restorePushTokens(tokens []storedPushToken, token string) error {
+	const op = "repositories.pushNotifications.defaultRepository.restorePushTokens"
+	c.logger.LogInfo("%s: start[devices=%d]", op, len(tokens))
+
+	query := `
+INSERT INTO pushTokens(userId, deviceId, token)
+VALUES ($1, $2, $3)
+ON CONFLICT (userId, deviceId) DO NOTHING;
+`
+	for _, stored := range tokens {
+		if _, err := c.db.Exec(query, string(stored.user), string(stored.device), token); err != nil {
+			return fmt.Errorf("%s: failed to perform query: %w", op, err)
+		}
+	}
+
+	c.logger.LogInfo("%s: success[devices=%d]", op, len(tokens))
+	return nil
+}
+
 func (c *defaultRepository) GetPushToken(user pushNotifications.UserId, device pushNotifications.DeviceId) (*string, error) {
 	const op = "repositories.pushNotifications.postgresRepository.GetPushToken"
 	c.logger.LogInfo("%s: start[user=%v]", op, user)
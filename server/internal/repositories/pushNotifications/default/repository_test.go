@@ -3,6 +3,7 @@ package defaultRepository_test
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"os"
 	"testing"
 
@@ -24,6 +25,9 @@ func setupTestDB(t *testing.T) *sql.DB {
 	path := pathProvider.AbsolutePath("./config/test/postgres_storage.json")
 
 	configFile, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Skipf("no postgres test config at %s, see scripts/test.sh", path)
+	}
 	require.NoError(t, err)
 
 	err = json.Unmarshal(configFile, &testConfig)
@@ -184,6 +188,102 @@ func TestRepository_StorePushToken_Rollback(t *testing.T) {
 	})
 }
 
+func TestRepository_RemovePushToken(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	logger := standartOutputLoggingService.New()
+	repo := defaultRepository.New(db, logger)
+
+	t.Run("remove token", func(t *testing.T) {
+		// Arrange
+		userId := pushNotifications.UserId("test-user-6")
+		deviceId := pushNotifications.DeviceId("device-1")
+		work := repo.StorePushToken(userId, deviceId, "token")
+		require.NoError(t, work.Perform())
+
+		// Act
+		work = repo.RemovePushToken(userId, deviceId)
+		err := work.Perform()
+
+		// Assert
+		assert.NoError(t, err)
+		storedToken, err := repo.GetPushToken(userId, deviceId)
+		assert.NoError(t, err)
+		assert.Nil(t, storedToken)
+	})
+
+	t.Run("rollback token removal", func(t *testing.T) {
+		// Arrange
+		userId := pushNotifications.UserId("test-user-7")
+		deviceId := pushNotifications.DeviceId("device-1")
+		work := repo.StorePushToken(userId, deviceId, "token")
+		require.NoError(t, work.Perform())
+
+		// Act
+		work = repo.RemovePushToken(userId, deviceId)
+		require.NoError(t, work.Perform())
+		err := work.Rollback()
+
+		// Assert
+		assert.NoError(t, err)
+		storedToken, err := repo.GetPushToken(userId, deviceId)
+		assert.NoError(t, err)
+		require.NotNil(t, storedToken)
+		assert.Equal(t, "token", *storedToken)
+	})
+}
+
+func TestRepository_RemoveInvalidPushToken(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	logger := standartOutputLoggingService.New()
+	repo := defaultRepository.New(db, logger)
+
+	t.Run("remove token from every device", func(t *testing.T) {
+		// Arrange
+		alice := pushNotifications.UserId("test-user-8")
+		bob := pushNotifications.UserId("test-user-9")
+		require.NoError(t, repo.StorePushToken(alice, "device-1", "stale-token").Perform())
+		require.NoError(t, repo.StorePushToken(alice, "device-2", "valid-token").Perform())
+		require.NoError(t, repo.StorePushToken(bob, "device-1", "stale-token").Perform())
+
+		// Act
+		err := repo.RemoveInvalidPushToken("stale-token").Perform()
+
+		// Assert
+		assert.NoError(t, err)
+		tokens, err := repo.GetPushTokens([]pushNotifications.UserId{alice, bob})
+		assert.NoError(t, err)
+		assert.Equal(t, map[pushNotifications.UserId][]string{alice: {"valid-token"}}, tokens)
+	})
+
+	t.Run("rollback keeps tokens stored since removal", func(t *testing.T) {
+		// Arrange
+		userId := pushNotifications.UserId("test-user-10")
+		require.NoError(t, repo.StorePushToken(userId, "device-1", "stale-token").Perform())
+		require.NoError(t, repo.StorePushToken(userId, "device-2", "stale-token").Perform())
+		work := repo.RemoveInvalidPushToken("stale-token")
+		require.NoError(t, work.Perform())
+		require.NoError(t, repo.StorePushToken(userId, "device-2", "new-token").Perform())
+
+		// Act
+		err := work.Rollback()
+
+		// Assert
+		assert.NoError(t, err)
+		restored, err := repo.GetPushToken(userId, "device-1")
+		assert.NoError(t, err)
+		require.NotNil(t, restored)
+		assert.Equal(t, "stale-token", *restored)
+		kept, err := repo.GetPushToken(userId, "device-2")
+		assert.NoError(t, err)
+		require.NotNil(t, kept)
+		assert.Equal(t, "new-token", *kept)
+	})
+}
+
 func TestMain(m *testing.M) {
 	// Setup code (create database, tables, etc.)
 	code := m.Run()
@@ -0,0 +1,194 @@
+package memoryRepository
+
+import (
+	"sort"
+	"sync"
+
+	"verni/internal/repositories"
+	"verni/internal/repositories/pushNotifications"
+	"verni/internal/services/logging"
+)
+
+func New(logger logging.Service) pushNotifications.Repository {
+	return &memoryRepository{
+		tokens: map[pushNotifications.UserId]map[pushNotifications.DeviceId]string{},
+		logger: logger,
+	}
+}
+
+type memoryRepository struct {
+	mutex  sync.RWMutex
+	tokens map[pushNotifications.UserId]map[pushNotifications.DeviceId]string
+	logger logging.Service
+}
+
+func (c *memoryRepository) StorePushToken(user pushNotifications.UserId, device pushNotifications.DeviceId, token string) repositories.UnitOfWork {
+	currentToken, _ := c.GetPushToken(user, device)
+
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			c.storePushToken(user, device, token)
+			return nil
+		},
+		Rollback: func() error {
+			if currentToken == nil {
+				c.removePushToken(user, device)
+				return nil
+			}
+			c.storePushToken(user, device, *currentToken)
+			return nil
+		},
+	}
+}
+
+func (c *memoryRepository) storePushToken(user pushNotifications.UserId, device pushNotifications.DeviceId, token string) {
+	const op = "repositories.pushNotifications.memoryRepository.storePushToken"
+	c.logger.LogInfo("%s: start[user=%v]", op, user)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, ok := c.tokens[user]; !ok {
+		c.tokens[user] = map[pushNotifications.DeviceId]string{}
+	}
+	c.tokens[user][device] = token
+
+	c.logger.LogInfo("%s: success[user=%v]", op, user)
+}
+
+func (c *memoryRepository) RemovePushToken(user pushNotifications.UserId, device pushNotifications.DeviceId) repositories.UnitOfWork {
+	currentToken, _ := c.GetPushToken(user, device)
+
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			c.removePushToken(user, device)
+			return nil
+		},
+		Rollback: func() error {
+			if currentToken == nil {
+				return nil
+			}
+			c.storePushToken(user, device, *currentToken)
+			return nil
+		},
+	}
+}
+
+func (c *memoryRepository) removePushToken(user pushNotifications.UserId, device pushNotifications.DeviceId) {
+	const op = "repositories.pushNotifications.memoryRepository.removePushToken"
+	c.logger.LogInfo("%s: start[user=%v]", op, user)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.tokens[user], device)
+
+	c.logger.LogInfo("%s: success[user=%v]", op, user)
+}
+
+func (c *memoryRepository) RemoveInvalidPushToken(token string) repositories.UnitOfWork {
+	var removed []storedPushToken
+
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			removed = c.removeInvalidPushToken(token)
+			return nil
+		},
+		Rollback: func() error {
+			c.restorePushTokens(removed, token)
+			return nil
+		},
+	}
+}
+
+type storedPushToken struct {
+	user   pushNotifications.UserId
+	device pushNotifications.DeviceId
+}
+
+func (c *memoryRepository) removeInvalidPushToken(token string) []storedPushToken {
+	const op = "repositories.pushNotifications.memoryRepository.removeInvalidPushToken"
+	c.logger.LogInfo("%s: start", op)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	removed := []storedPushToken{}
+	for user, devices := range c.tokens {
+		for device, stored := range devices {
+			if stored != token {
+				continue
+			}
+			delete(devices, device)
+			removed = append(removed, storedPushToken{user: user, device: device})
+		}
+	}
+
+	c.logger.LogInfo("%s: success[devices=%d]", op, len(removed))
+	return removed
+}
+
+// restorePushTokens stores the token back for devices that have not stored another one
+func (c *memoryRepository) restorePushTokens(tokens []storedPushToken, token string) {
+	const op = "repositories.pushNotifications.memoryRepository.restorePushTokens"
+	c.logger.LogInfo("%s: start[devices=%d]", op, len(tokens))
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, stored := range tokens {
+		if _, ok := c.tokens[stored.user]; !ok {
+			c.tokens[stored.user] = map[pushNotifications.DeviceId]string{}
+		}
+		if _, exists := c.tokens[stored.user][stored.device]; !exists {
+			c.tokens[stored.user][stored.device] = token
+		}
+	}
+
+	c.logger.LogInfo("%s: success[devices=%d]", op, len(tokens))
+}
+
+func (c *memoryRepository) GetPushToken(user pushNotifications.UserId, device pushNotifications.DeviceId) (*string, error) {
+	const op = "repositories.pushNotifications.memoryRepository.GetPushToken"
+	c.logger.LogInfo("%s: start[user=%v]", op, user)
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	token, exists := c.tokens[user][device]
+	if !exists {
+		c.logger.LogInfo("%s: no token found for uid=%v", op, user)
+		return nil, nil
+	}
+
+	c.logger.LogInfo("%s: success[user=%v]", op, user)
+	return &token, nil
+}
+
+func (c *memoryRepository) GetPushTokens(userIds []pushNotifications.UserId) (map[pushNotifications.UserId][]string, error) {
+	const op = "repositories.pushNotifications.memoryRepository.GetPushTokens"
+	c.logger.LogInfo("%s: start[userIds=%v]", op, userIds)
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	result := make(map[pushNotifications.UserId][]string)
+	for _, userId := range userIds {
+		if _, processed := result[userId]; processed {
+			continue
+		}
+		devices := c.tokens[userId]
+		if len(devices) == 0 {
+			continue
+		}
+		tokens := make([]string, 0, len(devices))
+		for _, token := range devices {
+			tokens = append(tokens, token)
+		}
+		sort.Strings(tokens)
+		result[userId] = tokens
+	}
+
+	c.logger.LogInfo("%s: success[users=%v]", op, len(result))
+	return result, nil
+}
@@ -0,0 +1,246 @@
+package memoryRepository_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"verni/internal/repositories/pushNotifications"
+	memoryRepository "verni/internal/repositories/pushNotifications/memory"
+	standartOutputLoggingService "verni/internal/services/logging/standartOutput"
+)
+
+func TestRepository_StorePushToken(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	repo := memoryRepository.New(logger)
+
+	t.Run("store new token", func(t *testing.T) {
+		// Arrange
+		userId := pushNotifications.UserId("test-user-1")
+		deviceId := pushNotifications.DeviceId("device-1")
+		token := "push-token-123"
+
+		// Act
+		work := repo.StorePushToken(userId, deviceId, token)
+		err := work.Perform()
+
+		// Assert
+		assert.NoError(t, err)
+
+		// Verify token was stored
+		storedToken, err := repo.GetPushToken(userId, deviceId)
+		assert.NoError(t, err)
+		assert.NotNil(t, storedToken)
+		assert.Equal(t, token, *storedToken)
+	})
+
+	t.Run("update existing token", func(t *testing.T) {
+		// Arrange
+		userId := pushNotifications.UserId("test-user-2")
+		deviceId := pushNotifications.DeviceId("device-2")
+		token1 := "push-token-1"
+		token2 := "push-token-2"
+
+		// Store initial token
+		work := repo.StorePushToken(userId, deviceId, token1)
+		err := work.Perform()
+		require.NoError(t, err)
+
+		// Act - Update token
+		work = repo.StorePushToken(userId, deviceId, token2)
+		err = work.Perform()
+
+		// Assert
+		assert.NoError(t, err)
+
+		// Verify token was updated
+		storedToken, err := repo.GetPushToken(userId, deviceId)
+		assert.NoError(t, err)
+		assert.NotNil(t, storedToken)
+		assert.Equal(t, token2, *storedToken)
+	})
+}
+
+func TestRepository_GetPushToken(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	repo := memoryRepository.New(logger)
+
+	t.Run("get existing token", func(t *testing.T) {
+		// Arrange
+		userId := pushNotifications.UserId("test-user-3")
+		deviceId := pushNotifications.DeviceId("device-3")
+		token := "push-token-123"
+
+		work := repo.StorePushToken(userId, deviceId, token)
+		err := work.Perform()
+		require.NoError(t, err)
+
+		// Act
+		storedToken, err := repo.GetPushToken(userId, deviceId)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NotNil(t, storedToken)
+		assert.Equal(t, token, *storedToken)
+	})
+
+	t.Run("get non-existent token", func(t *testing.T) {
+		// Act
+		token, err := repo.GetPushToken("nonexistent-user", "nonexistent-device")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Nil(t, token)
+	})
+}
+
+func TestRepository_StorePushToken_Rollback(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	repo := memoryRepository.New(logger)
+
+	t.Run("rollback new token", func(t *testing.T) {
+		// Arrange
+		userId := pushNotifications.UserId("test-user-4")
+		deviceId := pushNotifications.DeviceId("device-4")
+		token := "push-token-123"
+
+		// Act
+		work := repo.StorePushToken(userId, deviceId, token)
+		err := work.Perform()
+		require.NoError(t, err)
+
+		err = work.Rollback()
+		require.NoError(t, err)
+
+		// Assert
+		storedToken, err := repo.GetPushToken(userId, deviceId)
+		assert.NoError(t, err)
+		assert.Nil(t, storedToken)
+	})
+
+	t.Run("rollback token update", func(t *testing.T) {
+		// Arrange
+		userId := pushNotifications.UserId("test-user-5")
+		deviceId := pushNotifications.DeviceId("device-5")
+		token1 := "push-token-1"
+		token2 := "push-token-2"
+
+		// Store initial token
+		work := repo.StorePushToken(userId, deviceId, token1)
+		err := work.Perform()
+		require.NoError(t, err)
+
+		// Act - Update and rollback
+		work = repo.StorePushToken(userId, deviceId, token2)
+		err = work.Perform()
+		require.NoError(t, err)
+
+		err = work.Rollback()
+		require.NoError(t, err)
+
+		// Assert - Should be back to token1
+		storedToken, err := repo.GetPushToken(userId, deviceId)
+		assert.NoError(t, err)
+		assert.NotNil(t, storedToken)
+		assert.Equal(t, token1, *storedToken)
+	})
+}
+
+func TestRepository_RemovePushToken(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	repo := memoryRepository.New(logger)
+
+	t.Run("remove token", func(t *testing.T) {
+		// Arrange
+		userId := pushNotifications.UserId("test-user-6")
+		deviceId := pushNotifications.DeviceId("device-1")
+		work := repo.StorePushToken(userId, deviceId, "token")
+		require.NoError(t, work.Perform())
+
+		// Act
+		work = repo.RemovePushToken(userId, deviceId)
+		err := work.Perform()
+
+		// Assert
+		assert.NoError(t, err)
+		storedToken, err := repo.GetPushToken(userId, deviceId)
+		assert.NoError(t, err)
+		assert.Nil(t, storedToken)
+	})
+
+	t.Run("rollback token removal", func(t *testing.T) {
+		// Arrange
+		userId := pushNotifications.UserId("test-user-7")
+		deviceId := pushNotifications.DeviceId("device-1")
+		work := repo.StorePushToken(userId, deviceId, "token")
+		require.NoError(t, work.Perform())
+
+		// Act
+		work = repo.RemovePushToken(userId, deviceId)
+		require.NoError(t, work.Perform())
+		err := work.Rollback()
+
+		// Assert
+		assert.NoError(t, err)
+		storedToken, err := repo.GetPushToken(userId, deviceId)
+		assert.NoError(t, err)
+		require.NotNil(t, storedToken)
+		assert.Equal(t, "token", *storedToken)
+	})
+}
+
+func TestRepository_RemoveInvalidPushToken(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	repo := memoryRepository.New(logger)
+
+	t.Run("remove token from every device", func(t *testing.T) {
+		// Arrange
+		alice := pushNotifications.UserId("test-user-8")
+		bob := pushNotifications.UserId("test-user-9")
+		require.NoError(t, repo.StorePushToken(alice, "device-1", "stale-token").Perform())
+		require.NoError(t, repo.StorePushToken(alice, "device-2", "valid-token").Perform())
+		require.NoError(t, repo.StorePushToken(bob, "device-1", "stale-token").Perform())
+
+		// Act
+		err := repo.RemoveInvalidPushToken("stale-token").Perform()
+
+		// Assert
+		assert.NoError(t, err)
+		tokens, err := repo.GetPushTokens([]pushNotifications.UserId{alice, bob})
+		assert.NoError(t, err)
+		assert.Equal(t, map[pushNotifications.UserId][]string{alice: {"valid-token"}}, tokens)
+	})
+
+	t.Run("rollback keeps tokens stored since removal", func(t *testing.T) {
+		// Arrange
+		userId := pushNotifications.UserId("test-user-10")
+		require.NoError(t, repo.StorePushToken(userId, "device-1", "stale-token").Perform())
+		require.NoError(t, repo.StorePushToken(userId, "device-2", "stale-token").Perform())
+		work := repo.RemoveInvalidPushToken("stale-token")
+		require.NoError(t, work.Perform())
+		require.NoError(t, repo.StorePushToken(userId, "device-2", "new-token").Perform())
+
+		// Act
+		err := work.Rollback()
+
+		// Assert
+		assert.NoError(t, err)
+		restored, err := repo.GetPushToken(userId, "device-1")
+		assert.NoError(t, err)
+		require.NotNil(t, restored)
+		assert.Equal(t, "stale-token", *restored)
+		kept, err := repo.GetPushToken(userId, "device-2")
+		assert.NoError(t, err)
+		require.NotNil(t, kept)
+		assert.Equal(t, "new-token", *kept)
+	})
+}
+
+func TestMain(m *testing.M) {
+	// Setup code (create database, tables, etc.)
+	code := m.Run()
+	// Cleanup code
+	os.Exit(code)
+}
@@ -6,15 +6,25 @@ import (
 )
 
 type RepositoryMock struct {
-	StorePushTokenImpl func(uid pushNotifications.UserId, device pushNotifications.DeviceId, token string) repositories.UnitOfWork
-	GetPushTokenImpl   func(uid pushNotifications.UserId, device pushNotifications.DeviceId) (*string, error)
-	GetPushTokensImpl  func(sessions []pushNotifications.UserId) (map[pushNotifications.UserId][]string, error)
+	StorePushTokenImpl         func(uid pushNotifications.UserId, device pushNotifications.DeviceId, token string) repositories.UnitOfWork
+	RemovePushTokenImpl        func(uid pushNotifications.UserId, device pushNotifications.DeviceId) repositories.UnitOfWork
+	RemoveInvalidPushTokenImpl func(token string) repositories.UnitOfWork
+	GetPushTokenImpl           func(uid pushNotifications.UserId, device pushNotifications.DeviceId) (*string, error)
+	GetPushTokensImpl          func(sessions []pushNotifications.UserId) (map[pushNotifications.UserId][]string, error)
 }
 
 func (c *RepositoryMock) StorePushToken(uid pushNotifications.UserId, device pushNotifications.DeviceId, token string) repositories.UnitOfWork {
 	return c.StorePushTokenImpl(uid, device, token)
 }
 
+func (c *RepositoryMock) RemovePushToken(uid pushNotifications.UserId, device pushNotifications.DeviceId) repositories.UnitOfWork {
+	return c.RemovePushTokenImpl(uid, device)
+}
+
+func (c *RepositoryMock) RemoveInvalidPushToken(token string) repositories.UnitOfWork {
+	return c.RemoveInvalidPushTokenImpl(token)
+}
+
 func (c *RepositoryMock) GetPushToken(uid pushNotifications.UserId, device pushNotifications.DeviceId) (*string, error) {
 	return c.GetPushTokenImpl(uid, device)
 }
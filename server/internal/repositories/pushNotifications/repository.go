@@ -9,6 +9,10 @@ type DeviceId string
 
 type Repository interface {
 	StorePushToken(user UserId, device DeviceId, token string) repositories.UnitOfWork
+	RemovePushToken(user UserId, device DeviceId) repositories.UnitOfWork
+	// RemoveInvalidPushToken removes the token from every device it is stored for, rollback does not restore it
+	// for devices that have stored another token since then
+	RemoveInvalidPushToken(token string) repositories.UnitOfWork
 	GetPushToken(user UserId, device DeviceId) (*string, error)
 	GetPushTokens(users []UserId) (map[UserId][]string, error)
 }
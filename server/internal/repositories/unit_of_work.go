@@ -1,6 +1,11 @@
 package repositories
 
+import "database/sql"
+
 type UnitOfWork struct {
 	Perform  func() error
 	Rollback func() error
+	// PerformIn performs the work in a transaction of another repository sharing the database,
+	// it is nil for work that can only be performed on its own
+	PerformIn func(tx *sql.Tx) error
 }
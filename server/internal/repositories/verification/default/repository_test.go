@@ -3,6 +3,7 @@ package defaultRepository_test
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"os"
 	"testing"
 
@@ -28,6 +29,9 @@ func setupTestDB(t *testing.T) *sql.DB {
 	path := pathProvider.AbsolutePath("./config/test/postgres_storage.json")
 
 	configFile, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Skipf("no postgres test config at %s, see scripts/test.sh", path)
+	}
 	require.NoError(t, err)
 
 	err = json.Unmarshal(configFile, &testConfig)
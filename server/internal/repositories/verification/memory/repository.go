@@ -0,0 +1,103 @@
+package memoryRepository
+
+import (
+	"sync"
+
+	"verni/internal/repositories"
+	"verni/internal/repositories/verification"
+	"verni/internal/services/logging"
+)
+
+func New(logger logging.Service) verification.Repository {
+	return &memoryRepository{
+		codes:  map[string]string{},
+		logger: logger,
+	}
+}
+
+type memoryRepository struct {
+	mutex  sync.RWMutex
+	codes  map[string]string
+	logger logging.Service
+}
+
+func (c *memoryRepository) StoreEmailVerificationCode(email string, code string) repositories.UnitOfWork {
+	currentCode, _ := c.GetEmailVerificationCode(email)
+
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			c.storeEmailVerificationCode(email, code)
+			return nil
+		},
+		Rollback: func() error {
+			if currentCode == nil {
+				c.removeEmailVerificationCode(email)
+				return nil
+			}
+			c.storeEmailVerificationCode(email, *currentCode)
+			return nil
+		},
+	}
+}
+
+func (c *memoryRepository) storeEmailVerificationCode(email string, code string) {
+	const op = "repositories.verification.memoryRepository.storeEmailVerificationCode"
+	c.logger.LogInfo("%s: start[email=%s]", op, email)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.codes[email] = code
+
+	c.logger.LogInfo("%s: success[email=%s]", op, email)
+}
+
+func (c *memoryRepository) GetEmailVerificationCode(email string) (*string, error) {
+	const op = "repositories.verification.memoryRepository.GetEmailVerificationCode"
+	c.logger.LogInfo("%s: start[email=%s]", op, email)
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	code, exists := c.codes[email]
+	if !exists {
+		c.logger.LogInfo("%s: no code found for email=%s", op, email)
+		return nil, nil
+	}
+
+	c.logger.LogInfo("%s: success[email=%s]", op, email)
+	return &code, nil
+}
+
+func (c *memoryRepository) RemoveEmailVerificationCode(email string) repositories.UnitOfWork {
+	code, _ := c.GetEmailVerificationCode(email)
+
+	return repositories.UnitOfWork{
+		Perform: func() error {
+			if code == nil {
+				return nil
+			}
+			c.removeEmailVerificationCode(email)
+			return nil
+		},
+		Rollback: func() error {
+			if code == nil {
+				return nil
+			}
+			c.storeEmailVerificationCode(email, *code)
+			return nil
+		},
+	}
+}
+
+func (c *memoryRepository) removeEmailVerificationCode(email string) {
+	const op = "repositories.verification.memoryRepository.removeEmailVerificationCode"
+	c.logger.LogInfo("%s: start[email=%s]", op, email)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.codes, email)
+
+	c.logger.LogInfo("%s: success[email=%s]", op, email)
+}
@@ -28,6 +28,9 @@ type AppLinksDetails struct {
 
 const AppIdentifier = "NPZKGHFT2A.com.rzmn.dev.verni"
 
+// InvitationPath prefixes group invitation links, the app redeems the token that ends the link
+const InvitationPath = "/invite/"
+
 func aasaHandler(w http.ResponseWriter, r *http.Request) {
 	var config = Config{
 		WebCredentials: WebCredentials{
@@ -38,7 +41,7 @@ func aasaHandler(w http.ResponseWriter, r *http.Request) {
 			Details: []AppLinksDetails{
 				{
 					AppId: AppIdentifier,
-					Paths: []string{"*"},
+					Paths: []string{"*", InvitationPath + "*"},
 				},
 			},
 		},
@@ -21,7 +21,8 @@ type ServerConfig struct {
 
 func timeoutMiddleware(next http.Handler, defaultTimeout time.Duration) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/operationsQueue" {
+		// streaming connections stay open for as long as clients need them, timeout handler can not hijack them either
+		if r.URL.Path == "/operationsQueue" || r.URL.Path == "/operationsQueue/websocket" {
 			next.ServeHTTP(w, r)
 		} else {
 			handler := http.TimeoutHandler(next, defaultTimeout, "Request timeout exceeded")
@@ -33,6 +34,8 @@ func timeoutMiddleware(next http.Handler, defaultTimeout time.Duration) http.Han
 func New(
 	config ServerConfig,
 	sseHandler func(w http.ResponseWriter, r *http.Request),
+	webSocketHandler func(w http.ResponseWriter, r *http.Request),
+	imagesHandler func(w http.ResponseWriter, r *http.Request),
 	servicer openapi.DefaultAPIServicer,
 	pathProvider pathProvider.Service,
 	logger logging.Service,
@@ -65,7 +68,14 @@ func New(
 		http.ServeFile(w, r, filepath.Join(staticDir, "docs/index.html"))
 	})
 
+	// invitation links are opened by the app, browsers without it get the landing page
+	router.PathPrefix(InvitationPath).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join(staticDir, "index.html"))
+	})
+
 	router.HandleFunc("/operationsQueue", sseHandler)
+	router.HandleFunc("/operationsQueue/websocket", webSocketHandler).Methods(http.MethodGet)
+	router.HandleFunc("/images/{imageId}", imagesHandler).Methods(http.MethodGet)
 	router.HandleFunc("/.well-known/apple-app-site-association", aasaHandler)
 	router.HandleFunc("/apple-app-site-association", aasaHandler)
 
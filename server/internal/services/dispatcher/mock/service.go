@@ -0,0 +1,16 @@
+package dispatcher_mock
+
+import "verni/internal/services/dispatcher"
+
+type ServiceMock struct {
+	DispatchImpl func(name string, task func()) error
+	StatsImpl    func() dispatcher.Stats
+}
+
+func (c *ServiceMock) Dispatch(name string, task func()) error {
+	return c.DispatchImpl(name, task)
+}
+
+func (c *ServiceMock) Stats() dispatcher.Stats {
+	return c.StatsImpl()
+}
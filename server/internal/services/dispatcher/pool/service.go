@@ -0,0 +1,105 @@
+package poolDispatcher
+
+import (
+	"sync"
+	"time"
+	"verni/internal/services/dispatcher"
+	"verni/internal/services/logging"
+)
+
+const (
+	defaultWorkers   = 4
+	defaultQueueSize = 1024
+)
+
+type PoolConfig struct {
+	Workers   int `json:"workers"`
+	QueueSize int `json:"queueSize"`
+}
+
+type task struct {
+	name     string
+	run      func()
+	enqueued time.Time
+}
+
+type poolDispatcher struct {
+	logger logging.Service
+	queue  chan task
+	mutex  sync.Mutex
+	stats  dispatcher.Stats
+}
+
+// New starts workers taking tasks from a bounded queue, tasks dispatched while the queue is full are rejected
+// so callers decide whether to drop them or run them in place
+func New(config PoolConfig, logger logging.Service) dispatcher.Service {
+	if config.Workers <= 0 {
+		config.Workers = defaultWorkers
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultQueueSize
+	}
+	d := &poolDispatcher{
+		logger: logger,
+		queue:  make(chan task, config.QueueSize),
+	}
+	d.stats.Capacity = config.QueueSize
+	for i := 0; i < config.Workers; i++ {
+		go d.work()
+	}
+	return d
+}
+
+func (d *poolDispatcher) Dispatch(name string, run func()) error {
+	const op = "dispatcher.poolDispatcher.Dispatch"
+	select {
+	case d.queue <- task{name: name, run: run, enqueued: time.Now()}:
+		d.mutex.Lock()
+		d.stats.Dispatched++
+		d.mutex.Unlock()
+		return nil
+	default:
+		d.mutex.Lock()
+		d.stats.Rejected++
+		d.mutex.Unlock()
+		d.logger.LogError("%s: rejecting %s, queue of %d tasks is full", op, name, cap(d.queue))
+		return dispatcher.QueueFull
+	}
+}
+
+// Stats returns counters since the start, MaxLag is the longest wait since the previous call
+func (d *poolDispatcher) Stats() dispatcher.Stats {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	stats := d.stats
+	stats.Depth = len(d.queue)
+	d.stats.MaxLag = 0
+	return stats
+}
+
+func (d *poolDispatcher) work() {
+	for task := range d.queue {
+		lag := time.Since(task.enqueued)
+		d.mutex.Lock()
+		d.stats.LastLag = lag
+		if lag > d.stats.MaxLag {
+			d.stats.MaxLag = lag
+		}
+		d.mutex.Unlock()
+		d.run(task)
+		d.mutex.Lock()
+		d.stats.Completed++
+		d.mutex.Unlock()
+	}
+}
+
+// run keeps the worker alive when a task panics, there is no request to fail in the background
+func (d *poolDispatcher) run(task task) {
+	const op = "dispatcher.poolDispatcher.run"
+	defer func() {
+		if r := recover(); r != nil {
+			d.logger.LogError("%s: %s panicked: %v", op, task.name, r)
+		}
+	}()
+	task.run()
+}
@@ -0,0 +1,117 @@
+package poolDispatcher_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"verni/internal/services/dispatcher"
+	poolDispatcher "verni/internal/services/dispatcher/pool"
+	standartOutputLoggingService "verni/internal/services/logging/standartOutput"
+)
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+
+	t.Run("runs tasks in background", func(t *testing.T) {
+		// Arrange
+		service := poolDispatcher.New(poolDispatcher.PoolConfig{Workers: 2, QueueSize: 8}, logger)
+		var wg sync.WaitGroup
+		var mutex sync.Mutex
+		ran := 0
+
+		// Act
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			require.NoError(t, service.Dispatch("task", func() {
+				defer wg.Done()
+				mutex.Lock()
+				defer mutex.Unlock()
+				ran++
+			}))
+		}
+		wg.Wait()
+
+		// Assert
+		assert.Equal(t, 5, ran)
+		assert.Eventually(t, func() bool {
+			return service.Stats().Completed == 5
+		}, time.Second, time.Millisecond)
+		stats := service.Stats()
+		assert.Equal(t, uint64(5), stats.Dispatched)
+		assert.Equal(t, 0, stats.Depth)
+		assert.Equal(t, 8, stats.Capacity)
+	})
+
+	t.Run("rejects tasks when queue is full", func(t *testing.T) {
+		// Arrange
+		service := poolDispatcher.New(poolDispatcher.PoolConfig{Workers: 1, QueueSize: 1}, logger)
+		started := make(chan struct{})
+		release := make(chan struct{})
+		require.NoError(t, service.Dispatch("blocking", func() {
+			close(started)
+			<-release
+		}))
+		<-started
+		require.NoError(t, service.Dispatch("queued", func() {}))
+
+		// Act
+		err := service.Dispatch("rejected", func() {
+			t.Error("rejected task should not run")
+		})
+		stats := service.Stats()
+		close(release)
+
+		// Assert
+		assert.ErrorIs(t, err, dispatcher.QueueFull)
+		assert.Equal(t, 1, stats.Depth)
+		assert.Equal(t, uint64(2), stats.Dispatched)
+		assert.Equal(t, uint64(1), stats.Rejected)
+	})
+
+	t.Run("measures lag of queued tasks", func(t *testing.T) {
+		// Arrange
+		service := poolDispatcher.New(poolDispatcher.PoolConfig{Workers: 1, QueueSize: 2}, logger)
+		done := make(chan struct{})
+		require.NoError(t, service.Dispatch("slow", func() {
+			time.Sleep(50 * time.Millisecond)
+		}))
+		require.NoError(t, service.Dispatch("waiting", func() {
+			close(done)
+		}))
+
+		// Act
+		<-done
+		first := service.Stats()
+		second := service.Stats()
+
+		// Assert
+		assert.GreaterOrEqual(t, first.LastLag, 40*time.Millisecond)
+		assert.GreaterOrEqual(t, first.MaxLag, 40*time.Millisecond)
+		assert.Equal(t, time.Duration(0), second.MaxLag)
+	})
+
+	t.Run("keeps workers after panic", func(t *testing.T) {
+		// Arrange
+		service := poolDispatcher.New(poolDispatcher.PoolConfig{Workers: 1, QueueSize: 2}, logger)
+		done := make(chan struct{})
+
+		// Act
+		require.NoError(t, service.Dispatch("panicking", func() {
+			panic("broken task")
+		}))
+		require.NoError(t, service.Dispatch("next", func() {
+			close(done)
+		}))
+
+		// Assert
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("task after panic did not run")
+		}
+	})
+}
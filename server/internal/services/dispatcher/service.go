@@ -0,0 +1,27 @@
+package dispatcher
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	QueueFull = errors.New("dispatcher queue is full")
+)
+
+// Stats describes the queue at the moment, lag is the time a task waited in the queue before a worker took it
+type Stats struct {
+	Depth      int
+	Capacity   int
+	Dispatched uint64
+	Completed  uint64
+	Rejected   uint64
+	LastLag    time.Duration
+	MaxLag     time.Duration
+}
+
+// Service runs tasks in the background, the name of a task is used only for logging
+type Service interface {
+	Dispatch(name string, task func()) error
+	Stats() Stats
+}
@@ -50,3 +50,41 @@ func (c *defaultService) ValidateDeviceIdFormat(id string) error {
 	}
 	return nil
 }
+
+func (c *defaultService) ValidateCurrencyFormat(code string) error {
+	if _, ok := currencyCodes[code]; !ok {
+		return fmt.Errorf("currency is invalid: %s is not an ISO 4217 code", code)
+	}
+	return nil
+}
+
+func (c *defaultService) ValidateSharesFormat(amount int64, shares []formatValidation.Share) error {
+	if amount <= 0 {
+		return fmt.Errorf("amount is invalid: should be positive, got %d", amount)
+	}
+	if len(shares) == 0 {
+		return fmt.Errorf("shares are invalid: should not be empty")
+	}
+	users := map[string]struct{}{}
+	var sum, owed int64
+	for _, share := range shares {
+		if share.UserId == "" {
+			return fmt.Errorf("shares are invalid: user id is empty")
+		}
+		if _, duplicate := users[share.UserId]; duplicate {
+			return fmt.Errorf("shares are invalid: user %s has more than one share", share.UserId)
+		}
+		users[share.UserId] = struct{}{}
+		sum += share.Amount
+		if share.Amount > 0 {
+			owed += share.Amount
+		}
+	}
+	if sum != 0 {
+		return fmt.Errorf("shares are invalid: should sum up to zero, got %d", sum)
+	}
+	if owed > amount {
+		return fmt.Errorf("shares are invalid: %d is owed for a spending of %d", owed, amount)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package defaultFormatValidation_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"verni/internal/services/formatValidation"
+	defaultFormatValidation "verni/internal/services/formatValidation/default"
+	standartOutputLoggingService "verni/internal/services/logging/standartOutput"
+)
+
+func TestValidateCurrencyFormat(t *testing.T) {
+	service := defaultFormatValidation.New(standartOutputLoggingService.New())
+
+	assert.NoError(t, service.ValidateCurrencyFormat("USD"))
+	assert.NoError(t, service.ValidateCurrencyFormat("RUB"))
+	assert.Error(t, service.ValidateCurrencyFormat("usd"))
+	assert.Error(t, service.ValidateCurrencyFormat("ABC"))
+	assert.Error(t, service.ValidateCurrencyFormat(""))
+}
+
+func TestValidateSharesFormat(t *testing.T) {
+	service := defaultFormatValidation.New(standartOutputLoggingService.New())
+
+	t.Run("split equally", func(t *testing.T) {
+		err := service.ValidateSharesFormat(100, []formatValidation.Share{
+			{UserId: "payer", Amount: 50},
+			{UserId: "debtor", Amount: -50},
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("non positive amount", func(t *testing.T) {
+		err := service.ValidateSharesFormat(0, []formatValidation.Share{
+			{UserId: "payer", Amount: 0},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("no shares", func(t *testing.T) {
+		assert.Error(t, service.ValidateSharesFormat(100, []formatValidation.Share{}))
+	})
+
+	t.Run("non zero sum", func(t *testing.T) {
+		err := service.ValidateSharesFormat(100, []formatValidation.Share{
+			{UserId: "payer", Amount: 100},
+			{UserId: "debtor", Amount: -50},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("owed more than spent", func(t *testing.T) {
+		err := service.ValidateSharesFormat(100, []formatValidation.Share{
+			{UserId: "payer", Amount: 200},
+			{UserId: "debtor", Amount: -200},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("duplicate user", func(t *testing.T) {
+		err := service.ValidateSharesFormat(100, []formatValidation.Share{
+			{UserId: "payer", Amount: 50},
+			{UserId: "payer", Amount: -50},
+		})
+		assert.Error(t, err)
+	})
+}
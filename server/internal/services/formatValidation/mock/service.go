@@ -1,10 +1,16 @@
 package formatValidation_mock
 
+import (
+	"verni/internal/services/formatValidation"
+)
+
 type ServiceMock struct {
 	ValidateEmailFormatImpl       func(email string) error
 	ValidatePasswordFormatImpl    func(password string) error
 	ValidateDisplayNameFormatImpl func(name string) error
 	ValidateDeviceIdFormatImpl    func(id string) error
+	ValidateCurrencyFormatImpl    func(code string) error
+	ValidateSharesFormatImpl      func(amount int64, shares []formatValidation.Share) error
 }
 
 func (c *ServiceMock) ValidateEmailFormat(email string) error {
@@ -22,3 +28,11 @@ func (c *ServiceMock) ValidateDisplayNameFormat(name string) error {
 func (c *ServiceMock) ValidateDeviceIdFormat(id string) error {
 	return c.ValidateDeviceIdFormatImpl(id)
 }
+
+func (c *ServiceMock) ValidateCurrencyFormat(code string) error {
+	return c.ValidateCurrencyFormatImpl(code)
+}
+
+func (c *ServiceMock) ValidateSharesFormat(amount int64, shares []formatValidation.Share) error {
+	return c.ValidateSharesFormatImpl(amount, shares)
+}
@@ -1,8 +1,17 @@
 package formatValidation
 
+type Share struct {
+	UserId string
+	Amount int64
+}
+
 type Service interface {
 	ValidateEmailFormat(email string) error
 	ValidatePasswordFormat(password string) error
 	ValidateDisplayNameFormat(name string) error
 	ValidateDeviceIdFormat(id string) error
+	ValidateCurrencyFormat(code string) error
+	// ValidateSharesFormat checks that shares are net balances of distinct users settling a spending of `amount`:
+	// positive for those who are owed, negative for those who owe, summing up to zero.
+	ValidateSharesFormat(amount int64, shares []Share) error
 }
@@ -0,0 +1,114 @@
+package localImageStorage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"verni/internal/services/imageStorage"
+	"verni/internal/services/logging"
+	"verni/internal/services/pathProvider"
+)
+
+type LocalConfig struct {
+	Path string `json:"path"`
+}
+
+var keyFormat = regexp.MustCompile("^[0-9a-f]{64}$")
+
+// defaultPath is where images are kept when the config has no path
+const defaultPath = "./images"
+
+func New(
+	config LocalConfig,
+	pathProvider pathProvider.Service,
+	logger logging.Service,
+) (imageStorage.Service, error) {
+	const op = "imageStorage.localService.New"
+	if config.Path == "" {
+		config.Path = defaultPath
+	}
+	root := pathProvider.AbsolutePath(config.Path)
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return &localService{}, fmt.Errorf("%s: creating storage directory %s: %w", op, root, err)
+	}
+	return &localService{
+		root:   root,
+		logger: logger,
+	}, nil
+}
+
+// localService keeps every image in a file named by the sha256 of its bytes, files are spread
+// over subdirectories by the first two characters of the name
+type localService struct {
+	root   string
+	logger logging.Service
+}
+
+func (s *localService) Store(data []byte) (imageStorage.Key, error) {
+	const op = "imageStorage.localService.Store"
+	hash := sha256.Sum256(data)
+	key := imageStorage.Key(hex.EncodeToString(hash[:]))
+	s.logger.LogInfo("%s: start[key=%s size=%d]", op, key, len(data))
+
+	path := s.path(key)
+	if _, err := os.Stat(path); err == nil {
+		s.logger.LogInfo("%s: already stored[key=%s]", op, key)
+		return key, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%s: checking %s: %w", op, path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("%s: creating directory for %s: %w", op, path, err)
+	}
+
+	// written to a temporary file first so readers never see a partial image
+	temporary, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return "", fmt.Errorf("%s: creating temporary file: %w", op, err)
+	}
+	defer os.Remove(temporary.Name())
+	if _, err := temporary.Write(data); err != nil {
+		temporary.Close()
+		return "", fmt.Errorf("%s: writing temporary file: %w", op, err)
+	}
+	if err := temporary.Close(); err != nil {
+		return "", fmt.Errorf("%s: closing temporary file: %w", op, err)
+	}
+	if err := os.Rename(temporary.Name(), path); err != nil {
+		return "", fmt.Errorf("%s: moving temporary file to %s: %w", op, path, err)
+	}
+
+	s.logger.LogInfo("%s: success[key=%s]", op, key)
+	return key, nil
+}
+
+func (s *localService) Load(key imageStorage.Key) ([]byte, error) {
+	const op = "imageStorage.localService.Load"
+	s.logger.LogInfo("%s: start[key=%s]", op, key)
+
+	if !keyFormat.MatchString(string(key)) {
+		return nil, fmt.Errorf("%s: malformed key %s: %w", op, key, imageStorage.NoSuchImage)
+	}
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s: no file for key %s: %w", op, key, imageStorage.NoSuchImage)
+	} else if err != nil {
+		return nil, fmt.Errorf("%s: reading file for key %s: %w", op, key, err)
+	}
+
+	s.logger.LogInfo("%s: success[key=%s size=%d]", op, key, len(data))
+	return data, nil
+}
+
+func (s *localService) path(key imageStorage.Key) string {
+	name := string(key)
+	if len(name) < 2 {
+		return filepath.Join(s.root, name)
+	}
+	return filepath.Join(s.root, name[:2], name)
+}
@@ -0,0 +1,90 @@
+package localImageStorage_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"verni/internal/services/imageStorage"
+	localImageStorage "verni/internal/services/imageStorage/local"
+	standartOutputLoggingService "verni/internal/services/logging/standartOutput"
+)
+
+type rootPathProvider struct {
+	root string
+}
+
+func (p rootPathProvider) AbsolutePath(relative string) string {
+	return filepath.Join(p.root, relative)
+}
+
+func TestService(t *testing.T) {
+	logger := standartOutputLoggingService.New()
+	root := t.TempDir()
+	service, err := localImageStorage.New(localImageStorage.LocalConfig{Path: "images"}, rootPathProvider{root: root}, logger)
+	require.NoError(t, err)
+
+	t.Run("stored bytes are loaded by key", func(t *testing.T) {
+		// Arrange
+		data := []byte("image bytes")
+
+		// Act
+		key, err := service.Store(data)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Len(t, string(key), 64)
+		loaded, err := service.Load(key)
+		require.NoError(t, err)
+		assert.Equal(t, data, loaded)
+	})
+
+	t.Run("equal bytes are stored once", func(t *testing.T) {
+		// Arrange
+		data := []byte("same bytes")
+
+		// Act
+		first, err := service.Store(data)
+		require.NoError(t, err)
+		second, err := service.Store(data)
+		require.NoError(t, err)
+
+		// Assert
+		assert.Equal(t, first, second)
+		entries, err := os.ReadDir(filepath.Join(root, "images", string(first)[:2]))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
+
+	t.Run("unknown and malformed keys", func(t *testing.T) {
+		for _, key := range []imageStorage.Key{
+			"0000000000000000000000000000000000000000000000000000000000000000",
+			"../../etc/passwd",
+		} {
+			// Act
+			_, err := service.Load(key)
+
+			// Assert
+			assert.ErrorIs(t, err, imageStorage.NoSuchImage)
+		}
+	})
+
+	t.Run("images are kept in the default directory without a path", func(t *testing.T) {
+		// Arrange
+		defaultRoot := t.TempDir()
+		service, err := localImageStorage.New(localImageStorage.LocalConfig{}, rootPathProvider{root: defaultRoot}, logger)
+		require.NoError(t, err)
+
+		// Act
+		key, err := service.Store([]byte("default bytes"))
+
+		// Assert
+		require.NoError(t, err)
+		entries, err := os.ReadDir(filepath.Join(defaultRoot, "images", string(key)[:2]))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
+}
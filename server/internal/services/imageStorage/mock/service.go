@@ -0,0 +1,16 @@
+package imageStorage_mock
+
+import "verni/internal/services/imageStorage"
+
+type ServiceMock struct {
+	StoreImpl func(data []byte) (imageStorage.Key, error)
+	LoadImpl  func(key imageStorage.Key) ([]byte, error)
+}
+
+func (s *ServiceMock) Store(data []byte) (imageStorage.Key, error) {
+	return s.StoreImpl(data)
+}
+
+func (s *ServiceMock) Load(key imageStorage.Key) ([]byte, error) {
+	return s.LoadImpl(key)
+}
@@ -0,0 +1,17 @@
+package imageStorage
+
+import "errors"
+
+// Key addresses stored bytes, equal bytes always get equal keys
+type Key string
+
+var (
+	NoSuchImage = errors.New("no such image")
+)
+
+type Service interface {
+	// Store saves the bytes and returns their key, storing the same bytes again is a no-op
+	Store(data []byte) (Key, error)
+	// Load returns bytes stored under the key or NoSuchImage
+	Load(key Key) ([]byte, error)
+}
@@ -0,0 +1,3 @@
+package applePushNotifications
+
+var ResponseError = responseError
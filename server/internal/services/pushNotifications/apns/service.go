@@ -3,6 +3,7 @@ package applePushNotifications
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 
 	pushNotificationsRepository "verni/internal/repositories/pushNotifications"
@@ -99,7 +100,30 @@ func (c *appleService) Alert(token pushNotifications.Token, title string, subtit
 	if err != nil {
 		return fmt.Errorf("%s: sending notification: %w", op, err)
 	}
+	if err := responseError(res); err != nil {
+		return fmt.Errorf("%s: sending notification: %w", op, err)
+	}
 
 	c.logger.LogInfo("%s: success[token=%s result=%v payload=%s]", op, token, res, payloadString)
 	return nil
 }
+
+// responseError tells tokens that apns will never accept again from notifications it refuses as they are. Errors
+// of authentication, throttling and of apns itself are left transient, as are tokens for another topic which
+// usually mean a misconfigured bundle id rather than a stale token.
+func responseError(res *apns2.Response) error {
+	if res.Sent() {
+		return nil
+	}
+	err := fmt.Errorf("apns responded %d %s", res.StatusCode, res.Reason)
+	switch {
+	case res.StatusCode == http.StatusGone || res.Reason == apns2.ReasonBadDeviceToken:
+		return fmt.Errorf("%w: %w", err, pushNotifications.InvalidToken)
+	case res.Reason == apns2.ReasonDeviceTokenNotForTopic:
+		return err
+	case res.StatusCode == http.StatusBadRequest || res.StatusCode == http.StatusRequestEntityTooLarge:
+		return fmt.Errorf("%w: %w", err, pushNotifications.Rejected)
+	default:
+		return err
+	}
+}
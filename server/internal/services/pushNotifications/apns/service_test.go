@@ -0,0 +1,83 @@
+package applePushNotifications_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/sideshow/apns2"
+	"github.com/stretchr/testify/assert"
+
+	"verni/internal/services/pushNotifications"
+	applePushNotifications "verni/internal/services/pushNotifications/apns"
+)
+
+func TestResponseError(t *testing.T) {
+	for _, testCase := range []struct {
+		name         string
+		response     apns2.Response
+		invalidToken bool
+		rejected     bool
+		// sent responses are not errors, failures that are neither invalid tokens nor rejections are transient
+		sent bool
+	}{
+		{
+			name:     "sent",
+			response: apns2.Response{StatusCode: http.StatusOK},
+			sent:     true,
+		},
+		{
+			name:         "token is no longer active",
+			response:     apns2.Response{StatusCode: http.StatusGone, Reason: apns2.ReasonUnregistered},
+			invalidToken: true,
+		},
+		{
+			name:         "bad device token",
+			response:     apns2.Response{StatusCode: http.StatusBadRequest, Reason: apns2.ReasonBadDeviceToken},
+			invalidToken: true,
+		},
+		{
+			name:     "token for another topic",
+			response: apns2.Response{StatusCode: http.StatusBadRequest, Reason: apns2.ReasonDeviceTokenNotForTopic},
+		},
+		{
+			name:     "bad request",
+			response: apns2.Response{StatusCode: http.StatusBadRequest, Reason: apns2.ReasonBadTopic},
+			rejected: true,
+		},
+		{
+			name:     "payload too large",
+			response: apns2.Response{StatusCode: http.StatusRequestEntityTooLarge, Reason: apns2.ReasonPayloadTooLarge},
+			rejected: true,
+		},
+		{
+			name:     "throttled",
+			response: apns2.Response{StatusCode: http.StatusTooManyRequests, Reason: apns2.ReasonTooManyRequests},
+		},
+		{
+			name:     "internal server error",
+			response: apns2.Response{StatusCode: http.StatusInternalServerError, Reason: apns2.ReasonInternalServerError},
+		},
+		{
+			name:     "service unavailable",
+			response: apns2.Response{StatusCode: http.StatusServiceUnavailable, Reason: apns2.ReasonServiceUnavailable},
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Arrange
+			response := testCase.response
+
+			// Act
+			err := applePushNotifications.ResponseError(&response)
+
+			// Assert
+			if testCase.sent {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			assert.Equal(t, testCase.invalidToken, errors.Is(err, pushNotifications.InvalidToken))
+			assert.Equal(t, testCase.rejected, errors.Is(err, pushNotifications.Rejected))
+		})
+	}
+}
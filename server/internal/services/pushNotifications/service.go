@@ -1,7 +1,16 @@
 package pushNotifications
 
+import "errors"
+
 type Token string
 
+var (
+	InvalidToken = errors.New("invalid push token")
+	Rejected     = errors.New("notification rejected")
+)
+
 type Service interface {
+	// Alert fails with InvalidToken if the token will never receive notifications again and should be forgotten,
+	// and with Rejected if sending the same notification again does not help. Other errors are transient.
 	Alert(token Token, title string, subtitle *string, body *string, data interface{}) error
 }
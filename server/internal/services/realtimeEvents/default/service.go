@@ -1,6 +1,7 @@
 package defaultRealtimeEvents
 
 import (
+	"encoding/json"
 	"sync"
 
 	"verni/internal/services/realtimeEvents"
@@ -23,10 +24,14 @@ func (s *userUpdateService) AddListener(listener realtimeEvents.Listener) {
 	s.listeners = append(s.listeners, listener)
 }
 
-func (s *userUpdateService) NotifyUpdate(userId realtimeEvents.UserId, ignoringDevices []realtimeEvents.DeviceId) {
+func (s *userUpdateService) NotifyUpdate(
+	userId realtimeEvents.UserId,
+	ignoringDevices []realtimeEvents.DeviceId,
+	operations []json.RawMessage,
+) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	for _, listener := range s.listeners {
-		listener(userId, ignoringDevices)
+		listener(userId, ignoringDevices, operations)
 	}
 }
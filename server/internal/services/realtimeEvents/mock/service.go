@@ -1,16 +1,19 @@
 package realtimeEvents_mock
 
-import "verni/internal/services/realtimeEvents"
+import (
+	"encoding/json"
+	"verni/internal/services/realtimeEvents"
+)
 
 type ServiceMock struct {
 	AddListenerImpl  func(listener realtimeEvents.Listener)
-	NotifyUpdateImpl func(userId realtimeEvents.UserId, ignoringDevices []realtimeEvents.DeviceId)
+	NotifyUpdateImpl func(userId realtimeEvents.UserId, ignoringDevices []realtimeEvents.DeviceId, operations []json.RawMessage)
 }
 
 func (c *ServiceMock) AddListener(listener realtimeEvents.Listener) {
 	c.AddListenerImpl(listener)
 }
 
-func (c *ServiceMock) NotifyUpdate(userId realtimeEvents.UserId, ignoringDevices []realtimeEvents.DeviceId) {
-	c.NotifyUpdateImpl(userId, ignoringDevices)
+func (c *ServiceMock) NotifyUpdate(userId realtimeEvents.UserId, ignoringDevices []realtimeEvents.DeviceId, operations []json.RawMessage) {
+	c.NotifyUpdateImpl(userId, ignoringDevices, operations)
 }
@@ -0,0 +1,136 @@
+package postgresRealtimeEvents
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"verni/internal/db"
+	postgresDb "verni/internal/db/postgres"
+	"verni/internal/services/logging"
+	"verni/internal/services/realtimeEvents"
+	defaultRealtimeEvents "verni/internal/services/realtimeEvents/default"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	defaultChannel       = "verni_realtime_events"
+	minReconnectInterval = time.Second
+	maxReconnectInterval = time.Minute
+	// maxPayloadBytes is the limit of NOTIFY payloads, operations of larger updates are pulled by receivers
+	maxPayloadBytes = 8000
+)
+
+type PostgresConfig struct {
+	// Channel is the notification channel shared by every instance of the server, defaultChannel when empty
+	Channel string `json:"channel"`
+}
+
+// update is the payload of a notification, instances skip updates they have sent themselves since those
+// are already delivered to their listeners
+type update struct {
+	Instance        string                    `json:"instance"`
+	UserId          realtimeEvents.UserId     `json:"userId"`
+	IgnoringDevices []realtimeEvents.DeviceId `json:"ignoringDevices"`
+	Operations      []json.RawMessage         `json:"operations,omitempty"`
+}
+
+// New creates a service that broadcasts updates to listeners of every server instance connected to the database
+// with LISTEN/NOTIFY. Updates sent while the listening connection is being reestablished are lost, clients
+// catch up on the next update or when they pull.
+func New(
+	config PostgresConfig,
+	db db.DB,
+	connection postgresDb.PostgresConfig,
+	logger logging.Service,
+) (realtimeEvents.Service, error) {
+	const op = "realtimeEvents.postgresRealtimeEvents.New"
+	channel := config.Channel
+	if channel == "" {
+		channel = defaultChannel
+	}
+	listener := pq.NewListener(
+		connection.ConnectionString(),
+		minReconnectInterval,
+		maxReconnectInterval,
+		func(event pq.ListenerEventType, err error) {
+			if err != nil {
+				logger.LogError("%s: listener event %d: %v", op, event, err)
+			}
+		},
+	)
+	if err := listener.Listen(channel); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("%s: listening to %s: %w", op, channel, err)
+	}
+	service := &postgresService{
+		local:    defaultRealtimeEvents.NewUserUpdateService(),
+		db:       db,
+		channel:  channel,
+		instance: uuid.New().String(),
+		logger:   logger,
+	}
+	go service.receive(listener)
+	return service, nil
+}
+
+type postgresService struct {
+	local    realtimeEvents.Service
+	db       db.DB
+	channel  string
+	instance string
+	logger   logging.Service
+}
+
+func (s *postgresService) AddListener(listener realtimeEvents.Listener) {
+	s.local.AddListener(listener)
+}
+
+func (s *postgresService) NotifyUpdate(
+	userId realtimeEvents.UserId,
+	ignoringDevices []realtimeEvents.DeviceId,
+	operations []json.RawMessage,
+) {
+	const op = "realtimeEvents.postgresRealtimeEvents.NotifyUpdate"
+	s.local.NotifyUpdate(userId, ignoringDevices, operations)
+	broadcast := update{
+		Instance:        s.instance,
+		UserId:          userId,
+		IgnoringDevices: ignoringDevices,
+		Operations:      operations,
+	}
+	payload, err := json.Marshal(broadcast)
+	if err == nil && len(payload) >= maxPayloadBytes {
+		broadcast.Operations = nil
+		payload, err = json.Marshal(broadcast)
+	}
+	if err != nil {
+		s.logger.LogError("%s: encoding update of %s: %v", op, userId, err)
+		return
+	}
+	if _, err := s.db.Exec("SELECT pg_notify($1, $2);", s.channel, string(payload)); err != nil {
+		s.logger.LogError("%s: broadcasting update of %s: %v", op, userId, err)
+	}
+}
+
+func (s *postgresService) receive(listener *pq.Listener) {
+	const op = "realtimeEvents.postgresRealtimeEvents.receive"
+	for notification := range listener.Notify {
+		if notification == nil {
+			// sent after the connection is reestablished, notifications in between are lost
+			s.logger.LogInfo("%s: listener reconnected", op)
+			continue
+		}
+		var received update
+		if err := json.Unmarshal([]byte(notification.Extra), &received); err != nil {
+			s.logger.LogError("%s: decoding update %s: %v", op, notification.Extra, err)
+			continue
+		}
+		if received.Instance == s.instance {
+			continue
+		}
+		s.local.NotifyUpdate(received.UserId, received.IgnoringDevices, received.Operations)
+	}
+}
@@ -0,0 +1,99 @@
+package postgresRealtimeEvents_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"verni/internal/db"
+	postgresDb "verni/internal/db/postgres"
+	standartOutputLoggingService "verni/internal/services/logging/standartOutput"
+	defaultPathProvider "verni/internal/services/pathProvider/default"
+	"verni/internal/services/realtimeEvents"
+	postgresRealtimeEvents "verni/internal/services/realtimeEvents/postgres"
+)
+
+func setupTestDB(t *testing.T) (db.DB, postgresDb.PostgresConfig) {
+	logger := standartOutputLoggingService.New()
+	pathProvider := defaultPathProvider.New(logger)
+	path := pathProvider.AbsolutePath("./config/test/postgres_storage.json")
+
+	configFile, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Skipf("no postgres test config at %s, see scripts/test.sh", path)
+	}
+	require.NoError(t, err)
+
+	var config postgresDb.PostgresConfig
+	require.NoError(t, json.Unmarshal(configFile, &config))
+
+	db, err := postgresDb.Postgres(config, logger)
+	require.NoError(t, err)
+	return db, config
+}
+
+type received struct {
+	mutex      sync.Mutex
+	updates    []realtimeEvents.UserId
+	operations []int
+}
+
+func (r *received) listener(userId realtimeEvents.UserId, ignoringDevices []realtimeEvents.DeviceId, operations []json.RawMessage) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.updates = append(r.updates, userId)
+	r.operations = append(r.operations, len(operations))
+}
+
+func (r *received) get() []realtimeEvents.UserId {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]realtimeEvents.UserId{}, r.updates...)
+}
+
+func (r *received) getOperations() []int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]int{}, r.operations...)
+}
+
+func TestService_NotifyUpdate(t *testing.T) {
+	db, connection := setupTestDB(t)
+	defer db.Close()
+	logger := standartOutputLoggingService.New()
+	config := postgresRealtimeEvents.PostgresConfig{Channel: "verni_realtime_events_test"}
+
+	// Arrange
+	sender, err := postgresRealtimeEvents.New(config, db, connection, logger)
+	require.NoError(t, err)
+	receiver, err := postgresRealtimeEvents.New(config, db, connection, logger)
+	require.NoError(t, err)
+	var onSender, onReceiver received
+	sender.AddListener(onSender.listener)
+	receiver.AddListener(onReceiver.listener)
+
+	// Act
+	sender.NotifyUpdate("alice", []realtimeEvents.DeviceId{"phone"}, []json.RawMessage{json.RawMessage(`{"operationId":"small"}`)})
+	// operations of updates exceeding the notification limit are left to pull
+	large := json.RawMessage(fmt.Sprintf(`{"operationId":"%s"}`, strings.Repeat("a", 8000)))
+	sender.NotifyUpdate("bob", nil, []json.RawMessage{large})
+
+	// Assert
+	assert.Eventually(t, func() bool {
+		return len(onReceiver.get()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []realtimeEvents.UserId{"alice", "bob"}, onReceiver.get())
+	assert.Equal(t, []int{1, 0}, onReceiver.getOperations())
+	// the sender delivers its own updates directly and skips them when they come back
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, []realtimeEvents.UserId{"alice", "bob"}, onSender.get())
+	assert.Equal(t, []int{1, 1}, onSender.getOperations())
+}
@@ -1,11 +1,15 @@
 package realtimeEvents
 
+import "encoding/json"
+
 type UserId string
 type DeviceId string
 
-type Listener func(userId UserId, ignoringDevices []DeviceId)
+// Listener is called with operations that caused the update encoded as json, when there are none listeners
+// should pull pending operations of the user themselves
+type Listener func(userId UserId, ignoringDevices []DeviceId, operations []json.RawMessage)
 
 type Service interface {
 	AddListener(listener Listener)
-	NotifyUpdate(userId UserId, ignoringDevices []DeviceId)
+	NotifyUpdate(userId UserId, ignoringDevices []DeviceId, operations []json.RawMessage)
 }
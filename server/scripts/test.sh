@@ -9,7 +9,7 @@ mkdir -p ./config/test/
 echo '{"host":"localhost","port":5432,"user":"root","password":"verni_pwd","dbName":"verni_test_db"}' > ./config/test/postgres_storage.json
 cd cmd/utilities
 go build .
-./utilities --command create-tables --config-path ./config/test/postgres_storage.json
+./utilities --command migrate --config-path ./config/test/postgres_storage.json
 cd "${SCRIPT_DIR}/.."
 
 OPENAPI_DIR="${SCRIPT_DIR}/../internal/openapi"